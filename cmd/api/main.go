@@ -6,27 +6,35 @@ import (
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/identitystore"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/sfn"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
 
 	"github.com/dgwhited/jit-aws-controller/internal/audit"
 	"github.com/dgwhited/jit-aws-controller/internal/auth"
 	"github.com/dgwhited/jit-aws-controller/internal/config"
 	"github.com/dgwhited/jit-aws-controller/internal/dynamo"
+	"github.com/dgwhited/jit-aws-controller/internal/events"
+	"github.com/dgwhited/jit-aws-controller/internal/fieldcrypt"
 	"github.com/dgwhited/jit-aws-controller/internal/handlers"
 	"github.com/dgwhited/jit-aws-controller/internal/identity"
+	"github.com/dgwhited/jit-aws-controller/internal/logging"
 	"github.com/dgwhited/jit-aws-controller/internal/secrets"
 	"github.com/dgwhited/jit-aws-controller/internal/webhook"
 )
 
 func main() {
 	// Set up structured logging.
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	slog.SetDefault(logger)
+	if err := logging.Setup(); err != nil {
+		slog.Warn("invalid logging configuration, using defaults", "error", err)
+	}
 
 	// Load configuration.
 	cfg, err := config.Load()
@@ -45,10 +53,24 @@ func main() {
 	}
 
 	// Initialize AWS service clients.
-	ddbClient := dynamodb.NewFromConfig(awsCfg)
+	ddbClient := dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+		if cfg.DynamoDBEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.DynamoDBEndpoint)
+		}
+	})
 	sfnClient := sfn.NewFromConfig(awsCfg)
-	ssoAdminClient := ssoadmin.NewFromConfig(awsCfg)
-	identityStoreClient := identitystore.NewFromConfig(awsCfg)
+	// Identity Center APIs must be called in the instance's home region,
+	// which may differ from the Lambda's region.
+	ssoAdminClient := ssoadmin.NewFromConfig(awsCfg, func(o *ssoadmin.Options) {
+		if cfg.IdentityCenterRegion != "" {
+			o.Region = cfg.IdentityCenterRegion
+		}
+	})
+	identityStoreClient := identitystore.NewFromConfig(awsCfg, func(o *identitystore.Options) {
+		if cfg.IdentityCenterRegion != "" {
+			o.Region = cfg.IdentityCenterRegion
+		}
+	})
 	smClient := secretsmanager.NewFromConfig(awsCfg)
 
 	// Fetch signing keys from Secrets Manager.
@@ -57,6 +79,10 @@ func main() {
 		slog.Error("failed to fetch signing keys", "error", err)
 		os.Exit(1)
 	}
+	if len(signingKeys) == 0 {
+		slog.Error("signing secret contains no keys", "secret_arn", cfg.SigningSecretARN)
+		os.Exit(1)
+	}
 
 	// Fetch callback signing key for webhook.
 	callbackKeys, err := secrets.FetchSigningKeys(ctx, smClient, cfg.CallbackSigningSecretARN)
@@ -64,10 +90,50 @@ func main() {
 		slog.Error("failed to fetch callback signing keys", "error", err)
 		os.Exit(1)
 	}
+	if len(callbackKeys) == 0 {
+		slog.Error("callback signing secret contains no keys", "secret_arn", cfg.CallbackSigningSecretARN)
+		os.Exit(1)
+	}
+
+	// Fetch the action signing key when Step Functions actions are signed.
+	var actionSigner handlers.ActionSigner
+	if cfg.ActionSigningEnabled {
+		actionKeys, err := secrets.FetchSigningKeys(ctx, smClient, cfg.ActionSigningSecretARN)
+		if err != nil {
+			slog.Error("failed to fetch action signing keys", "error", err)
+			os.Exit(1)
+		}
+		actionSigner = auth.NewActionSigner(actionKeys)
+	}
+
+	// Fetch the export signing key when request exports are configured.
+	var exportSigner handlers.ExportSigner
+	if cfg.ExportSigningSecretARN != "" {
+		exportKeys, err := secrets.FetchSigningKeys(ctx, smClient, cfg.ExportSigningSecretARN)
+		if err != nil {
+			slog.Error("failed to fetch export signing keys", "error", err)
+			os.Exit(1)
+		}
+		exportSigner = auth.NewExportSigner(exportKeys)
+	}
 
 	// Build internal clients.
-	db := dynamo.NewClient(ddbClient, cfg.TableConfig, cfg.TableRequests, cfg.TableAudit, cfg.TableNonces)
-	identityClient := identity.NewClient(ssoAdminClient, identityStoreClient, cfg.SSOInstanceARN, cfg.IdentityStoreID, cfg.PermissionSetARN)
+	var dbOpts []dynamo.Option
+	if cfg.FieldEncryptionEnabled {
+		encryptor := fieldcrypt.NewKMSEncryptor(kms.NewFromConfig(awsCfg), cfg.KMSKeyARN)
+		dbOpts = append(dbOpts, dynamo.WithFieldEncryptor(encryptor))
+	}
+	if cfg.TableWebhookFailures != "" {
+		dbOpts = append(dbOpts, dynamo.WithWebhookFailuresTable(cfg.TableWebhookFailures))
+	}
+	db := dynamo.NewClient(ddbClient, cfg.TableConfig, cfg.TableRequests, cfg.TableAudit, cfg.TableNonces, dbOpts...)
+	identityClient := identity.NewClient(ssoAdminClient, identityStoreClient, cfg.SSOInstanceARN, cfg.IdentityStoreID, cfg.PermissionSetARN,
+		identity.WithRetryBackoffs(identity.BackoffSchedule(cfg.IdentityRetryMaxAttempts, cfg.IdentityRetryBackoffBase)),
+		identity.WithPrincipalType(ssotypes.PrincipalType(cfg.IdentityPrincipalType)))
+	if err := identityClient.Validate(); err != nil {
+		slog.Error("invalid identity configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Use the first callback key for signing webhooks.
 	var callbackKeyID, callbackSecret string
@@ -76,9 +142,28 @@ func main() {
 		callbackSecret = v
 		break
 	}
-	webhookClient := webhook.NewClient(cfg.PluginWebhookURL, callbackKeyID, callbackSecret)
+	webhookOpts := []webhook.Option{
+		webhook.WithFallbackURLs(cfg.PluginWebhookFallbackURLs...),
+		webhook.WithSigningPath(cfg.PluginWebhookPath),
+		webhook.WithChannelDestinations(db, callbackKeys),
+	}
+	var webhookFailures handlers.WebhookFailureStore
+	if cfg.TableWebhookFailures != "" {
+		webhookOpts = append(webhookOpts, webhook.WithFailureRecorder(db))
+		webhookFailures = db
+	}
+	if !cfg.WebhookEnabled {
+		slog.Warn("webhook notifications are disabled, the plugin will not be notified")
+		webhookOpts = append(webhookOpts, webhook.WithDisabled())
+	}
+	webhookClient := webhook.NewClient(cfg.PluginWebhookURL, callbackKeyID, callbackSecret, webhookOpts...)
 
-	auditLogger := audit.NewLogger(db)
+	auditOpts := []audit.Option{audit.WithIdempotentLifecycleEvents()}
+	if cfg.EventBusARN != "" {
+		publisher := events.NewEventBridgePublisher(eventbridge.NewFromConfig(awsCfg), cfg.EventBusARN)
+		auditOpts = append(auditOpts, audit.WithPublisher(publisher))
+	}
+	auditLogger := audit.NewLogger(db, auditOpts...)
 	hmacValidator := auth.NewHMACValidator(signingKeys, db)
 
 	handler := &handlers.Handler{
@@ -89,11 +174,28 @@ func main() {
 		SFN: &handlers.SFNClient{
 			Client:          sfnClient,
 			StateMachineARN: cfg.StepFunctionARN,
+			Signer:          actionSigner,
 		},
+		ApprovalTokens: hmacValidator,
+		Exports:        exportSigner,
+		AdminMMUserIDs: cfg.AdminMMUserIDs,
+
+		WebhookFailures: webhookFailures,
+		Redeliverer:     webhookClient,
+
+		NoSelfApprovalChannels: cfg.NoSelfApprovalChannels,
+		MaxApprovers:           cfg.MaxApprovers,
+		MaxWaitSeconds:         cfg.MaxWaitSeconds,
+		MaxBatchGrantStarts:    cfg.MaxBatchGrantStarts,
+		RevokeMode:             cfg.RevokeMode,
+		NotifyOnApprove:        cfg.NotifyOnApprove,
+		NotifyRequesterOnDeny:  cfg.NotifyRequesterOnDeny,
 	}
 
 	router := handlers.NewRouter(handler, hmacValidator)
 	actionHandler := handlers.NewActionHandler(handler)
+	actionHandler.Signer = actionSigner
+	actionHandler.PreGrantJitter = cfg.PreGrantJitter
 	dispatcher := handlers.NewDispatcher(router, actionHandler)
 
 	slog.Info("starting JIT API Lambda")
@@ -2,30 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/identitystore"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
 
 	"github.com/dgwhited/jit-aws-controller/internal/audit"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
 	"github.com/dgwhited/jit-aws-controller/internal/config"
 	"github.com/dgwhited/jit-aws-controller/internal/dynamo"
+	"github.com/dgwhited/jit-aws-controller/internal/events"
+	"github.com/dgwhited/jit-aws-controller/internal/handlers"
 	"github.com/dgwhited/jit-aws-controller/internal/identity"
+	"github.com/dgwhited/jit-aws-controller/internal/logging"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 	"github.com/dgwhited/jit-aws-controller/internal/secrets"
 	"github.com/dgwhited/jit-aws-controller/internal/webhook"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	slog.SetDefault(logger)
+	if err := logging.Setup(); err != nil {
+		slog.Warn("invalid logging configuration, using defaults", "error", err)
+	}
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -41,9 +51,23 @@ func main() {
 		os.Exit(1)
 	}
 
-	ddbClient := dynamodb.NewFromConfig(awsCfg)
-	ssoAdminClient := ssoadmin.NewFromConfig(awsCfg)
-	identityStoreClient := identitystore.NewFromConfig(awsCfg)
+	ddbClient := dynamodb.NewFromConfig(awsCfg, func(o *dynamodb.Options) {
+		if cfg.DynamoDBEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.DynamoDBEndpoint)
+		}
+	})
+	// Identity Center APIs must be called in the instance's home region,
+	// which may differ from the Lambda's region.
+	ssoAdminClient := ssoadmin.NewFromConfig(awsCfg, func(o *ssoadmin.Options) {
+		if cfg.IdentityCenterRegion != "" {
+			o.Region = cfg.IdentityCenterRegion
+		}
+	})
+	identityStoreClient := identitystore.NewFromConfig(awsCfg, func(o *identitystore.Options) {
+		if cfg.IdentityCenterRegion != "" {
+			o.Region = cfg.IdentityCenterRegion
+		}
+	})
 	smClient := secretsmanager.NewFromConfig(awsCfg)
 
 	// Fetch callback signing key for webhook notifications.
@@ -52,9 +76,25 @@ func main() {
 		slog.Error("failed to fetch callback signing keys", "error", err)
 		os.Exit(1)
 	}
+	if len(callbackKeys) == 0 {
+		slog.Error("callback signing secret contains no keys", "secret_arn", cfg.CallbackSigningSecretARN)
+		os.Exit(1)
+	}
 
-	db := dynamo.NewClient(ddbClient, cfg.TableConfig, cfg.TableRequests, cfg.TableAudit, cfg.TableNonces)
-	identityClient := identity.NewClient(ssoAdminClient, identityStoreClient, cfg.SSOInstanceARN, cfg.IdentityStoreID, cfg.PermissionSetARN)
+	// The reconciler sweeps every active grant in one run, so it rides out
+	// throttling bursts rather than deferring grants to the next run.
+	dbOpts := []dynamo.Option{dynamo.WithThrottleBackoffs(dynamo.BulkThrottleBackoffs)}
+	if cfg.TableWebhookFailures != "" {
+		dbOpts = append(dbOpts, dynamo.WithWebhookFailuresTable(cfg.TableWebhookFailures))
+	}
+	db := dynamo.NewClient(ddbClient, cfg.TableConfig, cfg.TableRequests, cfg.TableAudit, cfg.TableNonces, dbOpts...)
+	identityClient := identity.NewClient(ssoAdminClient, identityStoreClient, cfg.SSOInstanceARN, cfg.IdentityStoreID, cfg.PermissionSetARN,
+		identity.WithRetryBackoffs(identity.BackoffSchedule(cfg.IdentityRetryMaxAttempts, cfg.IdentityRetryBackoffBase)),
+		identity.WithPrincipalType(ssotypes.PrincipalType(cfg.IdentityPrincipalType)))
+	if err := identityClient.Validate(); err != nil {
+		slog.Error("invalid identity configuration", "error", err)
+		os.Exit(1)
+	}
 
 	var callbackKeyID, callbackSecret string
 	for k, v := range callbackKeys {
@@ -62,46 +102,219 @@ func main() {
 		callbackSecret = v
 		break
 	}
-	webhookClient := webhook.NewClient(cfg.PluginWebhookURL, callbackKeyID, callbackSecret)
-	auditLogger := audit.NewLogger(db)
+	webhookOpts := []webhook.Option{
+		webhook.WithFallbackURLs(cfg.PluginWebhookFallbackURLs...),
+		webhook.WithSigningPath(cfg.PluginWebhookPath),
+		webhook.WithBatchPath(cfg.PluginWebhookBatchPath),
+		webhook.WithChannelDestinations(db, callbackKeys),
+	}
+	if cfg.TableWebhookFailures != "" {
+		webhookOpts = append(webhookOpts, webhook.WithFailureRecorder(db))
+	}
+	if !cfg.WebhookEnabled {
+		slog.Warn("webhook notifications are disabled, the plugin will not be notified")
+		webhookOpts = append(webhookOpts, webhook.WithDisabled())
+	}
+	webhookClient := webhook.NewClient(cfg.PluginWebhookURL, callbackKeyID, callbackSecret, webhookOpts...)
+	auditOpts := []audit.Option{audit.WithIdempotentLifecycleEvents()}
+	if cfg.EventBusARN != "" {
+		publisher := events.NewEventBridgePublisher(eventbridge.NewFromConfig(awsCfg), cfg.EventBusARN)
+		auditOpts = append(auditOpts, audit.WithPublisher(publisher))
+	}
+	auditLogger := audit.NewLogger(db, auditOpts...)
+
+	// Nonces are meant to be cleaned up by DynamoDB TTL; flag a table where
+	// that cannot happen. The sweep below deletes them either way.
+	if err := db.CheckNonceTTL(ctx); err != nil {
+		slog.Error("nonce table TTL self-check failed, nonces will only be removed by the reconciler sweep", "error", err)
+	}
 
 	reconciler := &Reconciler{
-		DB:       db,
-		Identity: identityClient,
-		Webhook:  webhookClient,
-		Audit:    auditLogger,
+		DB:              db,
+		Identity:        identityClient,
+		Webhook:         webhookClient,
+		Audit:           auditLogger,
+		Nonces:          db,
+		MissingEndTimes: db,
+		Cursors:         db,
+
+		WarningWindow:    cfg.ExpiryWarningWindow,
+		ReminderInterval: cfg.ApproverReminderInterval,
+		ReminderCooldown: cfg.ApproverReminderCooldown,
+		ApprovalGrace:    cfg.ApprovalGracePeriod,
+
+		MaxRevokeAttempts: cfg.RevokeMaxAttempts,
 	}
 
 	slog.Info("starting JIT Reconciler Lambda")
 	lambda.Start(reconciler.Handle)
 }
 
-// Reconciler processes expired GRANTED requests.
+// defaultSafetyMargin is the time reserved at the end of an invocation; no new
+// revocation is started once less than this remains before the Lambda deadline.
+// A single revocation can poll Identity Center for up to a minute.
+const defaultSafetyMargin = 90 * time.Second
+
+// ReconcilerStore is the subset of dynamo.Client used by the reconciler.
+type ReconcilerStore interface {
+	QueryRequestsByStatus(ctx context.Context, status string, beforeEndTime string, limit int32) ([]models.JitRequest, error)
+	ConditionalUpdateStatus(ctx context.Context, requestID, expectedStatus string, updates map[string]interface{}) error
+	MarkExpiryWarned(ctx context.Context, requestID, warnedAt string) error
+	MarkReminded(ctx context.Context, requestID, remindedAt, remindedBefore string) error
+	GetConfig(ctx context.Context, channelID, accountID string) (*models.JitConfig, error)
+	handlers.GrantCounter
+}
+
+// NonceSweeper is the subset of dynamo.Client used to sweep expired nonces.
+type NonceSweeper interface {
+	ScanNonces(ctx context.Context, limit int32, nextToken string) ([]models.NonceEntry, string, error)
+	DeleteNonce(ctx context.Context, keyID, nonce string, expiredBy int64) error
+}
+
+// ScanCursorStore is the subset of dynamo.Client that remembers where a paged
+// table scan stopped, so the next run carries on from there.
+type ScanCursorStore interface {
+	GetScanCursor(ctx context.Context, name string) (string, error)
+	PutScanCursor(ctx context.Context, name, token string) error
+}
+
+// MissingEndTimeScanner is the subset of dynamo.Client used to find GRANTED
+// requests without an end_time.
+type MissingEndTimeScanner interface {
+	ScanGrantsMissingEndTime(ctx context.Context, limit int32, nextToken string) ([]models.JitRequest, string, error)
+}
+
+// missingEndTimePages caps the request pages scanned per run for grants
+// without an end_time.
+const missingEndTimePages = 5
+
+// missingEndTimeCursor names the saved position of the missing end_time scan.
+const missingEndTimeCursor = "missing-end-time"
+
+// nonceSweepGrace is how long past expiry a nonce is left for DynamoDB TTL,
+// which normally deletes expired items within 48 hours, before the sweep
+// deletes it explicitly.
+const nonceSweepGrace = 48 * time.Hour
+
+// nonceSweepPages caps the nonce pages scanned per run, so a table that has
+// grown large is worked down over several runs.
+const nonceSweepPages = 5
+
+// nonceSweepCursor names the saved position of the nonce sweep's scan.
+const nonceSweepCursor = "nonce-sweep"
+
+// BatchNotifier delivers many webhook payloads in as few requests as possible.
+type BatchNotifier interface {
+	NotifyBatch(ctx context.Context, payloads []models.WebhookPayload) error
+}
+
+// Reconciler processes expired GRANTED requests and, when WarningWindow is
+// set, warns requesters whose grants are about to expire. When
+// ReminderInterval is set it also reminds approvers of stale PENDING requests,
+// and when ApprovalGrace is set it fails approvals whose grant never ran.
 type Reconciler struct {
-	DB       *dynamo.Client
-	Identity *identity.Client
-	Webhook  *webhook.Client
-	Audit    *audit.Logger
+	DB       ReconcilerStore
+	Identity handlers.IdentityProvider
+	Webhook  BatchNotifier
+	Audit    handlers.AuditLogger
+
+	// Nonces, when set, enables the fallback sweep of nonces that DynamoDB
+	// TTL has not deleted.
+	Nonces NonceSweeper
+	// Cursors, when set, saves where each run's capped table scans stopped,
+	// so the next run resumes there and a large table is covered over
+	// several runs. Without it every run scans from the start of the table.
+	Cursors ScanCursorStore
+	// MissingEndTimes, when set, enables the pass that repairs GRANTED
+	// requests without an end_time. gsi_status_endtime does not index them,
+	// so the expiry query would never find them.
+	MissingEndTimes MissingEndTimeScanner
+
+	// SafetyMargin overrides defaultSafetyMargin when non-zero.
+	SafetyMargin time.Duration
+	// WarningWindow enables the expiring-soon pass when positive.
+	WarningWindow time.Duration
+	// ReminderInterval enables the approver reminder pass when positive:
+	// requests PENDING at least this long are reminded, at most once per
+	// ReminderCooldown (which defaults to the interval).
+	ReminderInterval time.Duration
+	ReminderCooldown time.Duration
+	// ApprovalGrace enables the stalled-approval pass when positive: requests
+	// still APPROVED this long after approval are moved to ERROR.
+	ApprovalGrace time.Duration
+	// MaxRevokeAttempts is how many runs may fail to revoke an expired grant
+	// before it is moved to ERROR for manual intervention. Until then a
+	// failure leaves the grant GRANTED with its revoke_attempts and last
+	// error recorded, and the next run retries. Values below 1 mean 1.
+	MaxRevokeAttempts int
+	// Clock supplies the current time. Defaults to the system clock.
+	Clock clock.Clock
+}
+
+// now returns the current UTC time from r.Clock.
+func (r *Reconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now().UTC()
+	}
+	return r.Clock.Now().UTC()
 }
 
 // Handle is the Lambda handler invoked by EventBridge on a schedule.
+//
+// The run is bounded by the invocation deadline: once the remaining time drops
+// below the safety margin, no further revocations are started. Progress is
+// persisted per request (each revoked grant leaves GRANTED), so the next
+// scheduled run resumes with whatever is still outstanding.
 func (r *Reconciler) Handle(ctx context.Context) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	nowTime := r.now()
+	now := nowTime.Format(time.RFC3339)
 
 	slog.Info("reconciler run starting", "now", now)
 
-	// Query all GRANTED requests whose end_time has passed.
-	requests, err := r.DB.QueryRequestsByStatus(ctx, models.StatusGranted, now, 0)
+	// Repair grants the expiry query cannot see before running it, so a
+	// backfilled grant that has already ended is revoked in this run.
+	if r.MissingEndTimes != nil {
+		r.repairMissingEndTimes(ctx, nowTime)
+	}
+
+	// Query all GRANTED requests whose end_time has passed. A grant whose
+	// clock was paused expires later than its end_time, so the query finds
+	// every expired grant and some that are paused or extended, which are
+	// skipped.
+	candidates, err := r.DB.QueryRequestsByStatus(ctx, models.StatusGranted, now, 0)
 	if err != nil {
 		slog.Error("failed to query expired grants", "error", err)
 		return fmt.Errorf("query expired grants: %w", err)
 	}
+	requests := candidates[:0]
+	for _, req := range candidates {
+		if req.ClockPaused() || req.ExpiresAt() > now {
+			slog.Debug("grant paused or extended past end_time, not expired",
+				"request_id", req.RequestID,
+				"end_time", req.EndTime,
+				"effective_end_time", req.EffectiveEndTime,
+				"paused_at", req.PausedAt,
+			)
+			continue
+		}
+		requests = append(requests, req)
+	}
 
 	slog.Info("found expired grants", "count", len(requests))
 
-	var errCount int
+	var errCount, processed int
+	var expired []models.WebhookPayload
 	for _, req := range requests {
-		if err := r.revokeExpired(ctx, req); err != nil {
+		if r.budgetExhausted(ctx) {
+			slog.Warn("reconciler run budget exhausted, deferring remaining grants to next run",
+				"processed", processed,
+				"remaining", len(requests)-processed,
+			)
+			break
+		}
+		processed++
+
+		if err := r.revokeExpired(ctx, req, &expired); err != nil {
 			slog.Error("failed to revoke expired grant",
 				"request_id", req.RequestID,
 				"account_id", req.AccountID,
@@ -112,6 +325,23 @@ func (r *Reconciler) Handle(ctx context.Context) error {
 			continue
 		}
 	}
+	r.notify(ctx, expired)
+
+	if r.WarningWindow > 0 && !r.budgetExhausted(ctx) {
+		r.warnExpiring(ctx, nowTime)
+	}
+
+	if r.ReminderInterval > 0 && !r.budgetExhausted(ctx) {
+		r.remindApprovers(ctx, nowTime)
+	}
+
+	if r.ApprovalGrace > 0 && !r.budgetExhausted(ctx) {
+		r.failStalledApprovals(ctx, nowTime)
+	}
+
+	if r.Nonces != nil && !r.budgetExhausted(ctx) {
+		r.sweepNonces(ctx, nowTime)
+	}
 
 	if errCount > 0 {
 		slog.Warn("reconciler completed with errors",
@@ -121,34 +351,48 @@ func (r *Reconciler) Handle(ctx context.Context) error {
 		return fmt.Errorf("reconciler completed with %d errors out of %d", errCount, len(requests))
 	}
 
-	slog.Info("reconciler run completed", "processed", len(requests))
+	slog.Info("reconciler run completed", "processed", processed, "total", len(requests))
 	return nil
 }
 
-func (r *Reconciler) revokeExpired(ctx context.Context, req models.JitRequest) error {
-	// Revoke IAM Identity Center access.
-	if err := r.Identity.RevokeAccess(ctx, req.AccountID, req.IdentityStoreUserID); err != nil {
-		// Record error but continue.
-		errUpdates := map[string]interface{}{
-			"status":        models.StatusError,
-			"error_details": fmt.Sprintf("reconciler revoke failed: %s", err.Error()),
-		}
-		_ = r.DB.ConditionalUpdateStatus(ctx, req.RequestID, models.StatusGranted, errUpdates)
+// budgetExhausted reports whether the invocation is too close to its deadline
+// to safely start another revocation.
+func (r *Reconciler) budgetExhausted(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false
+	}
+	margin := r.SafetyMargin
+	if margin <= 0 {
+		margin = defaultSafetyMargin
+	}
+	return time.Until(deadline) < margin
+}
 
-		_ = r.Audit.Log(ctx, req.RequestID, models.EventError, req.AccountID, req.ChannelID,
-			"", "reconciler",
-			map[string]string{"error": err.Error()},
-		)
+// revokeExpired removes an expired grant and queues its EXPIRED webhook on
+// notices for the batched delivery at the end of the run.
+func (r *Reconciler) revokeExpired(ctx context.Context, req models.JitRequest, notices *[]models.WebhookPayload) error {
+	// Revoke IAM Identity Center access, covering every set in a bundle.
+	psStatus, err := handlers.RevokePermissionSets(ctx, r.Identity, &req)
+	if err != nil {
+		r.recordRevokeFailure(ctx, req, psStatus, err, notices)
 		return fmt.Errorf("revoke access for %s: %w", req.RequestID, err)
 	}
 
 	// Update status to EXPIRED with conditional check.
-	now := time.Now().UTC()
+	now := r.now()
 	updates := map[string]interface{}{
-		"status":     models.StatusExpired,
-		"expired_at": now.Format(time.RFC3339),
+		"status":            models.StatusExpired,
+		"expired_at":        now.Format(time.RFC3339),
+		"assignment_status": models.AssignmentDeleted,
+	}
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
 	}
-	if err := r.DB.ConditionalUpdateStatus(ctx, req.RequestID, models.StatusGranted, updates); err != nil {
+	if err := handlers.TransitionStatus(ctx, r.DB, req.RequestID, models.StatusGranted, updates); err != nil {
 		// If conditional update fails, the request was likely already updated (e.g., manually revoked).
 		slog.Warn("conditional update to EXPIRED failed, may have been revoked already",
 			"request_id", req.RequestID,
@@ -156,13 +400,13 @@ func (r *Reconciler) revokeExpired(ctx context.Context, req models.JitRequest) e
 		)
 		return nil
 	}
+	handlers.ReleaseGrantSlot(ctx, r.DB, &req)
 
 	// Audit the expiration.
 	_ = r.Audit.Log(ctx, req.RequestID, models.EventExpired, req.AccountID, req.ChannelID,
-		"", "reconciler", nil)
+		models.ReconcilerActor, nil)
 
-	// Webhook notify.
-	_ = r.Webhook.Notify(ctx, models.WebhookPayload{
+	*notices = append(*notices, models.WebhookPayload{
 		RequestID: req.RequestID,
 		Status:    models.StatusExpired,
 		AccountID: req.AccountID,
@@ -177,3 +421,563 @@ func (r *Reconciler) revokeExpired(ctx context.Context, req models.JitRequest) e
 	)
 	return nil
 }
+
+// ManualInterventionAction is the ERROR details.action sent once a grant has
+// used up its revoke attempts and the reconciler stops retrying it.
+const ManualInterventionAction = "manual_intervention"
+
+// recordRevokeFailure counts a failed revocation on the request. Below
+// MaxRevokeAttempts the grant stays GRANTED for the next run to retry. On
+// the last attempt it moves to ERROR, is audited, and an ERROR webhook asking
+// for manual intervention is queued on notices. The grant keeps its slot in
+// the binding's active-grant counter, since its assignment may still be in
+// place; an admin recount frees it once the access has been removed.
+func (r *Reconciler) recordRevokeFailure(ctx context.Context, req models.JitRequest, psStatus map[string]string, cause error, notices *[]models.WebhookPayload) {
+	attempts := req.RevokeAttempts + 1
+	maxAttempts := r.MaxRevokeAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	info := handlers.NewErrorInfo(models.ErrorPhaseRevoke, cause, r.now())
+	updates := map[string]interface{}{
+		"revoke_attempts": attempts,
+		"error_details":   fmt.Sprintf("reconciler revoke failed (attempt %d of %d): %s", attempts, maxAttempts, info.String()),
+		"error_info":      info,
+	}
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
+	}
+
+	if attempts < maxAttempts {
+		// The conditional update leaves the grant alone if it was revoked
+		// or expired meanwhile.
+		if err := r.DB.ConditionalUpdateStatus(ctx, req.RequestID, models.StatusGranted, updates); err != nil {
+			slog.Warn("could not record revoke attempt",
+				"request_id", req.RequestID,
+				"error", err,
+			)
+		}
+		slog.Warn("revoke failed, will retry next run",
+			"request_id", req.RequestID,
+			"revoke_attempts", attempts,
+			"max_revoke_attempts", maxAttempts,
+		)
+		return
+	}
+
+	updates["status"] = models.StatusError
+	updates["assignment_status"] = models.AssignmentFailed
+	if err := handlers.TransitionStatus(ctx, r.DB, req.RequestID, models.StatusGranted, updates); err != nil {
+		slog.Warn("conditional update to ERROR failed, may have been revoked already",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return
+	}
+	slog.Error("revoke attempts exhausted, manual intervention required",
+		"request_id", req.RequestID,
+		"account_id", req.AccountID,
+		"revoke_attempts", attempts,
+		"error", cause,
+	)
+
+	details := map[string]string{
+		"error":           cause.Error(),
+		"phase":           info.Phase,
+		"revoke_attempts": strconv.Itoa(attempts),
+	}
+	if info.Code != "" {
+		details["code"] = info.Code
+	}
+	if info.FailureReason != "" {
+		details["failure_reason"] = info.FailureReason
+	}
+	_ = r.Audit.Log(ctx, req.RequestID, models.EventError, req.AccountID, req.ChannelID,
+		models.ReconcilerActor, details)
+
+	alert := make(map[string]string, len(details)+2)
+	for k, v := range details {
+		alert[k] = v
+	}
+	alert["action"] = ManualInterventionAction
+	alert["requester_email"] = req.RequesterEmail
+	*notices = append(*notices, models.WebhookPayload{
+		RequestID: req.RequestID,
+		Status:    models.StatusError,
+		AccountID: req.AccountID,
+		ChannelID: req.ChannelID,
+		Actor:     "reconciler",
+		Details:   alert,
+	})
+}
+
+// ExtendAvailableAction is the EXPIRING_SOON details.action hint telling the
+// plugin it may offer the requester an "extend" button.
+const ExtendAvailableAction = "extend_available"
+
+// extendAvailable reports whether the requester could be granted more time:
+// the binding still exists and is not paused, and the grant is shorter than
+// its max_request_hours (any length, when there is no maximum). A failed
+// lookup offers no hint.
+func (r *Reconciler) extendAvailable(ctx context.Context, req models.JitRequest) bool {
+	cfg, err := r.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+	if err != nil {
+		slog.Warn("failed to look up binding for expiry warning",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return false
+	}
+	if cfg == nil || cfg.Paused {
+		return false
+	}
+	maxMinutes := cfg.MaxRequestHours * 60
+	return maxMinutes <= 0 || req.DurationMinutes() < maxMinutes
+}
+
+// warnExpiring notifies requesters whose grants end within the warning window.
+// Each grant is marked warned before the webhook is sent, so a grant is never
+// warned twice even if runs overlap. Failures are logged and do not fail the
+// run; revocation is what matters.
+func (r *Reconciler) warnExpiring(ctx context.Context, now time.Time) {
+	nowStr := now.Format(time.RFC3339)
+	cutoff := now.Add(r.WarningWindow).Format(time.RFC3339)
+
+	requests, err := r.DB.QueryRequestsByStatus(ctx, models.StatusGranted, cutoff, 0)
+	if err != nil {
+		slog.Error("failed to query expiring grants", "error", err)
+		return
+	}
+
+	var warnings []models.WebhookPayload
+	for _, req := range requests {
+		// Already-expired grants belong to the revocation pass. Paused grants
+		// are not expiring, and a pause may have pushed expiry past the window.
+		expiresAt := req.ExpiresAt()
+		if expiresAt <= nowStr || expiresAt > cutoff || req.ClockPaused() || req.WarnedAt != "" {
+			continue
+		}
+		if r.budgetExhausted(ctx) {
+			slog.Warn("reconciler run budget exhausted, deferring expiry warnings to next run")
+			break
+		}
+
+		if err := r.DB.MarkExpiryWarned(ctx, req.RequestID, nowStr); err != nil {
+			slog.Warn("could not mark grant warned, skipping",
+				"request_id", req.RequestID,
+				"error", err,
+			)
+			continue
+		}
+
+		details := map[string]string{
+			"end_time":             expiresAt,
+			"requester_email":      req.RequesterEmail,
+			"requester_mm_user_id": req.RequesterMMUserID,
+		}
+		if r.extendAvailable(ctx, req) {
+			details["action"] = ExtendAvailableAction
+		}
+		warnings = append(warnings, models.WebhookPayload{
+			RequestID: req.RequestID,
+			Status:    models.StatusExpiringSoon,
+			AccountID: req.AccountID,
+			ChannelID: req.ChannelID,
+			Actor:     "reconciler",
+			Details:   details,
+		})
+	}
+
+	r.notify(ctx, warnings)
+	slog.Info("expiry warnings sent", "count", len(warnings))
+}
+
+// reminderDue reports whether approvers of a PENDING request should be
+// reminded: it was created at least interval ago and was not reminded within
+// cooldown.
+func reminderDue(req models.JitRequest, now time.Time, interval, cooldown time.Duration) bool {
+	created, err := time.Parse(time.RFC3339, req.CreatedAt)
+	if err != nil || now.Sub(created) < interval {
+		return false
+	}
+	if req.LastRemindedAt == "" {
+		return true
+	}
+	last, err := time.Parse(time.RFC3339, req.LastRemindedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(last) >= cooldown
+}
+
+// remindApprovers sends an APPROVAL_REMINDER webhook for each PENDING request
+// that is due one. Each request is marked reminded before the webhook is sent,
+// so overlapping runs cannot remind it twice within the cooldown. Failures
+// are logged and do not fail the run.
+func (r *Reconciler) remindApprovers(ctx context.Context, now time.Time) {
+	cooldown := r.ReminderCooldown
+	if cooldown <= 0 {
+		cooldown = r.ReminderInterval
+	}
+	nowStr := now.Format(time.RFC3339)
+	remindedBefore := now.Add(-cooldown).Format(time.RFC3339)
+
+	requests, err := r.DB.QueryRequestsByStatus(ctx, models.StatusPending, "", 0)
+	if err != nil {
+		slog.Error("failed to query pending requests", "error", err)
+		return
+	}
+
+	var reminders []models.WebhookPayload
+	for _, req := range requests {
+		if !reminderDue(req, now, r.ReminderInterval, cooldown) {
+			continue
+		}
+		if r.budgetExhausted(ctx) {
+			slog.Warn("reconciler run budget exhausted, deferring approver reminders to next run")
+			break
+		}
+
+		if err := r.DB.MarkReminded(ctx, req.RequestID, nowStr, remindedBefore); err != nil {
+			slog.Warn("could not mark request reminded, skipping",
+				"request_id", req.RequestID,
+				"error", err,
+			)
+			continue
+		}
+
+		reminders = append(reminders, models.WebhookPayload{
+			RequestID: req.RequestID,
+			Status:    models.StatusApprovalReminder,
+			AccountID: req.AccountID,
+			ChannelID: req.ChannelID,
+			Actor:     "reconciler",
+			Details: map[string]string{
+				"created_at":      req.CreatedAt,
+				"requester_email": req.RequesterEmail,
+			},
+		})
+	}
+
+	r.notify(ctx, reminders)
+	slog.Info("approver reminders sent", "count", len(reminders))
+}
+
+// approvalStalled reports whether an APPROVED request was approved at least
+// grace ago. Requests without a parseable approved_at are left alone.
+func approvalStalled(req models.JitRequest, now time.Time, grace time.Duration) bool {
+	approved, err := time.Parse(time.RFC3339, req.ApprovedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(approved) >= grace
+}
+
+// failStalledApprovals moves requests that have sat APPROVED past the grace
+// period to ERROR and notifies their channels, so a grant workflow that
+// stalled does not leave the requester waiting forever. A grant that died
+// mid-call can leave its assignment in place (assignment_status CREATING or
+// CREATED); that is revoked first, and the request is retried next run if
+// the revoke fails. The transition is conditional on APPROVED, so a grant
+// that completes meanwhile wins, and a workflow that resumes afterwards can
+// no longer grant. Failures are logged and do not fail the run.
+func (r *Reconciler) failStalledApprovals(ctx context.Context, now time.Time) {
+	requests, err := r.DB.QueryRequestsByStatus(ctx, models.StatusApproved, "", 0)
+	if err != nil {
+		slog.Error("failed to query approved requests", "error", err)
+		return
+	}
+
+	var failures []models.WebhookPayload
+	for _, req := range requests {
+		if !approvalStalled(req, now, r.ApprovalGrace) {
+			continue
+		}
+		if r.budgetExhausted(ctx) {
+			slog.Warn("reconciler run budget exhausted, deferring stalled approvals to next run")
+			break
+		}
+
+		info := models.ErrorInfo{
+			Phase:     models.ErrorPhaseGrant,
+			Code:      "GrantWorkflowTimeout",
+			Message:   fmt.Sprintf("grant workflow did not complete within %s of approval", r.ApprovalGrace),
+			Timestamp: now.Format(time.RFC3339),
+		}
+		updates := handlers.ErrorUpdates(info)
+		assigned := req.AssignmentStatus == models.AssignmentCreating || req.AssignmentStatus == models.AssignmentCreated
+		if assigned {
+			psStatus, err := handlers.RevokePermissionSets(ctx, r.Identity, &req)
+			if err != nil {
+				slog.Warn("could not revoke stalled approval's assignment, retrying next run",
+					"request_id", req.RequestID,
+					"assignment_status", req.AssignmentStatus,
+					"error", err,
+				)
+				continue
+			}
+			updates["assignment_status"] = models.AssignmentDeleted
+			if psStatus != nil {
+				updates["permission_set_status"] = psStatus
+			}
+		}
+		if err := handlers.TransitionStatus(ctx, r.DB, req.RequestID, models.StatusApproved, updates); err != nil {
+			slog.Warn("could not fail stalled approval, skipping",
+				"request_id", req.RequestID,
+				"error", err,
+			)
+			continue
+		}
+		if assigned {
+			// The grant took its slot before it started creating the assignment.
+			handlers.ReleaseGrantSlot(ctx, r.DB, &req)
+		}
+		slog.Error("grant workflow stalled",
+			"request_id", req.RequestID,
+			"approved_at", req.ApprovedAt,
+			"execution_arn", req.ExecutionARN,
+			"assignment_revoked", assigned,
+		)
+
+		details := map[string]string{"error": info.String(), "phase": info.Phase, "code": info.Code}
+		if assigned {
+			details["assignment_revoked"] = "true"
+		}
+		_ = r.Audit.Log(ctx, req.RequestID, models.EventError, req.AccountID, req.ChannelID,
+			models.ReconcilerActor, details)
+
+		failures = append(failures, models.WebhookPayload{
+			RequestID: req.RequestID,
+			Status:    models.StatusError,
+			AccountID: req.AccountID,
+			ChannelID: req.ChannelID,
+			Actor:     "reconciler",
+			Details: map[string]string{
+				"error":           info.String(),
+				"phase":           info.Phase,
+				"code":            info.Code,
+				"requester_email": req.RequesterEmail,
+			},
+		})
+	}
+
+	r.notify(ctx, failures)
+	slog.Info("stalled approvals failed", "count", len(failures))
+}
+
+// backfillEndTime returns the end_time a grant without one should have had:
+// its grant (or, failing that, approval) time plus its duration. ok is false
+// when neither time nor a duration is recorded.
+func backfillEndTime(req models.JitRequest) (string, bool) {
+	start := req.GrantTime
+	if start == "" {
+		start = req.ApprovedAt
+	}
+	started, err := time.Parse(time.RFC3339, start)
+	if err != nil || req.DurationMinutes() <= 0 {
+		return "", false
+	}
+	return started.Add(time.Duration(req.DurationMinutes()) * time.Minute).UTC().Format(time.RFC3339), true
+}
+
+// repairMissingEndTimes finds GRANTED requests without an end_time, which
+// would otherwise never expire. Where the end can be worked out it is
+// backfilled; otherwise the request is moved to ERROR for manual
+// intervention, like a revoke that keeps failing. Failures are logged and do
+// not fail the run. The scan limit counts every item read, not just matches,
+// so each run resumes where the previous one stopped and the whole table is
+// covered over several runs.
+func (r *Reconciler) repairMissingEndTimes(ctx context.Context, now time.Time) {
+	token := r.loadCursor(ctx, missingEndTimeCursor)
+	var backfilled int
+	var flagged []models.WebhookPayload
+	scanned := false
+	for page := 0; page < missingEndTimePages; page++ {
+		requests, next, err := r.MissingEndTimes.ScanGrantsMissingEndTime(ctx, models.MaxQueryLimit, token)
+		if err != nil {
+			slog.Error("failed to scan for grants without end_time", "error", err)
+			if errors.Is(err, models.ErrInvalidNextToken) {
+				r.saveCursor(ctx, missingEndTimeCursor, "")
+			}
+			break
+		}
+		scanned = true
+		for _, req := range requests {
+			if endTime, ok := backfillEndTime(req); ok {
+				if err := r.DB.ConditionalUpdateStatus(ctx, req.RequestID, models.StatusGranted,
+					map[string]interface{}{"end_time": endTime}); err != nil {
+					slog.Warn("could not backfill end_time, skipping",
+						"request_id", req.RequestID,
+						"error", err,
+					)
+					continue
+				}
+				slog.Warn("backfilled missing end_time on grant",
+					"request_id", req.RequestID,
+					"end_time", endTime,
+				)
+				backfilled++
+				continue
+			}
+			if alert, ok := r.flagMissingEndTime(ctx, req, now); ok {
+				flagged = append(flagged, alert)
+			}
+		}
+		token = next
+		if next == "" || r.budgetExhausted(ctx) {
+			break
+		}
+	}
+	if scanned {
+		r.saveCursor(ctx, missingEndTimeCursor, token)
+	}
+
+	r.notify(ctx, flagged)
+	if backfilled > 0 || len(flagged) > 0 {
+		slog.Warn("repaired grants without end_time", "backfilled", backfilled, "flagged", len(flagged))
+	}
+}
+
+// flagMissingEndTime moves a grant whose end cannot be worked out to ERROR
+// and returns the alert for its channel. ok is false if the request left
+// GRANTED meanwhile.
+func (r *Reconciler) flagMissingEndTime(ctx context.Context, req models.JitRequest, now time.Time) (models.WebhookPayload, bool) {
+	info := models.ErrorInfo{
+		Phase:     models.ErrorPhaseRevoke,
+		Code:      "MissingEndTime",
+		Message:   "grant has no end_time and none can be derived, so it would never expire",
+		Timestamp: now.Format(time.RFC3339),
+	}
+	if err := handlers.TransitionStatus(ctx, r.DB, req.RequestID, models.StatusGranted, handlers.ErrorUpdates(info)); err != nil {
+		slog.Warn("could not flag grant without end_time, skipping",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return models.WebhookPayload{}, false
+	}
+	slog.Error("grant without end_time, manual intervention required",
+		"request_id", req.RequestID,
+		"account_id", req.AccountID,
+	)
+
+	details := map[string]string{"error": info.String(), "phase": info.Phase, "code": info.Code}
+	_ = r.Audit.Log(ctx, req.RequestID, models.EventError, req.AccountID, req.ChannelID,
+		models.ReconcilerActor, details)
+
+	return models.WebhookPayload{
+		RequestID: req.RequestID,
+		Status:    models.StatusError,
+		AccountID: req.AccountID,
+		ChannelID: req.ChannelID,
+		Actor:     "reconciler",
+		Details: map[string]string{
+			"error":           info.String(),
+			"phase":           info.Phase,
+			"code":            info.Code,
+			"action":          ManualInterventionAction,
+			"requester_email": req.RequesterEmail,
+		},
+	}, true
+}
+
+// nonceSweepable reports whether a nonce is long enough past expiry that TTL
+// should have deleted it. Nonces without an expiry fall back to their
+// creation time; ones with neither are left alone.
+func nonceSweepable(entry models.NonceEntry, now time.Time) bool {
+	cutoff := now.Add(-nonceSweepGrace)
+	if entry.ExpiresAt > 0 {
+		return entry.ExpiresAt <= cutoff.Unix()
+	}
+	created, err := time.Parse(time.RFC3339, entry.CreatedAt)
+	if err != nil {
+		return false
+	}
+	return !created.After(cutoff)
+}
+
+// sweepNonces deletes nonces that DynamoDB TTL failed to remove. It is a
+// fallback for a misconfigured TTL, so failures are logged and do not fail
+// the run. The scan resumes where the previous run stopped, so nonces still
+// in their grace period at the start of the table do not hide expired ones
+// behind them.
+func (r *Reconciler) sweepNonces(ctx context.Context, now time.Time) {
+	expiredBy := now.Add(-nonceSweepGrace).Unix()
+	token := r.loadCursor(ctx, nonceSweepCursor)
+	var deleted, failed int
+	for page := 0; page < nonceSweepPages; page++ {
+		entries, next, err := r.Nonces.ScanNonces(ctx, models.MaxQueryLimit, token)
+		if err != nil {
+			slog.Error("failed to scan nonces", "error", err)
+			if errors.Is(err, models.ErrInvalidNextToken) {
+				r.saveCursor(ctx, nonceSweepCursor, "")
+			}
+			return
+		}
+		for _, entry := range entries {
+			if !nonceSweepable(entry, now) {
+				continue
+			}
+			if err := r.Nonces.DeleteNonce(ctx, entry.KeyID, entry.Nonce, expiredBy); err != nil {
+				failed++
+				continue
+			}
+			deleted++
+		}
+		token = next
+		if next == "" || r.budgetExhausted(ctx) {
+			break
+		}
+	}
+	r.saveCursor(ctx, nonceSweepCursor, token)
+
+	if deleted > 0 || failed > 0 {
+		// Anything to sweep means TTL is not keeping up with the table.
+		slog.Warn("swept expired nonces missed by TTL", "deleted", deleted, "failed", failed)
+	}
+}
+
+// loadCursor returns where the named scan stopped last run, or "" to start
+// at the beginning.
+func (r *Reconciler) loadCursor(ctx context.Context, name string) string {
+	if r.Cursors == nil {
+		return ""
+	}
+	token, err := r.Cursors.GetScanCursor(ctx, name)
+	if err != nil {
+		slog.Warn("failed to load scan cursor, scanning from the start", "scan", name, "error", err)
+		return ""
+	}
+	return token
+}
+
+// saveCursor records where the named scan stopped; "" once it reached the
+// end of the table, so the next run wraps to the beginning.
+func (r *Reconciler) saveCursor(ctx context.Context, name, token string) {
+	if r.Cursors == nil {
+		return
+	}
+	if err := r.Cursors.PutScanCursor(ctx, name, token); err != nil {
+		slog.Warn("failed to save scan cursor", "scan", name, "error", err)
+	}
+}
+
+// notify delivers queued webhooks in batches. Delivery is best-effort: the
+// state changes are already recorded, so failures are only logged.
+func (r *Reconciler) notify(ctx context.Context, payloads []models.WebhookPayload) {
+	if len(payloads) == 0 {
+		return
+	}
+	if err := r.Webhook.NotifyBatch(ctx, payloads); err != nil {
+		var batchErr *webhook.BatchError
+		if errors.As(err, &batchErr) {
+			slog.Warn("some reconciler webhooks were not delivered",
+				"failed_request_ids", batchErr.RequestIDs,
+				"total", batchErr.Total,
+				"error", err,
+			)
+			return
+		}
+		slog.Warn("reconciler webhooks were not delivered", "count", len(payloads), "error", err)
+	}
+}
@@ -0,0 +1,1004 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+	"github.com/dgwhited/jit-aws-controller/internal/webhook"
+)
+
+type mockStore struct {
+	requests []models.JitRequest
+	updated  []string
+	released int
+	configs  map[string]*models.JitConfig // key: "channelID|accountID"
+
+	// sparseIndex leaves requests without an end_time out of end_time
+	// queries, as gsi_status_endtime does.
+	sparseIndex bool
+}
+
+func (m *mockStore) GetConfig(_ context.Context, channelID, accountID string) (*models.JitConfig, error) {
+	return m.configs[channelID+"|"+accountID], nil
+}
+
+func (m *mockStore) QueryRequestsByStatus(_ context.Context, status string, beforeEndTime string, _ int32) ([]models.JitRequest, error) {
+	var out []models.JitRequest
+	for _, req := range m.requests {
+		if req.Status != status {
+			continue
+		}
+		if beforeEndTime != "" && req.EndTime > beforeEndTime {
+			continue
+		}
+		if m.sparseIndex && req.EndTime == "" {
+			continue
+		}
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+func (m *mockStore) ScanGrantsMissingEndTime(_ context.Context, _ int32, _ string) ([]models.JitRequest, string, error) {
+	var out []models.JitRequest
+	for _, req := range m.requests {
+		if req.Status == models.StatusGranted && req.EndTime == "" {
+			out = append(out, req)
+		}
+	}
+	return out, "", nil
+}
+
+func (m *mockStore) ConditionalUpdateStatus(_ context.Context, requestID, _ string, updates map[string]interface{}) error {
+	m.updated = append(m.updated, requestID)
+	for i := range m.requests {
+		if m.requests[i].RequestID == requestID {
+			if status, ok := updates["status"].(string); ok {
+				m.requests[i].Status = status
+			}
+			if info, ok := updates["error_info"].(models.ErrorInfo); ok {
+				m.requests[i].ErrorInfo = &info
+			}
+			if n, ok := updates["revoke_attempts"].(int); ok {
+				m.requests[i].RevokeAttempts = n
+			}
+			if endTime, ok := updates["end_time"].(string); ok {
+				m.requests[i].EndTime = endTime
+			}
+			if as, ok := updates["assignment_status"].(string); ok {
+				m.requests[i].AssignmentStatus = as
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockStore) MarkExpiryWarned(_ context.Context, requestID, warnedAt string) error {
+	for i := range m.requests {
+		if m.requests[i].RequestID != requestID {
+			continue
+		}
+		if m.requests[i].WarnedAt != "" {
+			return errors.New("conditional check failed")
+		}
+		m.requests[i].WarnedAt = warnedAt
+		return nil
+	}
+	return errors.New("not found")
+}
+
+func (m *mockStore) MarkReminded(_ context.Context, requestID, remindedAt, remindedBefore string) error {
+	for i := range m.requests {
+		if m.requests[i].RequestID != requestID {
+			continue
+		}
+		if m.requests[i].LastRemindedAt > remindedBefore {
+			return errors.New("conditional check failed")
+		}
+		m.requests[i].LastRemindedAt = remindedAt
+		return nil
+	}
+	return errors.New("not found")
+}
+
+func (m *mockStore) IncrementActiveGrants(_ context.Context, _, _ string, _ int) error {
+	return nil
+}
+
+func (m *mockStore) DecrementActiveGrants(_ context.Context, _, _ string) error {
+	m.released++
+	return nil
+}
+
+type mockIdentity struct {
+	revoked   int
+	revokeErr error
+}
+
+func (m *mockIdentity) LookupUserByEmail(_ context.Context, _ string) (string, error) {
+	return "", nil
+}
+
+func (m *mockIdentity) GrantAccess(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (m *mockIdentity) RevokeAccess(_ context.Context, _, _ string) error {
+	if m.revokeErr != nil {
+		return m.revokeErr
+	}
+	m.revoked++
+	return nil
+}
+
+func (m *mockIdentity) GrantPermissionSet(_ context.Context, _, _, _ string) error {
+	return nil
+}
+
+func (m *mockIdentity) RevokePermissionSet(_ context.Context, _, _, _ string) error {
+	m.revoked++
+	return nil
+}
+
+type mockWebhook struct {
+	payloads []models.WebhookPayload
+	batches  int
+	err      error
+}
+
+func (m *mockWebhook) NotifyBatch(_ context.Context, payloads []models.WebhookPayload) error {
+	m.batches++
+	m.payloads = append(m.payloads, payloads...)
+	return m.err
+}
+
+type mockAudit struct {
+	events []string
+}
+
+func (m *mockAudit) Log(_ context.Context, _, eventType, _, _ string, _ models.Actor, _ map[string]string) error {
+	m.events = append(m.events, eventType)
+	return nil
+}
+
+// mockNonces serves the nonce page named by each token ("page-N", or "" for
+// the first) and records deletions.
+type mockNonces struct {
+	pages   [][]models.NonceEntry
+	tokens  []string
+	deleted []string
+}
+
+func (m *mockNonces) ScanNonces(_ context.Context, _ int32, nextToken string) ([]models.NonceEntry, string, error) {
+	m.tokens = append(m.tokens, nextToken)
+	page := 0
+	if nextToken != "" {
+		page, _ = strconv.Atoi(strings.TrimPrefix(nextToken, "page-"))
+	}
+	next := ""
+	if page+1 < len(m.pages) {
+		next = "page-" + strconv.Itoa(page+1)
+	}
+	return m.pages[page], next, nil
+}
+
+func (m *mockNonces) DeleteNonce(_ context.Context, _, nonce string, _ int64) error {
+	m.deleted = append(m.deleted, nonce)
+	return nil
+}
+
+// mockCursors keeps saved scan cursors in memory.
+type mockCursors map[string]string
+
+func (m mockCursors) GetScanCursor(_ context.Context, name string) (string, error) {
+	return m[name], nil
+}
+
+func (m mockCursors) PutScanCursor(_ context.Context, name, token string) error {
+	m[name] = token
+	return nil
+}
+
+func newTestReconciler() (*Reconciler, *mockStore, *mockIdentity) {
+	store := &mockStore{requests: []models.JitRequest{
+		{RequestID: "req-1", AccountID: "acct1", Status: models.StatusGranted},
+		{RequestID: "req-2", AccountID: "acct1", Status: models.StatusGranted},
+	}}
+	id := &mockIdentity{}
+	return &Reconciler{
+		DB:       store,
+		Identity: id,
+		Webhook:  &mockWebhook{},
+		Audit:    &mockAudit{},
+	}, store, id
+}
+
+func TestHandle_RevokesAllWithinBudget(t *testing.T) {
+	r, store, id := newTestReconciler()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := r.Handle(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.revoked != 2 {
+		t.Errorf("expected 2 revocations, got %d", id.revoked)
+	}
+	if len(store.updated) != 2 {
+		t.Errorf("expected 2 status updates, got %d", len(store.updated))
+	}
+	if store.released != 2 {
+		t.Errorf("expected 2 grant slots released, got %d", store.released)
+	}
+}
+
+func TestHandle_EscalatesAfterMaxRevokeAttempts(t *testing.T) {
+	store := &mockStore{requests: []models.JitRequest{
+		{RequestID: "req-1", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusGranted},
+	}}
+	id := &mockIdentity{revokeErr: errors.New("ResourceNotFoundException: permission set deleted")}
+	hook := &mockWebhook{}
+	au := &mockAudit{}
+	r := &Reconciler{DB: store, Identity: id, Webhook: hook, Audit: au, MaxRevokeAttempts: 3}
+
+	for run := 1; run <= 2; run++ {
+		if err := r.Handle(context.Background()); err == nil {
+			t.Fatalf("run %d: expected the failed revoke to be reported", run)
+		}
+		req := store.requests[0]
+		if req.Status != models.StatusGranted || req.RevokeAttempts != run {
+			t.Fatalf("run %d: expected GRANTED with %d revoke attempts, got %s with %d", run, run, req.Status, req.RevokeAttempts)
+		}
+		if req.ErrorInfo == nil || req.ErrorInfo.Phase != models.ErrorPhaseRevoke {
+			t.Errorf("run %d: expected the last revoke error recorded, got %+v", run, req.ErrorInfo)
+		}
+		if len(hook.payloads) != 0 || len(au.events) != 0 {
+			t.Fatalf("run %d: expected no alert before the last attempt, got %d webhooks and %v", run, len(hook.payloads), au.events)
+		}
+	}
+
+	if err := r.Handle(context.Background()); err == nil {
+		t.Fatal("run 3: expected the failed revoke to be reported")
+	}
+	req := store.requests[0]
+	if req.Status != models.StatusError || req.RevokeAttempts != 3 {
+		t.Fatalf("expected ERROR after 3 attempts, got %s with %d", req.Status, req.RevokeAttempts)
+	}
+	if len(au.events) != 1 || au.events[0] != models.EventError {
+		t.Errorf("expected one ERROR audit event, got %v", au.events)
+	}
+	if len(hook.payloads) != 1 {
+		t.Fatalf("expected one alert webhook, got %d", len(hook.payloads))
+	}
+	alert := hook.payloads[0]
+	if alert.Status != models.StatusError || alert.Details["action"] != ManualInterventionAction || alert.Details["revoke_attempts"] != "3" {
+		t.Errorf("expected a manual intervention alert after 3 attempts, got %+v", alert)
+	}
+
+	// ERROR requests are no longer retried or alerted on.
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("run 4: unexpected error: %v", err)
+	}
+	if len(hook.payloads) != 1 || len(au.events) != 1 {
+		t.Errorf("expected no further alerts, got %d webhooks and %v", len(hook.payloads), au.events)
+	}
+}
+
+func TestHandle_RevokeFailureFinalByDefault(t *testing.T) {
+	r, store, id := newTestReconciler()
+	id.revokeErr = errors.New("AccessDeniedException")
+
+	if err := r.Handle(context.Background()); err == nil {
+		t.Fatal("expected the failed revokes to be reported")
+	}
+	for _, req := range store.requests {
+		if req.Status != models.StatusError || req.RevokeAttempts != 1 {
+			t.Errorf("%s: expected ERROR after one attempt without MaxRevokeAttempts, got %s with %d", req.RequestID, req.Status, req.RevokeAttempts)
+		}
+	}
+}
+
+func TestHandle_RepairsGrantsMissingEndTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &mockStore{sparseIndex: true, requests: []models.JitRequest{
+		{RequestID: "ended", AccountID: "acct1", Status: models.StatusGranted,
+			GrantTime: now.Add(-2 * time.Hour).Format(time.RFC3339), ApprovedDurationMinutes: 60},
+		{RequestID: "running", AccountID: "acct1", Status: models.StatusGranted,
+			GrantTime: now.Add(-10 * time.Minute).Format(time.RFC3339), RequestedDurationMinutes: 60},
+		{RequestID: "unknown", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusGranted},
+	}}
+	id := &mockIdentity{}
+	hook := &mockWebhook{}
+	au := &mockAudit{}
+	r := &Reconciler{DB: store, Identity: id, Webhook: hook, Audit: au, MissingEndTimes: store, Clock: clock.NewMock(now)}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ended, running, unknown := store.requests[0], store.requests[1], store.requests[2]
+	if ended.EndTime != now.Add(-time.Hour).Format(time.RFC3339) || ended.Status != models.StatusExpired {
+		t.Errorf("expected the ended grant backfilled and expired in the same run, got end_time %q and %s", ended.EndTime, ended.Status)
+	}
+	if running.EndTime != now.Add(50*time.Minute).Format(time.RFC3339) || running.Status != models.StatusGranted {
+		t.Errorf("expected the running grant backfilled and left GRANTED, got end_time %q and %s", running.EndTime, running.Status)
+	}
+	if id.revoked != 1 {
+		t.Errorf("expected only the ended grant revoked, got %d revocations", id.revoked)
+	}
+
+	if unknown.Status != models.StatusError || unknown.ErrorInfo == nil || unknown.ErrorInfo.Code != "MissingEndTime" {
+		t.Fatalf("expected the grant with no derivable end flagged ERROR, got %s with %+v", unknown.Status, unknown.ErrorInfo)
+	}
+	var alert *models.WebhookPayload
+	for i, p := range hook.payloads {
+		if p.RequestID == "unknown" {
+			alert = &hook.payloads[i]
+		}
+	}
+	if alert == nil || alert.Status != models.StatusError || alert.Details["action"] != ManualInterventionAction {
+		t.Errorf("expected a manual intervention alert for the flagged grant, got %+v", alert)
+	}
+}
+
+func TestHandle_GrantMissingEndTimeInvisibleWithoutRepair(t *testing.T) {
+	store := &mockStore{sparseIndex: true, requests: []models.JitRequest{
+		{RequestID: "req-1", AccountID: "acct1", Status: models.StatusGranted},
+	}}
+	id := &mockIdentity{}
+	r := &Reconciler{DB: store, Identity: id, Webhook: &mockWebhook{}, Audit: &mockAudit{}}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.revoked != 0 || store.requests[0].Status != models.StatusGranted {
+		t.Errorf("expected the expiry query alone to miss the grant, got %d revocations and %s", id.revoked, store.requests[0].Status)
+	}
+}
+
+func TestHandle_NearDeadlineExitsEarly(t *testing.T) {
+	r, store, id := newTestReconciler()
+	r.SafetyMargin = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := r.Handle(ctx); err != nil {
+		t.Fatalf("expected early exit without error, got: %v", err)
+	}
+	if id.revoked != 0 {
+		t.Errorf("expected no revocations near the deadline, got %d", id.revoked)
+	}
+	if len(store.updated) != 0 {
+		t.Errorf("expected no status updates near the deadline, got %d", len(store.updated))
+	}
+}
+
+func TestHandle_NoDeadlineProcessesAll(t *testing.T) {
+	r, _, id := newTestReconciler()
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.revoked != 2 {
+		t.Errorf("expected 2 revocations, got %d", id.revoked)
+	}
+}
+
+func TestHandle_WarnsGrantsInsideWindow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	store := &mockStore{requests: []models.JitRequest{
+		{RequestID: "expired", AccountID: "acct1", Status: models.StatusGranted, EndTime: at(-time.Minute)},
+		{RequestID: "now", AccountID: "acct1", Status: models.StatusGranted, EndTime: at(0)},
+		{RequestID: "soon", AccountID: "acct1", Status: models.StatusGranted, EndTime: at(5 * time.Minute)},
+		{RequestID: "edge", AccountID: "acct1", Status: models.StatusGranted, EndTime: at(10 * time.Minute)},
+		{RequestID: "later", AccountID: "acct1", Status: models.StatusGranted, EndTime: at(10*time.Minute + time.Second)},
+		{RequestID: "pending", AccountID: "acct1", Status: models.StatusPending, EndTime: at(5 * time.Minute)},
+	}}
+	hook := &mockWebhook{}
+	r := &Reconciler{
+		DB:            store,
+		Identity:      &mockIdentity{},
+		Webhook:       hook,
+		Audit:         &mockAudit{},
+		WarningWindow: 10 * time.Minute,
+		Clock:         clock.NewMock(now),
+	}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warned := map[string]bool{}
+	for _, p := range hook.payloads {
+		if p.Status == models.StatusExpiringSoon {
+			warned[p.RequestID] = true
+			if p.Details["end_time"] == "" {
+				t.Errorf("%s: expected end_time in warning details", p.RequestID)
+			}
+		}
+	}
+	if !warned["soon"] || !warned["edge"] {
+		t.Errorf("expected grants inside the window to be warned, got %v", warned)
+	}
+	if warned["later"] || warned["expired"] || warned["now"] || warned["pending"] {
+		t.Errorf("expected only grants inside the window to be warned, got %v", warned)
+	}
+}
+
+// applyClockUpdates applies pause or resume updates to req as the store would.
+func applyClockUpdates(req *models.JitRequest, updates map[string]interface{}) {
+	req.PausedAt = updates["paused_at"].(string)
+	if n, ok := updates["paused_seconds"].(int); ok {
+		req.PausedSeconds = n
+	}
+	if v, ok := updates["effective_end_time"].(string); ok {
+		req.EffectiveEndTime = v
+	}
+}
+
+func TestHandle_PausedGrantExpiresAtEffectiveEndTime(t *testing.T) {
+	day := func(hour, min int) time.Time { return time.Date(2025, 6, 1, hour, min, 0, 0, time.UTC) }
+	req := models.JitRequest{RequestID: "req-1", AccountID: "acct1", Status: models.StatusGranted, EndTime: "2025-06-01T10:00:00Z"}
+	store := &mockStore{}
+	clk := clock.NewMock(day(9, 0))
+	id := &mockIdentity{}
+	hook := &mockWebhook{}
+	r := &Reconciler{
+		DB:            store,
+		Identity:      id,
+		Webhook:       hook,
+		Audit:         &mockAudit{},
+		WarningWindow: 15 * time.Minute,
+		Clock:         clk,
+	}
+	run := func(at time.Time) {
+		t.Helper()
+		clk.Set(at)
+		store.requests = []models.JitRequest{req}
+		if err := r.Handle(context.Background()); err != nil {
+			t.Fatalf("run at %s: unexpected error: %v", at.Format(time.Kitchen), err)
+		}
+		req = store.requests[0]
+	}
+
+	updates, err := req.PauseClockUpdates(day(9, 0))
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	applyClockUpdates(&req, updates)
+
+	// Paused past end_time: neither expired nor warned.
+	run(day(10, 15))
+	if req.Status != models.StatusGranted || id.revoked != 0 || len(hook.payloads) != 0 {
+		t.Fatalf("expected the paused grant left alone, got status %s, %d revokes, %d webhooks", req.Status, id.revoked, len(hook.payloads))
+	}
+
+	// Resumed after 80 minutes paused: expiry moves from 10:00 to 11:20.
+	updates, err = req.ResumeClockUpdates(day(10, 20))
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	applyClockUpdates(&req, updates)
+	if req.EffectiveEndTime != "2025-06-01T11:20:00Z" || req.EndTime != "2025-06-01T10:00:00Z" || req.ClockPaused() {
+		t.Fatalf("expected effective expiry 11:20 with end_time kept, got %+v", req)
+	}
+
+	run(day(11, 10))
+	if req.Status != models.StatusGranted || id.revoked != 0 {
+		t.Fatalf("expected the grant to outlive its end_time, got status %s", req.Status)
+	}
+	if len(hook.payloads) != 1 || hook.payloads[0].Status != models.StatusExpiringSoon || hook.payloads[0].Details["end_time"] != "2025-06-01T11:20:00Z" {
+		t.Errorf("expected one expiry warning for the effective expiry, got %+v", hook.payloads)
+	}
+
+	run(day(11, 20))
+	if req.Status != models.StatusExpired || id.revoked != 1 {
+		t.Errorf("expected the grant expired at its effective expiry, got status %s with %d revokes", req.Status, id.revoked)
+	}
+}
+
+func TestHandle_WarnsOnce(t *testing.T) {
+	end := time.Now().UTC().Add(5 * time.Minute).Format(time.RFC3339)
+	store := &mockStore{requests: []models.JitRequest{
+		{RequestID: "req-1", AccountID: "acct1", Status: models.StatusGranted, EndTime: end},
+	}}
+	hook := &mockWebhook{}
+	r := &Reconciler{
+		DB:            store,
+		Identity:      &mockIdentity{},
+		Webhook:       hook,
+		Audit:         &mockAudit{},
+		WarningWindow: 10 * time.Minute,
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := r.Handle(context.Background()); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+	}
+	if len(hook.payloads) != 1 {
+		t.Fatalf("expected exactly one warning across runs, got %d", len(hook.payloads))
+	}
+	if store.requests[0].WarnedAt == "" {
+		t.Error("expected warned_at to be recorded")
+	}
+}
+
+func TestHandle_WarningOffersExtendOnlyWhenAllowed(t *testing.T) {
+	end := time.Now().UTC().Add(5 * time.Minute).Format(time.RFC3339)
+	store := &mockStore{
+		requests: []models.JitRequest{
+			{RequestID: "short", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1", Status: models.StatusGranted, EndTime: end, RequestedDurationMinutes: 60},
+			{RequestID: "at-max", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-2", Status: models.StatusGranted, EndTime: end, RequestedDurationMinutes: 240},
+			{RequestID: "trimmed", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-3", Status: models.StatusGranted, EndTime: end, RequestedDurationMinutes: 240, ApprovedDurationMinutes: 120},
+			{RequestID: "paused", ChannelID: "ch1", AccountID: "acct2", RequesterMMUserID: "user-4", Status: models.StatusGranted, EndTime: end, RequestedDurationMinutes: 60},
+			{RequestID: "unbound", ChannelID: "ch1", AccountID: "acct3", RequesterMMUserID: "user-5", Status: models.StatusGranted, EndTime: end, RequestedDurationMinutes: 60},
+		},
+		configs: map[string]*models.JitConfig{
+			"ch1|acct1": {ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4},
+			"ch1|acct2": {ChannelID: "ch1", AccountID: "acct2", MaxRequestHours: 4, Paused: true},
+		},
+	}
+	hook := &mockWebhook{}
+	r := &Reconciler{DB: store, Identity: &mockIdentity{}, Webhook: hook, Audit: &mockAudit{}, WarningWindow: 10 * time.Minute}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]bool{"short": true, "at-max": false, "trimmed": true, "paused": false, "unbound": false}
+	if len(hook.payloads) != len(want) {
+		t.Fatalf("expected %d warnings, got %d", len(want), len(hook.payloads))
+	}
+	for _, p := range hook.payloads {
+		offered := p.Details["action"] == ExtendAvailableAction
+		if offered != want[p.RequestID] {
+			t.Errorf("%s: expected extend hint %v, got details %v", p.RequestID, want[p.RequestID], p.Details)
+		}
+		if p.Details["requester_mm_user_id"] == "" {
+			t.Errorf("%s: expected requester_mm_user_id in warning details", p.RequestID)
+		}
+	}
+}
+
+func TestHandle_NoWindowSendsNoWarnings(t *testing.T) {
+	end := time.Now().UTC().Add(5 * time.Minute).Format(time.RFC3339)
+	store := &mockStore{requests: []models.JitRequest{
+		{RequestID: "req-1", AccountID: "acct1", Status: models.StatusGranted, EndTime: end},
+	}}
+	hook := &mockWebhook{}
+	r := &Reconciler{DB: store, Identity: &mockIdentity{}, Webhook: hook, Audit: &mockAudit{}}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hook.payloads) != 0 {
+		t.Errorf("expected no webhooks with warnings disabled, got %d", len(hook.payloads))
+	}
+}
+
+func TestHandle_BatchesExpiryWebhooks(t *testing.T) {
+	r, _, _ := newTestReconciler()
+	hook := &mockWebhook{}
+	r.Webhook = hook
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hook.batches != 1 {
+		t.Errorf("expected one batched delivery, got %d", hook.batches)
+	}
+	if len(hook.payloads) != 2 {
+		t.Fatalf("expected 2 EXPIRED payloads, got %d", len(hook.payloads))
+	}
+	for _, p := range hook.payloads {
+		if p.Status != models.StatusExpired {
+			t.Errorf("expected EXPIRED, got %s", p.Status)
+		}
+	}
+}
+
+func TestHandle_BatchFailureDoesNotFailRun(t *testing.T) {
+	r, store, _ := newTestReconciler()
+	r.Webhook = &mockWebhook{err: &webhook.BatchError{RequestIDs: []string{"req-2"}, Total: 2}}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("expected webhook failures to be logged only, got: %v", err)
+	}
+	if len(store.updated) != 2 {
+		t.Errorf("expected both grants expired regardless of webhook delivery, got %d", len(store.updated))
+	}
+}
+
+func newReminderReconciler(now time.Time, requests ...models.JitRequest) (*Reconciler, *mockStore, *mockWebhook, *clock.Mock) {
+	store := &mockStore{requests: requests}
+	hook := &mockWebhook{}
+	clk := clock.NewMock(now)
+	return &Reconciler{
+		DB:               store,
+		Identity:         &mockIdentity{},
+		Webhook:          hook,
+		Audit:            &mockAudit{},
+		ReminderInterval: 30 * time.Minute,
+		ReminderCooldown: time.Hour,
+		Clock:            clk,
+	}, store, hook, clk
+}
+
+func TestHandle_RemindsApproversOfStalePendingRequests(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+	r, store, hook, _ := newReminderReconciler(now,
+		models.JitRequest{RequestID: "stale", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusPending,
+			RequesterEmail: "user@example.com", CreatedAt: at(-45 * time.Minute)},
+		models.JitRequest{RequestID: "edge", AccountID: "acct1", Status: models.StatusPending, CreatedAt: at(-30 * time.Minute)},
+		models.JitRequest{RequestID: "fresh", AccountID: "acct1", Status: models.StatusPending, CreatedAt: at(-10 * time.Minute)},
+		models.JitRequest{RequestID: "approved", AccountID: "acct1", Status: models.StatusApproved, CreatedAt: at(-2 * time.Hour)},
+	)
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reminded := map[string]models.WebhookPayload{}
+	for _, p := range hook.payloads {
+		if p.Status == models.StatusApprovalReminder {
+			reminded[p.RequestID] = p
+		}
+	}
+	if len(reminded) != 2 {
+		t.Fatalf("expected stale and edge to be reminded, got %v", reminded)
+	}
+	p, ok := reminded["stale"]
+	if !ok || p.ChannelID != "ch1" || p.Details["requester_email"] != "user@example.com" || p.Details["created_at"] == "" {
+		t.Errorf("unexpected reminder payload: %+v", p)
+	}
+	if _, ok := reminded["edge"]; !ok {
+		t.Error("expected a request pending exactly the interval to be reminded")
+	}
+	if store.requests[0].LastRemindedAt != now.Format(time.RFC3339) {
+		t.Errorf("expected last_reminded_at recorded, got %q", store.requests[0].LastRemindedAt)
+	}
+	if store.requests[2].LastRemindedAt != "" || store.requests[3].LastRemindedAt != "" {
+		t.Error("expected fresh and non-pending requests left untouched")
+	}
+}
+
+func TestHandle_ReminderSuppressedByCooldown(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r, store, hook, clk := newReminderReconciler(now, models.JitRequest{
+		RequestID: "req-1", AccountID: "acct1", Status: models.StatusPending,
+		CreatedAt:      now.Add(-2 * time.Hour).Format(time.RFC3339),
+		LastRemindedAt: now.Add(-40 * time.Minute).Format(time.RFC3339),
+	})
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hook.payloads) != 0 {
+		t.Fatalf("expected no reminder inside the cooldown, got %d", len(hook.payloads))
+	}
+
+	clk.Advance(20 * time.Minute)
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hook.payloads) != 1 || hook.payloads[0].Status != models.StatusApprovalReminder {
+		t.Fatalf("expected one reminder once the cooldown elapsed, got %+v", hook.payloads)
+	}
+	if got := store.requests[0].LastRemindedAt; got != clk.Now().Format(time.RFC3339) {
+		t.Errorf("expected last_reminded_at advanced, got %q", got)
+	}
+}
+
+func TestHandle_NoReminderIntervalSendsNoReminders(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r, _, hook, _ := newReminderReconciler(now, models.JitRequest{
+		RequestID: "req-1", AccountID: "acct1", Status: models.StatusPending,
+		CreatedAt: now.Add(-2 * time.Hour).Format(time.RFC3339),
+	})
+	r.ReminderInterval = 0
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hook.payloads) != 0 {
+		t.Errorf("expected reminders disabled, got %d", len(hook.payloads))
+	}
+}
+
+func newGraceReconciler(now time.Time, requests ...models.JitRequest) (*Reconciler, *mockStore, *mockWebhook) {
+	store := &mockStore{requests: requests}
+	hook := &mockWebhook{}
+	return &Reconciler{
+		DB:            store,
+		Identity:      &mockIdentity{},
+		Webhook:       hook,
+		Audit:         &mockAudit{},
+		ApprovalGrace: 30 * time.Minute,
+		Clock:         clock.NewMock(now),
+	}, store, hook
+}
+
+func TestHandle_FailsStalledApprovals(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+	r, store, hook := newGraceReconciler(now,
+		models.JitRequest{RequestID: "stalled", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusApproved,
+			RequesterEmail: "user@example.com", ApprovedAt: at(-45 * time.Minute)},
+		models.JitRequest{RequestID: "fresh", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusApproved,
+			ApprovedAt: at(-10 * time.Minute)},
+		models.JitRequest{RequestID: "pending", AccountID: "acct1", Status: models.StatusPending, CreatedAt: at(-2 * time.Hour)},
+	)
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stalled := store.requests[0]
+	if stalled.Status != models.StatusError || stalled.ErrorInfo == nil {
+		t.Fatalf("expected stalled approval moved to ERROR with error info, got %s %+v", stalled.Status, stalled.ErrorInfo)
+	}
+	if stalled.ErrorInfo.Phase != models.ErrorPhaseGrant || stalled.ErrorInfo.Code != "GrantWorkflowTimeout" {
+		t.Errorf("unexpected error info: %+v", stalled.ErrorInfo)
+	}
+	if store.requests[1].Status != models.StatusApproved || store.requests[2].Status != models.StatusPending {
+		t.Errorf("expected fresh approval and pending request untouched, got %s and %s",
+			store.requests[1].Status, store.requests[2].Status)
+	}
+	if len(hook.payloads) != 1 {
+		t.Fatalf("expected one ERROR notification, got %+v", hook.payloads)
+	}
+	if p := hook.payloads[0]; p.RequestID != "stalled" || p.Status != models.StatusError || p.ChannelID != "ch1" ||
+		p.Details["phase"] != models.ErrorPhaseGrant {
+		t.Errorf("unexpected notification: %+v", p)
+	}
+}
+
+func TestHandle_StalledApprovalRevokesHalfMadeAssignment(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	approvedAt := now.Add(-45 * time.Minute).Format(time.RFC3339)
+	r, store, hook := newGraceReconciler(now,
+		models.JitRequest{RequestID: "creating", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusApproved,
+			ApprovedAt: approvedAt, AssignmentStatus: models.AssignmentCreating},
+		models.JitRequest{RequestID: "never-started", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusApproved,
+			ApprovedAt: approvedAt},
+	)
+	idp := r.Identity.(*mockIdentity)
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if idp.revoked != 1 {
+		t.Errorf("expected only the half-made assignment revoked, got %d revocations", idp.revoked)
+	}
+	creating := store.requests[0]
+	if creating.Status != models.StatusError || creating.AssignmentStatus != models.AssignmentDeleted {
+		t.Errorf("expected ERROR with assignment DELETED, got %s/%q", creating.Status, creating.AssignmentStatus)
+	}
+	if store.released != 1 {
+		t.Errorf("expected the revoked grant's slot released, got %d", store.released)
+	}
+	if store.requests[1].Status != models.StatusError {
+		t.Errorf("expected the never-started approval failed, got %s", store.requests[1].Status)
+	}
+	if len(hook.payloads) != 2 {
+		t.Errorf("expected two ERROR notifications, got %d", len(hook.payloads))
+	}
+}
+
+func TestHandle_StalledApprovalRevokeFailureRetriesNextRun(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r, store, hook := newGraceReconciler(now, models.JitRequest{
+		RequestID: "created", AccountID: "acct1", ChannelID: "ch1", Status: models.StatusApproved,
+		ApprovedAt: now.Add(-45 * time.Minute).Format(time.RFC3339), AssignmentStatus: models.AssignmentCreated,
+	})
+	idp := r.Identity.(*mockIdentity)
+	idp.revokeErr = errors.New("sso unavailable")
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.requests[0].Status != models.StatusApproved || len(hook.payloads) != 0 {
+		t.Fatalf("expected the approval left for the next run while access may remain, got %s with %d notifications",
+			store.requests[0].Status, len(hook.payloads))
+	}
+
+	idp.revokeErr = nil
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.requests[0].Status != models.StatusError || store.requests[0].AssignmentStatus != models.AssignmentDeleted {
+		t.Errorf("expected ERROR with assignment DELETED once revoked, got %s/%q",
+			store.requests[0].Status, store.requests[0].AssignmentStatus)
+	}
+}
+
+func TestHandle_NoApprovalGraceLeavesApprovals(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r, store, hook := newGraceReconciler(now, models.JitRequest{
+		RequestID: "req-1", AccountID: "acct1", Status: models.StatusApproved,
+		ApprovedAt: now.Add(-24 * time.Hour).Format(time.RFC3339),
+	})
+	r.ApprovalGrace = 0
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.requests[0].Status != models.StatusApproved || len(hook.payloads) != 0 {
+		t.Errorf("expected approvals left alone when disabled, got %s with %d notifications",
+			store.requests[0].Status, len(hook.payloads))
+	}
+}
+
+func TestNonceSweepable(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		entry models.NonceEntry
+		want  bool
+	}{
+		{"not yet expired", models.NonceEntry{ExpiresAt: now.Add(time.Minute).Unix()}, false},
+		{"expired within grace", models.NonceEntry{ExpiresAt: now.Add(-time.Hour).Unix()}, false},
+		{"expired past grace", models.NonceEntry{ExpiresAt: now.Add(-nonceSweepGrace).Unix()}, true},
+		{"no expiry, old", models.NonceEntry{CreatedAt: now.Add(-72 * time.Hour).Format(time.RFC3339)}, true},
+		{"no expiry, recent", models.NonceEntry{CreatedAt: now.Add(-time.Hour).Format(time.RFC3339)}, false},
+		{"no expiry or creation time", models.NonceEntry{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nonceSweepable(tt.entry, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandle_SweepsExpiredNonces(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	stale := now.Add(-72 * time.Hour).Unix()
+	nonces := &mockNonces{pages: [][]models.NonceEntry{
+		{{KeyID: "k1", Nonce: "stale-1", ExpiresAt: stale}, {KeyID: "k1", Nonce: "live", ExpiresAt: now.Add(time.Minute).Unix()}},
+		{{KeyID: "k1", Nonce: "stale-2", ExpiresAt: stale}},
+	}}
+	r := &Reconciler{
+		DB:       &mockStore{},
+		Identity: &mockIdentity{},
+		Webhook:  &mockWebhook{},
+		Audit:    &mockAudit{},
+		Nonces:   nonces,
+		Clock:    clock.NewMock(now),
+	}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nonces.tokens) != 2 || nonces.tokens[1] != "page-1" {
+		t.Errorf("expected both pages scanned in order, got tokens %v", nonces.tokens)
+	}
+	if len(nonces.deleted) != 2 || nonces.deleted[0] != "stale-1" || nonces.deleted[1] != "stale-2" {
+		t.Errorf("expected only stale nonces deleted, got %v", nonces.deleted)
+	}
+}
+
+func TestHandle_NonceSweepResumesWhereLastRunStopped(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	live := models.NonceEntry{KeyID: "k1", Nonce: "live", ExpiresAt: now.Add(time.Minute).Unix()}
+	// Live nonces fill more pages than one run scans; the only stale one
+	// sits behind them.
+	pages := make([][]models.NonceEntry, nonceSweepPages+2)
+	for i := range pages {
+		pages[i] = []models.NonceEntry{live}
+	}
+	pages[nonceSweepPages+1] = []models.NonceEntry{{KeyID: "k1", Nonce: "stale", ExpiresAt: now.Add(-72 * time.Hour).Unix()}}
+	nonces := &mockNonces{pages: pages}
+	cursors := mockCursors{}
+	r := &Reconciler{
+		DB:       &mockStore{},
+		Identity: &mockIdentity{},
+		Webhook:  &mockWebhook{},
+		Audit:    &mockAudit{},
+		Nonces:   nonces,
+		Cursors:  cursors,
+		Clock:    clock.NewMock(now),
+	}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("first run: unexpected error: %v", err)
+	}
+	if len(nonces.deleted) != 0 || cursors[nonceSweepCursor] != "page-"+strconv.Itoa(nonceSweepPages) {
+		t.Fatalf("expected the first run capped with its position saved, got deleted %v and cursor %q", nonces.deleted, cursors[nonceSweepCursor])
+	}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("second run: unexpected error: %v", err)
+	}
+	if len(nonces.deleted) != 1 || nonces.deleted[0] != "stale" {
+		t.Errorf("expected the second run to reach the stale nonce, got %v", nonces.deleted)
+	}
+	if got, ok := cursors[nonceSweepCursor]; !ok || got != "" {
+		t.Errorf("expected the cursor reset after the end of the table, got %q", got)
+	}
+	if len(nonces.tokens) != len(pages) {
+		t.Errorf("expected each page scanned once across both runs, got tokens %v", nonces.tokens)
+	}
+}
+
+// pagedMissingEndTimes serves scan pages by token ("page-N", or "" for the
+// first) from store, matching only the requests listed for each page, as a
+// filtered Scan returns empty pages for items that do not match.
+type pagedMissingEndTimes struct {
+	store  *mockStore
+	pages  [][]string // request IDs read on each page
+	tokens []string
+}
+
+func (m *pagedMissingEndTimes) ScanGrantsMissingEndTime(_ context.Context, _ int32, nextToken string) ([]models.JitRequest, string, error) {
+	m.tokens = append(m.tokens, nextToken)
+	page := 0
+	if nextToken != "" {
+		page, _ = strconv.Atoi(strings.TrimPrefix(nextToken, "page-"))
+	}
+	var out []models.JitRequest
+	for _, id := range m.pages[page] {
+		for _, req := range m.store.requests {
+			if req.RequestID == id && req.Status == models.StatusGranted && req.EndTime == "" {
+				out = append(out, req)
+			}
+		}
+	}
+	next := ""
+	if page+1 < len(m.pages) {
+		next = "page-" + strconv.Itoa(page+1)
+	}
+	return out, next, nil
+}
+
+func TestHandle_MissingEndTimeScanResumesWhereLastRunStopped(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &mockStore{sparseIndex: true, requests: []models.JitRequest{
+		{RequestID: "far", AccountID: "acct1", Status: models.StatusGranted,
+			GrantTime: now.Add(-10 * time.Minute).Format(time.RFC3339), RequestedDurationMinutes: 60},
+	}}
+	// The only match sits past the pages one run may read.
+	pages := make([][]string, missingEndTimePages+1)
+	pages[missingEndTimePages] = []string{"far"}
+	scanner := &pagedMissingEndTimes{store: store, pages: pages}
+	cursors := mockCursors{}
+	r := &Reconciler{DB: store, Identity: &mockIdentity{}, Webhook: &mockWebhook{}, Audit: &mockAudit{},
+		MissingEndTimes: scanner, Cursors: cursors, Clock: clock.NewMock(now)}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("first run: unexpected error: %v", err)
+	}
+	if store.requests[0].EndTime != "" {
+		t.Fatalf("expected the first run to stop before the match, got end_time %q", store.requests[0].EndTime)
+	}
+	if got := cursors[missingEndTimeCursor]; got != "page-"+strconv.Itoa(missingEndTimePages) {
+		t.Fatalf("expected the scan position saved, got %q", got)
+	}
+
+	if err := r.Handle(context.Background()); err != nil {
+		t.Fatalf("second run: unexpected error: %v", err)
+	}
+	if got := store.requests[0].EndTime; got != now.Add(50*time.Minute).Format(time.RFC3339) {
+		t.Errorf("expected the second run to find and backfill the grant, got end_time %q", got)
+	}
+	if got, ok := cursors[missingEndTimeCursor]; !ok || got != "" {
+		t.Errorf("expected the cursor reset after the end of the table, got %q", got)
+	}
+	if len(scanner.tokens) != len(pages) {
+		t.Errorf("expected each page scanned once across both runs, got tokens %v", scanner.tokens)
+	}
+}
@@ -0,0 +1,117 @@
+// Package apierr defines the stable error codes returned in API error bodies.
+// Codes are part of the API contract: the plugin branches on them and uses
+// them to localize messages, so existing codes must never be renamed.
+package apierr
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Code is a stable, machine-readable error code.
+type Code string
+
+// Generic codes, one per HTTP status class the API returns.
+const (
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeConflict         Code = "CONFLICT"
+	CodeInternal         Code = "INTERNAL"
+)
+
+// Specific codes for failures the plugin is expected to handle individually.
+const (
+	CodeInvalidBody          Code = "INVALID_BODY"
+	CodeInvalidSignature     Code = "INVALID_SIGNATURE"
+	CodeRequestNotFound      Code = "REQUEST_NOT_FOUND"
+	CodeBindingNotFound      Code = "BINDING_NOT_FOUND"
+	CodeInvalidState         Code = "INVALID_STATE"
+	CodeNotApprover          Code = "NOT_APPROVER"
+	CodeNotAdmin             Code = "NOT_ADMIN"
+	CodeSelfApprovalDenied   Code = "SELF_APPROVAL_DENIED"
+	CodeSelfDenialDenied     Code = "SELF_DENIAL_DENIED"
+	CodeSelfRevokeDenied     Code = "SELF_REVOKE_DENIED"
+	CodeStrongAuthRequired   Code = "STRONG_AUTH_REQUIRED"
+	CodeInvalidApprovalToken Code = "INVALID_APPROVAL_TOKEN"
+	CodeAlreadyBound         Code = "ALREADY_BOUND"
+	CodeChannelPaused        Code = "CHANNEL_PAUSED"
+	CodeAmbiguousIdentity    Code = "AMBIGUOUS_IDENTITY"
+)
+
+// Error is the error object returned to API callers.
+// RequestID is set on request-scoped routes; Reason optionally narrows the
+// code further (for example, why a signature failed to verify).
+type Error struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Body is the top-level shape of every API error response.
+type Body struct {
+	Error Error `json:"error"`
+}
+
+// messageCodes maps handler error message fragments to specific codes. Order
+// matters: the first matching fragment wins.
+var messageCodes = []struct {
+	fragment string
+	code     Code
+}{
+	{"invalid request body", CodeInvalidBody},
+	{"strong authentication required", CodeStrongAuthRequired},
+	{"invalid approval token", CodeInvalidApprovalToken},
+	{"self-approval is not allowed", CodeSelfApprovalDenied},
+	{"self-denial is not allowed", CodeSelfDenialDenied},
+	{"self-revocation is not allowed", CodeSelfRevokeDenied},
+	{"is not an authorized approver", CodeNotApprover},
+	{"is not an admin", CodeNotAdmin},
+	{"is already bound to channel", CodeAlreadyBound},
+	{"is paused", CodeChannelPaused},
+	{"ambiguous identity", CodeAmbiguousIdentity},
+	{"no binding found", CodeBindingNotFound},
+	{"no config found", CodeBindingNotFound},
+	{"expected PENDING", CodeInvalidState},
+	{"expected GRANTED", CodeInvalidState},
+	{"illegal transition", CodeInvalidState},
+	{"closed to comments", CodeInvalidState},
+}
+
+// Classify returns the code for an error response, preferring a specific code
+// recognised from the message and falling back to the generic code for the
+// HTTP status.
+func Classify(status int, message string) Code {
+	for _, mc := range messageCodes {
+		if strings.Contains(message, mc.fragment) {
+			return mc.code
+		}
+	}
+	if status == http.StatusNotFound && strings.HasPrefix(message, "request ") {
+		return CodeRequestNotFound
+	}
+	return CodeForStatus(status)
+}
+
+// CodeForStatus returns the generic code for an HTTP status.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeValidationFailed
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	default:
+		return CodeInternal
+	}
+}
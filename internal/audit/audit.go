@@ -2,46 +2,180 @@ package audit
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
-	"github.com/dgwhited/jit-aws-controller/internal/dynamo"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/events"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
+// maxAppendAttempts bounds how often Log re-reads the chain after losing a
+// race for the next chain position to a concurrent writer.
+const maxAppendAttempts = 5
+
+// ErrChainBroken is returned (wrapped) by VerifyChain when a request's audit
+// events have been modified, removed, or re-ordered.
+var ErrChainBroken = errors.New("audit chain broken")
+
+// Store is the persistence needed by Logger. Implemented by dynamo.Client.
+type Store interface {
+	// AppendAuditEvent stores event at position seq (0-based) of its
+	// request's chain. It fails with an error wrapping
+	// models.ErrAuditChainConflict if another event already holds seq, and,
+	// when unique is set, with models.ErrAuditEventExists if an event with
+	// the same ID was already recorded.
+	AppendAuditEvent(ctx context.Context, event *models.AuditEvent, seq int, unique bool) error
+	QueryAuditByRequest(ctx context.Context, requestID string) ([]models.AuditEvent, error)
+}
+
+// UserDirectory resolves Mattermost user IDs to email addresses. An empty
+// email with a nil error means the user is unknown.
+type UserDirectory interface {
+	EmailForUserID(ctx context.Context, mmUserID string) (string, error)
+}
+
 // Logger records audit events for JIT request state transitions.
 type Logger struct {
-	db *dynamo.Client
+	db         Store
+	clock      clock.Clock
+	directory  UserDirectory
+	idempotent bool
+	publisher  events.Publisher
+
+	mu     sync.Mutex
+	emails map[string]string // mmUserID -> email, including unknown ("") users
+}
+
+// Option configures optional Logger behavior.
+type Option func(*Logger)
+
+// WithClock sets the clock used to timestamp events. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(l *Logger) {
+		l.clock = c
+	}
+}
+
+// WithUserDirectory fills in actor_email for events that only carry an actor
+// MM user ID. Lookups are cached for the life of the Logger. Without a
+// directory events are stored as given.
+func WithUserDirectory(d UserDirectory) Option {
+	return func(l *Logger) {
+		l.directory = d
+	}
+}
+
+// WithIdempotentLifecycleEvents gives lifecycle events (see IsLifecycleEvent)
+// an event ID derived from the request, event type and error phase, and
+// writes them so the store refuses a second event with that ID. A retried or
+// racing workflow step then records its GRANTED or ERROR event once. Other
+// events, such as comments, always get a random ID.
+func WithIdempotentLifecycleEvents() Option {
+	return func(l *Logger) {
+		l.idempotent = true
+	}
+}
+
+// WithPublisher publishes each recorded lifecycle event (see IsLifecycleEvent)
+// for downstream consumers. Publishing is best effort: a failure is logged and
+// does not fail Log, since the event is already recorded. Defaults to
+// events.Noop.
+func WithPublisher(p events.Publisher) Option {
+	return func(l *Logger) {
+		l.publisher = p
+	}
 }
 
 // NewLogger creates a new audit logger backed by DynamoDB.
-func NewLogger(db *dynamo.Client) *Logger {
-	return &Logger{db: db}
+func NewLogger(db Store, opts ...Option) *Logger {
+	l := &Logger{db: db, clock: clock.Real{}, publisher: events.Noop{}, emails: map[string]string{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
-// Log records an audit event with auto-generated event ID and timestamp.
-func (l *Logger) Log(ctx context.Context, requestID, eventType, accountID, channelID, actorMMUserID, actorEmail string, details map[string]string) error {
+// Log records an audit event performed by actor, with auto-generated event ID
+// and timestamp. Each event is hash-chained to the previous event for the same
+// request.
+func (l *Logger) Log(ctx context.Context, requestID, eventType, accountID, channelID string, actor models.Actor, details map[string]string) error {
+	idempotent := l.idempotent && IsLifecycleEvent(eventType)
 	eventID := uuid.New().String()
-	eventTime := time.Now().UTC().Format(time.RFC3339)
-	sortKey := eventTime + "#" + eventID
-
-	event := &models.AuditEvent{
-		RequestID:        requestID,
-		EventTimeEventID: sortKey,
-		EventID:          eventID,
-		EventTime:        eventTime,
-		EventType:        eventType,
-		AccountID:        accountID,
-		ChannelID:        channelID,
-		ActorMMUserID:    actorMMUserID,
-		ActorEmail:       actorEmail,
-		Details:          details,
-	}
-
-	if err := l.db.PutAuditEvent(ctx, event); err != nil {
+	if idempotent {
+		eventID = LifecycleEventID(requestID, eventType, details["phase"])
+	}
+	now := l.clock.Now().UTC()
+	eventTime := now.Format(time.RFC3339)
+
+	if actor.Email == "" && actor.MMUserID != "" {
+		actor.Email = l.emailFor(ctx, actor.MMUserID)
+	}
+
+	// Each attempt links to the chain tip it read and claims the next chain
+	// position. A concurrent writer that claimed it first moved the tip, so
+	// re-read and link to the new one.
+	var event *models.AuditEvent
+	for attempt := 1; ; attempt++ {
+		existing, err := l.db.QueryAuditByRequest(ctx, requestID)
+		if err != nil {
+			slog.Error("failed to read audit chain",
+				"request_id", requestID,
+				"error", err,
+			)
+			return fmt.Errorf("audit log: %w", err)
+		}
+		// The store enforces uniqueness, but events recorded before it did
+		// are only found in the chain.
+		if idempotent && hasEvent(existing, eventID) {
+			logSuppressed(requestID, eventType, eventID)
+			return nil
+		}
+
+		event = &models.AuditEvent{
+			RequestID:        requestID,
+			EventTimeEventID: sortKey(now, len(existing), eventID),
+			EventID:          eventID,
+			EventTime:        eventTime,
+			EventDate:        now.Format(models.AuditEventDateLayout),
+			EventType:        eventType,
+			AccountID:        accountID,
+			ChannelID:        channelID,
+			ActorType:        actor.Type,
+			ActorMMUserID:    actor.MMUserID,
+			ActorEmail:       actor.Email,
+			Details:          details,
+			PrevHash:         chainTip(existing),
+		}
+		event.Hash, err = computeHash(event)
+		if err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+
+		err = l.db.AppendAuditEvent(ctx, event, len(existing), idempotent)
+		if err == nil {
+			break
+		}
+		if idempotent && errors.Is(err, models.ErrAuditEventExists) {
+			logSuppressed(requestID, eventType, eventID)
+			return nil
+		}
+		if errors.Is(err, models.ErrAuditChainConflict) && attempt < maxAppendAttempts {
+			slog.Info("audit chain moved, retrying",
+				"request_id", requestID,
+				"event_type", eventType,
+				"attempt", attempt,
+			)
+			continue
+		}
 		slog.Error("failed to write audit event",
 			"request_id", requestID,
 			"event_type", eventType,
@@ -54,6 +188,177 @@ func (l *Logger) Log(ctx context.Context, requestID, eventType, accountID, chann
 		"request_id", requestID,
 		"event_type", eventType,
 		"event_id", eventID,
+		"actor_type", actor.Type,
+	)
+
+	if IsLifecycleEvent(eventType) {
+		if err := l.publisher.Publish(ctx, event); err != nil {
+			slog.Error("failed to publish lifecycle event",
+				"request_id", requestID,
+				"event_type", eventType,
+				"event_id", eventID,
+				"error", err,
+			)
+		}
+	}
+	return nil
+}
+
+// lifecycleNamespace scopes the name-based UUIDs of lifecycle events.
+var lifecycleNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("jit-aws-controller/audit/lifecycle"))
+
+// IsLifecycleEvent reports whether eventType records a request state change,
+// which happens at most once per request (ERROR once per phase).
+func IsLifecycleEvent(eventType string) bool {
+	switch eventType {
+	case models.EventRequested, models.EventApproved, models.EventAutoApproved, models.EventDenied,
+		models.EventGranted, models.EventRevoked, models.EventExpired, models.EventError, models.EventImported:
+		return true
+	}
+	return false
+}
+
+// LifecycleEventID returns the deterministic event ID of a lifecycle event.
+// phase distinguishes ERROR events and is empty for the rest.
+func LifecycleEventID(requestID, eventType, phase string) string {
+	return uuid.NewSHA1(lifecycleNamespace, []byte(requestID+"\x00"+eventType+"\x00"+phase)).String()
+}
+
+func logSuppressed(requestID, eventType, eventID string) {
+	slog.Info("duplicate audit event suppressed",
+		"request_id", requestID,
+		"event_type", eventType,
+		"event_id", eventID,
 	)
+}
+
+func hasEvent(events []models.AuditEvent, eventID string) bool {
+	for _, e := range events {
+		if e.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// emailFor returns the email for an MM user ID from the directory, or "" if
+// there is no directory, the user is unknown, or the lookup fails. Failed
+// lookups are not cached so a later event can retry.
+func (l *Logger) emailFor(ctx context.Context, mmUserID string) string {
+	if l.directory == nil {
+		return ""
+	}
+	l.mu.Lock()
+	email, ok := l.emails[mmUserID]
+	l.mu.Unlock()
+	if ok {
+		return email
+	}
+
+	email, err := l.directory.EmailForUserID(ctx, mmUserID)
+	if err != nil {
+		slog.Warn("failed to resolve audit actor email",
+			"actor_mm_user_id", mmUserID,
+			"error", err,
+		)
+		return ""
+	}
+	l.mu.Lock()
+	l.emails[mmUserID] = email
+	l.mu.Unlock()
+	return email
+}
+
+// sortKey builds the event_time_event_id range key. seq is the number of
+// events already recorded for the request; it orders events that share a
+// timestamp, leaving the random event ID only to break concurrent ties.
+func sortKey(t time.Time, seq int, eventID string) string {
+	return fmt.Sprintf("%s#%08d#%s", t.Format(models.AuditSortKeyTimeFormat), seq, eventID)
+}
+
+// VerifyChain walks the hash chain for a request and returns an error wrapping
+// ErrChainBroken if any event was altered or a link is missing. Events without
+// a hash predate chaining and are ignored.
+func (l *Logger) VerifyChain(ctx context.Context, requestID string) error {
+	events, err := l.db.QueryAuditByRequest(ctx, requestID)
+	if err != nil {
+		return fmt.Errorf("verify chain: %w", err)
+	}
+
+	// Index hashed events by their predecessor, checking each event's own hash.
+	byPrev := make(map[string]models.AuditEvent)
+	count := 0
+	for _, e := range events {
+		if e.Hash == "" {
+			continue
+		}
+		want, err := computeHash(&e)
+		if err != nil {
+			return fmt.Errorf("verify chain: %w", err)
+		}
+		if want != e.Hash {
+			return fmt.Errorf("%w: event %s content does not match its hash", ErrChainBroken, e.EventID)
+		}
+		if other, dup := byPrev[e.PrevHash]; dup {
+			return fmt.Errorf("%w: events %s and %s share predecessor", ErrChainBroken, other.EventID, e.EventID)
+		}
+		byPrev[e.PrevHash] = e
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	// Walk from the genesis event; every hashed event must be reachable.
+	cur, ok := byPrev[""]
+	if !ok {
+		return fmt.Errorf("%w: no genesis event for request %s", ErrChainBroken, requestID)
+	}
+	visited := 1
+	for {
+		next, ok := byPrev[cur.Hash]
+		if !ok {
+			break
+		}
+		cur = next
+		visited++
+	}
+	if visited != count {
+		return fmt.Errorf("%w: %d of %d events unreachable from genesis (missing link after event %s)",
+			ErrChainBroken, count-visited, count, cur.EventID)
+	}
 	return nil
 }
+
+// chainTip returns the hash of the last event in the chain, i.e. the hashed
+// event that no other event names as its predecessor. Sort keys only have
+// second resolution, so the chain is followed by linkage rather than order.
+func chainTip(events []models.AuditEvent) string {
+	referenced := make(map[string]bool, len(events))
+	for _, e := range events {
+		if e.PrevHash != "" {
+			referenced[e.PrevHash] = true
+		}
+	}
+	tip := ""
+	for _, e := range events {
+		if e.Hash != "" && !referenced[e.Hash] {
+			tip = e.Hash
+		}
+	}
+	return tip
+}
+
+// computeHash returns hex(sha256(prev_hash "\n" json(event without hash))).
+// JSON encoding is deterministic: struct fields in declaration order and map
+// keys sorted.
+func computeHash(event *models.AuditEvent) (string, error) {
+	content := *event
+	content.Hash = ""
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("marshal audit event for hashing: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(event.PrevHash+"\n"), b...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,570 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// memStore is an in-memory Store keeping events in insertion order. Like the
+// DynamoDB store, it lets only one event claim each chain position, and each
+// unique event ID.
+type memStore struct {
+	mu      sync.Mutex
+	events  []models.AuditEvent
+	markers map[string]bool // request_id#seq and request_id#event_id
+}
+
+func (m *memStore) AppendAuditEvent(_ context.Context, event *models.AuditEvent, seq int, unique bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.markers == nil {
+		m.markers = map[string]bool{}
+	}
+	pos, id := event.RequestID+"#"+strconv.Itoa(seq), event.RequestID+"#"+event.EventID
+	if unique && m.markers[id] {
+		return fmt.Errorf("append: %w", models.ErrAuditEventExists)
+	}
+	if m.markers[pos] {
+		return fmt.Errorf("append: %w", models.ErrAuditChainConflict)
+	}
+	m.markers[pos] = true
+	if unique {
+		m.markers[id] = true
+	}
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *memStore) QueryAuditByRequest(_ context.Context, requestID string) ([]models.AuditEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.AuditEvent
+	for _, e := range m.events {
+		if e.RequestID == requestID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func logChain(t *testing.T, l *Logger) {
+	t.Helper()
+	ctx := context.Background()
+	steps := []string{models.EventRequested, models.EventApproved, models.EventGranted, models.EventExpired}
+	for _, eventType := range steps {
+		if err := l.Log(ctx, "req-1", eventType, "acct1", "ch1", models.HumanActor("user-1", "user@example.com"),
+			map[string]string{"step": eventType}); err != nil {
+			t.Fatalf("Log(%s) failed: %v", eventType, err)
+		}
+	}
+}
+
+func TestLog_ChainsEvents(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	logChain(t, l)
+
+	if len(store.events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(store.events))
+	}
+	if store.events[0].PrevHash != "" {
+		t.Errorf("expected genesis event to have empty prev hash, got %q", store.events[0].PrevHash)
+	}
+	for i := 1; i < len(store.events); i++ {
+		if store.events[i].PrevHash != store.events[i-1].Hash {
+			t.Errorf("event %d: prev hash does not link to event %d", i, i-1)
+		}
+	}
+}
+
+func TestVerifyChain_Valid(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	logChain(t, l)
+
+	// Storage order must not matter; the chain is followed by linkage.
+	store.events[1], store.events[2] = store.events[2], store.events[1]
+
+	if err := l.VerifyChain(context.Background(), "req-1"); err != nil {
+		t.Fatalf("expected valid chain, got: %v", err)
+	}
+}
+
+func TestVerifyChain_TamperedContent(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	logChain(t, l)
+
+	store.events[1].ActorEmail = "someone-else@example.com"
+
+	err := l.VerifyChain(context.Background(), "req-1")
+	if !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected ErrChainBroken for tampered event, got: %v", err)
+	}
+}
+
+func TestVerifyChain_MissingLink(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	logChain(t, l)
+
+	// Delete a middle event.
+	store.events = append(store.events[:2], store.events[3:]...)
+
+	err := l.VerifyChain(context.Background(), "req-1")
+	if !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected ErrChainBroken for missing link, got: %v", err)
+	}
+}
+
+func TestVerifyChain_MissingGenesis(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	logChain(t, l)
+
+	store.events = store.events[1:]
+
+	err := l.VerifyChain(context.Background(), "req-1")
+	if !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected ErrChainBroken for missing genesis, got: %v", err)
+	}
+}
+
+func TestVerifyChain_IgnoresUnhashedLegacyEvents(t *testing.T) {
+	store := &memStore{events: []models.AuditEvent{{
+		RequestID: "req-1",
+		EventID:   "legacy-1",
+		EventType: models.EventRequested,
+	}}}
+	l := NewLogger(store)
+
+	if err := l.Log(context.Background(), "req-1", models.EventApproved, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if store.events[1].PrevHash != "" {
+		t.Errorf("expected first hashed event to start a new chain, got prev hash %q", store.events[1].PrevHash)
+	}
+	if err := l.VerifyChain(context.Background(), "req-1"); err != nil {
+		t.Fatalf("expected valid chain, got: %v", err)
+	}
+}
+
+func TestLog_RecordsActorType(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	ctx := context.Background()
+
+	if err := l.Log(ctx, "req-1", models.EventApproved, "acct1", "ch1",
+		models.HumanActor("approver-1", "approver@example.com"), nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if err := l.Log(ctx, "req-1", models.EventGranted, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	if got := store.events[0]; got.ActorType != models.ActorHuman || got.ActorMMUserID != "approver-1" {
+		t.Errorf("expected human actor approver-1, got type %q user %q", got.ActorType, got.ActorMMUserID)
+	}
+	if got := store.events[1]; got.ActorType != models.ActorSystem || got.ActorMMUserID != "" || got.ActorEmail != "system" {
+		t.Errorf("expected system actor, got type %q user %q email %q", got.ActorType, got.ActorMMUserID, got.ActorEmail)
+	}
+
+	// The actor type is covered by the hash chain.
+	store.events[1].ActorType = models.ActorHuman
+	if err := l.VerifyChain(ctx, "req-1"); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected ErrChainBroken for altered actor type, got: %v", err)
+	}
+}
+
+func TestLog_UsesClock(t *testing.T) {
+	store := &memStore{}
+	clk := clock.NewMock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	l := NewLogger(store, WithClock(clk))
+
+	if err := l.Log(context.Background(), "req-1", models.EventRequested, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	clk.Advance(time.Hour)
+	if err := l.Log(context.Background(), "req-1", models.EventApproved, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	if got := store.events[0].EventTime; got != "2025-01-02T03:04:05Z" {
+		t.Errorf("expected event time from clock, got %s", got)
+	}
+	if got := store.events[1].EventTime; got != "2025-01-02T04:04:05Z" {
+		t.Errorf("expected advanced event time, got %s", got)
+	}
+	if got := store.events[0].EventDate; got != "2025-01-02" {
+		t.Errorf("expected event date for the date index, got %q", got)
+	}
+}
+
+// mockDirectory resolves MM user IDs from a map and counts lookups.
+type mockDirectory struct {
+	emails  map[string]string
+	err     error
+	lookups int
+}
+
+func (m *mockDirectory) EmailForUserID(_ context.Context, mmUserID string) (string, error) {
+	m.lookups++
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.emails[mmUserID], nil
+}
+
+func TestLog_EnrichesActorEmailFromDirectory(t *testing.T) {
+	store := &memStore{}
+	dir := &mockDirectory{emails: map[string]string{"user-1": "user@example.com"}}
+	l := NewLogger(store, WithUserDirectory(dir))
+	ctx := context.Background()
+
+	for _, eventType := range []string{models.EventRequested, models.EventApproved} {
+		if err := l.Log(ctx, "req-1", eventType, "acct1", "ch1", models.HumanActor("user-1", ""), nil); err != nil {
+			t.Fatalf("Log(%s) failed: %v", eventType, err)
+		}
+	}
+	if err := l.Log(ctx, "req-1", models.EventGranted, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if err := l.Log(ctx, "req-1", models.EventDenied, "acct1", "ch1", models.HumanActor("user-1", "given@example.com"), nil); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	if store.events[0].ActorEmail != "user@example.com" || store.events[1].ActorEmail != "user@example.com" {
+		t.Errorf("expected actor email resolved from directory, got %q and %q",
+			store.events[0].ActorEmail, store.events[1].ActorEmail)
+	}
+	if store.events[2].ActorEmail != "system" || store.events[3].ActorEmail != "given@example.com" {
+		t.Errorf("expected supplied emails kept, got %q and %q", store.events[2].ActorEmail, store.events[3].ActorEmail)
+	}
+	if dir.lookups != 1 {
+		t.Errorf("expected one cached lookup, got %d", dir.lookups)
+	}
+	if err := l.VerifyChain(ctx, "req-1"); err != nil {
+		t.Fatalf("expected valid chain with enriched events, got: %v", err)
+	}
+}
+
+func TestLog_DirectoryFailureStillLogs(t *testing.T) {
+	store := &memStore{}
+	dir := &mockDirectory{err: errors.New("directory unavailable")}
+	l := NewLogger(store, WithUserDirectory(dir))
+
+	for i := 0; i < 2; i++ {
+		if err := l.Log(context.Background(), "req-1", models.EventApproved, "acct1", "ch1", models.HumanActor("user-1", ""), nil); err != nil {
+			t.Fatalf("expected event logged despite directory failure, got: %v", err)
+		}
+	}
+	if store.events[0].ActorEmail != "" {
+		t.Errorf("expected no actor email, got %q", store.events[0].ActorEmail)
+	}
+	if dir.lookups != 2 {
+		t.Errorf("expected failed lookups to be retried, got %d lookups", dir.lookups)
+	}
+}
+
+func TestLog_SortKeysOrderEventsWithinASecond(t *testing.T) {
+	store := &memStore{}
+	clk := clock.NewMock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	l := NewLogger(store, WithClock(clk))
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		if i == n/2 {
+			clk.Advance(time.Millisecond)
+		}
+		if err := l.Log(context.Background(), "req-1", models.EventApproved, "acct1", "ch1", models.SystemActor,
+			map[string]string{"seq": strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+
+	sorted := append([]models.AuditEvent(nil), store.events...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].EventTimeEventID < sorted[j].EventTimeEventID })
+	for i, e := range sorted {
+		if e.Details["seq"] != strconv.Itoa(i) {
+			t.Fatalf("position %d: expected event %d, got %s (key %s)", i, i, e.Details["seq"], e.EventTimeEventID)
+		}
+		if e.EventTime != "2025-01-02T03:04:05Z" {
+			t.Errorf("expected event_time to stay at second precision, got %s", e.EventTime)
+		}
+	}
+}
+
+func TestLog_IdempotentLifecycleEvents(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store, WithIdempotentLifecycleEvents())
+	ctx := context.Background()
+	actor := models.StepFnActor
+
+	for i := 0; i < 2; i++ {
+		if err := l.Log(ctx, "req-1", models.EventGranted, "acct1", "ch1", actor, nil); err != nil {
+			t.Fatalf("Log(GRANTED) attempt %d failed: %v", i, err)
+		}
+	}
+	if len(store.events) != 1 {
+		t.Fatalf("expected a retried GRANTED to be recorded once, got %d events", len(store.events))
+	}
+	if store.events[0].EventID != LifecycleEventID("req-1", models.EventGranted, "") {
+		t.Errorf("expected a deterministic event ID, got %s", store.events[0].EventID)
+	}
+
+	// ERROR events are distinguished by phase.
+	for _, phase := range []string{models.ErrorPhaseGrant, models.ErrorPhaseGrant, models.ErrorPhaseRevoke} {
+		if err := l.Log(ctx, "req-1", models.EventError, "acct1", "ch1", actor, map[string]string{"phase": phase}); err != nil {
+			t.Fatalf("Log(ERROR %s) failed: %v", phase, err)
+		}
+	}
+	if len(store.events) != 3 {
+		t.Fatalf("expected one ERROR per phase, got %d events", len(store.events))
+	}
+
+	// Comments are not lifecycle events and are never collapsed.
+	for i := 0; i < 2; i++ {
+		if err := l.Log(ctx, "req-1", models.EventComment, "acct1", "ch1", models.HumanActor("user-1", "user@example.com"),
+			map[string]string{"comment": "same text"}); err != nil {
+			t.Fatalf("Log(COMMENT) failed: %v", err)
+		}
+	}
+	if len(store.events) != 5 {
+		t.Fatalf("expected both comments recorded, got %d events", len(store.events))
+	}
+
+	// The same lifecycle event on another request is its own event.
+	if err := l.Log(ctx, "req-2", models.EventGranted, "acct1", "ch1", actor, nil); err != nil {
+		t.Fatalf("Log(GRANTED req-2) failed: %v", err)
+	}
+	if len(store.events) != 6 {
+		t.Fatalf("expected GRANTED on req-2 recorded, got %d events", len(store.events))
+	}
+	if err := l.VerifyChain(ctx, "req-1"); err != nil {
+		t.Errorf("expected an intact chain, got %v", err)
+	}
+}
+
+func TestLog_ConcurrentLifecycleEventRecordedOnce(t *testing.T) {
+	store := &memStore{}
+	pub := &recordingPublisher{}
+	ctx := context.Background()
+
+	// Separate loggers, as in separate Lambda invocations racing on one step.
+	const writers = maxAppendAttempts
+	start := make(chan struct{})
+	errs := make(chan error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		l := NewLogger(store, WithIdempotentLifecycleEvents(), WithPublisher(pub))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- l.Log(ctx, "req-1", models.EventGranted, "acct1", "ch1", models.StepFnActor, nil)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("a racing duplicate should be suppressed, not fail: %v", err)
+		}
+	}
+
+	if len(store.events) != 1 {
+		t.Fatalf("expected GRANTED recorded once, got %d events", len(store.events))
+	}
+	if len(pub.published) != 1 {
+		t.Errorf("expected GRANTED published once, got %d", len(pub.published))
+	}
+}
+
+// existsStore reports every unique append as already recorded, as DynamoDB
+// does when the event ID marker was claimed by a write the chain read missed.
+type existsStore struct {
+	memStore
+}
+
+func (e *existsStore) AppendAuditEvent(ctx context.Context, event *models.AuditEvent, seq int, unique bool) error {
+	if unique {
+		return fmt.Errorf("append: %w", models.ErrAuditEventExists)
+	}
+	return e.memStore.AppendAuditEvent(ctx, event, seq, unique)
+}
+
+func TestLog_EventExistsIsSuppressed(t *testing.T) {
+	store := &existsStore{}
+	pub := &recordingPublisher{}
+	l := NewLogger(store, WithIdempotentLifecycleEvents(), WithPublisher(pub))
+
+	if err := l.Log(context.Background(), "req-1", models.EventGranted, "acct1", "ch1", models.StepFnActor, nil); err != nil {
+		t.Fatalf("expected an existing lifecycle event to be suppressed, got %v", err)
+	}
+	if len(store.events) != 0 || len(pub.published) != 0 {
+		t.Errorf("expected nothing recorded or published, got %d events and %d published", len(store.events), len(pub.published))
+	}
+
+	// Only lifecycle events are written as unique.
+	if err := l.Log(context.Background(), "req-1", models.EventComment, "acct1", "ch1", models.StepFnActor, nil); err != nil {
+		t.Fatalf("Log(COMMENT) failed: %v", err)
+	}
+	if len(store.events) != 1 {
+		t.Errorf("expected the comment recorded, got %d events", len(store.events))
+	}
+}
+
+func TestLog_LifecycleEventsRepeatWithoutIdempotency(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	for i := 0; i < 2; i++ {
+		if err := l.Log(context.Background(), "req-1", models.EventGranted, "acct1", "ch1", models.StepFnActor, nil); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	if len(store.events) != 2 {
+		t.Errorf("expected both events recorded by default, got %d", len(store.events))
+	}
+}
+
+// recordingPublisher records published events and fails with err.
+type recordingPublisher struct {
+	published []models.AuditEvent
+	err       error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event *models.AuditEvent) error {
+	p.published = append(p.published, *event)
+	return p.err
+}
+
+func TestLog_PublishesLifecycleEvents(t *testing.T) {
+	store := &memStore{}
+	pub := &recordingPublisher{}
+	l := NewLogger(store, WithIdempotentLifecycleEvents(), WithPublisher(pub))
+	ctx := context.Background()
+
+	for _, eventType := range []string{models.EventGranted, models.EventComment, models.EventGranted, models.EventRevoked} {
+		if err := l.Log(ctx, "req-1", eventType, "acct1", "ch1", models.StepFnActor, nil); err != nil {
+			t.Fatalf("Log(%s) failed: %v", eventType, err)
+		}
+	}
+
+	// Comments are not published and a suppressed duplicate is not republished.
+	if len(pub.published) != 2 {
+		t.Fatalf("expected GRANTED and REVOKED published, got %d events", len(pub.published))
+	}
+	if pub.published[0].EventType != models.EventGranted || pub.published[1].EventType != models.EventRevoked {
+		t.Errorf("expected GRANTED then REVOKED, got %s then %s", pub.published[0].EventType, pub.published[1].EventType)
+	}
+	if pub.published[0].Hash != store.events[0].Hash {
+		t.Error("expected the recorded event to be published")
+	}
+}
+
+func TestLog_PublishFailureStillLogs(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store, WithPublisher(&recordingPublisher{err: errors.New("bus unavailable")}))
+
+	if err := l.Log(context.Background(), "req-1", models.EventGranted, "acct1", "ch1", models.StepFnActor, nil); err != nil {
+		t.Fatalf("expected publish failure to be tolerated, got: %v", err)
+	}
+	if len(store.events) != 1 {
+		t.Errorf("expected the event recorded, got %d", len(store.events))
+	}
+}
+
+func TestLog_ConcurrentWritersKeepOneChain(t *testing.T) {
+	store := &memStore{}
+	l := NewLogger(store)
+	ctx := context.Background()
+	if err := l.Log(ctx, "req-1", models.EventRequested, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log(REQUESTED) failed: %v", err)
+	}
+
+	// Each writer can lose the race for a position only to another writer
+	// that then succeeds, so maxAppendAttempts writers always all land.
+	const writers = maxAppendAttempts
+	start := make(chan struct{})
+	errs := make(chan error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs <- l.Log(ctx, "req-1", models.EventComment, "acct1", "ch1", models.SystemActor,
+				map[string]string{"comment": strconv.Itoa(i)})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Log failed: %v", err)
+		}
+	}
+
+	if len(store.events) != writers+1 {
+		t.Fatalf("expected %d events, got %d", writers+1, len(store.events))
+	}
+	if err := l.VerifyChain(ctx, "req-1"); err != nil {
+		t.Errorf("expected concurrent writes to form one chain, got %v", err)
+	}
+}
+
+// racingStore lets a competing writer append between a writer's read of the
+// chain and its write, once.
+type racingStore struct {
+	*memStore
+	race func()
+}
+
+func (r *racingStore) AppendAuditEvent(ctx context.Context, event *models.AuditEvent, seq int, unique bool) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.memStore.AppendAuditEvent(ctx, event, seq, unique)
+}
+
+func TestLog_RetriesAfterLosingChainPosition(t *testing.T) {
+	mem := &memStore{}
+	store := &racingStore{memStore: mem}
+	l := NewLogger(store)
+	ctx := context.Background()
+	if err := l.Log(ctx, "req-1", models.EventRequested, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log(REQUESTED) failed: %v", err)
+	}
+
+	other := NewLogger(mem)
+	store.race = func() {
+		if err := other.Log(ctx, "req-1", models.EventComment, "acct1", "ch1", models.SystemActor, nil); err != nil {
+			t.Errorf("competing Log failed: %v", err)
+		}
+	}
+	if err := l.Log(ctx, "req-1", models.EventApproved, "acct1", "ch1", models.SystemActor, nil); err != nil {
+		t.Fatalf("Log(APPROVED) failed: %v", err)
+	}
+
+	if len(mem.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(mem.events))
+	}
+	if got := mem.events[2]; got.EventType != models.EventApproved || got.PrevHash != mem.events[1].Hash {
+		t.Errorf("expected APPROVED to link to the competing event after a retry, got %+v", got)
+	}
+	if err := l.VerifyChain(ctx, "req-1"); err != nil {
+		t.Errorf("expected an intact chain, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package auth
+
+// actionPurpose domain-separates Step Functions action signatures from other
+// signatures produced with the same keys.
+const actionPurpose = "sfn-action"
+
+// ActionSigner signs and verifies Step Functions action payloads with a
+// dedicated set of keys, so the API Lambda can tell actions from its own
+// state machine apart from other invocations.
+type ActionSigner struct {
+	// Keys maps key IDs to secrets. Signatures name the key they were made
+	// with, so any key in the set verifies during rotation.
+	Keys map[string]string
+}
+
+// NewActionSigner creates an ActionSigner over keys.
+func NewActionSigner(keys map[string]string) *ActionSigner {
+	return &ActionSigner{Keys: keys}
+}
+
+// SignAction returns the signature for action on requestID.
+// Format: key ID "." hex(hmac-sha256), signed with the lowest key ID.
+func (s *ActionSigner) SignAction(action, requestID string) (string, error) {
+	return s.signer().sign(action + "\n" + requestID)
+}
+
+// VerifyAction checks that signature was produced by SignAction for the same
+// action and request ID.
+func (s *ActionSigner) VerifyAction(action, requestID, signature string) error {
+	return s.signer().verify(action+"\n"+requestID, signature)
+}
+
+func (s *ActionSigner) signer() keyedSigner {
+	return keyedSigner{purpose: actionPurpose, kind: "action", keys: s.Keys}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestActionSigner_RoundTrip(t *testing.T) {
+	s := NewActionSigner(map[string]string{"k2": "secret-2", "k1": "secret-1"})
+
+	sig, err := s.SignAction("grant", "req-1")
+	if err != nil {
+		t.Fatalf("SignAction: %v", err)
+	}
+	if !strings.HasPrefix(sig, "k1.") {
+		t.Errorf("expected signature with the lowest key ID, got %q", sig)
+	}
+	if err := s.VerifyAction("grant", "req-1", sig); err != nil {
+		t.Errorf("expected signature to verify, got: %v", err)
+	}
+
+	// A signature from the other key still verifies during rotation.
+	rotated := NewActionSigner(map[string]string{"k2": "secret-2"})
+	sig2, _ := rotated.SignAction("grant", "req-1")
+	if err := s.VerifyAction("grant", "req-1", sig2); err != nil {
+		t.Errorf("expected signature from another known key to verify, got: %v", err)
+	}
+}
+
+func TestActionSigner_Rejects(t *testing.T) {
+	s := NewActionSigner(map[string]string{"k1": "secret-1"})
+	sig, _ := s.SignAction("grant", "req-1")
+
+	tests := []struct {
+		name, action, requestID, sig, want string
+	}{
+		{"other action", "revoke", "req-1", sig, "invalid action signature"},
+		{"other request", "grant", "req-2", sig, "invalid action signature"},
+		{"missing", "grant", "req-1", "", "missing"},
+		{"malformed", "grant", "req-1", "deadbeef", "malformed"},
+		{"unknown key", "grant", "req-1", "k9." + strings.SplitN(sig, ".", 2)[1], "unknown key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.VerifyAction(tt.action, tt.requestID, tt.sig)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultApprovalTokenTTL is how long a minted approval token remains redeemable.
+	DefaultApprovalTokenTTL = 15 * time.Minute
+
+	// approvalTokenNonceKeyID is the nonce-store key ID under which redeemed
+	// approval tokens are recorded to enforce single use.
+	approvalTokenNonceKeyID = "approval-token"
+
+	// approvalTokenPurpose domain-separates approval token signatures from
+	// request signatures produced with the same keys.
+	approvalTokenPurpose = "approval-token"
+)
+
+// ApprovalTokenClaims is the signed content of a one-time approval token.
+type ApprovalTokenClaims struct {
+	KeyID            string `json:"kid"`
+	RequestID        string `json:"rid"`
+	ApproverMMUserID string `json:"uid"`
+	ApproverEmail    string `json:"email"`
+	ExpiresAt        int64  `json:"exp"`
+}
+
+// MintApprovalToken creates a short-lived, single-use token authorizing the
+// given approver to approve the given request out-of-band (e.g. from an email link).
+// The token is signed with one of the validator's signing keys.
+func (v *HMACValidator) MintApprovalToken(requestID, approverMMUserID, approverEmail string, ttl time.Duration) (string, time.Time, error) {
+	if requestID == "" || approverMMUserID == "" || approverEmail == "" {
+		return "", time.Time{}, fmt.Errorf("request ID and approver identity are required")
+	}
+	if ttl <= 0 {
+		ttl = DefaultApprovalTokenTTL
+	}
+
+	keyID, secret, ok := v.tokenSigningKey()
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("no signing key available for approval tokens")
+	}
+
+	expiresAt := v.now().Add(ttl).Truncate(time.Second)
+	token, err := mintApprovalToken(secret, ApprovalTokenClaims{
+		KeyID:            keyID,
+		RequestID:        requestID,
+		ApproverMMUserID: approverMMUserID,
+		ApproverEmail:    approverEmail,
+		ExpiresAt:        expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// RedeemApprovalToken verifies an approval token and immediately consumes it.
+// Callers that act on the token should prefer VerifyApprovalToken followed by
+// ConsumeApprovalToken once the action has succeeded, so a failed action does
+// not burn the token.
+func (v *HMACValidator) RedeemApprovalToken(ctx context.Context, token, requestID string) (*ApprovalTokenClaims, error) {
+	claims, err := v.VerifyApprovalToken(ctx, token, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.ConsumeApprovalToken(ctx, token, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// VerifyApprovalToken checks an approval token's signature, expiry, and
+// target request, and that it has not already been used. It does not consume
+// the token.
+func (v *HMACValidator) VerifyApprovalToken(ctx context.Context, token, requestID string) (*ApprovalTokenClaims, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || encoded == "" || signature == "" {
+		return nil, fmt.Errorf("malformed approval token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed approval token: %w", err)
+	}
+	var claims ApprovalTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("malformed approval token: %w", err)
+	}
+
+	secret, ok := v.SigningKeys[claims.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("approval token signed with unknown key")
+	}
+	expected := computeHMAC(secret, approvalTokenSigningMessage(encoded))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, fmt.Errorf("invalid approval token signature")
+	}
+
+	if v.now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("approval token expired")
+	}
+	if claims.RequestID != requestID {
+		return nil, fmt.Errorf("approval token is not valid for request %s", requestID)
+	}
+
+	used, err := v.NonceStore.CheckNonce(ctx, approvalTokenNonceKeyID, signature)
+	if err != nil {
+		return nil, fmt.Errorf("approval token nonce check failed: %w", err)
+	}
+	if used {
+		return nil, fmt.Errorf("approval token already used")
+	}
+
+	return &claims, nil
+}
+
+// ConsumeApprovalToken records a verified approval token as a used nonce so it
+// cannot be redeemed again. It fails if the token was consumed concurrently.
+func (v *HMACValidator) ConsumeApprovalToken(ctx context.Context, token string, claims *ApprovalTokenClaims) error {
+	_, signature, found := strings.Cut(token, ".")
+	if !found || signature == "" {
+		return fmt.Errorf("malformed approval token")
+	}
+	// Keep the nonce until slightly after the token would have expired anyway.
+	ttl := claims.ExpiresAt - v.now().Unix() + int64(maxTimestampSkew.Seconds())
+	if err := v.NonceStore.StoreNonce(ctx, approvalTokenNonceKeyID, signature, ttl); err != nil {
+		return fmt.Errorf("approval token already used: %w", err)
+	}
+	return nil
+}
+
+// tokenSigningKey deterministically selects the key used to sign approval tokens.
+func (v *HMACValidator) tokenSigningKey() (string, string, bool) {
+	if len(v.SigningKeys) == 0 {
+		return "", "", false
+	}
+	keyIDs := make([]string, 0, len(v.SigningKeys))
+	for kid := range v.SigningKeys {
+		keyIDs = append(keyIDs, kid)
+	}
+	sort.Strings(keyIDs)
+	return keyIDs[0], v.SigningKeys[keyIDs[0]], true
+}
+
+// mintApprovalToken encodes and signs the claims.
+// Format: base64url(json(claims)) "." hex(hmac-sha256)
+func mintApprovalToken(secret string, claims ApprovalTokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal approval token claims: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + computeHMAC(secret, approvalTokenSigningMessage(encoded)), nil
+}
+
+func approvalTokenSigningMessage(encodedClaims string) string {
+	return approvalTokenPurpose + "\n" + encodedClaims
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+)
+
+func newTokenValidator() *HMACValidator {
+	return NewHMACValidator(map[string]string{"key-1": "test-secret-key-very-long-and-secure-1234567890"}, newMockNonceStore())
+}
+
+func TestApprovalToken_Valid(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, expiresAt, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Errorf("expected expiry in the future, got %v", expiresAt)
+	}
+
+	claims, err := validator.RedeemApprovalToken(ctx, token, "req-1")
+	if err != nil {
+		t.Fatalf("RedeemApprovalToken failed: %v", err)
+	}
+	if claims.RequestID != "req-1" || claims.ApproverMMUserID != "approver-1" || claims.ApproverEmail != "approver@example.com" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestApprovalToken_Expired(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, err := mintApprovalToken(validator.SigningKeys["key-1"], ApprovalTokenClaims{
+		KeyID:            "key-1",
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+		ExpiresAt:        time.Now().Add(-time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("mintApprovalToken failed: %v", err)
+	}
+
+	_, err = validator.RedeemApprovalToken(ctx, token, "req-1")
+	if err == nil {
+		t.Fatal("expected error for expired token, got nil")
+	}
+	if !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected expiry error, got: %v", err)
+	}
+}
+
+func TestApprovalToken_Reused(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, _, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+
+	if _, err := validator.RedeemApprovalToken(ctx, token, "req-1"); err != nil {
+		t.Fatalf("first redemption should succeed: %v", err)
+	}
+	_, err = validator.RedeemApprovalToken(ctx, token, "req-1")
+	if err == nil {
+		t.Fatal("expected error for reused token, got nil")
+	}
+	if !strings.Contains(err.Error(), "already used") {
+		t.Errorf("expected reuse error, got: %v", err)
+	}
+}
+
+func TestApprovalToken_WrongRequest(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, _, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+
+	_, err = validator.RedeemApprovalToken(ctx, token, "req-2")
+	if err == nil {
+		t.Fatal("expected error for token presented against a different request, got nil")
+	}
+
+	// A wrong-request attempt must not consume the token.
+	if _, err := validator.RedeemApprovalToken(ctx, token, "req-1"); err != nil {
+		t.Fatalf("token should still be valid for its own request: %v", err)
+	}
+}
+
+func TestApprovalToken_TamperedClaims(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, _, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+
+	// Swap in claims for another approver while keeping the original signature.
+	forged, err := mintApprovalToken("attacker-secret", ApprovalTokenClaims{
+		KeyID:            "key-1",
+		RequestID:        "req-1",
+		ApproverMMUserID: "attacker",
+		ApproverEmail:    "attacker@example.com",
+		ExpiresAt:        time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("mintApprovalToken failed: %v", err)
+	}
+	_, origSig, _ := strings.Cut(token, ".")
+	forgedClaims, _, _ := strings.Cut(forged, ".")
+
+	_, err = validator.RedeemApprovalToken(ctx, forgedClaims+"."+origSig, "req-1")
+	if err == nil {
+		t.Fatal("expected error for tampered token, got nil")
+	}
+}
+
+func TestApprovalToken_ExpiryUsesClock(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+	mock := clock.NewMock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	validator.Clock = mock
+
+	token, expiresAt, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+	if want := mock.Now().Add(time.Minute); !expiresAt.Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, expiresAt)
+	}
+
+	mock.Advance(time.Minute)
+	_, err = validator.VerifyApprovalToken(ctx, token, "req-1")
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("expected expiry error once the clock passes exp, got: %v", err)
+	}
+}
+
+func TestApprovalToken_VerifyDoesNotConsume(t *testing.T) {
+	ctx := context.Background()
+	validator := newTokenValidator()
+
+	token, _, err := validator.MintApprovalToken("req-1", "approver-1", "approver@example.com", time.Minute)
+	if err != nil {
+		t.Fatalf("MintApprovalToken failed: %v", err)
+	}
+
+	claims, err := validator.VerifyApprovalToken(ctx, token, "req-1")
+	if err != nil {
+		t.Fatalf("VerifyApprovalToken failed: %v", err)
+	}
+	if _, err := validator.VerifyApprovalToken(ctx, token, "req-1"); err != nil {
+		t.Fatalf("verifying again before consumption should succeed: %v", err)
+	}
+
+	if err := validator.ConsumeApprovalToken(ctx, token, claims); err != nil {
+		t.Fatalf("ConsumeApprovalToken failed: %v", err)
+	}
+	if _, err := validator.VerifyApprovalToken(ctx, token, "req-1"); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("expected reuse error after consumption, got: %v", err)
+	}
+	if err := validator.ConsumeApprovalToken(ctx, token, claims); err == nil {
+		t.Error("expected second consumption to fail")
+	}
+}
@@ -0,0 +1,34 @@
+package auth
+
+// exportPurpose domain-separates request export signatures from other
+// signatures produced with the same keys.
+const exportPurpose = "request-export"
+
+// ExportSigner signs compliance exports of a request with a dedicated set of
+// keys, so an auditor holding the key can later check that an artifact was
+// produced by this service and has not been altered.
+type ExportSigner struct {
+	// Keys maps key IDs to secrets; see ActionSigner.Keys.
+	Keys map[string]string
+}
+
+// NewExportSigner creates an ExportSigner over keys.
+func NewExportSigner(keys map[string]string) *ExportSigner {
+	return &ExportSigner{Keys: keys}
+}
+
+// SignExport returns the signature over the canonical bytes of an export.
+// Format: key ID "." hex(hmac-sha256), signed with the lowest key ID.
+func (s *ExportSigner) SignExport(canonical []byte) (string, error) {
+	return s.signer().sign(string(canonical))
+}
+
+// VerifyExport checks that signature was produced by SignExport over exactly
+// canonical.
+func (s *ExportSigner) VerifyExport(canonical []byte, signature string) error {
+	return s.signer().verify(string(canonical), signature)
+}
+
+func (s *ExportSigner) signer() keyedSigner {
+	return keyedSigner{purpose: exportPurpose, kind: "export", keys: s.Keys}
+}
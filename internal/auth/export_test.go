@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportSigner_RoundTrip(t *testing.T) {
+	s := NewExportSigner(map[string]string{"k2": "secret-2", "k1": "secret-1"})
+	canonical := []byte(`{"request":{"request_id":"req-1"}}`)
+
+	sig, err := s.SignExport(canonical)
+	if err != nil {
+		t.Fatalf("SignExport: %v", err)
+	}
+	if !strings.HasPrefix(sig, "k1.") {
+		t.Errorf("expected signature with the lowest key ID, got %q", sig)
+	}
+	if err := s.VerifyExport(canonical, sig); err != nil {
+		t.Errorf("expected signature to verify, got: %v", err)
+	}
+
+	tampered := []byte(`{"request":{"request_id":"req-2"}}`)
+	if err := s.VerifyExport(tampered, sig); err == nil || !strings.Contains(err.Error(), "invalid export signature") {
+		t.Errorf("expected tampered export to fail verification, got: %v", err)
+	}
+	if err := s.VerifyExport(canonical, "k9."+strings.SplitN(sig, ".", 2)[1]); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("expected unknown key error, got: %v", err)
+	}
+}
+
+func TestExportSigner_NoKeys(t *testing.T) {
+	if _, err := NewExportSigner(nil).SignExport([]byte("{}")); err == nil {
+		t.Fatal("expected error signing without keys")
+	}
+}
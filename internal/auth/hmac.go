@@ -5,13 +5,17 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
 )
 
 const (
@@ -26,8 +30,33 @@ const (
 	HeaderNonce = "X-JIT-Nonce"
 	// HeaderSignature is the header carrying the HMAC-SHA256 hex-encoded signature.
 	HeaderSignature = "X-JIT-Signature"
+	// HeaderAuthLevel is the optional header carrying the caller's asserted
+	// authentication level (e.g. 2 for a recent MFA re-authentication).
+	HeaderAuthLevel = "X-JIT-Auth-Level"
 )
 
+// ReasonBodyOrSigMismatch is the ValidationError reason for a signature that
+// does not verify. Either the key differs or the body bytes the backend received
+// differ from those the caller signed; the body hash in the debug log tells the
+// two apart.
+const ReasonBodyOrSigMismatch = "body_or_sig_mismatch"
+
+// ErrNoSigningKeys means the validator was built without any signing keys,
+// usually because the signing secret is empty. It is a server
+// misconfiguration, not a bad request signature.
+var ErrNoSigningKeys = errors.New("no signing keys configured")
+
+// ValidationError is a request validation failure carrying a machine-readable
+// reason that is safe to return to the caller.
+type ValidationError struct {
+	Reason  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
 // NonceStore abstracts nonce persistence for replay protection.
 type NonceStore interface {
 	// StoreNonce persists a nonce with a TTL. Returns error if already exists.
@@ -42,6 +71,9 @@ type HMACValidator struct {
 	// containing both current and previous keys simultaneously.
 	SigningKeys map[string]string
 	NonceStore  NonceStore
+	// Clock supplies the current time for approval token expiry. Nil uses
+	// the system clock.
+	Clock clock.Clock
 }
 
 // NewHMACValidator creates a validator with the provided signing keys and nonce store.
@@ -52,9 +84,29 @@ func NewHMACValidator(signingKeys map[string]string, store NonceStore) *HMACVali
 	}
 }
 
+func (v *HMACValidator) now() time.Time {
+	if v.Clock == nil {
+		return time.Now()
+	}
+	return v.Clock.Now()
+}
+
+// Validate reports ErrNoSigningKeys if the validator has no keys and so
+// cannot accept any request.
+func (v *HMACValidator) Validate() error {
+	if len(v.SigningKeys) == 0 {
+		return ErrNoSigningKeys
+	}
+	return nil
+}
+
 // ValidateRequest verifies the HMAC signature on an inbound request.
 // It checks the timestamp freshness, nonce uniqueness, and signature validity.
 func (v *HMACValidator) ValidateRequest(ctx context.Context, method, path string, headers map[string]string, body []byte) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
 	keyID := headerValue(headers, HeaderKeyID)
 	timestamp := headerValue(headers, HeaderTimestamp)
 	nonce := headerValue(headers, HeaderNonce)
@@ -63,6 +115,9 @@ func (v *HMACValidator) ValidateRequest(ctx context.Context, method, path string
 	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
 		return fmt.Errorf("missing required HMAC headers")
 	}
+	if _, _, err := ParseAuthLevel(headers); err != nil {
+		return err
+	}
 
 	// Validate timestamp freshness (Unix epoch seconds).
 	ts, err := strconv.ParseInt(timestamp, 10, 64)
@@ -89,7 +144,8 @@ func (v *HMACValidator) ValidateRequest(ctx context.Context, method, path string
 	// Compute expected signature and try all keys matching the key ID.
 	// During rotation, the caller might present a key ID that maps to either
 	// the current or previous secret.
-	signingMessage := buildSigningMessage(timestamp, nonce, method, path, body)
+	signingMessage := withAuthLevel(buildSigningMessage(timestamp, nonce, method, path, body),
+		headerValue(headers, HeaderAuthLevel))
 
 	matched := false
 	for kid, secret := range v.SigningKeys {
@@ -115,7 +171,14 @@ func (v *HMACValidator) ValidateRequest(ctx context.Context, method, path string
 	}
 
 	if !matched {
-		return fmt.Errorf("invalid signature")
+		// Log only the derived hash so operators can compare it with the
+		// plugin's; the body itself is never logged or returned.
+		slog.Debug("HMAC signature mismatch",
+			"key_id", keyID,
+			"body_sha256", bodySHA256Hex(body),
+			"body_length", len(body),
+		)
+		return &ValidationError{Reason: ReasonBodyOrSigMismatch, Message: "invalid signature"}
 	}
 
 	// Store nonce to prevent replay. TTL slightly longer than skew window.
@@ -147,18 +210,23 @@ func SignPayload(keyID, secret string, method, path string, body []byte) (map[st
 // buildSigningMessage constructs the canonical message to be signed.
 // Format: timestamp\nnonce\nMETHOD\npath\nhex(sha256(body))
 // This matches the plugin's canonical format for interoperability.
+// When an auth level is asserted, withAuthLevel appends it as a sixth line.
 func buildSigningMessage(timestamp, nonce, method, path string, body []byte) string {
-	bodyHash := sha256.Sum256(body)
-	bodyHashHex := hex.EncodeToString(bodyHash[:])
 	return strings.Join([]string{
 		timestamp,
 		nonce,
 		strings.ToUpper(method),
 		path,
-		bodyHashHex,
+		bodySHA256Hex(body),
 	}, "\n")
 }
 
+// bodySHA256Hex returns the hex-encoded SHA-256 of the request body.
+func bodySHA256Hex(body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return hex.EncodeToString(bodyHash[:])
+}
+
 // computeHMAC computes an HMAC-SHA256 and returns the hex-encoded string.
 func computeHMAC(secret, message string) string {
 	mac := hmac.New(sha256.New, []byte(secret))
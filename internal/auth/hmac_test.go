@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -137,6 +141,69 @@ func TestInvalidSignature(t *testing.T) {
 	t.Logf("correctly rejected invalid signature: %v", err)
 }
 
+func TestEmptySigningKeys(t *testing.T) {
+	store := newMockNonceStore()
+	validator := NewHMACValidator(map[string]string{}, store)
+
+	if err := validator.Validate(); !errors.Is(err, ErrNoSigningKeys) {
+		t.Fatalf("expected ErrNoSigningKeys from Validate, got: %v", err)
+	}
+
+	body := []byte(`{"test":"data"}`)
+	headers, err := SignPayload("key-1", "some-secret", "POST", "/requests", body)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+	err = validator.ValidateRequest(context.Background(), "POST", "/requests", headers, body)
+	if !errors.Is(err, ErrNoSigningKeys) {
+		t.Fatalf("expected ErrNoSigningKeys, got: %v", err)
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		t.Error("empty key set must not look like a signature failure")
+	}
+	if len(store.nonces) != 0 {
+		t.Error("expected no nonce stored when no keys are configured")
+	}
+}
+
+func TestBodyMismatch_HintWithoutLeakingBody(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	secret := "test-secret-key-very-long-and-secure-1234567890"
+	validator := NewHMACValidator(map[string]string{"key-1": secret}, newMockNonceStore())
+
+	signed := []byte(`{"reason":"top-secret-incident"}`)
+	received := []byte(`{"reason": "top-secret-incident"}`)
+	headers, err := SignPayload("key-1", secret, "POST", "/requests", signed)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	err = validator.ValidateRequest(context.Background(), "POST", "/requests", headers, received)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if verr.Reason != ReasonBodyOrSigMismatch {
+		t.Errorf("expected reason %q, got %q", ReasonBodyOrSigMismatch, verr.Reason)
+	}
+	if strings.Contains(err.Error(), "top-secret-incident") {
+		t.Errorf("error leaked body contents: %v", err)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, bodySHA256Hex(received)) {
+		t.Errorf("expected debug log to include the derived body hash, got: %s", out)
+	}
+	if strings.Contains(out, "top-secret-incident") {
+		t.Errorf("debug log leaked body contents: %s", out)
+	}
+}
+
 func TestReplayProtection(t *testing.T) {
 	ctx := context.Background()
 	store := newMockNonceStore()
@@ -251,3 +318,58 @@ func TestCrossCompatibility(t *testing.T) {
 		t.Errorf("expected 64-char hex signature, got %d chars: %q", len(sig), sig)
 	}
 }
+
+func TestAuthLevel_SignedAndValidated(t *testing.T) {
+	ctx := context.Background()
+	secret := "test-secret-key-very-long-and-secure-1234567890"
+	validator := NewHMACValidator(map[string]string{"key-1": secret}, newMockNonceStore())
+	body := []byte(`{"approver_mm_user_id":"u1"}`)
+
+	headers, err := SignPayloadWithAuthLevel("key-1", secret, "POST", "/requests/r1/approve", body, 2)
+	if err != nil {
+		t.Fatalf("SignPayloadWithAuthLevel failed: %v", err)
+	}
+	if err := validator.ValidateRequest(ctx, "POST", "/requests/r1/approve", headers, body); err != nil {
+		t.Fatalf("ValidateRequest failed: %v", err)
+	}
+	level, ok, err := ParseAuthLevel(headers)
+	if err != nil || !ok || level != 2 {
+		t.Errorf("expected auth level 2, got %d (present=%v, err=%v)", level, ok, err)
+	}
+}
+
+func TestAuthLevel_ForgedLevelRejected(t *testing.T) {
+	ctx := context.Background()
+	secret := "test-secret-key-very-long-and-secure-1234567890"
+	validator := NewHMACValidator(map[string]string{"key-1": secret}, newMockNonceStore())
+	body := []byte(`{}`)
+
+	// Raising an asserted level invalidates the signature.
+	headers, err := SignPayloadWithAuthLevel("key-1", secret, "POST", "/requests/r1/approve", body, 1)
+	if err != nil {
+		t.Fatalf("SignPayloadWithAuthLevel failed: %v", err)
+	}
+	headers[HeaderAuthLevel] = "3"
+	if err := validator.ValidateRequest(ctx, "POST", "/requests/r1/approve", headers, body); err == nil {
+		t.Error("expected error for altered auth level")
+	}
+
+	// Adding a level to a request signed without one invalidates the signature.
+	headers, err = SignPayload("key-1", secret, "POST", "/requests/r1/approve", body)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+	headers[HeaderAuthLevel] = "2"
+	if err := validator.ValidateRequest(ctx, "POST", "/requests/r1/approve", headers, body); err == nil {
+		t.Error("expected error for injected auth level")
+	}
+}
+
+func TestAuthLevel_Malformed(t *testing.T) {
+	if _, _, err := ParseAuthLevel(map[string]string{HeaderAuthLevel: "high"}); err == nil {
+		t.Error("expected error for non-integer auth level")
+	}
+	if _, ok, err := ParseAuthLevel(map[string]string{}); ok || err != nil {
+		t.Errorf("expected absent auth level, got present=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// keyedSigner signs messages for one purpose with a set of rotating keys.
+// The purpose is prefixed to every message, so a signature made for one
+// purpose never verifies for another even when the keys are shared.
+type keyedSigner struct {
+	// purpose domain-separates the signatures.
+	purpose string
+	// kind names the signature in errors, e.g. "action".
+	kind string
+	// keys maps key IDs to secrets. Signatures name the key they were made
+	// with, so any key in the set verifies during rotation.
+	keys map[string]string
+}
+
+// sign returns the signature over message.
+// Format: key ID "." hex(hmac-sha256), signed with the lowest key ID.
+func (s keyedSigner) sign(message string) (string, error) {
+	if len(s.keys) == 0 {
+		return "", fmt.Errorf("no %s signing key available", s.kind)
+	}
+	keyIDs := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		keyIDs = append(keyIDs, kid)
+	}
+	sort.Strings(keyIDs)
+	kid := keyIDs[0]
+	return kid + "." + computeHMAC(s.keys[kid], s.message(message)), nil
+}
+
+// verify checks that signature was produced by sign over exactly message.
+func (s keyedSigner) verify(message, signature string) error {
+	if signature == "" {
+		return fmt.Errorf("%s signature missing", s.kind)
+	}
+	kid, mac, found := strings.Cut(signature, ".")
+	if !found || mac == "" {
+		return fmt.Errorf("malformed %s signature", s.kind)
+	}
+	secret, ok := s.keys[kid]
+	if !ok {
+		return fmt.Errorf("%s signed with unknown key", s.kind)
+	}
+	expected := computeHMAC(secret, s.message(message))
+	if !hmac.Equal([]byte(expected), []byte(mac)) {
+		return fmt.Errorf("invalid %s signature", s.kind)
+	}
+	return nil
+}
+
+func (s keyedSigner) message(message string) string {
+	return s.purpose + "\n" + message
+}
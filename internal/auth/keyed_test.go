@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyedSigner_PurposeSeparatesSignatures(t *testing.T) {
+	keys := map[string]string{"k1": "shared-secret"}
+	action := keyedSigner{purpose: actionPurpose, kind: "action", keys: keys}
+	export := keyedSigner{purpose: exportPurpose, kind: "export", keys: keys}
+
+	sig, err := action.sign("message")
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := action.verify("message", sig); err != nil {
+		t.Errorf("expected signature to verify for its own purpose, got: %v", err)
+	}
+	if err := export.verify("message", sig); err == nil || !strings.Contains(err.Error(), "invalid export signature") {
+		t.Errorf("expected signature refused for another purpose, got: %v", err)
+	}
+}
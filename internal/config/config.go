@@ -2,7 +2,22 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIdentityRetryMaxAttempts = 4
+	defaultIdentityRetryBackoffBase = 1 * time.Second
+	defaultPluginWebhookPath        = "/jit/webhook"
+	defaultPluginWebhookBatchPath   = "/batch"
+	defaultRevokeMode               = "stepfn"
+	defaultIdentityPrincipalType    = "USER"
+	defaultMaxApprovers             = 50
+	defaultRevokeMaxAttempts        = 3
 )
 
 // Config holds all environment-sourced configuration for the JIT controller.
@@ -19,6 +34,135 @@ type Config struct {
 	PluginWebhookURL         string
 	StepFunctionARN          string
 	AWSRegion                string
+
+	// IdentityCenterRegion is the IAM Identity Center home region used for SSO
+	// Admin and Identity Store calls (IDENTITY_CENTER_REGION). When empty the
+	// Lambda's own region is used, which only works if it is the home region.
+	IdentityCenterRegion string
+
+	// IdentityPrincipalType is the principal type of account assignments
+	// (IDENTITY_PRINCIPAL_TYPE): "USER" (default) assigns the requester
+	// directly, "GROUP" assigns the group whose display name is their email.
+	IdentityPrincipalType string
+
+	// IdentityRetryMaxAttempts is the total number of attempts for Identity
+	// Center grant/revoke operations (IDENTITY_RETRY_MAX_ATTEMPTS, default 4).
+	IdentityRetryMaxAttempts int
+	// IdentityRetryBackoffBase is the first retry delay; each subsequent delay
+	// is 4x the previous (IDENTITY_RETRY_BACKOFF_BASE, default 1s).
+	IdentityRetryBackoffBase time.Duration
+
+	// PluginWebhookFallbackURLs are tried in order when PluginWebhookURL cannot
+	// be reached (PLUGIN_WEBHOOK_FALLBACK_URLS, comma-separated).
+	PluginWebhookFallbackURLs []string
+	// PluginWebhookPath is the request path covered by outbound webhook
+	// signatures (PLUGIN_WEBHOOK_PATH, default "/jit/webhook"). It must match
+	// the path the plugin verifies against.
+	PluginWebhookPath string
+	// PluginWebhookBatchPath is appended to the webhook URL and signing path
+	// for batched reconciler notifications (PLUGIN_WEBHOOK_BATCH_PATH,
+	// default "/batch").
+	PluginWebhookBatchPath string
+
+	// FieldEncryptionEnabled turns on envelope encryption of sensitive request
+	// fields (FIELD_ENCRYPTION_ENABLED). It requires KMSKeyARN (KMS_KEY_ARN).
+	FieldEncryptionEnabled bool
+	KMSKeyARN              string
+
+	// ActionSigningEnabled makes the API Lambda sign each workflow action when
+	// it starts an execution and reject unsigned action payloads
+	// (ACTION_SIGNING_ENABLED). It requires ActionSigningSecretARN
+	// (ACTION_SIGNING_SECRET_ARN).
+	ActionSigningEnabled   bool
+	ActionSigningSecretARN string
+
+	// ExportSigningSecretARN holds the key that signs request compliance
+	// exports (EXPORT_SIGNING_SECRET_ARN). GET /requests/{id}/export is
+	// unavailable when it is empty.
+	ExportSigningSecretARN string
+
+	// DynamoDBEndpoint overrides the DynamoDB endpoint (DYNAMODB_ENDPOINT),
+	// e.g. "http://localhost:8000" for DynamoDB Local in end-to-end tests.
+	// Deployments never set it and use the regional endpoint.
+	DynamoDBEndpoint string
+
+	// EventBusARN is the EventBridge bus that request lifecycle events are
+	// published to (EVENT_BUS_ARN). Nothing is published when it is empty.
+	EventBusARN string
+
+	// TableWebhookFailures is the table recording notifications still
+	// undelivered after retries (TABLE_WEBHOOK_FAILURES). Failures are only
+	// logged, and the /admin/webhook-failures endpoints are unavailable, when
+	// it is empty.
+	TableWebhookFailures string
+
+	// PreGrantJitter delays each Step Functions grant by a random time up to
+	// this long (PRE_GRANT_JITTER, e.g. "5s"), so a burst of approvals does
+	// not hit SSO Admin at once. Zero disables the delay.
+	PreGrantJitter time.Duration
+
+	// ExpiryWarningWindow makes the reconciler warn requesters whose grants
+	// end within this window (EXPIRY_WARNING_WINDOW, e.g. "10m"). Zero
+	// disables warnings.
+	ExpiryWarningWindow time.Duration
+
+	// ApproverReminderInterval makes the reconciler remind approvers of
+	// requests that have been PENDING at least this long
+	// (APPROVER_REMINDER_INTERVAL, e.g. "30m"). Zero disables reminders.
+	// ApproverReminderCooldown is the minimum time between reminders for one
+	// request (APPROVER_REMINDER_COOLDOWN); it defaults to the interval.
+	ApproverReminderInterval time.Duration
+	ApproverReminderCooldown time.Duration
+
+	// ApprovalGracePeriod makes the reconciler fail requests still APPROVED
+	// this long after approval, whose grant workflow has evidently stalled
+	// (APPROVAL_GRACE_PERIOD, e.g. "30m"). Zero disables the check.
+	ApprovalGracePeriod time.Duration
+
+	// RevokeMaxAttempts is how many reconciler runs may fail to revoke an
+	// expired grant before it is moved to ERROR for manual intervention
+	// (REVOKE_MAX_ATTEMPTS, default 3).
+	RevokeMaxAttempts int
+
+	// AdminMMUserIDs lists Mattermost users allowed to call admin endpoints
+	// (ADMIN_MM_USER_IDS, comma-separated).
+	AdminMMUserIDs []string
+
+	// MaxApprovers caps the approver list set for a channel or account
+	// (MAX_APPROVERS, default 50).
+	MaxApprovers int
+
+	// MaxWaitSeconds caps the Step Functions wait before revoking
+	// (MAX_WAIT_SECONDS). Zero, the default, means no cap.
+	MaxWaitSeconds int
+
+	// MaxBatchGrantStarts caps the grants started by one batch approval
+	// (MAX_BATCH_GRANT_STARTS). Zero, the default, means 10, or 5 when
+	// REVOKE_MODE is reconciler.
+	MaxBatchGrantStarts int
+
+	// NoSelfApprovalChannels lists channels where self-approval is always
+	// refused, whatever their bindings say (NO_SELF_APPROVAL_CHANNELS,
+	// comma-separated).
+	NoSelfApprovalChannels []string
+
+	// RevokeMode is "stepfn" (default) to grant and revoke through Step
+	// Functions, or "reconciler" to grant at approval and leave expiry to the
+	// scheduled reconciler (REVOKE_MODE).
+	RevokeMode string
+
+	// WebhookEnabled sends notifications to the plugin (WEBHOOK_ENABLED,
+	// default true). When false every notification is dropped, for
+	// environments with no plugin to receive them.
+	WebhookEnabled bool
+
+	// NotifyOnApprove sends the plugin an APPROVED webhook when a request is
+	// approved, before access is granted (NOTIFY_ON_APPROVE).
+	NotifyOnApprove bool
+
+	// NotifyRequesterOnDeny sends the plugin a DENIED webhook addressed to
+	// the requester when a request is denied (NOTIFY_REQUESTER_ON_DENY).
+	NotifyRequesterOnDeny bool
 }
 
 // Load reads configuration from environment variables and validates required fields.
@@ -36,6 +180,195 @@ func Load() (*Config, error) {
 		PluginWebhookURL:         os.Getenv("PLUGIN_WEBHOOK_URL"),
 		StepFunctionARN:          os.Getenv("STEP_FUNCTION_ARN"),
 		AWSRegion:                os.Getenv("AWS_REGION"),
+		IdentityRetryMaxAttempts: defaultIdentityRetryMaxAttempts,
+		IdentityRetryBackoffBase: defaultIdentityRetryBackoffBase,
+		MaxApprovers:             defaultMaxApprovers,
+		RevokeMaxAttempts:        defaultRevokeMaxAttempts,
+		AdminMMUserIDs:           splitList(os.Getenv("ADMIN_MM_USER_IDS")),
+		IdentityCenterRegion:     os.Getenv("IDENTITY_CENTER_REGION"),
+		KMSKeyARN:                os.Getenv("KMS_KEY_ARN"),
+		ActionSigningSecretARN:   os.Getenv("ACTION_SIGNING_SECRET_ARN"),
+		ExportSigningSecretARN:   os.Getenv("EXPORT_SIGNING_SECRET_ARN"),
+		TableWebhookFailures:     os.Getenv("TABLE_WEBHOOK_FAILURES"),
+		DynamoDBEndpoint:         os.Getenv("DYNAMODB_ENDPOINT"),
+		EventBusARN:              os.Getenv("EVENT_BUS_ARN"),
+
+		PluginWebhookFallbackURLs: splitList(os.Getenv("PLUGIN_WEBHOOK_FALLBACK_URLS")),
+		PluginWebhookPath:         defaultPluginWebhookPath,
+		PluginWebhookBatchPath:    defaultPluginWebhookBatchPath,
+		NoSelfApprovalChannels:    splitList(os.Getenv("NO_SELF_APPROVAL_CHANNELS")),
+		RevokeMode:                defaultRevokeMode,
+		WebhookEnabled:            true,
+		IdentityPrincipalType:     defaultIdentityPrincipalType,
+	}
+
+	if v := os.Getenv("IDENTITY_PRINCIPAL_TYPE"); v != "" {
+		if v != "USER" && v != "GROUP" {
+			return nil, fmt.Errorf("invalid IDENTITY_PRINCIPAL_TYPE %q: must be USER or GROUP", v)
+		}
+		cfg.IdentityPrincipalType = v
+	}
+
+	if v := os.Getenv("REVOKE_MODE"); v != "" {
+		if v != "stepfn" && v != "reconciler" {
+			return nil, fmt.Errorf("invalid REVOKE_MODE %q: must be stepfn or reconciler", v)
+		}
+		cfg.RevokeMode = v
+	}
+
+	if v := os.Getenv("PLUGIN_WEBHOOK_PATH"); v != "" {
+		if !strings.HasPrefix(v, "/") {
+			return nil, fmt.Errorf("invalid PLUGIN_WEBHOOK_PATH %q: must start with /", v)
+		}
+		cfg.PluginWebhookPath = v
+	}
+	if v := os.Getenv("PLUGIN_WEBHOOK_BATCH_PATH"); v != "" {
+		if !strings.HasPrefix(v, "/") {
+			return nil, fmt.Errorf("invalid PLUGIN_WEBHOOK_BATCH_PATH %q: must start with /", v)
+		}
+		cfg.PluginWebhookBatchPath = v
+	}
+
+	if v := cfg.DynamoDBEndpoint; v != "" {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid DYNAMODB_ENDPOINT %q: must be an absolute http or https URL", v)
+		}
+	}
+
+	if v := cfg.EventBusARN; v != "" && !strings.HasPrefix(v, "arn:") {
+		return nil, fmt.Errorf("invalid EVENT_BUS_ARN %q: must be an event bus ARN", v)
+	}
+
+	if v := os.Getenv("MAX_WAIT_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MAX_WAIT_SECONDS %q: must be a non-negative integer", v)
+		}
+		cfg.MaxWaitSeconds = n
+	}
+
+	if v := os.Getenv("MAX_BATCH_GRANT_STARTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MAX_BATCH_GRANT_STARTS %q: must be a non-negative integer", v)
+		}
+		cfg.MaxBatchGrantStarts = n
+	}
+
+	if v := os.Getenv("MAX_APPROVERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid MAX_APPROVERS %q: must be a positive integer", v)
+		}
+		cfg.MaxApprovers = n
+	}
+
+	if v := os.Getenv("REVOKE_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid REVOKE_MAX_ATTEMPTS %q: must be a positive integer", v)
+		}
+		cfg.RevokeMaxAttempts = n
+	}
+
+	if v := os.Getenv("IDENTITY_RETRY_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid IDENTITY_RETRY_MAX_ATTEMPTS %q: must be a positive integer", v)
+		}
+		cfg.IdentityRetryMaxAttempts = n
+	}
+	if v := os.Getenv("IDENTITY_RETRY_BACKOFF_BASE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid IDENTITY_RETRY_BACKOFF_BASE %q: must be a non-negative duration", v)
+		}
+		cfg.IdentityRetryBackoffBase = d
+	}
+
+	if v := os.Getenv("EXPIRY_WARNING_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid EXPIRY_WARNING_WINDOW %q: must be a non-negative duration", v)
+		}
+		cfg.ExpiryWarningWindow = d
+	}
+
+	if v := os.Getenv("PRE_GRANT_JITTER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid PRE_GRANT_JITTER %q: must be a non-negative duration", v)
+		}
+		cfg.PreGrantJitter = d
+	}
+
+	if v := os.Getenv("APPROVER_REMINDER_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid APPROVER_REMINDER_INTERVAL %q: must be a non-negative duration", v)
+		}
+		cfg.ApproverReminderInterval = d
+	}
+	cfg.ApproverReminderCooldown = cfg.ApproverReminderInterval
+	if v := os.Getenv("APPROVER_REMINDER_COOLDOWN"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid APPROVER_REMINDER_COOLDOWN %q: must be a non-negative duration", v)
+		}
+		cfg.ApproverReminderCooldown = d
+	}
+
+	if v := os.Getenv("APPROVAL_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid APPROVAL_GRACE_PERIOD %q: must be a non-negative duration", v)
+		}
+		cfg.ApprovalGracePeriod = d
+	}
+
+	if v := os.Getenv("FIELD_ENCRYPTION_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FIELD_ENCRYPTION_ENABLED %q: must be a boolean", v)
+		}
+		cfg.FieldEncryptionEnabled = enabled
+	}
+	if cfg.FieldEncryptionEnabled && cfg.KMSKeyARN == "" {
+		return nil, fmt.Errorf("KMS_KEY_ARN is required when FIELD_ENCRYPTION_ENABLED is true")
+	}
+
+	if v := os.Getenv("ACTION_SIGNING_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACTION_SIGNING_ENABLED %q: must be a boolean", v)
+		}
+		cfg.ActionSigningEnabled = enabled
+	}
+	if cfg.ActionSigningEnabled && cfg.ActionSigningSecretARN == "" {
+		return nil, fmt.Errorf("ACTION_SIGNING_SECRET_ARN is required when ACTION_SIGNING_ENABLED is true")
+	}
+
+	if v := os.Getenv("WEBHOOK_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOK_ENABLED %q: must be a boolean", v)
+		}
+		cfg.WebhookEnabled = enabled
+	}
+
+	if v := os.Getenv("NOTIFY_ON_APPROVE"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NOTIFY_ON_APPROVE %q: must be a boolean", v)
+		}
+		cfg.NotifyOnApprove = enabled
+	}
+	if v := os.Getenv("NOTIFY_REQUESTER_ON_DENY"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NOTIFY_REQUESTER_ON_DENY %q: must be a boolean", v)
+		}
+		cfg.NotifyRequesterOnDeny = enabled
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -70,3 +403,14 @@ func (c *Config) validate() error {
 	}
 	return nil
 }
+
+// splitList parses a comma-separated list, trimming whitespace and dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 // requiredEnvVars lists all required environment variables for config.Load().
@@ -97,3 +98,578 @@ func TestLoad_StepFunctionARNLoadedWhenSet(t *testing.T) {
 		t.Errorf("expected StepFunctionARN to be set, got %q", cfg.StepFunctionARN)
 	}
 }
+
+func TestLoad_IdentityRetryDefaults(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityRetryMaxAttempts != 4 {
+		t.Errorf("expected IdentityRetryMaxAttempts 4, got %d", cfg.IdentityRetryMaxAttempts)
+	}
+	if cfg.IdentityRetryBackoffBase != time.Second {
+		t.Errorf("expected IdentityRetryBackoffBase 1s, got %v", cfg.IdentityRetryBackoffBase)
+	}
+}
+
+func TestLoad_IdentityRetryOverrides(t *testing.T) {
+	setAllRequiredEnvVars(t)
+	t.Setenv("IDENTITY_RETRY_MAX_ATTEMPTS", "6")
+	t.Setenv("IDENTITY_RETRY_BACKOFF_BASE", "500ms")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityRetryMaxAttempts != 6 {
+		t.Errorf("expected IdentityRetryMaxAttempts 6, got %d", cfg.IdentityRetryMaxAttempts)
+	}
+	if cfg.IdentityRetryBackoffBase != 500*time.Millisecond {
+		t.Errorf("expected IdentityRetryBackoffBase 500ms, got %v", cfg.IdentityRetryBackoffBase)
+	}
+}
+
+func TestLoad_MaxWaitSeconds(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.MaxWaitSeconds != 0 {
+		t.Errorf("expected no wait cap by default, got %d", cfg.MaxWaitSeconds)
+	}
+
+	t.Setenv("MAX_WAIT_SECONDS", "300")
+	if cfg, err = Load(); err != nil || cfg.MaxWaitSeconds != 300 {
+		t.Errorf("expected MaxWaitSeconds 300, got %v (err %v)", cfg, err)
+	}
+
+	for _, bad := range []string{"-1", "5m"} {
+		t.Setenv("MAX_WAIT_SECONDS", bad)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MAX_WAIT_SECONDS") {
+			t.Errorf("MAX_WAIT_SECONDS %q: expected error, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoad_MaxBatchGrantStarts(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.MaxBatchGrantStarts != 0 {
+		t.Errorf("expected no batch start cap by default, got %d", cfg.MaxBatchGrantStarts)
+	}
+
+	t.Setenv("MAX_BATCH_GRANT_STARTS", "10")
+	if cfg, err = Load(); err != nil || cfg.MaxBatchGrantStarts != 10 {
+		t.Errorf("expected MaxBatchGrantStarts 10, got %v (err %v)", cfg, err)
+	}
+
+	for _, bad := range []string{"-1", "ten"} {
+		t.Setenv("MAX_BATCH_GRANT_STARTS", bad)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MAX_BATCH_GRANT_STARTS") {
+			t.Errorf("MAX_BATCH_GRANT_STARTS %q: expected error, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoad_MaxApprovers(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.MaxApprovers != 50 {
+		t.Errorf("expected MaxApprovers 50 by default, got %d", cfg.MaxApprovers)
+	}
+
+	t.Setenv("MAX_APPROVERS", "10")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.MaxApprovers != 10 {
+		t.Errorf("expected MaxApprovers 10, got %d", cfg.MaxApprovers)
+	}
+
+	t.Setenv("MAX_APPROVERS", "0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "MAX_APPROVERS") {
+		t.Errorf("expected error for MAX_APPROVERS=0, got: %v", err)
+	}
+}
+
+func TestLoad_RevokeMaxAttempts(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.RevokeMaxAttempts != 3 {
+		t.Errorf("expected RevokeMaxAttempts 3 by default, got %d", cfg.RevokeMaxAttempts)
+	}
+
+	t.Setenv("REVOKE_MAX_ATTEMPTS", "5")
+	if cfg, err = Load(); err != nil || cfg.RevokeMaxAttempts != 5 {
+		t.Errorf("expected RevokeMaxAttempts 5, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("REVOKE_MAX_ATTEMPTS", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "REVOKE_MAX_ATTEMPTS") {
+		t.Errorf("expected error for REVOKE_MAX_ATTEMPTS=-1, got: %v", err)
+	}
+}
+
+func TestLoad_IdentityRetryInvalid(t *testing.T) {
+	setAllRequiredEnvVars(t)
+	t.Setenv("IDENTITY_RETRY_MAX_ATTEMPTS", "zero")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("expected error for invalid IDENTITY_RETRY_MAX_ATTEMPTS, got nil")
+	}
+	if !strings.Contains(err.Error(), "IDENTITY_RETRY_MAX_ATTEMPTS") {
+		t.Errorf("expected error to mention IDENTITY_RETRY_MAX_ATTEMPTS, got: %v", err)
+	}
+}
+
+func TestLoad_AdminMMUserIDs(t *testing.T) {
+	setAllRequiredEnvVars(t)
+	t.Setenv("ADMIN_MM_USER_IDS", " admin-1, ,admin-2 ")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(cfg.AdminMMUserIDs) != 2 || cfg.AdminMMUserIDs[0] != "admin-1" || cfg.AdminMMUserIDs[1] != "admin-2" {
+		t.Errorf("expected [admin-1 admin-2], got %v", cfg.AdminMMUserIDs)
+	}
+}
+
+func TestLoad_NoSelfApprovalChannels(t *testing.T) {
+	setAllRequiredEnvVars(t)
+	t.Setenv("NO_SELF_APPROVAL_CHANNELS", "ch-prod, ch-breakglass")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(cfg.NoSelfApprovalChannels) != 2 || cfg.NoSelfApprovalChannels[1] != "ch-breakglass" {
+		t.Errorf("expected [ch-prod ch-breakglass], got %v", cfg.NoSelfApprovalChannels)
+	}
+}
+
+func TestLoad_IdentityPrincipalType(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityPrincipalType != "USER" {
+		t.Errorf("expected default principal type USER, got %q", cfg.IdentityPrincipalType)
+	}
+
+	t.Setenv("IDENTITY_PRINCIPAL_TYPE", "GROUP")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityPrincipalType != "GROUP" {
+		t.Errorf("expected principal type GROUP, got %q", cfg.IdentityPrincipalType)
+	}
+
+	t.Setenv("IDENTITY_PRINCIPAL_TYPE", "ROLE")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for unknown IDENTITY_PRINCIPAL_TYPE")
+	}
+}
+
+func TestLoad_RevokeMode(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.RevokeMode != "stepfn" {
+		t.Errorf("expected default revoke mode stepfn, got %q", cfg.RevokeMode)
+	}
+
+	t.Setenv("REVOKE_MODE", "reconciler")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.RevokeMode != "reconciler" {
+		t.Errorf("expected revoke mode reconciler, got %q", cfg.RevokeMode)
+	}
+
+	t.Setenv("REVOKE_MODE", "lambda")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for unknown REVOKE_MODE")
+	}
+}
+
+func TestLoad_PluginWebhookFallbackURLs(t *testing.T) {
+	setAllRequiredEnvVars(t)
+	t.Setenv("PLUGIN_WEBHOOK_FALLBACK_URLS", "https://dr-1.example.com/hook,https://dr-2.example.com/hook")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(cfg.PluginWebhookFallbackURLs) != 2 || cfg.PluginWebhookFallbackURLs[1] != "https://dr-2.example.com/hook" {
+		t.Errorf("expected two fallback URLs in order, got %v", cfg.PluginWebhookFallbackURLs)
+	}
+}
+
+func TestLoad_PluginWebhookPath(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.PluginWebhookPath != "/jit/webhook" {
+		t.Errorf("expected default path /jit/webhook, got %q", cfg.PluginWebhookPath)
+	}
+
+	t.Setenv("PLUGIN_WEBHOOK_PATH", "/plugins/jit/callback")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.PluginWebhookPath != "/plugins/jit/callback" {
+		t.Errorf("expected configured path, got %q", cfg.PluginWebhookPath)
+	}
+
+	t.Setenv("PLUGIN_WEBHOOK_PATH", "plugins/jit")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for path without leading slash")
+	}
+}
+
+func TestLoad_IdentityCenterRegion(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityCenterRegion != "" {
+		t.Errorf("expected empty IdentityCenterRegion by default, got %q", cfg.IdentityCenterRegion)
+	}
+
+	t.Setenv("IDENTITY_CENTER_REGION", "us-east-1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.IdentityCenterRegion != "us-east-1" {
+		t.Errorf("expected IdentityCenterRegion us-east-1, got %q", cfg.IdentityCenterRegion)
+	}
+}
+
+func TestLoad_DynamoDBEndpoint(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.DynamoDBEndpoint != "" {
+		t.Errorf("expected no DynamoDB endpoint override by default, got %q", cfg.DynamoDBEndpoint)
+	}
+
+	t.Setenv("DYNAMODB_ENDPOINT", "http://localhost:8000")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.DynamoDBEndpoint != "http://localhost:8000" {
+		t.Errorf("expected DynamoDB endpoint http://localhost:8000, got %q", cfg.DynamoDBEndpoint)
+	}
+
+	for _, bad := range []string{"localhost:8000", "tcp://localhost:8000", "http://"} {
+		t.Setenv("DYNAMODB_ENDPOINT", bad)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DYNAMODB_ENDPOINT") {
+			t.Errorf("DYNAMODB_ENDPOINT %q: expected error, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoad_EventBusARN(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.EventBusARN != "" {
+		t.Errorf("expected no event bus by default, got %q", cfg.EventBusARN)
+	}
+
+	arn := "arn:aws:events:us-east-1:123456789012:event-bus/jit"
+	t.Setenv("EVENT_BUS_ARN", arn)
+	if cfg, err = Load(); err != nil || cfg.EventBusARN != arn {
+		t.Errorf("expected event bus %s, got %v (err %v)", arn, cfg, err)
+	}
+
+	t.Setenv("EVENT_BUS_ARN", "jit")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "EVENT_BUS_ARN") {
+		t.Errorf("expected error for a bus name, got: %v", err)
+	}
+}
+
+func TestLoad_FieldEncryption(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.FieldEncryptionEnabled {
+		t.Error("expected field encryption to be disabled by default")
+	}
+
+	t.Setenv("FIELD_ENCRYPTION_ENABLED", "true")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "KMS_KEY_ARN") {
+		t.Fatalf("expected KMS_KEY_ARN to be required, got: %v", err)
+	}
+
+	t.Setenv("KMS_KEY_ARN", "arn:aws:kms:us-east-1:123456789012:key/abc")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !cfg.FieldEncryptionEnabled || cfg.KMSKeyARN == "" {
+		t.Errorf("expected field encryption enabled with key, got %+v", cfg)
+	}
+
+	t.Setenv("FIELD_ENCRYPTION_ENABLED", "maybe")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for invalid FIELD_ENCRYPTION_ENABLED")
+	}
+}
+
+func TestLoad_ActionSigning(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ActionSigningEnabled {
+		t.Error("expected action signing to be disabled by default")
+	}
+
+	t.Setenv("ACTION_SIGNING_ENABLED", "true")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ACTION_SIGNING_SECRET_ARN") {
+		t.Fatalf("expected ACTION_SIGNING_SECRET_ARN to be required, got: %v", err)
+	}
+
+	t.Setenv("ACTION_SIGNING_SECRET_ARN", "arn:aws:secretsmanager:us-east-1:123456789012:secret:action")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !cfg.ActionSigningEnabled || cfg.ActionSigningSecretARN == "" {
+		t.Errorf("expected action signing enabled with secret, got %+v", cfg)
+	}
+}
+
+func TestLoad_WebhookEnabled(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !cfg.WebhookEnabled {
+		t.Error("expected webhooks to be enabled by default")
+	}
+
+	t.Setenv("WEBHOOK_ENABLED", "false")
+	if cfg, err = Load(); err != nil || cfg.WebhookEnabled {
+		t.Errorf("expected webhooks to be disabled, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("WEBHOOK_ENABLED", "maybe")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "WEBHOOK_ENABLED") {
+		t.Fatalf("expected invalid WEBHOOK_ENABLED error, got: %v", err)
+	}
+}
+
+func TestLoad_NotifyOnApprove(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.NotifyOnApprove {
+		t.Error("expected approval notifications to be disabled by default")
+	}
+
+	t.Setenv("NOTIFY_ON_APPROVE", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !cfg.NotifyOnApprove {
+		t.Error("expected approval notifications to be enabled")
+	}
+
+	t.Setenv("NOTIFY_ON_APPROVE", "maybe")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "NOTIFY_ON_APPROVE") {
+		t.Fatalf("expected invalid NOTIFY_ON_APPROVE error, got: %v", err)
+	}
+}
+
+func TestLoad_NotifyRequesterOnDeny(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.NotifyRequesterOnDeny {
+		t.Error("expected deny notifications to be disabled by default")
+	}
+
+	t.Setenv("NOTIFY_REQUESTER_ON_DENY", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !cfg.NotifyRequesterOnDeny {
+		t.Error("expected deny notifications to be enabled")
+	}
+
+	t.Setenv("NOTIFY_REQUESTER_ON_DENY", "sometimes")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "NOTIFY_REQUESTER_ON_DENY") {
+		t.Fatalf("expected invalid NOTIFY_REQUESTER_ON_DENY error, got: %v", err)
+	}
+}
+
+func TestLoad_ExpiryWarningWindow(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ExpiryWarningWindow != 0 {
+		t.Errorf("expected warnings disabled by default, got %v", cfg.ExpiryWarningWindow)
+	}
+
+	t.Setenv("EXPIRY_WARNING_WINDOW", "10m")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ExpiryWarningWindow != 10*time.Minute {
+		t.Errorf("expected 10m, got %v", cfg.ExpiryWarningWindow)
+	}
+
+	t.Setenv("EXPIRY_WARNING_WINDOW", "soon")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for invalid EXPIRY_WARNING_WINDOW")
+	}
+}
+
+func TestLoad_PreGrantJitter(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.PreGrantJitter != 0 {
+		t.Errorf("expected no pre-grant delay by default, got %v", cfg.PreGrantJitter)
+	}
+
+	t.Setenv("PRE_GRANT_JITTER", "5s")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.PreGrantJitter != 5*time.Second {
+		t.Errorf("expected 5s, got %v", cfg.PreGrantJitter)
+	}
+
+	t.Setenv("PRE_GRANT_JITTER", "-1s")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for negative PRE_GRANT_JITTER")
+	}
+}
+
+func TestLoad_ApproverReminder(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ApproverReminderInterval != 0 {
+		t.Errorf("expected reminders disabled by default, got %v", cfg.ApproverReminderInterval)
+	}
+
+	t.Setenv("APPROVER_REMINDER_INTERVAL", "30m")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ApproverReminderInterval != 30*time.Minute || cfg.ApproverReminderCooldown != 30*time.Minute {
+		t.Errorf("expected 30m interval and cooldown defaulting to it, got %v and %v",
+			cfg.ApproverReminderInterval, cfg.ApproverReminderCooldown)
+	}
+
+	t.Setenv("APPROVER_REMINDER_COOLDOWN", "2h")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ApproverReminderCooldown != 2*time.Hour {
+		t.Errorf("expected 2h cooldown, got %v", cfg.ApproverReminderCooldown)
+	}
+
+	t.Setenv("APPROVER_REMINDER_COOLDOWN", "often")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for invalid APPROVER_REMINDER_COOLDOWN")
+	}
+}
+
+func TestLoad_ApprovalGracePeriod(t *testing.T) {
+	setAllRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ApprovalGracePeriod != 0 {
+		t.Errorf("expected the grace period check disabled by default, got %v", cfg.ApprovalGracePeriod)
+	}
+
+	t.Setenv("APPROVAL_GRACE_PERIOD", "45m")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.ApprovalGracePeriod != 45*time.Minute {
+		t.Errorf("expected 45m grace period, got %v", cfg.ApprovalGracePeriod)
+	}
+
+	t.Setenv("APPROVAL_GRACE_PERIOD", "-1m")
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for negative APPROVAL_GRACE_PERIOD")
+	}
+}
@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoAPI is the subset of the DynamoDB client used by Client.
+type DynamoAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// capacityLogger wraps a DynamoAPI, asking DynamoDB to report consumed
+// capacity on every call and logging it at debug level. Paginated queries log
+// once per page.
+type capacityLogger struct {
+	api DynamoAPI
+}
+
+func (l capacityLogger) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.GetItem(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "GetItem", out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (l capacityLogger) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.PutItem(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "PutItem", out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (l capacityLogger) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.UpdateItem(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "UpdateItem", out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (l capacityLogger) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.Query(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "Query", out.ConsumedCapacity, "index", aws.ToString(params.IndexName), "items", out.Count)
+	}
+	return out, err
+}
+
+func (l capacityLogger) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.Scan(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "Scan", out.ConsumedCapacity, "items", out.Count)
+	}
+	return out, err
+}
+
+func (l capacityLogger) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.DeleteItem(ctx, params, optFns...)
+	if err == nil {
+		logConsumedCapacity(ctx, "DeleteItem", out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+// TransactWriteItems logs the capacity consumed on each table it writes.
+func (l capacityLogger) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	params.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal
+	out, err := l.api.TransactWriteItems(ctx, params, optFns...)
+	if err == nil {
+		for i := range out.ConsumedCapacity {
+			logConsumedCapacity(ctx, "TransactWriteItems", &out.ConsumedCapacity[i])
+		}
+	}
+	return out, err
+}
+
+// DescribeTimeToLive is a control-plane call and consumes no capacity.
+func (l capacityLogger) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return l.api.DescribeTimeToLive(ctx, params, optFns...)
+}
+
+func logConsumedCapacity(ctx context.Context, op string, cc *types.ConsumedCapacity, extra ...any) {
+	if cc == nil {
+		return
+	}
+	attrs := append([]any{
+		"op", op,
+		"table", aws.ToString(cc.TableName),
+		"capacity_units", aws.ToFloat64(cc.CapacityUnits),
+	}, extra...)
+	slog.DebugContext(ctx, "dynamodb consumed capacity", attrs...)
+}
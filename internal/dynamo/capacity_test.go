@@ -0,0 +1,130 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// mockDynamo records the consumed-capacity setting of every input it sees
+// and serves the last item written back from GetItem.
+type mockDynamo struct {
+	flags map[string]types.ReturnConsumedCapacity
+	item  map[string]types.AttributeValue
+
+	transact    *dynamodb.TransactWriteItemsInput
+	transactErr error
+}
+
+func newMockDynamo() *mockDynamo {
+	return &mockDynamo{flags: map[string]types.ReturnConsumedCapacity{}}
+}
+
+func consumed(table string) *types.ConsumedCapacity {
+	return &types.ConsumedCapacity{TableName: aws.String(table), CapacityUnits: aws.Float64(0.5)}
+}
+
+func (m *mockDynamo) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	m.flags["GetItem"] = params.ReturnConsumedCapacity
+	return &dynamodb.GetItemOutput{Item: m.item, ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.flags["PutItem"] = params.ReturnConsumedCapacity
+	m.item = params.Item
+	return &dynamodb.PutItemOutput{ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) UpdateItem(_ context.Context, params *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	m.flags["UpdateItem"] = params.ReturnConsumedCapacity
+	return &dynamodb.UpdateItemOutput{ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.flags["Query"] = params.ReturnConsumedCapacity
+	return &dynamodb.QueryOutput{ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) Scan(_ context.Context, params *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.flags["Scan"] = params.ReturnConsumedCapacity
+	return &dynamodb.ScanOutput{ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) DeleteItem(_ context.Context, params *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	m.flags["DeleteItem"] = params.ReturnConsumedCapacity
+	return &dynamodb.DeleteItemOutput{ConsumedCapacity: consumed(*params.TableName)}, nil
+}
+
+func (m *mockDynamo) TransactWriteItems(_ context.Context, params *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.flags["TransactWriteItems"] = params.ReturnConsumedCapacity
+	m.transact = params
+	if m.transactErr != nil {
+		return nil, m.transactErr
+	}
+	return &dynamodb.TransactWriteItemsOutput{ConsumedCapacity: []types.ConsumedCapacity{*consumed("audit")}}, nil
+}
+
+func (m *mockDynamo) DescribeTimeToLive(_ context.Context, _ *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: &types.TimeToLiveDescription{
+		AttributeName:    aws.String(models.NonceTTLAttribute),
+		TimeToLiveStatus: types.TimeToLiveStatusEnabled,
+	}}, nil
+}
+
+func TestClient_RequestsConsumedCapacity(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	if _, err := c.GetRequest(ctx, "req-1"); err != nil {
+		t.Fatalf("GetRequest: %v", err)
+	}
+	if err := c.CreateRequest(ctx, &models.JitRequest{RequestID: "req-1"}); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	if err := c.ConditionalUpdateStatus(ctx, "req-1", models.StatusPending, map[string]interface{}{"status": models.StatusApproved}); err != nil {
+		t.Fatalf("ConditionalUpdateStatus: %v", err)
+	}
+	if _, err := c.QueryRequestsByStatus(ctx, models.StatusGranted, "2025-01-01T00:00:00Z", 0); err != nil {
+		t.Fatalf("QueryRequestsByStatus: %v", err)
+	}
+	if _, _, err := c.ScanConfigs(ctx, 10, ""); err != nil {
+		t.Fatalf("ScanConfigs: %v", err)
+	}
+	if err := c.AppendAuditEvent(ctx, &models.AuditEvent{RequestID: "req-1", EventID: "ev-1"}, 0, false); err != nil {
+		t.Fatalf("AppendAuditEvent: %v", err)
+	}
+
+	for _, op := range []string{"GetItem", "PutItem", "UpdateItem", "Query", "Scan", "TransactWriteItems"} {
+		if got := mock.flags[op]; got != types.ReturnConsumedCapacityTotal {
+			t.Errorf("%s: expected ReturnConsumedCapacity TOTAL, got %q", op, got)
+		}
+	}
+}
+
+func TestClient_LogsConsumedCapacityAtDebug(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	c := NewClient(newMockDynamo(), "cfg", "reqs", "audit", "nonces")
+	if _, err := c.QueryRequestsByStatus(context.Background(), models.StatusGranted, "", 0); err != nil {
+		t.Fatalf("QueryRequestsByStatus: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"level=DEBUG", "op=Query", "table=reqs", "capacity_units=0.5", "index=gsi_status_endtime"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log output, got %s", want, out)
+		}
+	}
+}
@@ -2,36 +2,80 @@ package dynamo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 
+	"github.com/dgwhited/jit-aws-controller/internal/fieldcrypt"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
 // Client provides DynamoDB operations for all JIT tables.
 type Client struct {
-	db            *dynamodb.Client
+	db            DynamoAPI
 	tableConfig   string
 	tableRequests string
 	tableAudit    string
 	tableNonces   string
+	encryptor     fieldcrypt.Encryptor
+
+	tableWebhookFailures string
+
+	throttleBackoffs []time.Duration
 }
 
-// NewClient creates a new DynamoDB client wrapper.
-func NewClient(db *dynamodb.Client, tableConfig, tableRequests, tableAudit, tableNonces string) *Client {
-	return &Client{
-		db:            db,
+// Option configures optional Client behavior.
+type Option func(*Client)
+
+// WithFieldEncryptor encrypts sensitive request fields before they are
+// written and decrypts them on read.
+func WithFieldEncryptor(enc fieldcrypt.Encryptor) Option {
+	return func(c *Client) {
+		c.encryptor = enc
+	}
+}
+
+// WithThrottleBackoffs retries calls that are still throttled after the SDK's
+// own retries, sleeping for each of backoffs in turn. The number of attempts
+// is len(backoffs)+1. By default throttled calls are not retried, only logged.
+func WithThrottleBackoffs(backoffs []time.Duration) Option {
+	return func(c *Client) {
+		c.throttleBackoffs = backoffs
+	}
+}
+
+// WithWebhookFailuresTable sets the table holding undelivered webhook
+// notifications. Without it the webhook failure operations fail.
+func WithWebhookFailuresTable(name string) Option {
+	return func(c *Client) {
+		c.tableWebhookFailures = name
+	}
+}
+
+// NewClient creates a new DynamoDB client wrapper. Every call reports its
+// consumed capacity, which is logged at debug level, and every throttled call
+// is logged as a warning.
+func NewClient(db DynamoAPI, tableConfig, tableRequests, tableAudit, tableNonces string, opts ...Option) *Client {
+	c := &Client{
 		tableConfig:   tableConfig,
 		tableRequests: tableRequests,
 		tableAudit:    tableAudit,
 		tableNonces:   tableNonces,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.db = capacityLogger{api: throttleRetrier{api: db, backoffs: c.throttleBackoffs}}
+	return c
 }
 
 // ---------------------------------------------------------------------------
@@ -95,6 +139,87 @@ func (c *Client) PutConfig(ctx context.Context, cfg *models.JitConfig) error {
 	return nil
 }
 
+// UpdateConfig applies a partial update to an existing config entry and
+// returns the updated entry. It fails if the entry does not exist.
+func (c *Client) UpdateConfig(ctx context.Context, channelID, accountID string, updates map[string]interface{}) (*models.JitConfig, error) {
+	updateExpr := "SET"
+	exprNames := map[string]string{}
+	exprValues := map[string]types.AttributeValue{}
+
+	i := 0
+	for field, val := range updates {
+		if i > 0 {
+			updateExpr += ","
+		}
+		nameAlias := fmt.Sprintf("#f%d", i)
+		valAlias := fmt.Sprintf(":v%d", i)
+		updateExpr += fmt.Sprintf(" %s = %s", nameAlias, valAlias)
+		exprNames[nameAlias] = field
+
+		av, err := attributevalue.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateConfig marshal field %s: %w", field, err)
+		}
+		exprValues[valAlias] = av
+		i++
+	}
+
+	out, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.tableConfig,
+		Key: map[string]types.AttributeValue{
+			"channel_id": &types.AttributeValueMemberS{Value: channelID},
+			"account_id": &types.AttributeValueMemberS{Value: accountID},
+		},
+		UpdateExpression:          &updateExpr,
+		ConditionExpression:       aws.String("attribute_exists(channel_id)"),
+		ExpressionAttributeNames:  exprNames,
+		ExpressionAttributeValues: exprValues,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("UpdateConfig: %w", err)
+	}
+
+	var cfg models.JitConfig
+	if err := attributevalue.UnmarshalMap(out.Attributes, &cfg); err != nil {
+		return nil, fmt.Errorf("UpdateConfig unmarshal: %w", err)
+	}
+	return &cfg, nil
+}
+
+// ScanConfigs returns one page of config entries across all channels.
+// This is a full table scan intended only for admin reporting. The page size
+// is always bounded: limit is normalized like any other query limit.
+func (c *Client) ScanConfigs(ctx context.Context, limit int32, nextToken string) ([]models.JitConfig, string, error) {
+	input := &dynamodb.ScanInput{
+		TableName: &c.tableConfig,
+		Limit:     aws.Int32(int32(models.NormalizeLimit(int(limit)))),
+	}
+	if nextToken != "" {
+		startKey, err := deserializeStartKey(nextToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("ScanConfigs: %w: %v", models.ErrInvalidNextToken, err)
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	out, err := c.db.Scan(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanConfigs: %w", err)
+	}
+
+	var configs []models.JitConfig
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &configs); err != nil {
+		return nil, "", fmt.Errorf("ScanConfigs unmarshal: %w", err)
+	}
+
+	token, err := serializeStartKey(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanConfigs serialize token: %w", err)
+	}
+	return configs, token, nil
+}
+
 // GetChannelForAccount looks up the channel binding for an account using gsi_account.
 func (c *Client) GetChannelForAccount(ctx context.Context, accountID string) (*models.JitConfig, error) {
 	out, err := c.db.Query(ctx, &dynamodb.QueryInput{
@@ -119,12 +244,52 @@ func (c *Client) GetChannelForAccount(ctx context.Context, accountID string) (*m
 	return &cfg, nil
 }
 
+// GetAllBindingsForAccount returns every binding for an account from
+// gsi_account. An account should be bound to a single channel; more than one
+// result means the bindings have drifted.
+func (c *Client) GetAllBindingsForAccount(ctx context.Context, accountID string) ([]models.JitConfig, error) {
+	var configs []models.JitConfig
+	var startKey map[string]types.AttributeValue
+	for {
+		out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &c.tableConfig,
+			IndexName:              aws.String("gsi_account"),
+			KeyConditionExpression: aws.String("account_id = :aid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":aid": &types.AttributeValueMemberS{Value: accountID},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GetAllBindingsForAccount: %w", err)
+		}
+		var page []models.JitConfig
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, fmt.Errorf("GetAllBindingsForAccount unmarshal: %w", err)
+		}
+		configs = append(configs, page...)
+
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		startKey = out.LastEvaluatedKey
+	}
+	return configs, nil
+}
+
 // ---------------------------------------------------------------------------
 // Request operations
 // ---------------------------------------------------------------------------
 
 // CreateRequest stores a new JIT request.
 func (c *Client) CreateRequest(ctx context.Context, req *models.JitRequest) error {
+	if c.encryptor != nil {
+		encrypted, err := fieldcrypt.EncryptRequest(ctx, c.encryptor, req)
+		if err != nil {
+			return fmt.Errorf("CreateRequest: %w", err)
+		}
+		req = encrypted
+	}
 	item, err := attributevalue.MarshalMap(req)
 	if err != nil {
 		return fmt.Errorf("CreateRequest marshal: %w", err)
@@ -158,6 +323,9 @@ func (c *Client) GetRequest(ctx context.Context, requestID string) (*models.JitR
 	if err := attributevalue.UnmarshalMap(out.Item, &req); err != nil {
 		return nil, fmt.Errorf("GetRequest unmarshal: %w", err)
 	}
+	if err := c.decryptRequest(ctx, &req); err != nil {
+		return nil, fmt.Errorf("GetRequest: %w", err)
+	}
 	return &req, nil
 }
 
@@ -247,6 +415,162 @@ func (c *Client) ConditionalUpdateStatus(ctx context.Context, requestID, expecte
 	return nil
 }
 
+// MarkExpiryWarned records that the requester of a GRANTED request has been
+// warned about its upcoming expiry. The update is conditional on warned_at
+// being unset, so concurrent reconciler runs warn each grant at most once.
+func (c *Client) MarkExpiryWarned(ctx context.Context, requestID, warnedAt string) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: requestID},
+		},
+		UpdateExpression:    aws.String("SET warned_at = :w"),
+		ConditionExpression: aws.String("#status = :granted AND attribute_not_exists(warned_at)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":w":       &types.AttributeValueMemberS{Value: warnedAt},
+			":granted": &types.AttributeValueMemberS{Value: models.StatusGranted},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("MarkExpiryWarned: %w", err)
+	}
+	return nil
+}
+
+// MarkReminded records that approvers of a PENDING request were reminded at
+// remindedAt. The update is conditional on the previous reminder, if any,
+// being no later than remindedBefore, so concurrent reconciler runs cannot
+// both remind within one cooldown.
+func (c *Client) MarkReminded(ctx context.Context, requestID, remindedAt, remindedBefore string) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: requestID},
+		},
+		UpdateExpression:    aws.String("SET last_reminded_at = :r"),
+		ConditionExpression: aws.String("#status = :pending AND (attribute_not_exists(last_reminded_at) OR last_reminded_at <= :before)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":r":       &types.AttributeValueMemberS{Value: remindedAt},
+			":before":  &types.AttributeValueMemberS{Value: remindedBefore},
+			":pending": &types.AttributeValueMemberS{Value: models.StatusPending},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("MarkReminded: %w", err)
+	}
+	return nil
+}
+
+// IncrementActiveGrants atomically adds one to a binding's active-grant
+// counter. When limit is positive the update is conditional, so the counter
+// can never exceed limit no matter how many grants race.
+func (c *Client) IncrementActiveGrants(ctx context.Context, channelID, accountID string, limit int) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: models.GrantCounterKey(channelID, accountID)},
+		},
+		UpdateExpression: aws.String("ADD active_grants :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+	if limit > 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(active_grants) OR active_grants < :limit")
+		input.ExpressionAttributeValues[":limit"] = &types.AttributeValueMemberN{Value: strconv.Itoa(limit)}
+	}
+
+	if _, err := c.db.UpdateItem(ctx, input); err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return fmt.Errorf("IncrementActiveGrants: concurrent grant limit %d reached for account %s", limit, accountID)
+		}
+		return fmt.Errorf("IncrementActiveGrants: %w", err)
+	}
+	return nil
+}
+
+// DecrementActiveGrants atomically subtracts one from a binding's active-grant
+// counter. It never takes the counter below zero.
+func (c *Client) DecrementActiveGrants(ctx context.Context, channelID, accountID string) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: models.GrantCounterKey(channelID, accountID)},
+		},
+		UpdateExpression:    aws.String("ADD active_grants :neg"),
+		ConditionExpression: aws.String("active_grants > :zero"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":neg":  &types.AttributeValueMemberN{Value: "-1"},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("DecrementActiveGrants: %w", err)
+	}
+	return nil
+}
+
+// SetActiveGrants overwrites a binding's active-grant counter with count.
+// It repairs a counter that has drifted from the binding's GRANTED requests.
+func (c *Client) SetActiveGrants(ctx context.Context, channelID, accountID string, count int) error {
+	_, err := c.db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: models.GrantCounterKey(channelID, accountID)},
+		},
+		UpdateExpression: aws.String("SET active_grants = :count"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":count": &types.AttributeValueMemberN{Value: strconv.Itoa(count)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("SetActiveGrants: %w", err)
+	}
+	return nil
+}
+
+// GetScanCursor returns the next_token saved for the named scan by
+// PutScanCursor, or "" when the scan should start at the beginning.
+func (c *Client) GetScanCursor(ctx context.Context, name string) (string, error) {
+	out, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &c.tableRequests,
+		Key: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: models.ScanCursorKey(name)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("GetScanCursor: %w", err)
+	}
+	if v, ok := out.Item["next_token"].(*types.AttributeValueMemberS); ok {
+		return v.Value, nil
+	}
+	return "", nil
+}
+
+// PutScanCursor saves where the named scan stopped. An empty token restarts
+// the scan at the beginning.
+func (c *Client) PutScanCursor(ctx context.Context, name, token string) error {
+	_, err := c.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &c.tableRequests,
+		Item: map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: models.ScanCursorKey(name)},
+			"next_token": &types.AttributeValueMemberS{Value: token},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("PutScanCursor: %w", err)
+	}
+	return nil
+}
+
 // QueryRequestsByChannel queries requests by channel using gsi_channel_created.
 func (c *Client) QueryRequestsByChannel(ctx context.Context, channelID string, limit int32, startKey map[string]types.AttributeValue) ([]models.JitRequest, map[string]types.AttributeValue, error) {
 	input := &dynamodb.QueryInput{
@@ -271,12 +595,56 @@ func (c *Client) QueryRequestsByChannel(ctx context.Context, channelID string, l
 	if err := attributevalue.UnmarshalListOfMaps(out.Items, &requests); err != nil {
 		return nil, nil, fmt.Errorf("QueryRequestsByChannel unmarshal: %w", err)
 	}
+	if err := c.decryptRequests(ctx, requests); err != nil {
+		return nil, nil, fmt.Errorf("QueryRequestsByChannel: %w", err)
+	}
 	return requests, out.LastEvaluatedKey, nil
 }
 
 // QueryRequestsByStatus queries requests by status using gsi_status_endtime.
 // If beforeEndTime is non-empty, only returns items with end_time <= beforeEndTime.
+// It follows pagination until limit items are collected (all items when
+// limit is zero) and never returns more than limit.
 func (c *Client) QueryRequestsByStatus(ctx context.Context, status string, beforeEndTime string, limit int32) ([]models.JitRequest, error) {
+	var allRequests []models.JitRequest
+	var startKey map[string]types.AttributeValue
+	for {
+		pageLimit := int32(0)
+		if limit > 0 {
+			pageLimit = limit - int32(len(allRequests))
+		}
+		page, lastKey, err := c.queryStatusPage(ctx, status, beforeEndTime, pageLimit, startKey)
+		if err != nil {
+			return nil, fmt.Errorf("QueryRequestsByStatus: %w", err)
+		}
+		allRequests = append(allRequests, page...)
+
+		if lastKey == nil || (limit > 0 && int32(len(allRequests)) >= limit) {
+			break
+		}
+		startKey = lastKey
+	}
+	return allRequests, nil
+}
+
+// QueryRequestsByStatusPage returns one page of requests with the given
+// status, ordered by end_time, and a token for the next page. The token is
+// empty on the last page.
+func (c *Client) QueryRequestsByStatusPage(ctx context.Context, status string, limit int32, nextToken string) ([]models.JitRequest, string, error) {
+	startKey, err := deserializeStartKey(nextToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("QueryRequestsByStatusPage: %w: %v", models.ErrInvalidNextToken, err)
+	}
+	requests, lastKey, err := c.queryStatusPage(ctx, status, "", limit, startKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("QueryRequestsByStatusPage: %w", err)
+	}
+	token, _ := serializeStartKey(lastKey)
+	return requests, token, nil
+}
+
+// queryStatusPage runs a single gsi_status_endtime query.
+func (c *Client) queryStatusPage(ctx context.Context, status, beforeEndTime string, limit int32, startKey map[string]types.AttributeValue) ([]models.JitRequest, map[string]types.AttributeValue, error) {
 	keyExpr := "#status = :s"
 	exprNames := map[string]string{
 		"#status": "status",
@@ -297,44 +665,104 @@ func (c *Client) QueryRequestsByStatus(ctx context.Context, status string, befor
 		ExpressionAttributeNames:  exprNames,
 		ExpressionAttributeValues: exprValues,
 		ScanIndexForward:          aws.Bool(true),
+		ExclusiveStartKey:         startKey,
 	}
 	if limit > 0 {
 		input.Limit = &limit
 	}
 
-	var allRequests []models.JitRequest
-	for {
-		out, err := c.db.Query(ctx, input)
+	out, err := c.db.Query(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	var page []models.JitRequest
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	if err := c.decryptRequests(ctx, page); err != nil {
+		return nil, nil, err
+	}
+	return page, out.LastEvaluatedKey, nil
+}
+
+// ScanGrantsMissingEndTime returns one page of GRANTED requests with no
+// end_time. gsi_status_endtime cannot find them, since items without the
+// index's sort key are not indexed, so this scans the table with a filter.
+// A page may be empty while the token is not. It is only used by the
+// reconciler's safety pass.
+func (c *Client) ScanGrantsMissingEndTime(ctx context.Context, limit int32, nextToken string) ([]models.JitRequest, string, error) {
+	input := &dynamodb.ScanInput{
+		TableName:        &c.tableRequests,
+		FilterExpression: aws.String("#status = :s AND (attribute_not_exists(end_time) OR end_time = :empty)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":s":     &types.AttributeValueMemberS{Value: models.StatusGranted},
+			":empty": &types.AttributeValueMemberS{Value: ""},
+		},
+		Limit: aws.Int32(int32(models.NormalizeLimit(int(limit)))),
+	}
+	if nextToken != "" {
+		startKey, err := deserializeStartKey(nextToken)
 		if err != nil {
-			return nil, fmt.Errorf("QueryRequestsByStatus: %w", err)
-		}
-		var page []models.JitRequest
-		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
-			return nil, fmt.Errorf("QueryRequestsByStatus unmarshal: %w", err)
+			return nil, "", fmt.Errorf("ScanGrantsMissingEndTime: %w: %v", models.ErrInvalidNextToken, err)
 		}
-		allRequests = append(allRequests, page...)
+		input.ExclusiveStartKey = startKey
+	}
 
-		if out.LastEvaluatedKey == nil {
-			break
-		}
-		if limit > 0 && int32(len(allRequests)) >= limit {
-			break
-		}
-		input.ExclusiveStartKey = out.LastEvaluatedKey
+	out, err := c.db.Scan(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanGrantsMissingEndTime: %w", err)
 	}
-	return allRequests, nil
+
+	var requests []models.JitRequest
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &requests); err != nil {
+		return nil, "", fmt.Errorf("ScanGrantsMissingEndTime unmarshal: %w", err)
+	}
+	if err := c.decryptRequests(ctx, requests); err != nil {
+		return nil, "", fmt.Errorf("ScanGrantsMissingEndTime: %w", err)
+	}
+
+	token, err := serializeStartKey(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanGrantsMissingEndTime serialize token: %w", err)
+	}
+	return requests, token, nil
 }
 
 // QueryRequests provides general purpose reporting queries with optional filters.
 func (c *Client) QueryRequests(ctx context.Context, input models.ReportingInput) ([]models.JitRequest, string, error) {
 	var queryInput *dynamodb.QueryInput
-	limit := int32(input.Limit)
-	if limit <= 0 {
-		limit = 50
-	}
+	limit := int32(models.NormalizeLimit(input.Limit))
 
 	// Determine which GSI to use based on available filters.
 	switch {
+	case input.Jira != "":
+		// Encrypted jira values are randomized ciphertext and never match.
+		if c.encryptor != nil {
+			return nil, "", fmt.Errorf("QueryRequests: jira search is unavailable while field encryption is enabled")
+		}
+		queryInput = &dynamodb.QueryInput{
+			TableName:              &c.tableRequests,
+			IndexName:              aws.String(jiraIndex),
+			KeyConditionExpression: aws.String("jira = :jira"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":jira": &types.AttributeValueMemberS{Value: input.Jira},
+			},
+			ScanIndexForward: aws.Bool(false),
+			Limit:            &limit,
+		}
+
+		filterExpr, filterNames, filterValues := buildFilters(input, false)
+		if filterExpr != "" {
+			queryInput.FilterExpression = aws.String(filterExpr)
+			queryInput.ExpressionAttributeNames = filterNames
+			for k, v := range filterValues {
+				queryInput.ExpressionAttributeValues[k] = v
+			}
+		}
+
 	case input.ChannelID != "":
 		keyExpr := "channel_id = :cid"
 		exprValues := map[string]types.AttributeValue{
@@ -430,6 +858,10 @@ func (c *Client) QueryRequests(ctx context.Context, input models.ReportingInput)
 		}
 
 	case input.Status != "":
+		statuses := input.StatusList()
+		if len(statuses) > 1 {
+			return c.queryRequestsByStatuses(ctx, statuses, limit, input.NextToken)
+		}
 		keyExpr := "#status = :st"
 		exprNames := map[string]string{
 			"#status": "status",
@@ -449,26 +881,32 @@ func (c *Client) QueryRequests(ctx context.Context, input models.ReportingInput)
 
 	default:
 		// D5/E4: Reject unfiltered queries — table scans are not permitted.
-		return nil, "", fmt.Errorf("QueryRequests: at least one filter (channel_id, account_id, requester_email, or status) is required")
+		return nil, "", fmt.Errorf("QueryRequests: at least one filter (channel_id, account_id, requester_email, jira, or status) is required")
 	}
 
 	// Apply pagination token.
 	if input.NextToken != "" {
 		startKey, err := deserializeStartKey(input.NextToken)
 		if err != nil {
-			return nil, "", fmt.Errorf("QueryRequests invalid next_token: %w", err)
+			return nil, "", fmt.Errorf("QueryRequests: %w: %v", models.ErrInvalidNextToken, err)
 		}
 		queryInput.ExclusiveStartKey = startKey
 	}
 
 	out, err := c.db.Query(ctx, queryInput)
 	if err != nil {
+		if input.Jira != "" && isMissingIndex(err) {
+			return nil, "", fmt.Errorf("QueryRequests: jira search is unavailable: the requests table has no %s index", jiraIndex)
+		}
 		return nil, "", fmt.Errorf("QueryRequests: %w", err)
 	}
 	var requests []models.JitRequest
 	if err := attributevalue.UnmarshalListOfMaps(out.Items, &requests); err != nil {
 		return nil, "", fmt.Errorf("QueryRequests unmarshal: %w", err)
 	}
+	if err := c.decryptRequests(ctx, requests); err != nil {
+		return nil, "", fmt.Errorf("QueryRequests: %w", err)
+	}
 
 	var nextToken string
 	if out.LastEvaluatedKey != nil {
@@ -477,16 +915,37 @@ func (c *Client) QueryRequests(ctx context.Context, input models.ReportingInput)
 	return requests, nextToken, nil
 }
 
+// jiraIndex is the requests-table GSI keyed on jira. Deployments that predate
+// it have no such index.
+const jiraIndex = "gsi_jira_created"
+
+// isMissingIndex reports whether err is DynamoDB rejecting a query against an
+// index the table does not have.
+func isMissingIndex(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.ErrorCode() == "ValidationException" &&
+		strings.Contains(apiErr.ErrorMessage(), "specified index")
+}
+
 // buildFilters constructs optional filter expressions for fields not covered by keys.
 func buildFilters(input models.ReportingInput, skipChannel bool) (string, map[string]string, map[string]types.AttributeValue) {
 	var parts []string
 	names := map[string]string{}
 	values := map[string]types.AttributeValue{}
 
-	if input.Status != "" {
+	if statuses := input.StatusList(); len(statuses) == 1 {
 		parts = append(parts, "#fstatus = :fstatus")
 		names["#fstatus"] = "status"
-		values[":fstatus"] = &types.AttributeValueMemberS{Value: input.Status}
+		values[":fstatus"] = &types.AttributeValueMemberS{Value: statuses[0]}
+	} else if len(statuses) > 1 {
+		placeholders := make([]string, len(statuses))
+		for i, status := range statuses {
+			placeholders[i] = ":fstatus" + strconv.Itoa(i)
+			values[placeholders[i]] = &types.AttributeValueMemberS{Value: status}
+		}
+		parts = append(parts, "#fstatus IN ("+strings.Join(placeholders, ", ")+")")
+		names["#fstatus"] = "status"
 	}
 	// AccountID is only a filter when it isn't the key (i.e. skipChannel is false),
 	// but account-based queries are handled by the key condition in QueryRequests,
@@ -509,34 +968,118 @@ func buildFilters(input models.ReportingInput, skipChannel bool) (string, map[st
 	return expr, names, values
 }
 
+// decryptRequest decrypts a request's sensitive fields when field encryption
+// is enabled.
+func (c *Client) decryptRequest(ctx context.Context, req *models.JitRequest) error {
+	if c.encryptor == nil {
+		return nil
+	}
+	return fieldcrypt.DecryptRequest(ctx, c.encryptor, req)
+}
+
+// decryptRequests decrypts the sensitive fields of each request in place.
+func (c *Client) decryptRequests(ctx context.Context, requests []models.JitRequest) error {
+	for i := range requests {
+		if err := c.decryptRequest(ctx, &requests[i]); err != nil {
+			return fmt.Errorf("request %s: %w", requests[i].RequestID, err)
+		}
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Audit operations
 // ---------------------------------------------------------------------------
 
-// PutAuditEvent stores an audit event.
-func (c *Client) PutAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+// auditMarkerPrefix starts the sort key of audit-table marker items, which
+// enforce uniqueness rather than record events. It sorts after every event
+// key, since those start with a timestamp, so request queries stop short of
+// the markers. Markers have no event_date and stay out of the date index.
+const auditMarkerPrefix = "~"
+
+// auditChainKey is the sort key of the marker claiming position seq of a
+// request's audit chain.
+func auditChainKey(seq int) string {
+	return fmt.Sprintf("%schain#%08d", auditMarkerPrefix, seq)
+}
+
+// auditEventKey is the sort key of the marker claiming a unique event ID.
+func auditEventKey(eventID string) string {
+	return auditMarkerPrefix + "event#" + eventID
+}
+
+// AppendAuditEvent stores event as the seq'th (0-based) event of its
+// request's hash chain. A marker item claims the position in the same
+// transaction, so two writers that read the same chain tip cannot both link
+// to it: the loser gets an error wrapping models.ErrAuditChainConflict. When
+// unique is set a second marker claims the event ID as well, and an event
+// whose ID is already recorded fails with models.ErrAuditEventExists.
+func (c *Client) AppendAuditEvent(ctx context.Context, event *models.AuditEvent, seq int, unique bool) error {
 	item, err := attributevalue.MarshalMap(event)
 	if err != nil {
-		return fmt.Errorf("PutAuditEvent marshal: %w", err)
+		return fmt.Errorf("AppendAuditEvent marshal: %w", err)
 	}
-	_, err = c.db.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &c.tableAudit,
-		Item:      item,
-	})
+	// Never overwrite a recorded event: the chain links to it by hash.
+	items := []types.TransactWriteItem{
+		c.auditMarkerPut(event, auditChainKey(seq)),
+		{Put: &types.Put{
+			TableName:           &c.tableAudit,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(event_time_event_id)"),
+		}},
+	}
+	if unique {
+		items = append(items, c.auditMarkerPut(event, auditEventKey(event.EventID)))
+	}
+	_, err = c.db.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
 	if err != nil {
-		return fmt.Errorf("PutAuditEvent: %w", err)
+		switch {
+		case unique && transactConditionFailed(err, 2):
+			return fmt.Errorf("AppendAuditEvent: %w", models.ErrAuditEventExists)
+		case transactConditionFailed(err, 0):
+			return fmt.Errorf("AppendAuditEvent: %w", models.ErrAuditChainConflict)
+		}
+		return fmt.Errorf("AppendAuditEvent: %w", err)
 	}
 	return nil
 }
 
+// auditMarkerPut writes the marker item with sort key key for event, only if
+// it does not exist yet.
+func (c *Client) auditMarkerPut(event *models.AuditEvent, key string) types.TransactWriteItem {
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName: &c.tableAudit,
+		Item: map[string]types.AttributeValue{
+			"request_id":          &types.AttributeValueMemberS{Value: event.RequestID},
+			"event_time_event_id": &types.AttributeValueMemberS{Value: key},
+			"event_id":            &types.AttributeValueMemberS{Value: event.EventID},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(event_time_event_id)"),
+	}}
+}
+
+// transactConditionFailed reports whether err cancelled a transaction because
+// the condition on item i failed.
+func transactConditionFailed(err error, i int) bool {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) || i >= len(tce.CancellationReasons) {
+		return false
+	}
+	return aws.ToString(tce.CancellationReasons[i].Code) == "ConditionalCheckFailed"
+}
+
 // QueryAuditByRequest retrieves all audit events for a given request.
 func (c *Client) QueryAuditByRequest(ctx context.Context, requestID string) ([]models.AuditEvent, error) {
 	out, err := c.db.Query(ctx, &dynamodb.QueryInput{
 		TableName:              &c.tableAudit,
-		KeyConditionExpression: aws.String("request_id = :rid"),
+		KeyConditionExpression: aws.String("request_id = :rid AND event_time_event_id < :marker"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":rid": &types.AttributeValueMemberS{Value: requestID},
+			":rid":    &types.AttributeValueMemberS{Value: requestID},
+			":marker": &types.AttributeValueMemberS{Value: auditMarkerPrefix},
 		},
+		// Writers read the chain tip to link to it, so a stale read only
+		// costs a retry; reading consistently keeps retries rare.
+		ConsistentRead:   aws.Bool(true),
 		ScanIndexForward: aws.Bool(true),
 	})
 	if err != nil {
@@ -549,6 +1092,98 @@ func (c *Client) QueryAuditByRequest(ctx context.Context, requestID string) ([]m
 	return events, nil
 }
 
+// auditDateIndex is the audit-table GSI keyed on event_date. Deployments that
+// predate it have no such index, and events recorded before it was added have
+// no event_date, so are not in it.
+const auditDateIndex = "gsi_date_event"
+
+// QueryAuditByTimeRange returns one page of audit events recorded from start
+// to end inclusive, across all requests, in time order, and a token for the
+// next page. The token is empty on the last page. It queries the date index
+// one UTC day at a time, so a page may hold fewer than limit events while the
+// token is not empty.
+func (c *Client) QueryAuditByTimeRange(ctx context.Context, start, end time.Time, limit int32, nextToken string) ([]models.AuditEvent, string, error) {
+	start, end = start.UTC(), end.UTC()
+	if end.Before(start) {
+		return []models.AuditEvent{}, "", nil
+	}
+	lastDay := end.Format(models.AuditEventDateLayout)
+	day := start.Format(models.AuditEventDateLayout)
+	limit = int32(models.NormalizeLimit(int(limit)))
+
+	// The token is the last evaluated key, which carries event_date, or only
+	// the event_date of a day not yet started.
+	var startKey map[string]types.AttributeValue
+	if nextToken != "" {
+		key, err := deserializeStartKey(nextToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("QueryAuditByTimeRange: %w: %v", models.ErrInvalidNextToken, err)
+		}
+		d, ok := key["event_date"].(*types.AttributeValueMemberS)
+		if ok {
+			_, err = time.Parse(models.AuditEventDateLayout, d.Value)
+		}
+		if !ok || err != nil || d.Value < day || d.Value > lastDay {
+			return nil, "", fmt.Errorf("QueryAuditByTimeRange: %w: not from this date range", models.ErrInvalidNextToken)
+		}
+		day = d.Value
+		if len(key) > 1 {
+			startKey = key
+		}
+	}
+
+	// The sort key starts with the event time, to the nanosecond; "~" sorts
+	// after the "#" and digits that follow it.
+	lower := start.Format(models.AuditSortKeyTimeFormat)
+	upper := end.Add(time.Second-time.Nanosecond).Format(models.AuditSortKeyTimeFormat) + "~"
+
+	events := []models.AuditEvent{}
+	for {
+		remaining := limit - int32(len(events))
+		out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &c.tableAudit,
+			IndexName:              aws.String(auditDateIndex),
+			KeyConditionExpression: aws.String("event_date = :d AND event_time_event_id BETWEEN :lo AND :hi"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":d":  &types.AttributeValueMemberS{Value: day},
+				":lo": &types.AttributeValueMemberS{Value: lower},
+				":hi": &types.AttributeValueMemberS{Value: upper},
+			},
+			ScanIndexForward:  aws.Bool(true),
+			ExclusiveStartKey: startKey,
+			Limit:             &remaining,
+		})
+		if err != nil {
+			if isMissingIndex(err) {
+				return nil, "", fmt.Errorf("QueryAuditByTimeRange: audit export is unavailable: the audit table has no %s index", auditDateIndex)
+			}
+			return nil, "", fmt.Errorf("QueryAuditByTimeRange: %w", err)
+		}
+		var page []models.AuditEvent
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return nil, "", fmt.Errorf("QueryAuditByTimeRange unmarshal: %w", err)
+		}
+		events = append(events, page...)
+
+		if out.LastEvaluatedKey != nil {
+			token, err := serializeStartKey(out.LastEvaluatedKey)
+			if err != nil {
+				return nil, "", fmt.Errorf("QueryAuditByTimeRange serialize token: %w", err)
+			}
+			return events, token, nil
+		}
+		if day == lastDay {
+			return events, "", nil
+		}
+		next, _ := time.Parse(models.AuditEventDateLayout, day)
+		day = next.AddDate(0, 0, 1).Format(models.AuditEventDateLayout)
+		startKey = nil
+		if int32(len(events)) >= limit {
+			return events, "event_date=" + day, nil
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Nonce operations (implements auth.NonceStore)
 // ---------------------------------------------------------------------------
@@ -596,6 +1231,209 @@ func (c *Client) CheckNonce(ctx context.Context, keyID, nonce string) (bool, err
 	return out.Item != nil, nil
 }
 
+// nonceTTLProbeKeyID is the key ID under which CheckNonceTTL writes its
+// probe nonce. It never matches a real signing key.
+const nonceTTLProbeKeyID = "ttl-self-check"
+
+// CheckNonceTTL verifies that nonces can expire: DynamoDB TTL must be enabled
+// on the nonce table under models.NonceTTLAttribute, and a nonce written with
+// a short TTL must carry that attribute. A mismatch means nonces are never
+// cleaned up and the table grows without bound.
+func (c *Client) CheckNonceTTL(ctx context.Context) error {
+	out, err := c.db.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: &c.tableNonces})
+	if err != nil {
+		return fmt.Errorf("CheckNonceTTL describe: %w", err)
+	}
+	desc := out.TimeToLiveDescription
+	if desc == nil {
+		return fmt.Errorf("nonce table %s has no TTL description", c.tableNonces)
+	}
+	if status := desc.TimeToLiveStatus; status != types.TimeToLiveStatusEnabled && status != types.TimeToLiveStatusEnabling {
+		return fmt.Errorf("nonce table %s TTL is %s, expected ENABLED", c.tableNonces, status)
+	}
+	if attr := aws.ToString(desc.AttributeName); attr != models.NonceTTLAttribute {
+		return fmt.Errorf("nonce table %s TTL attribute is %q, but nonces expire on %q", c.tableNonces, attr, models.NonceTTLAttribute)
+	}
+
+	probe := fmt.Sprintf("probe-%d", time.Now().UnixNano())
+	if err := c.StoreNonce(ctx, nonceTTLProbeKeyID, probe, 60); err != nil {
+		return fmt.Errorf("CheckNonceTTL probe write: %w", err)
+	}
+	got, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &c.tableNonces,
+		Key: map[string]types.AttributeValue{
+			"key_id": &types.AttributeValueMemberS{Value: nonceTTLProbeKeyID},
+			"nonce":  &types.AttributeValueMemberS{Value: probe},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("CheckNonceTTL probe read: %w", err)
+	}
+	if _, ok := got.Item[models.NonceTTLAttribute].(*types.AttributeValueMemberN); !ok {
+		return fmt.Errorf("probe nonce has no numeric %q attribute", models.NonceTTLAttribute)
+	}
+	return nil
+}
+
+// ScanNonces returns one page of nonce entries. The scan is only used by the
+// reconciler's fallback sweep of nonces DynamoDB TTL failed to delete.
+func (c *Client) ScanNonces(ctx context.Context, limit int32, nextToken string) ([]models.NonceEntry, string, error) {
+	input := &dynamodb.ScanInput{
+		TableName: &c.tableNonces,
+		Limit:     aws.Int32(int32(models.NormalizeLimit(int(limit)))),
+	}
+	if nextToken != "" {
+		startKey, err := deserializeStartKey(nextToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("ScanNonces: %w: %v", models.ErrInvalidNextToken, err)
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	out, err := c.db.Scan(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanNonces: %w", err)
+	}
+
+	var entries []models.NonceEntry
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &entries); err != nil {
+		return nil, "", fmt.Errorf("ScanNonces unmarshal: %w", err)
+	}
+
+	token, err := serializeStartKey(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanNonces serialize token: %w", err)
+	}
+	return entries, token, nil
+}
+
+// DeleteNonce removes a nonce, provided it expired at or before expiredBy
+// (Unix seconds), so a sweep never deletes a nonce that is still protecting
+// against replay.
+func (c *Client) DeleteNonce(ctx context.Context, keyID, nonce string, expiredBy int64) error {
+	_, err := c.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &c.tableNonces,
+		Key: map[string]types.AttributeValue{
+			"key_id": &types.AttributeValueMemberS{Value: keyID},
+			"nonce":  &types.AttributeValueMemberS{Value: nonce},
+		},
+		ConditionExpression:      aws.String("attribute_not_exists(#exp) OR #exp <= :by"),
+		ExpressionAttributeNames: map[string]string{"#exp": models.NonceTTLAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":by": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiredBy, 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteNonce: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Webhook failure operations
+// ---------------------------------------------------------------------------
+
+// errNoWebhookFailuresTable is returned by the webhook failure operations when
+// the client was built without WithWebhookFailuresTable.
+var errNoWebhookFailuresTable = errors.New("webhook failures table is not configured")
+
+// PutWebhookFailure creates or replaces an undelivered webhook record.
+func (c *Client) PutWebhookFailure(ctx context.Context, failure *models.WebhookFailure) error {
+	if c.tableWebhookFailures == "" {
+		return fmt.Errorf("PutWebhookFailure: %w", errNoWebhookFailuresTable)
+	}
+	item, err := attributevalue.MarshalMap(failure)
+	if err != nil {
+		return fmt.Errorf("PutWebhookFailure marshal: %w", err)
+	}
+	_, err = c.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &c.tableWebhookFailures,
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("PutWebhookFailure: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookFailure retrieves an undelivered webhook record, or nil if it
+// does not exist.
+func (c *Client) GetWebhookFailure(ctx context.Context, failureID string) (*models.WebhookFailure, error) {
+	if c.tableWebhookFailures == "" {
+		return nil, fmt.Errorf("GetWebhookFailure: %w", errNoWebhookFailuresTable)
+	}
+	out, err := c.db.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &c.tableWebhookFailures,
+		Key: map[string]types.AttributeValue{
+			"failure_id": &types.AttributeValueMemberS{Value: failureID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetWebhookFailure: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	var failure models.WebhookFailure
+	if err := attributevalue.UnmarshalMap(out.Item, &failure); err != nil {
+		return nil, fmt.Errorf("GetWebhookFailure unmarshal: %w", err)
+	}
+	return &failure, nil
+}
+
+// ScanWebhookFailures returns one page of undelivered webhook records. The
+// table only holds failures from the retention window, so a scan stays small.
+func (c *Client) ScanWebhookFailures(ctx context.Context, limit int32, nextToken string) ([]models.WebhookFailure, string, error) {
+	if c.tableWebhookFailures == "" {
+		return nil, "", fmt.Errorf("ScanWebhookFailures: %w", errNoWebhookFailuresTable)
+	}
+	input := &dynamodb.ScanInput{
+		TableName: &c.tableWebhookFailures,
+		Limit:     aws.Int32(int32(models.NormalizeLimit(int(limit)))),
+	}
+	if nextToken != "" {
+		startKey, err := deserializeStartKey(nextToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("ScanWebhookFailures: %w: %v", models.ErrInvalidNextToken, err)
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	out, err := c.db.Scan(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanWebhookFailures: %w", err)
+	}
+
+	var failures []models.WebhookFailure
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &failures); err != nil {
+		return nil, "", fmt.Errorf("ScanWebhookFailures unmarshal: %w", err)
+	}
+
+	token, err := serializeStartKey(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("ScanWebhookFailures serialize token: %w", err)
+	}
+	return failures, token, nil
+}
+
+// DeleteWebhookFailure removes an undelivered webhook record once it has been
+// re-sent.
+func (c *Client) DeleteWebhookFailure(ctx context.Context, failureID string) error {
+	if c.tableWebhookFailures == "" {
+		return fmt.Errorf("DeleteWebhookFailure: %w", errNoWebhookFailuresTable)
+	}
+	_, err := c.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &c.tableWebhookFailures,
+		Key: map[string]types.AttributeValue{
+			"failure_id": &types.AttributeValueMemberS{Value: failureID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteWebhookFailure: %w", err)
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // Pagination helpers
 // ---------------------------------------------------------------------------
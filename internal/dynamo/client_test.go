@@ -0,0 +1,644 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func TestCreateRequest_MetadataRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	req := &models.JitRequest{
+		RequestID: "req-1",
+		Status:    models.StatusPending,
+		Metadata:  map[string]string{"cost_center": "cc-42", "environment": "prod"},
+	}
+	if err := c.CreateRequest(ctx, req); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	if _, ok := mock.item["metadata"].(*types.AttributeValueMemberM); !ok {
+		t.Fatalf("expected metadata stored as a DynamoDB map, got %T", mock.item["metadata"])
+	}
+
+	got, err := c.GetRequest(ctx, "req-1")
+	if err != nil {
+		t.Fatalf("GetRequest: %v", err)
+	}
+	if len(got.Metadata) != 2 || got.Metadata["cost_center"] != "cc-42" || got.Metadata["environment"] != "prod" {
+		t.Errorf("expected metadata to round-trip, got %v", got.Metadata)
+	}
+}
+
+func TestCreateRequest_NoMetadataOmitted(t *testing.T) {
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	if err := c.CreateRequest(context.Background(), &models.JitRequest{RequestID: "req-1"}); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	if _, ok := mock.item["metadata"]; ok {
+		t.Error("expected no metadata attribute for requests without metadata")
+	}
+}
+
+// pagedDynamo serves count GRANTED requests from Query, at most pageSize (or
+// the input Limit, if smaller) per call, keyed by request_id.
+type pagedDynamo struct {
+	*mockDynamo
+	count    int
+	pageSize int
+	calls    int
+}
+
+func (m *pagedDynamo) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.calls++
+	start := 0
+	if sv, ok := params.ExclusiveStartKey["request_id"].(*types.AttributeValueMemberS); ok {
+		fmt.Sscanf(sv.Value, "req-%d", &start)
+	}
+	size := m.pageSize
+	if params.Limit != nil && int(*params.Limit) < size {
+		size = int(*params.Limit)
+	}
+	out := &dynamodb.QueryOutput{}
+	i := start
+	for ; i < m.count && i < start+size; i++ {
+		out.Items = append(out.Items, map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: fmt.Sprintf("req-%d", i+1)},
+			"status":     &types.AttributeValueMemberS{Value: models.StatusGranted},
+		})
+	}
+	if i < m.count {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: fmt.Sprintf("req-%d", i)},
+		}
+	}
+	return out, nil
+}
+
+func TestQueryRequestsByStatus_RespectsLimitAcrossPages(t *testing.T) {
+	mock := &pagedDynamo{mockDynamo: newMockDynamo(), count: 7, pageSize: 2}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	got, err := c.QueryRequestsByStatus(context.Background(), models.StatusGranted, "", 5)
+	if err != nil {
+		t.Fatalf("QueryRequestsByStatus: %v", err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected exactly 5 requests, got %d", len(got))
+	}
+
+	all, err := c.QueryRequestsByStatus(context.Background(), models.StatusGranted, "", 0)
+	if err != nil {
+		t.Fatalf("QueryRequestsByStatus: %v", err)
+	}
+	if len(all) != 7 {
+		t.Errorf("expected all 7 requests without a limit, got %d", len(all))
+	}
+}
+
+func TestQueryRequestsByStatusPage_Tokens(t *testing.T) {
+	mock := &pagedDynamo{mockDynamo: newMockDynamo(), count: 3, pageSize: 10}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	ctx := context.Background()
+
+	page, token, err := c.QueryRequestsByStatusPage(ctx, models.StatusGranted, 2, "")
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if len(page) != 2 || token == "" {
+		t.Fatalf("expected 2 items and a token, got %d items, token %q", len(page), token)
+	}
+
+	page, token, err = c.QueryRequestsByStatusPage(ctx, models.StatusGranted, 2, token)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(page) != 1 || page[0].RequestID != "req-3" || token != "" {
+		t.Errorf("expected final page with req-3 and no token, got %+v, token %q", page, token)
+	}
+}
+
+// noIndexDynamo fails every Query the way DynamoDB does for an index the
+// table lacks.
+type noIndexDynamo struct {
+	*mockDynamo
+}
+
+func (m *noIndexDynamo) Query(_ context.Context, _ *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return nil, &smithy.GenericAPIError{
+		Code:    "ValidationException",
+		Message: "The table does not have the specified index: gsi_jira_created",
+	}
+}
+
+func TestQueryRequests_JiraWithoutIndex(t *testing.T) {
+	c := NewClient(&noIndexDynamo{mockDynamo: newMockDynamo()}, "cfg", "reqs", "audit", "nonces")
+
+	_, _, err := c.QueryRequests(context.Background(), models.ReportingInput{Jira: "PROJ-123", Limit: 10})
+	if err == nil || !strings.Contains(err.Error(), "jira search is unavailable") {
+		t.Fatalf("expected jira search unavailable error, got: %v", err)
+	}
+}
+
+// auditRangeDynamo serves gsi_date_event queries over events, given as
+// event_time_event_id sort keys, evaluating the key condition, Limit and
+// ExclusiveStartKey like DynamoDB.
+type auditRangeDynamo struct {
+	*mockDynamo
+	events []string
+	days   []string
+}
+
+func (m *auditRangeDynamo) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	day := params.ExpressionAttributeValues[":d"].(*types.AttributeValueMemberS).Value
+	lo := params.ExpressionAttributeValues[":lo"].(*types.AttributeValueMemberS).Value
+	hi := params.ExpressionAttributeValues[":hi"].(*types.AttributeValueMemberS).Value
+	after := ""
+	if sv, ok := params.ExclusiveStartKey["event_time_event_id"].(*types.AttributeValueMemberS); ok {
+		after = sv.Value
+	}
+	m.days = append(m.days, day)
+
+	out := &dynamodb.QueryOutput{}
+	for _, sk := range m.events {
+		if sk[:10] != day || sk < lo || sk > hi || sk <= after {
+			continue
+		}
+		if len(out.Items) == int(*params.Limit) {
+			last := out.Items[len(out.Items)-1]
+			out.LastEvaluatedKey = map[string]types.AttributeValue{
+				"event_date":          last["event_date"],
+				"event_time_event_id": last["event_time_event_id"],
+				"request_id":          last["request_id"],
+			}
+			break
+		}
+		out.Items = append(out.Items, map[string]types.AttributeValue{
+			"request_id":          &types.AttributeValueMemberS{Value: "req-" + sk[len(sk)-1:]},
+			"event_date":          &types.AttributeValueMemberS{Value: day},
+			"event_time_event_id": &types.AttributeValueMemberS{Value: sk},
+		})
+	}
+	return out, nil
+}
+
+func TestQueryAuditByTimeRange_BoundsAndPages(t *testing.T) {
+	mock := &auditRangeDynamo{mockDynamo: newMockDynamo(), events: []string{
+		"2025-05-31T23:59:59.000000000Z#00000000#0",
+		"2025-06-01T00:00:00.000000000Z#00000000#1",
+		"2025-06-01T09:00:00.000000000Z#00000001#2",
+		"2025-06-01T17:30:00.000000000Z#00000000#3",
+		"2025-06-03T23:59:59.500000000Z#00000002#4",
+		"2025-06-04T00:00:00.000000000Z#00000000#5",
+	}}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	ctx := context.Background()
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 6, 3, 23, 59, 59, 0, time.UTC)
+
+	var got []string
+	var token string
+	pages := 0
+	for {
+		page, next, err := c.QueryAuditByTimeRange(ctx, start, end, 2, token)
+		if err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		pages++
+		for _, e := range page {
+			got = append(got, e.RequestID)
+		}
+		if next == "" {
+			break
+		}
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		token = next
+	}
+
+	if strings.Join(got, ",") != "req-1,req-2,req-3,req-4" {
+		t.Errorf("expected the events inside the range once each, in order, got %v", got)
+	}
+	for _, day := range mock.days {
+		if day < "2025-06-01" || day > "2025-06-03" {
+			t.Errorf("expected only days in the range queried, got %s", day)
+		}
+	}
+}
+
+func TestQueryAuditByTimeRange_InvalidToken(t *testing.T) {
+	c := NewClient(&auditRangeDynamo{mockDynamo: newMockDynamo()}, "cfg", "reqs", "audit", "nonces")
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, token := range []string{"garbage", "event_date=2025-07-01", "event_date=not-a-date"} {
+		_, _, err := c.QueryAuditByTimeRange(context.Background(), start, start.Add(time.Hour), 10, token)
+		if !errors.Is(err, models.ErrInvalidNextToken) {
+			t.Errorf("token %q: expected ErrInvalidNextToken, got %v", token, err)
+		}
+	}
+}
+
+func TestQueryAuditByTimeRange_WithoutIndex(t *testing.T) {
+	c := NewClient(&noIndexDynamo{mockDynamo: newMockDynamo()}, "cfg", "reqs", "audit", "nonces")
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _, err := c.QueryAuditByTimeRange(context.Background(), start, start.Add(time.Hour), 10, "")
+	if err == nil || !strings.Contains(err.Error(), "audit export is unavailable") {
+		t.Fatalf("expected audit export unavailable error, got: %v", err)
+	}
+}
+
+func TestAppendAuditEvent_ClaimsChainPosition(t *testing.T) {
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	event := &models.AuditEvent{RequestID: "req-1", EventTimeEventID: "2025-06-01T00:00:00.000000000Z#00000003#ev-1", EventID: "ev-1"}
+
+	if err := c.AppendAuditEvent(context.Background(), event, 3, false); err != nil {
+		t.Fatalf("AppendAuditEvent: %v", err)
+	}
+	items := mock.transact.TransactItems
+	if len(items) != 2 {
+		t.Fatalf("expected marker and event in one transaction, got %d items", len(items))
+	}
+	marker := items[0].Put
+	if got := marker.Item["event_time_event_id"].(*types.AttributeValueMemberS).Value; got != "~chain#00000003" {
+		t.Errorf("expected chain marker key, got %q", got)
+	}
+	if aws.ToString(marker.ConditionExpression) != "attribute_not_exists(event_time_event_id)" {
+		t.Errorf("expected the marker put to be conditional, got %q", aws.ToString(marker.ConditionExpression))
+	}
+	if got := items[1].Put.Item["event_id"].(*types.AttributeValueMemberS).Value; got != "ev-1" {
+		t.Errorf("expected the event in the transaction, got %q", got)
+	}
+}
+
+func TestAppendAuditEvent_ChainConflict(t *testing.T) {
+	mock := newMockDynamo()
+	mock.transactErr = &types.TransactionCanceledException{
+		Message: aws.String("Transaction cancelled"),
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")},
+			{Code: aws.String("None")},
+		},
+	}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	err := c.AppendAuditEvent(context.Background(), &models.AuditEvent{RequestID: "req-1", EventID: "ev-1"}, 0, false)
+	if !errors.Is(err, models.ErrAuditChainConflict) {
+		t.Fatalf("expected ErrAuditChainConflict, got %v", err)
+	}
+
+	mock.transactErr = errors.New("boom")
+	err = c.AppendAuditEvent(context.Background(), &models.AuditEvent{RequestID: "req-1", EventID: "ev-1"}, 0, false)
+	if err == nil || errors.Is(err, models.ErrAuditChainConflict) {
+		t.Fatalf("expected a plain error for other failures, got %v", err)
+	}
+}
+
+func TestAppendAuditEvent_UniqueClaimsEventID(t *testing.T) {
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	event := &models.AuditEvent{RequestID: "req-1", EventTimeEventID: "2025-06-01T00:00:00.000000000Z#00000000#ev-1", EventID: "ev-1"}
+
+	if err := c.AppendAuditEvent(context.Background(), event, 0, true); err != nil {
+		t.Fatalf("AppendAuditEvent: %v", err)
+	}
+	items := mock.transact.TransactItems
+	if len(items) != 3 {
+		t.Fatalf("expected chain marker, event and event ID marker, got %d items", len(items))
+	}
+	marker := items[2].Put
+	if got := marker.Item["event_time_event_id"].(*types.AttributeValueMemberS).Value; got != "~event#ev-1" {
+		t.Errorf("expected event ID marker key, got %q", got)
+	}
+	if aws.ToString(marker.ConditionExpression) != "attribute_not_exists(event_time_event_id)" {
+		t.Errorf("expected the event ID marker put to be conditional, got %q", aws.ToString(marker.ConditionExpression))
+	}
+
+	// A racing writer that already recorded the event fails the ID marker;
+	// it may or may not also have taken the chain position.
+	mock.transactErr = &types.TransactionCanceledException{
+		Message: aws.String("Transaction cancelled"),
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")},
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed")},
+		},
+	}
+	err := c.AppendAuditEvent(context.Background(), event, 0, true)
+	if !errors.Is(err, models.ErrAuditEventExists) {
+		t.Fatalf("expected ErrAuditEventExists, got %v", err)
+	}
+}
+
+func TestCreateRequest_EmptyJiraOmitted(t *testing.T) {
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	if err := c.CreateRequest(context.Background(), &models.JitRequest{RequestID: "req-1", Reason: "no ticket"}); err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	if _, ok := mock.item["jira"]; ok {
+		t.Error("expected no jira attribute, which would be an empty gsi_jira_created key")
+	}
+}
+
+// bindingsDynamo serves one gsi_account result per Query page.
+type bindingsDynamo struct {
+	*mockDynamo
+	channels []string
+	queries  []*dynamodb.QueryInput
+}
+
+func (m *bindingsDynamo) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.queries = append(m.queries, params)
+	i := 0
+	if sv, ok := params.ExclusiveStartKey["channel_id"].(*types.AttributeValueMemberS); ok {
+		for i < len(m.channels) && m.channels[i] != sv.Value {
+			i++
+		}
+		i++
+	}
+	out := &dynamodb.QueryOutput{}
+	if i < len(m.channels) {
+		key := map[string]types.AttributeValue{
+			"channel_id": &types.AttributeValueMemberS{Value: m.channels[i]},
+			"account_id": &types.AttributeValueMemberS{Value: "acct1"},
+		}
+		out.Items = append(out.Items, key)
+		if i+1 < len(m.channels) {
+			out.LastEvaluatedKey = key
+		}
+	}
+	return out, nil
+}
+
+// configPagesDynamo serves its configs one Scan page at a time, limit items
+// per page.
+type configPagesDynamo struct {
+	*mockDynamo
+	channels []string
+	scans    []*dynamodb.ScanInput
+}
+
+func (m *configPagesDynamo) Scan(_ context.Context, params *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.scans = append(m.scans, params)
+	start := 0
+	if sv, ok := params.ExclusiveStartKey["channel_id"].(*types.AttributeValueMemberS); ok {
+		for start < len(m.channels) && m.channels[start] != sv.Value {
+			start++
+		}
+		start++
+	}
+	end := start + int(aws.ToInt32(params.Limit))
+	if end > len(m.channels) {
+		end = len(m.channels)
+	}
+	out := &dynamodb.ScanOutput{}
+	for _, ch := range m.channels[start:end] {
+		key := map[string]types.AttributeValue{
+			"channel_id": &types.AttributeValueMemberS{Value: ch},
+			"account_id": &types.AttributeValueMemberS{Value: "acct1"},
+		}
+		out.Items = append(out.Items, key)
+		if end < len(m.channels) {
+			out.LastEvaluatedKey = key
+		}
+	}
+	return out, nil
+}
+
+func TestScanConfigs_PagesWithToken(t *testing.T) {
+	mock := &configPagesDynamo{mockDynamo: newMockDynamo(), channels: []string{"ch1", "ch2", "ch3"}}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	ctx := context.Background()
+
+	first, token, err := c.ScanConfigs(ctx, 2, "")
+	if err != nil {
+		t.Fatalf("ScanConfigs: %v", err)
+	}
+	if len(first) != 2 || first[0].ChannelID != "ch1" || first[1].ChannelID != "ch2" {
+		t.Fatalf("expected ch1 and ch2 on the first page, got %+v", first)
+	}
+	if token == "" {
+		t.Fatal("expected a continuation token after the first page")
+	}
+
+	second, token, err := c.ScanConfigs(ctx, 2, token)
+	if err != nil {
+		t.Fatalf("ScanConfigs page 2: %v", err)
+	}
+	if len(second) != 1 || second[0].ChannelID != "ch3" {
+		t.Fatalf("expected ch3 on the second page, got %+v", second)
+	}
+	if token != "" {
+		t.Errorf("expected no token after the last page, got %q", token)
+	}
+
+	if _, _, err := c.ScanConfigs(ctx, 2, "not-a-token"); err == nil {
+		t.Error("expected an invalid token to be rejected")
+	}
+}
+
+func TestScanConfigs_AlwaysBounded(t *testing.T) {
+	mock := &configPagesDynamo{mockDynamo: newMockDynamo()}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	for _, limit := range []int32{0, -1, 10000} {
+		if _, _, err := c.ScanConfigs(context.Background(), limit, ""); err != nil {
+			t.Fatalf("ScanConfigs(%d): %v", limit, err)
+		}
+	}
+	for i, s := range mock.scans {
+		if s.Limit == nil || *s.Limit < 1 || *s.Limit > models.MaxQueryLimit {
+			t.Errorf("scan %d: expected a bounded limit, got %v", i, s.Limit)
+		}
+	}
+}
+
+// missingEndTimeDynamo answers Scan with one GRANTED request without an
+// end_time and records the input.
+type missingEndTimeDynamo struct {
+	*mockDynamo
+	scans []*dynamodb.ScanInput
+}
+
+func (m *missingEndTimeDynamo) Scan(_ context.Context, params *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.scans = append(m.scans, params)
+	return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{{
+		"request_id": &types.AttributeValueMemberS{Value: "req-1"},
+		"status":     &types.AttributeValueMemberS{Value: models.StatusGranted},
+	}}}, nil
+}
+
+func TestScanGrantsMissingEndTime(t *testing.T) {
+	mock := &missingEndTimeDynamo{mockDynamo: newMockDynamo()}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	requests, token, err := c.ScanGrantsMissingEndTime(context.Background(), 0, "")
+	if err != nil {
+		t.Fatalf("ScanGrantsMissingEndTime: %v", err)
+	}
+	if len(requests) != 1 || requests[0].RequestID != "req-1" || requests[0].EndTime != "" || token != "" {
+		t.Fatalf("expected req-1 without an end_time and no token, got %+v and %q", requests, token)
+	}
+
+	scan := mock.scans[0]
+	if aws.ToString(scan.TableName) != "reqs" || scan.IndexName != nil {
+		t.Errorf("expected a scan of the requests table, got table %q index %v", aws.ToString(scan.TableName), scan.IndexName)
+	}
+	filter := aws.ToString(scan.FilterExpression)
+	if !strings.Contains(filter, "attribute_not_exists(end_time)") || !strings.Contains(filter, "end_time = :empty") {
+		t.Errorf("expected the filter to match missing and empty end_time, got %q", filter)
+	}
+	if s, ok := scan.ExpressionAttributeValues[":s"].(*types.AttributeValueMemberS); !ok || s.Value != models.StatusGranted {
+		t.Errorf("expected the filter limited to GRANTED, got %v", scan.ExpressionAttributeValues[":s"])
+	}
+	if scan.Limit == nil || *scan.Limit < 1 || *scan.Limit > models.MaxQueryLimit {
+		t.Errorf("expected a bounded limit, got %v", scan.Limit)
+	}
+
+	if _, _, err := c.ScanGrantsMissingEndTime(context.Background(), 0, "not-a-token"); err == nil {
+		t.Error("expected an invalid token to be rejected")
+	}
+}
+
+// ttlDynamo reports a configurable TTL description for the nonce table.
+type ttlDynamo struct {
+	*mockDynamo
+	ttl *types.TimeToLiveDescription
+}
+
+func (m *ttlDynamo) DescribeTimeToLive(_ context.Context, _ *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: m.ttl}, nil
+}
+
+func TestScanCursor_RoundTrip(t *testing.T) {
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	ctx := context.Background()
+
+	if err := c.PutScanCursor(ctx, "nonce-sweep", "tok-1"); err != nil {
+		t.Fatalf("PutScanCursor: %v", err)
+	}
+	if got := mock.item["request_id"].(*types.AttributeValueMemberS).Value; got != "cursor#nonce-sweep" {
+		t.Errorf("expected the cursor item keyed by scan name, got %q", got)
+	}
+	got, err := c.GetScanCursor(ctx, "nonce-sweep")
+	if err != nil || got != "tok-1" {
+		t.Fatalf("expected the saved token back, got %q, %v", got, err)
+	}
+
+	mock.item = nil
+	if got, err := c.GetScanCursor(ctx, "nonce-sweep"); err != nil || got != "" {
+		t.Errorf("expected an empty token for a scan never saved, got %q, %v", got, err)
+	}
+}
+
+func TestCheckNonceTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     *types.TimeToLiveDescription
+		wantErr string
+	}{
+		{"enabled on expires_at", &types.TimeToLiveDescription{
+			AttributeName: aws.String("expires_at"), TimeToLiveStatus: types.TimeToLiveStatusEnabled}, ""},
+		{"wrong attribute", &types.TimeToLiveDescription{
+			AttributeName: aws.String("ttl"), TimeToLiveStatus: types.TimeToLiveStatusEnabled}, `TTL attribute is "ttl"`},
+		{"disabled", &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}, "expected ENABLED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &ttlDynamo{mockDynamo: newMockDynamo(), ttl: tt.ttl}
+			c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+			err := c.CheckNonceTTL(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if _, ok := mock.item[models.NonceTTLAttribute].(*types.AttributeValueMemberN); !ok {
+					t.Errorf("expected a probe nonce with a numeric %s", models.NonceTTLAttribute)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetAllBindingsForAccount_ReturnsDuplicates(t *testing.T) {
+	mock := &bindingsDynamo{mockDynamo: newMockDynamo(), channels: []string{"ch1", "ch2"}}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	got, err := c.GetAllBindingsForAccount(context.Background(), "acct1")
+	if err != nil {
+		t.Fatalf("GetAllBindingsForAccount: %v", err)
+	}
+	if len(got) != 2 || got[0].ChannelID != "ch1" || got[1].ChannelID != "ch2" {
+		t.Fatalf("expected bindings in both channels, got %+v", got)
+	}
+	for _, q := range mock.queries {
+		if q.Limit != nil {
+			t.Errorf("expected no query limit, got %d", *q.Limit)
+		}
+		if q.IndexName == nil || *q.IndexName != "gsi_account" {
+			t.Errorf("expected gsi_account query, got %v", q.IndexName)
+		}
+	}
+}
+
+func TestWebhookFailure_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDynamo()
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces", WithWebhookFailuresTable("webhook-failures"))
+
+	failure := &models.WebhookFailure{
+		FailureID: "fail-1",
+		RequestID: "req-1",
+		Payload:   models.WebhookPayload{RequestID: "req-1", Status: models.StatusGranted, Details: map[string]string{"k": "v"}},
+		LastError: "webhook returned status 503",
+		Attempts:  1,
+		ExpiresAt: 1700000000,
+	}
+	if err := c.PutWebhookFailure(ctx, failure); err != nil {
+		t.Fatalf("PutWebhookFailure: %v", err)
+	}
+	if _, ok := mock.item["payload"].(*types.AttributeValueMemberM); !ok {
+		t.Fatalf("expected payload stored as a DynamoDB map, got %T", mock.item["payload"])
+	}
+
+	got, err := c.GetWebhookFailure(ctx, "fail-1")
+	if err != nil {
+		t.Fatalf("GetWebhookFailure: %v", err)
+	}
+	if got.Payload.Status != models.StatusGranted || got.Payload.Details["k"] != "v" || got.ExpiresAt != 1700000000 {
+		t.Errorf("expected failure to round-trip, got %+v", got)
+	}
+}
+
+func TestWebhookFailure_TableNotConfigured(t *testing.T) {
+	c := NewClient(newMockDynamo(), "cfg", "reqs", "audit", "nonces")
+
+	err := c.PutWebhookFailure(context.Background(), &models.WebhookFailure{FailureID: "fail-1"})
+	if err == nil || !strings.Contains(err.Error(), "is not configured") {
+		t.Fatalf("expected not configured error, got: %v", err)
+	}
+}
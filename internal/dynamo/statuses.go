@@ -0,0 +1,163 @@
+package dynamo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// statusPage is one status's page of a multi-status report.
+type statusPage struct {
+	status  string
+	start   string
+	items   []models.JitRequest
+	lastKey map[string]types.AttributeValue
+}
+
+// queryRequestsByStatuses serves a status-only report over several statuses.
+// gsi_status_endtime is keyed on a single status, so each status is queried
+// separately and the pages are merged in end_time descending order, the order
+// each query returns. The next token holds a cursor for every status that may
+// still have items, positioned just past the last item of that status that
+// was returned.
+func (c *Client) queryRequestsByStatuses(ctx context.Context, statuses []string, limit int32, token string) ([]models.JitRequest, string, error) {
+	cursors := make(map[string]string, len(statuses))
+	if token == "" {
+		for _, status := range statuses {
+			cursors[status] = ""
+		}
+	} else {
+		decoded, err := decodeStatusCursors(token)
+		if err != nil {
+			return nil, "", fmt.Errorf("QueryRequests: %w: %v", models.ErrInvalidNextToken, err)
+		}
+		for _, status := range statuses {
+			if cursor, ok := decoded[status]; ok {
+				cursors[status] = cursor
+			}
+		}
+	}
+
+	var pages []*statusPage
+	for _, status := range statuses {
+		cursor, ok := cursors[status]
+		if !ok {
+			continue
+		}
+		page, err := c.queryReportPage(ctx, status, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		pages = append(pages, page)
+	}
+
+	// Merge by end_time, taking ties in status order, so each status
+	// contributes a prefix of its page and its cursor stays exact.
+	taken := make([]int, len(pages))
+	var merged []models.JitRequest
+	for len(merged) < int(limit) {
+		best := -1
+		for i, p := range pages {
+			if taken[i] == len(p.items) {
+				continue
+			}
+			if best < 0 || p.items[taken[i]].EndTime > pages[best].items[taken[best]].EndTime {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		merged = append(merged, pages[best].items[taken[best]])
+		taken[best]++
+	}
+
+	next := map[string]string{}
+	for i, p := range pages {
+		switch {
+		case taken[i] < len(p.items) && taken[i] == 0:
+			next[p.status] = p.start
+		case taken[i] < len(p.items):
+			last := p.items[taken[i]-1]
+			next[p.status], _ = serializeStartKey(map[string]types.AttributeValue{
+				"request_id": &types.AttributeValueMemberS{Value: last.RequestID},
+				"status":     &types.AttributeValueMemberS{Value: last.Status},
+				"end_time":   &types.AttributeValueMemberS{Value: last.EndTime},
+			})
+		case p.lastKey != nil:
+			next[p.status], _ = serializeStartKey(p.lastKey)
+		}
+	}
+	if len(next) == 0 {
+		return merged, "", nil
+	}
+	nextToken, err := encodeStatusCursors(next)
+	if err != nil {
+		return nil, "", fmt.Errorf("QueryRequests: %w", err)
+	}
+	return merged, nextToken, nil
+}
+
+// queryReportPage reads one page of requests with the given status from
+// gsi_status_endtime, starting after the cursor.
+func (c *Client) queryReportPage(ctx context.Context, status, cursor string, limit int32) (*statusPage, error) {
+	startKey, err := deserializeStartKey(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("QueryRequests: %w: %v", models.ErrInvalidNextToken, err)
+	}
+	out, err := c.db.Query(ctx, &dynamodb.QueryInput{
+		TableName:              &c.tableRequests,
+		IndexName:              aws.String("gsi_status_endtime"),
+		KeyConditionExpression: aws.String("#status = :st"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":st": &types.AttributeValueMemberS{Value: status},
+		},
+		ScanIndexForward:  aws.Bool(false),
+		Limit:             &limit,
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("QueryRequests %s: %w", status, err)
+	}
+	page := &statusPage{status: status, start: cursor, lastKey: out.LastEvaluatedKey}
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &page.items); err != nil {
+		return nil, fmt.Errorf("QueryRequests unmarshal: %w", err)
+	}
+	if err := c.decryptRequests(ctx, page.items); err != nil {
+		return nil, fmt.Errorf("QueryRequests: %w", err)
+	}
+	return page, nil
+}
+
+// encodeStatusCursors packs per-status cursors into one next token.
+func encodeStatusCursors(cursors map[string]string) (string, error) {
+	raw, err := json.Marshal(cursors)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeStatusCursors unpacks a token from encodeStatusCursors.
+func decodeStatusCursors(token string) (map[string]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var cursors map[string]string
+	if err := json.Unmarshal(raw, &cursors); err != nil {
+		return nil, err
+	}
+	return cursors, nil
+}
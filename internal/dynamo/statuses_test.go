@@ -0,0 +1,136 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// statusIndexDynamo serves gsi_status_endtime queries from requests held in
+// end_time descending order, honouring Limit and ExclusiveStartKey.
+type statusIndexDynamo struct {
+	*mockDynamo
+	requests []models.JitRequest
+	queries  []string
+}
+
+func (m *statusIndexDynamo) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	status := params.ExpressionAttributeValues[":st"].(*types.AttributeValueMemberS).Value
+	m.queries = append(m.queries, status)
+
+	var matched []models.JitRequest
+	for _, r := range m.requests {
+		if r.Status == status {
+			matched = append(matched, r)
+		}
+	}
+	start := 0
+	if sv, ok := params.ExclusiveStartKey["request_id"].(*types.AttributeValueMemberS); ok {
+		for i, r := range matched {
+			if r.RequestID == sv.Value {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+int(*params.Limit), len(matched))
+
+	out := &dynamodb.QueryOutput{}
+	for _, r := range matched[start:end] {
+		out.Items = append(out.Items, map[string]types.AttributeValue{
+			"request_id": &types.AttributeValueMemberS{Value: r.RequestID},
+			"status":     &types.AttributeValueMemberS{Value: r.Status},
+			"end_time":   &types.AttributeValueMemberS{Value: r.EndTime},
+		})
+	}
+	if end < len(matched) {
+		out.LastEvaluatedKey = out.Items[len(out.Items)-1]
+	}
+	return out, nil
+}
+
+func TestQueryRequests_MultipleStatusesMergesAndPages(t *testing.T) {
+	mock := &statusIndexDynamo{mockDynamo: newMockDynamo(), requests: []models.JitRequest{
+		{RequestID: "g1", Status: models.StatusGranted, EndTime: "2025-06-01T16:00:00Z"},
+		{RequestID: "p1", Status: models.StatusPending, EndTime: "2025-06-01T15:00:00Z"},
+		{RequestID: "g2", Status: models.StatusGranted, EndTime: "2025-06-01T14:00:00Z"},
+		{RequestID: "p2", Status: models.StatusPending, EndTime: "2025-06-01T13:00:00Z"},
+		{RequestID: "g3", Status: models.StatusGranted, EndTime: "2025-06-01T12:00:00Z"},
+		{RequestID: "d1", Status: models.StatusDenied, EndTime: "2025-06-01T17:00:00Z"},
+	}}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+	ctx := context.Background()
+
+	var ids []string
+	token := ""
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		items, next, err := c.QueryRequests(ctx, models.ReportingInput{Status: "PENDING,GRANTED", Limit: 2, NextToken: token})
+		if err != nil {
+			t.Fatalf("QueryRequests: %v", err)
+		}
+		if len(items) > 2 {
+			t.Fatalf("expected at most 2 items per page, got %d", len(items))
+		}
+		for _, r := range items {
+			ids = append(ids, r.RequestID)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	if got := strings.Join(ids, ","); got != "g1,p1,g2,p2,g3" {
+		t.Errorf("expected both statuses merged by end_time without gaps or repeats, got %s", got)
+	}
+	for _, status := range mock.queries {
+		if status != models.StatusPending && status != models.StatusGranted {
+			t.Errorf("unexpected query for status %s", status)
+		}
+	}
+}
+
+func TestQueryRequests_MultipleStatusesInvalidToken(t *testing.T) {
+	c := NewClient(&statusIndexDynamo{mockDynamo: newMockDynamo()}, "cfg", "reqs", "audit", "nonces")
+
+	_, _, err := c.QueryRequests(context.Background(), models.ReportingInput{Status: "PENDING,GRANTED", NextToken: "not a token!"})
+	if !errors.Is(err, models.ErrInvalidNextToken) {
+		t.Fatalf("expected ErrInvalidNextToken, got: %v", err)
+	}
+}
+
+func TestQueryRequests_InvalidToken(t *testing.T) {
+	c := NewClient(newMockDynamo(), "cfg", "reqs", "audit", "nonces")
+
+	_, _, err := c.QueryRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", NextToken: "garbage"})
+	if !errors.Is(err, models.ErrInvalidNextToken) {
+		t.Fatalf("expected ErrInvalidNextToken, got: %v", err)
+	}
+}
+
+func TestBuildFilters_MultipleStatuses(t *testing.T) {
+	expr, names, values := buildFilters(models.ReportingInput{ChannelID: "ch1", Status: "PENDING,APPROVED,GRANTED"}, true)
+
+	if expr != "#fstatus IN (:fstatus0, :fstatus1, :fstatus2)" {
+		t.Errorf("unexpected filter expression: %s", expr)
+	}
+	if names["#fstatus"] != "status" {
+		t.Errorf("expected #fstatus to name status, got %v", names)
+	}
+	if v, ok := values[":fstatus1"].(*types.AttributeValueMemberS); !ok || v.Value != models.StatusApproved {
+		t.Errorf("expected :fstatus1 bound to APPROVED, got %v", values[":fstatus1"])
+	}
+
+	expr, _, _ = buildFilters(models.ReportingInput{ChannelID: "ch1", Status: "GRANTED"}, true)
+	if expr != "#fstatus = :fstatus" {
+		t.Errorf("expected equality filter for a single status, got %s", expr)
+	}
+}
@@ -0,0 +1,133 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+
+	"github.com/dgwhited/jit-aws-controller/internal/retry"
+)
+
+// throttleCodes are the DynamoDB error codes that mean the request was
+// rejected for capacity and was not applied, so it is safe to resend.
+var throttleCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"RequestLimitExceeded":                   true,
+}
+
+// IsThrottle reports whether err (or anything it wraps) is a DynamoDB
+// throttling error that outlasted the SDK's own retries.
+func IsThrottle(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && throttleCodes[apiErr.ErrorCode()]
+}
+
+// BulkThrottleBackoffs is a throttle retry schedule for batch callers such as
+// the reconciler, which can afford to wait out a burst: 100ms, 400ms, 1.6s.
+var BulkThrottleBackoffs = []time.Duration{
+	100 * time.Millisecond,
+	400 * time.Millisecond,
+	1600 * time.Millisecond,
+}
+
+// throttleRetrier wraps a DynamoAPI, retrying throttled calls after each of
+// backoffs in turn. Every throttle is logged as "dynamodb throttled", which
+// the DynamoDBThrottles metric filter counts.
+type throttleRetrier struct {
+	api      DynamoAPI
+	backoffs []time.Duration
+}
+
+func (r throttleRetrier) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "GetItem", aws.ToString(params.TableName), func() (*dynamodb.GetItemOutput, error) {
+		return r.api.GetItem(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "PutItem", aws.ToString(params.TableName), func() (*dynamodb.PutItemOutput, error) {
+		return r.api.PutItem(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "UpdateItem", aws.ToString(params.TableName), func() (*dynamodb.UpdateItemOutput, error) {
+		return r.api.UpdateItem(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "Query", aws.ToString(params.TableName), func() (*dynamodb.QueryOutput, error) {
+		return r.api.Query(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "Scan", aws.ToString(params.TableName), func() (*dynamodb.ScanOutput, error) {
+		return r.api.Scan(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "DeleteItem", aws.ToString(params.TableName), func() (*dynamodb.DeleteItemOutput, error) {
+		return r.api.DeleteItem(ctx, params, optFns...)
+	})
+}
+
+func (r throttleRetrier) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "DescribeTimeToLive", aws.ToString(params.TableName), func() (*dynamodb.DescribeTimeToLiveOutput, error) {
+		return r.api.DescribeTimeToLive(ctx, params, optFns...)
+	})
+}
+
+// TransactWriteItems is labelled with its first item's table, since a
+// transaction can span tables.
+func (r throttleRetrier) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return retryThrottled(ctx, r.backoffs, "TransactWriteItems", transactTable(params), func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return r.api.TransactWriteItems(ctx, params, optFns...)
+	})
+}
+
+func transactTable(params *dynamodb.TransactWriteItemsInput) string {
+	if len(params.TransactItems) == 0 || params.TransactItems[0].Put == nil {
+		return ""
+	}
+	return aws.ToString(params.TransactItems[0].Put.TableName)
+}
+
+// retryThrottled runs call, retrying after each backoff while it is throttled.
+// Other errors are returned immediately.
+func retryThrottled[T any](ctx context.Context, backoffs []time.Duration, op, table string, call func() (T, error)) (T, error) {
+	var out T
+	err := retry.Do(ctx, retry.Policy{
+		Backoffs:  backoffs,
+		Retryable: IsThrottle,
+		OnError: func(attempt int, err error, retrying bool) {
+			if !IsThrottle(err) {
+				return
+			}
+			slog.WarnContext(ctx, "dynamodb throttled",
+				"op", op,
+				"table", table,
+				"attempt", attempt,
+				"retrying", retrying,
+				"error", err,
+			)
+		},
+	}, func() error {
+		var err error
+		out, err = call()
+		return err
+	})
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) {
+		return out, exhausted.Err
+	}
+	return out, err
+}
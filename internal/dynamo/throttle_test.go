@@ -0,0 +1,105 @@
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// throttleDynamo rejects the first throttles Query and UpdateItem calls with
+// ProvisionedThroughputExceededException, then behaves like mockDynamo.
+type throttleDynamo struct {
+	*mockDynamo
+	throttles int
+	calls     int
+}
+
+func (m *throttleDynamo) throttled() error {
+	m.calls++
+	if m.calls <= m.throttles {
+		return &types.ProvisionedThroughputExceededException{Message: aws.String("rate exceeded")}
+	}
+	return nil
+}
+
+func (m *throttleDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := m.throttled(); err != nil {
+		return nil, err
+	}
+	return m.mockDynamo.Query(ctx, params, optFns...)
+}
+
+func (m *throttleDynamo) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := m.throttled(); err != nil {
+		return nil, err
+	}
+	return m.mockDynamo.UpdateItem(ctx, params, optFns...)
+}
+
+func TestClient_RetriesThrottleThenSucceeds(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	ctx := context.Background()
+	mock := &throttleDynamo{mockDynamo: newMockDynamo(), throttles: 1}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces", WithThrottleBackoffs([]time.Duration{time.Millisecond}))
+
+	if _, err := c.QueryRequestsByStatus(ctx, models.StatusGranted, "", 0); err != nil {
+		t.Fatalf("expected query to succeed after one throttle, got: %v", err)
+	}
+	if mock.calls != 2 {
+		t.Errorf("expected 2 Query calls, got %d", mock.calls)
+	}
+
+	mock.calls = 0
+	if err := c.ConditionalUpdateStatus(ctx, "req-1", models.StatusGranted, map[string]interface{}{"status": models.StatusExpired}); err != nil {
+		t.Fatalf("expected conditional update to succeed after one throttle, got: %v", err)
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, `msg="dynamodb throttled"`); n != 2 {
+		t.Errorf("expected 2 throttle log lines, got %d: %s", n, out)
+	}
+	for _, want := range []string{"op=Query", "op=UpdateItem", "table=reqs", "retrying=true"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log output, got %s", want, out)
+		}
+	}
+}
+
+func TestClient_ThrottleWithoutBackoffsIsClassified(t *testing.T) {
+	mock := &throttleDynamo{mockDynamo: newMockDynamo(), throttles: 1}
+	c := NewClient(mock, "cfg", "reqs", "audit", "nonces")
+
+	_, err := c.QueryRequestsByStatus(context.Background(), models.StatusGranted, "", 0)
+	if !IsThrottle(err) {
+		t.Fatalf("expected a throttle error, got: %v", err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("expected no extra retries by default, got %d calls", mock.calls)
+	}
+}
+
+func TestIsThrottle(t *testing.T) {
+	if IsThrottle(fmt.Errorf("wrapped: %w", &types.ConditionalCheckFailedException{})) {
+		t.Error("expected conditional check failure not to be a throttle")
+	}
+	if !IsThrottle(fmt.Errorf("wrapped: %w", &types.RequestLimitExceeded{})) {
+		t.Error("expected RequestLimitExceeded to be a throttle")
+	}
+	if IsThrottle(nil) {
+		t.Error("expected nil not to be a throttle")
+	}
+}
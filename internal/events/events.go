@@ -0,0 +1,120 @@
+// Package events publishes request lifecycle transitions to Amazon
+// EventBridge so other systems can react to them without polling the API.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// Source is the EventBridge source of every published event.
+const Source = "jit-aws-controller"
+
+// Publisher publishes a recorded lifecycle audit event.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.AuditEvent) error
+}
+
+// Noop is the Publisher used when no event bus is configured.
+type Noop struct{}
+
+// Publish does nothing.
+func (Noop) Publish(context.Context, *models.AuditEvent) error { return nil }
+
+// Detail is the JSON detail of a published event.
+type Detail struct {
+	EventID       string            `json:"event_id"`
+	EventType     string            `json:"event_type"`
+	EventTime     string            `json:"event_time"`
+	RequestID     string            `json:"request_id"`
+	AccountID     string            `json:"account_id"`
+	ChannelID     string            `json:"channel_id"`
+	ActorType     string            `json:"actor_type,omitempty"`
+	ActorMMUserID string            `json:"actor_mm_user_id,omitempty"`
+	ActorEmail    string            `json:"actor_email,omitempty"`
+	Details       map[string]string `json:"details,omitempty"`
+}
+
+// DetailType returns the detail-type for an audit event type, e.g.
+// "jit.request.granted" for GRANTED.
+func DetailType(eventType string) string {
+	return "jit.request." + strings.ToLower(eventType)
+}
+
+// PutEventsAPI is the subset of the EventBridge client used for publishing.
+type PutEventsAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgePublisher implements Publisher by putting one event per audit
+// event on an event bus.
+type EventBridgePublisher struct {
+	client PutEventsAPI
+	busARN string
+}
+
+// NewEventBridgePublisher creates a Publisher for the given event bus.
+func NewEventBridgePublisher(client PutEventsAPI, busARN string) *EventBridgePublisher {
+	return &EventBridgePublisher{client: client, busARN: busARN}
+}
+
+// Publish puts event on the bus with its type as the detail-type.
+func (p *EventBridgePublisher) Publish(ctx context.Context, event *models.AuditEvent) error {
+	detail, err := json.Marshal(Detail{
+		EventID:       event.EventID,
+		EventType:     event.EventType,
+		EventTime:     event.EventTime,
+		RequestID:     event.RequestID,
+		AccountID:     event.AccountID,
+		ChannelID:     event.ChannelID,
+		ActorType:     event.ActorType,
+		ActorMMUserID: event.ActorMMUserID,
+		ActorEmail:    event.ActorEmail,
+		Details:       event.Details,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal event detail: %w", err)
+	}
+
+	source := Source
+	detailType := DetailType(event.EventType)
+	detailJSON := string(detail)
+	entry := ebtypes.PutEventsRequestEntry{
+		EventBusName: &p.busARN,
+		Source:       &source,
+		DetailType:   &detailType,
+		Detail:       &detailJSON,
+	}
+	if t, err := time.Parse(time.RFC3339, event.EventTime); err == nil {
+		entry.Time = &t
+	}
+
+	out, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{entry},
+	})
+	if err != nil {
+		return fmt.Errorf("put event: %w", err)
+	}
+	// PutEvents reports per-entry failures in the output, not the error.
+	if out.FailedEntryCount > 0 {
+		code, msg := "unknown", ""
+		if len(out.Entries) > 0 {
+			if e := out.Entries[0]; e.ErrorCode != nil {
+				code = *e.ErrorCode
+				if e.ErrorMessage != nil {
+					msg = *e.ErrorMessage
+				}
+			}
+		}
+		return fmt.Errorf("put event rejected: %s: %s", code, msg)
+	}
+	return nil
+}
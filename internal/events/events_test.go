@@ -0,0 +1,125 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+const testBusARN = "arn:aws:events:us-east-1:123456789012:event-bus/jit"
+
+// mockEventBridge records PutEvents calls and answers with out or err.
+type mockEventBridge struct {
+	inputs []*eventbridge.PutEventsInput
+	out    *eventbridge.PutEventsOutput
+	err    error
+}
+
+func (m *mockEventBridge) PutEvents(_ context.Context, params *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	m.inputs = append(m.inputs, params)
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.out != nil {
+		return m.out, nil
+	}
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+func auditEvent(eventType string, details map[string]string) *models.AuditEvent {
+	return &models.AuditEvent{
+		RequestID:        "req-1",
+		EventTimeEventID: "2024-01-01T00:00:00.000000000Z#00000002#evt-1",
+		EventID:          "evt-1",
+		EventTime:        "2024-01-01T00:00:00Z",
+		EventType:        eventType,
+		AccountID:        "123456789012",
+		ChannelID:        "ch1",
+		ActorType:        models.ActorStepFn,
+		ActorEmail:       "stepfunctions",
+		Details:          details,
+		PrevHash:         "prev",
+		Hash:             "hash",
+	}
+}
+
+func TestEventBridgePublisher_GrantAndRevoke(t *testing.T) {
+	tests := []struct {
+		eventType  string
+		details    map[string]string
+		detailType string
+	}{
+		{models.EventGranted, map[string]string{"permission_set_arn": "arn:aws:sso:::permissionSet/ssoins-1/ps-1"}, "jit.request.granted"},
+		{models.EventRevoked, map[string]string{"reason": "manual"}, "jit.request.revoked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			client := &mockEventBridge{}
+			p := NewEventBridgePublisher(client, testBusARN)
+
+			if err := p.Publish(context.Background(), auditEvent(tt.eventType, tt.details)); err != nil {
+				t.Fatalf("Publish failed: %v", err)
+			}
+			if len(client.inputs) != 1 || len(client.inputs[0].Entries) != 1 {
+				t.Fatalf("expected one PutEvents call with one entry, got %+v", client.inputs)
+			}
+			entry := client.inputs[0].Entries[0]
+			if *entry.EventBusName != testBusARN || *entry.Source != Source || *entry.DetailType != tt.detailType {
+				t.Errorf("expected bus %s, source %s and detail-type %s, got %s, %s and %s",
+					testBusARN, Source, tt.detailType, *entry.EventBusName, *entry.Source, *entry.DetailType)
+			}
+			if entry.Time == nil || entry.Time.Unix() != 1704067200 {
+				t.Errorf("expected the event time, got %v", entry.Time)
+			}
+
+			var detail Detail
+			if err := json.Unmarshal([]byte(*entry.Detail), &detail); err != nil {
+				t.Fatalf("detail is not JSON: %v", err)
+			}
+			if detail.RequestID != "req-1" || detail.EventID != "evt-1" || detail.EventType != tt.eventType ||
+				detail.AccountID != "123456789012" || detail.ChannelID != "ch1" || detail.ActorType != models.ActorStepFn {
+				t.Errorf("unexpected detail: %+v", detail)
+			}
+			for k, v := range tt.details {
+				if detail.Details[k] != v {
+					t.Errorf("expected detail %s=%q, got %q", k, v, detail.Details[k])
+				}
+			}
+			if strings.Contains(*entry.Detail, "hash") {
+				t.Errorf("expected chain fields left out of the detail, got %s", *entry.Detail)
+			}
+		})
+	}
+}
+
+func TestEventBridgePublisher_Errors(t *testing.T) {
+	client := &mockEventBridge{err: errors.New("AccessDeniedException")}
+	p := NewEventBridgePublisher(client, testBusARN)
+	if err := p.Publish(context.Background(), auditEvent(models.EventGranted, nil)); err == nil {
+		t.Error("expected a PutEvents error to be returned")
+	}
+
+	code, msg := "ThrottlingException", "rate exceeded"
+	client = &mockEventBridge{out: &eventbridge.PutEventsOutput{
+		FailedEntryCount: 1,
+		Entries:          []ebtypes.PutEventsResultEntry{{ErrorCode: &code, ErrorMessage: &msg}},
+	}}
+	p = NewEventBridgePublisher(client, testBusARN)
+	err := p.Publish(context.Background(), auditEvent(models.EventGranted, nil))
+	if err == nil || !strings.Contains(err.Error(), code) {
+		t.Errorf("expected a rejected entry to be reported, got %v", err)
+	}
+}
+
+func TestDetailType(t *testing.T) {
+	if got := DetailType(models.EventAutoApproved); got != "jit.request.auto_approved" {
+		t.Errorf("expected jit.request.auto_approved, got %s", got)
+	}
+}
@@ -0,0 +1,65 @@
+// Package fieldcrypt provides optional envelope encryption for sensitive
+// request fields before they are written to DynamoDB.
+package fieldcrypt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// Prefix marks a stored value as encrypted. Values without it are treated as
+// plaintext, so rows written before encryption was enabled remain readable.
+const Prefix = "enc:v1:"
+
+// Encryptor encrypts and decrypts individual field values.
+type Encryptor interface {
+	Encrypt(ctx context.Context, plaintext string) (string, error)
+	Decrypt(ctx context.Context, ciphertext string) (string, error)
+}
+
+// sensitiveFields returns pointers to the request fields that are encrypted at
+// rest. Only jira is a key (of gsi_jira_created), which is why jira search is
+// unavailable while encryption is enabled.
+func sensitiveFields(req *models.JitRequest) map[string]*string {
+	return map[string]*string{
+		"reason": &req.Reason,
+		"jira":   &req.Jira,
+	}
+}
+
+// EncryptRequest returns a copy of req with its sensitive fields encrypted.
+// The caller's request is left untouched so it can still be returned in
+// plaintext.
+func EncryptRequest(ctx context.Context, enc Encryptor, req *models.JitRequest) (*models.JitRequest, error) {
+	out := *req
+	for name, field := range sensitiveFields(&out) {
+		if *field == "" || strings.HasPrefix(*field, Prefix) {
+			continue
+		}
+		ciphertext, err := enc.Encrypt(ctx, *field)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt %s: %w", name, err)
+		}
+		*field = Prefix + ciphertext
+	}
+	return &out, nil
+}
+
+// DecryptRequest decrypts req's sensitive fields in place. Plaintext values
+// are left as they are.
+func DecryptRequest(ctx context.Context, enc Encryptor, req *models.JitRequest) error {
+	for name, field := range sensitiveFields(req) {
+		if !strings.HasPrefix(*field, Prefix) {
+			continue
+		}
+		plaintext, err := enc.Decrypt(ctx, strings.TrimPrefix(*field, Prefix))
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", name, err)
+		}
+		*field = plaintext
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package fieldcrypt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// mockEncryptor reverses and tags values so ciphertext is distinguishable.
+type mockEncryptor struct {
+	failDecrypt bool
+}
+
+func (m *mockEncryptor) Encrypt(_ context.Context, plaintext string) (string, error) {
+	return "x" + reverse(plaintext), nil
+}
+
+func (m *mockEncryptor) Decrypt(_ context.Context, ciphertext string) (string, error) {
+	if m.failDecrypt {
+		return "", errors.New("key disabled")
+	}
+	return reverse(strings.TrimPrefix(ciphertext, "x")), nil
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func TestEncryptRequest_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	enc := &mockEncryptor{}
+	req := &models.JitRequest{
+		RequestID:      "req-1",
+		RequesterEmail: "user@example.com",
+		Jira:           "SEC-42 breach investigation",
+		Reason:         "rotate leaked credentials",
+	}
+
+	stored, err := EncryptRequest(ctx, enc, req)
+	if err != nil {
+		t.Fatalf("EncryptRequest failed: %v", err)
+	}
+	if !strings.HasPrefix(stored.Reason, Prefix) || !strings.HasPrefix(stored.Jira, Prefix) {
+		t.Fatalf("expected encrypted fields, got reason=%q jira=%q", stored.Reason, stored.Jira)
+	}
+	if strings.Contains(stored.Reason, "leaked") || strings.Contains(stored.Jira, "breach") {
+		t.Error("stored fields leak plaintext")
+	}
+	if stored.RequesterEmail != req.RequesterEmail {
+		t.Error("expected non-sensitive fields to be stored as-is")
+	}
+	if req.Reason != "rotate leaked credentials" {
+		t.Error("expected caller's request to be left in plaintext")
+	}
+
+	if err := DecryptRequest(ctx, enc, stored); err != nil {
+		t.Fatalf("DecryptRequest failed: %v", err)
+	}
+	if stored.Reason != req.Reason || stored.Jira != req.Jira {
+		t.Errorf("round trip mismatch: reason=%q jira=%q", stored.Reason, stored.Jira)
+	}
+}
+
+func TestEncryptRequest_SkipsEmptyFields(t *testing.T) {
+	stored, err := EncryptRequest(context.Background(), &mockEncryptor{}, &models.JitRequest{Reason: "needed"})
+	if err != nil {
+		t.Fatalf("EncryptRequest failed: %v", err)
+	}
+	if stored.Jira != "" {
+		t.Errorf("expected empty jira to stay empty, got %q", stored.Jira)
+	}
+}
+
+func TestDecryptRequest_LegacyPlaintext(t *testing.T) {
+	req := &models.JitRequest{Reason: "written before encryption", Jira: "OPS-1"}
+
+	if err := DecryptRequest(context.Background(), &mockEncryptor{failDecrypt: true}, req); err != nil {
+		t.Fatalf("expected plaintext values to pass through, got: %v", err)
+	}
+	if req.Reason != "written before encryption" || req.Jira != "OPS-1" {
+		t.Errorf("expected plaintext unchanged, got reason=%q jira=%q", req.Reason, req.Jira)
+	}
+}
+
+func TestDecryptRequest_Error(t *testing.T) {
+	stored, _ := EncryptRequest(context.Background(), &mockEncryptor{}, &models.JitRequest{Reason: "secret"})
+
+	err := DecryptRequest(context.Background(), &mockEncryptor{failDecrypt: true}, stored)
+	if err == nil || !strings.Contains(err.Error(), "decrypt reason") {
+		t.Fatalf("expected decrypt reason error, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// maxCachedKeys bounds the decrypted data key cache.
+const maxCachedKeys = 1024
+
+// KMSAPI is the subset of the KMS client used for envelope encryption.
+type KMSAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSEncryptor implements Encryptor with a fresh KMS data key per value and
+// AES-256-GCM. The encrypted data key is stored alongside the ciphertext as
+// base64(encrypted key) + "." + base64(nonce || sealed).
+type KMSEncryptor struct {
+	client KMSAPI
+	keyARN string
+
+	mu        sync.Mutex
+	plainKeys map[string][]byte // encrypted key (base64) -> plaintext key
+}
+
+// NewKMSEncryptor creates an Encryptor using the given KMS key.
+func NewKMSEncryptor(client KMSAPI, keyARN string) *KMSEncryptor {
+	return &KMSEncryptor{
+		client:    client,
+		keyARN:    keyARN,
+		plainKeys: make(map[string][]byte),
+	}
+}
+
+// Encrypt seals plaintext under a new data key.
+func (e *KMSEncryptor) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	out, err := e.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   &e.keyARN,
+		KeySpec: kmstypes.DataKeySpecAes256,
+	})
+	if err != nil {
+		return "", fmt.Errorf("generate data key: %w", err)
+	}
+
+	gcm, err := newGCM(out.Plaintext)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(out.CiphertextBlob) + "." +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt.
+func (e *KMSEncryptor) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	encKey, payload, ok := strings.Cut(ciphertext, ".")
+	if !ok {
+		return "", fmt.Errorf("malformed ciphertext")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("decode ciphertext: %w", err)
+	}
+
+	key, err := e.dataKey(ctx, encKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed ciphertext")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("open ciphertext: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// dataKey returns the plaintext data key for an encrypted key, asking KMS only
+// on a cache miss so that listing requests does not cost a KMS call per field.
+func (e *KMSEncryptor) dataKey(ctx context.Context, encKey string) ([]byte, error) {
+	e.mu.Lock()
+	key, ok := e.plainKeys[encKey]
+	e.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode data key: %w", err)
+	}
+	out, err := e.client.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: blob,
+		KeyId:          &e.keyARN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key: %w", err)
+	}
+
+	e.mu.Lock()
+	if len(e.plainKeys) >= maxCachedKeys {
+		e.plainKeys = make(map[string][]byte)
+	}
+	e.plainKeys[encKey] = out.Plaintext
+	e.mu.Unlock()
+	return out.Plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,79 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// mockKMS hands out a fixed data key and "encrypts" it by prefixing.
+type mockKMS struct {
+	key           []byte
+	decryptCalls  int
+	generateCalls int
+}
+
+func (m *mockKMS) GenerateDataKey(_ context.Context, _ *kms.GenerateDataKeyInput, _ ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	m.generateCalls++
+	return &kms.GenerateDataKeyOutput{
+		Plaintext:      m.key,
+		CiphertextBlob: append([]byte("wrapped:"), m.key...),
+	}, nil
+}
+
+func (m *mockKMS) Decrypt(_ context.Context, params *kms.DecryptInput, _ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	m.decryptCalls++
+	return &kms.DecryptOutput{Plaintext: bytes.TrimPrefix(params.CiphertextBlob, []byte("wrapped:"))}, nil
+}
+
+func TestKMSEncryptor_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	client := &mockKMS{key: bytes.Repeat([]byte{7}, 32)}
+	enc := NewKMSEncryptor(client, "arn:aws:kms:us-east-1:123456789012:key/test")
+
+	ciphertext, err := enc.Encrypt(ctx, "rotate leaked credentials")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if strings.Contains(ciphertext, "leaked") {
+		t.Fatal("ciphertext leaks plaintext")
+	}
+
+	for i := 0; i < 3; i++ {
+		plaintext, err := enc.Decrypt(ctx, ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if plaintext != "rotate leaked credentials" {
+			t.Errorf("expected round trip, got %q", plaintext)
+		}
+	}
+	if client.decryptCalls != 1 {
+		t.Errorf("expected data key to be decrypted once and cached, got %d KMS calls", client.decryptCalls)
+	}
+}
+
+func TestKMSEncryptor_TamperedCiphertext(t *testing.T) {
+	ctx := context.Background()
+	enc := NewKMSEncryptor(&mockKMS{key: bytes.Repeat([]byte{7}, 32)}, "arn:key")
+
+	ciphertext, err := enc.Encrypt(ctx, "secret")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	encKey, payload, _ := strings.Cut(ciphertext, ".")
+	tampered := encKey + "." + strings.ToUpper(payload[:4]) + payload[4:]
+	if tampered == ciphertext {
+		tampered = encKey + "." + "AAAA" + payload[4:]
+	}
+
+	if _, err := enc.Decrypt(ctx, tampered); err == nil {
+		t.Error("expected tampered ciphertext to fail authentication")
+	}
+	if _, err := enc.Decrypt(ctx, "no-separator"); err == nil {
+		t.Error("expected malformed ciphertext to be rejected")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/dgwhited/jit-aws-controller/internal/models"
@@ -20,23 +22,50 @@ type StepFunctionActionPayload struct {
 	RequesterEmail      string          `json:"requester_email"`
 	DurationSeconds     int             `json:"duration_seconds"`
 	Error               json.RawMessage `json:"error,omitempty"`
+	Signature           string          `json:"signature,omitempty"`
+}
+
+// workflowActions are the actions the state machine invokes, each of which is
+// signed separately when action signing is enabled.
+var workflowActions = []string{
+	"validate",
+	"grant",
+	"notify_granted",
+	"revoke",
+	"notify_revoked",
+	"handle_grant_error",
+	"handle_revoke_error",
 }
 
 // ActionResult is the response returned to Step Functions from each action.
 type ActionResult struct {
-	Status    string `json:"status"`
-	RequestID string `json:"request_id"`
-	Message   string `json:"message,omitempty"`
+	Status           string `json:"status"`
+	RequestID        string `json:"request_id"`
+	Message          string `json:"message,omitempty"`
+	AssignmentStatus string `json:"assignment_status,omitempty"`
 }
 
 // ActionHandler processes Step Functions action payloads.
 type ActionHandler struct {
 	Handler *Handler
+
+	// Signer, when set, rejects action payloads that do not carry a valid
+	// signature for their action and request ID.
+	Signer ActionSigner
+
+	// PreGrantJitter, when positive, delays each grant by a random time up
+	// to this long before it touches Identity Center, spreading a burst of
+	// approvals out. SSO Admin serializes assignment changes per instance.
+	PreGrantJitter time.Duration
+
+	// actor is recorded on audit events: Step Functions for workflow
+	// invocations, the system for in-process grants.
+	actor models.Actor
 }
 
 // NewActionHandler creates a new action handler.
 func NewActionHandler(handler *Handler) *ActionHandler {
-	return &ActionHandler{Handler: handler}
+	return &ActionHandler{Handler: handler, actor: models.StepFnActor}
 }
 
 // Handle dispatches to the appropriate action based on the payload.
@@ -51,6 +80,17 @@ func (a *ActionHandler) Handle(ctx context.Context, raw json.RawMessage) (*Actio
 		"request_id", payload.RequestID,
 	)
 
+	if a.Signer != nil {
+		if err := a.Signer.VerifyAction(payload.Action, payload.RequestID, payload.Signature); err != nil {
+			slog.Error("rejected unsigned step function action",
+				"action", payload.Action,
+				"request_id", payload.RequestID,
+				"error", err,
+			)
+			return nil, fmt.Errorf("action %s for request %s rejected: %w", payload.Action, payload.RequestID, err)
+		}
+	}
+
 	switch payload.Action {
 	case "validate":
 		return a.handleValidate(ctx, payload)
@@ -71,7 +111,8 @@ func (a *ActionHandler) Handle(ctx context.Context, raw json.RawMessage) (*Actio
 	}
 }
 
-// handleValidate verifies the request is still in APPROVED status and ready for granting.
+// handleValidate verifies the request is still in APPROVED status and its
+// account is still bound, so it is ready for granting.
 func (a *ActionHandler) handleValidate(ctx context.Context, p StepFunctionActionPayload) (*ActionResult, error) {
 	req, err := a.Handler.DB.GetRequest(ctx, p.RequestID)
 	if err != nil {
@@ -84,6 +125,19 @@ func (a *ActionHandler) handleValidate(ctx context.Context, p StepFunctionAction
 		return nil, fmt.Errorf("request %s is in status %s, expected APPROVED", p.RequestID, req.Status)
 	}
 
+	// The account may have been unbound between approval and this step.
+	cfg, err := a.Handler.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup config: %w", err)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", req.ChannelID, req.AccountID)
+	}
+
+	if err := a.repairIdentityStoreUserID(ctx, req); err != nil {
+		return nil, err
+	}
+
 	slog.Info("request validated for granting",
 		"request_id", p.RequestID,
 		"account_id", req.AccountID,
@@ -91,6 +145,58 @@ func (a *ActionHandler) handleValidate(ctx context.Context, p StepFunctionAction
 	return &ActionResult{Status: "validated", RequestID: p.RequestID}, nil
 }
 
+// repairIdentityStoreUserID re-resolves the requester by email and stores
+// their current Identity Store ID if it changed since the request was made,
+// e.g. because the user was deleted and recreated. A failed lookup keeps the
+// stored ID and leaves any error to the grant step.
+func (a *ActionHandler) repairIdentityStoreUserID(ctx context.Context, req *models.JitRequest) error {
+	if req.RequesterEmail == "" {
+		return nil
+	}
+	userID, err := a.Handler.Identity.LookupUserByEmail(ctx, req.RequesterEmail)
+	if err != nil {
+		slog.Warn("failed to re-resolve identity store user, keeping stored id",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return nil
+	}
+	if userID == "" || userID == req.IdentityStoreUserID {
+		return nil
+	}
+
+	if err := a.Handler.DB.UpdateRequestStatus(ctx, req.RequestID, map[string]interface{}{
+		"identity_store_user_id": userID,
+	}); err != nil {
+		return fmt.Errorf("repair identity store user id: %w", err)
+	}
+	slog.Warn("repaired stale identity store user id",
+		"request_id", req.RequestID,
+		"requester_email", req.RequesterEmail,
+		"old_user_id", req.IdentityStoreUserID,
+		"new_user_id", userID,
+	)
+	req.IdentityStoreUserID = userID
+	return nil
+}
+
+// jitterSource returns a value in [0, 1). Tests replace it for determinism.
+var jitterSource = rand.Float64
+
+// preGrantDelay waits a random time in [0, PreGrantJitter), or until ctx is
+// done.
+func (a *ActionHandler) preGrantDelay(ctx context.Context) error {
+	if a.PreGrantJitter <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(jitterSource() * float64(a.PreGrantJitter))):
+		return nil
+	}
+}
+
 // handleGrant creates the IAM Identity Center account assignment.
 func (a *ActionHandler) handleGrant(ctx context.Context, p StepFunctionActionPayload) (*ActionResult, error) {
 	req, err := a.Handler.DB.GetRequest(ctx, p.RequestID)
@@ -101,31 +207,191 @@ func (a *ActionHandler) handleGrant(ctx context.Context, p StepFunctionActionPay
 		return nil, fmt.Errorf("request %s not found", p.RequestID)
 	}
 
-	// Grant IAM Identity Center access.
-	if err := a.Handler.Identity.GrantAccess(ctx, req.AccountID, req.IdentityStoreUserID); err != nil {
+	// A window that has already closed (clock skew, a stalled execution)
+	// would only be revoked again by the reconciler; refuse it outright.
+	now := a.Handler.now()
+	if end, err := time.Parse(time.RFC3339, req.EndTime); err == nil && !end.After(now) {
+		return a.refuseElapsedGrant(ctx, req, now)
+	}
+
+	if err := a.preGrantDelay(ctx); err != nil {
+		return nil, fmt.Errorf("pre-grant delay: %w", err)
+	}
+
+	// Reserve a slot under the binding's concurrent grant cap before touching
+	// Identity Center; the slot is handed back if the grant does not complete.
+	counted, err := acquireGrantSlot(ctx, a.Handler.DB, req)
+	if err != nil {
+		return nil, fmt.Errorf("reserve grant slot: %w", err)
+	}
+	releaseOnFailure := func() {
+		if counted {
+			ReleaseGrantSlot(ctx, a.Handler.DB, req)
+		}
+	}
+
+	setAssignmentStatus(ctx, a.Handler.DB, p.RequestID, models.AssignmentCreating)
+
+	// Grant IAM Identity Center access. A failed bundle has already been
+	// rolled back; record the per-set outcome before failing the step.
+	psStatus, err := grantPermissionSets(ctx, a.Handler.Identity, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Cut off mid-call, so the assignment may exist: leave it
+			// CREATING, with its slot held, for the cleanup to revoke.
+			return nil, fmt.Errorf("grant access: %w", err)
+		}
+		releaseOnFailure()
+		failed := map[string]interface{}{"assignment_status": models.AssignmentFailed}
+		if psStatus != nil {
+			failed["permission_set_status"] = psStatus
+		}
+		_ = a.Handler.DB.UpdateRequestStatus(ctx, p.RequestID, failed)
 		return nil, fmt.Errorf("grant access: %w", err)
 	}
 
 	// Update status to GRANTED.
-	now := time.Now().UTC()
 	updates := map[string]interface{}{
-		"status":     models.StatusGranted,
-		"grant_time": now.Format(time.RFC3339),
+		"status":            models.StatusGranted,
+		"grant_time":        now.Format(time.RFC3339),
+		"assignment_status": models.AssignmentCreated,
+	}
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
 	}
-	if err := a.Handler.DB.ConditionalUpdateStatus(ctx, p.RequestID, models.StatusApproved, updates); err != nil {
+	if err := TransitionStatus(ctx, a.Handler.DB, p.RequestID, models.StatusApproved, updates); err != nil {
+		// The assignment exists, so its slot stays held until the grant
+		// error handler (or the stalled-approval pass) revokes it.
 		return nil, fmt.Errorf("update to GRANTED: %w", err)
 	}
 
 	// Audit the grant.
 	_ = a.Handler.Audit.Log(ctx, p.RequestID, models.EventGranted, req.AccountID, req.ChannelID,
-		"", "system", nil)
+		a.actor, nil)
 
 	slog.Info("access granted via step function",
 		"request_id", p.RequestID,
 		"account_id", req.AccountID,
 		"requester", req.RequesterEmail,
 	)
-	return &ActionResult{Status: "granted", RequestID: p.RequestID}, nil
+	return &ActionResult{Status: "granted", RequestID: p.RequestID, AssignmentStatus: models.AssignmentCreated}, nil
+}
+
+// Inline grants run inside the API invocation, so they are bounded by its
+// deadline. A grant is not started with less than inlineGrantMinBudget left,
+// and is cut off inlineGrantReserve before the deadline so the invocation is
+// not killed mid-call. Tests shorten them.
+var (
+	inlineGrantMinBudget = 15 * time.Second
+	inlineGrantReserve   = 5 * time.Second
+)
+
+// grantInline runs the validate, grant and notify_granted steps of the
+// workflow in-process, for deployments that revoke via the reconciler instead
+// of Step Functions. A failed step is handled as the state machine's grant
+// error branch would handle it, except a grant cut off at the deadline: its
+// assignment may already exist, so it is left APPROVED with assignment_status
+// CREATING for the reconciler's stalled-approval pass to revoke and fail.
+func (h *Handler) grantInline(ctx context.Context, requestID string) {
+	a := &ActionHandler{Handler: h, actor: models.SystemActor}
+	p := StepFunctionActionPayload{RequestID: requestID}
+
+	grantCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left < inlineGrantMinBudget {
+			a.failInlineGrant(ctx, p, fmt.Errorf("only %s left before the invocation deadline, grant not started", left.Round(time.Second)))
+			return
+		}
+		var cancel context.CancelFunc
+		grantCtx, cancel = context.WithDeadline(ctx, deadline.Add(-inlineGrantReserve))
+		defer cancel()
+	}
+
+	if _, err := a.handleValidate(grantCtx, p); err != nil {
+		a.failInlineGrant(ctx, p, err)
+		return
+	}
+	result, err := a.handleGrant(grantCtx, p)
+	if err != nil {
+		if grantCtx.Err() != nil && ctx.Err() == nil {
+			slog.Error("inline grant cut off at the invocation deadline, leaving it to the reconciler",
+				"request_id", requestID,
+				"error", err,
+			)
+			return
+		}
+		a.failInlineGrant(ctx, p, err)
+		return
+	}
+	if result.Status != "granted" {
+		return
+	}
+	_, _ = a.handleNotifyGranted(ctx, p)
+}
+
+// failInlineGrant records an in-process grant failure via handleGrantError.
+func (a *ActionHandler) failInlineGrant(ctx context.Context, p StepFunctionActionPayload, cause error) {
+	slog.Error("inline grant failed",
+		"request_id", p.RequestID,
+		"error", cause,
+	)
+	p.Error, _ = json.Marshal(map[string]string{"Error": "InlineGrantFailed", "Cause": cause.Error()})
+	if _, err := a.handleGrantError(ctx, p); err != nil {
+		slog.Error("failed to record inline grant error",
+			"request_id", p.RequestID,
+			"error", err,
+		)
+	}
+}
+
+// refuseElapsedGrant marks an approved request whose end_time has already
+// passed as ERROR without granting access. The "not_granted" result ends the
+// execution instead of routing it through the wait and revoke steps.
+func (a *ActionHandler) refuseElapsedGrant(ctx context.Context, req *models.JitRequest, now time.Time) (*ActionResult, error) {
+	info := models.ErrorInfo{
+		Phase:     models.ErrorPhaseGrant,
+		Code:      "GrantWindowElapsed",
+		Message:   fmt.Sprintf("end_time %s is not after grant time %s; access was not granted", req.EndTime, now.Format(time.RFC3339)),
+		Timestamp: now.Format(time.RFC3339),
+	}
+
+	if err := TransitionStatus(ctx, a.Handler.DB, req.RequestID, models.StatusApproved, ErrorUpdates(info)); err != nil {
+		return nil, fmt.Errorf("update to ERROR: %w", err)
+	}
+
+	_ = a.Handler.Audit.Log(ctx, req.RequestID, models.EventError, req.AccountID, req.ChannelID,
+		a.actor,
+		errorAuditDetails(info),
+	)
+	_ = a.Handler.Webhook.Notify(ctx, models.WebhookPayload{
+		RequestID: req.RequestID,
+		Status:    models.StatusError,
+		AccountID: req.AccountID,
+		ChannelID: req.ChannelID,
+		Actor:     "system",
+		Details:   requestDetails(errorAuditDetails(info), req),
+	})
+
+	slog.Warn("grant window already elapsed",
+		"request_id", req.RequestID,
+		"end_time", req.EndTime,
+	)
+	return &ActionResult{Status: "not_granted", RequestID: req.RequestID, Message: info.Message}, nil
+}
+
+// setAssignmentStatus records the assignment state before an SSO Admin call,
+// so a step that dies mid-call leaves it CREATING or DELETING. Best-effort:
+// the step's outcome is recorded regardless.
+func setAssignmentStatus(ctx context.Context, db DBStore, requestID, status string) {
+	if err := db.UpdateRequestStatus(ctx, requestID, map[string]interface{}{
+		"assignment_status": status,
+	}); err != nil {
+		slog.Warn("failed to record assignment status",
+			"request_id", requestID,
+			"assignment_status", status,
+			"error", err,
+		)
+	}
 }
 
 // handleNotifyGranted sends a webhook notification that access has been granted.
@@ -144,10 +410,12 @@ func (a *ActionHandler) handleNotifyGranted(ctx context.Context, p StepFunctionA
 		AccountID: req.AccountID,
 		ChannelID: req.ChannelID,
 		Actor:     "system",
-		Details: map[string]string{
+		Details: requestDetails(map[string]string{
 			"requester_email":  req.RequesterEmail,
-			"duration_minutes": fmt.Sprintf("%d", req.RequestedDurationMinutes),
-		},
+			"duration_minutes": fmt.Sprintf("%d", req.DurationMinutes()),
+			// Lets the plugin expire a stale "granted" card if it misses the revoke webhook.
+			"active_until": req.EndTime,
+		}, req),
 	})
 
 	slog.Info("grant notification sent",
@@ -175,35 +443,51 @@ func (a *ActionHandler) handleRevoke(ctx context.Context, p StepFunctionActionPa
 		return &ActionResult{Status: req.Status, RequestID: p.RequestID, Message: "already revoked or expired"}, nil
 	}
 
+	setAssignmentStatus(ctx, a.Handler.DB, p.RequestID, models.AssignmentDeleting)
+
 	// Revoke IAM Identity Center access.
-	if err := a.Handler.Identity.RevokeAccess(ctx, req.AccountID, req.IdentityStoreUserID); err != nil {
+	psStatus, err := RevokePermissionSets(ctx, a.Handler.Identity, req)
+	if err != nil {
+		failed := map[string]interface{}{"assignment_status": models.AssignmentFailed}
+		if psStatus != nil {
+			failed["permission_set_status"] = psStatus
+		}
+		_ = a.Handler.DB.UpdateRequestStatus(ctx, p.RequestID, failed)
 		return nil, fmt.Errorf("revoke access: %w", err)
 	}
 
 	// Update status to EXPIRED (this is an automatic expiration, not a manual revoke).
-	now := time.Now().UTC()
+	now := a.Handler.now()
 	updates := map[string]interface{}{
-		"status":     models.StatusExpired,
-		"expired_at": now.Format(time.RFC3339),
+		"status":            models.StatusExpired,
+		"expired_at":        now.Format(time.RFC3339),
+		"assignment_status": models.AssignmentDeleted,
 	}
-	if err := a.Handler.DB.ConditionalUpdateStatus(ctx, p.RequestID, models.StatusGranted, updates); err != nil {
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
+	}
+	if err := TransitionStatus(ctx, a.Handler.DB, p.RequestID, models.StatusGranted, updates); err != nil {
 		// May have been revoked by break-glass in the meantime — not a fatal error.
 		slog.Warn("conditional update to EXPIRED failed, may have been revoked already",
 			"request_id", p.RequestID,
 			"error", err,
 		)
-		return &ActionResult{Status: "already_handled", RequestID: p.RequestID, Message: "conditional update failed, likely already revoked"}, nil
+		_ = a.Handler.DB.UpdateRequestStatus(ctx, p.RequestID, map[string]interface{}{
+			"assignment_status": models.AssignmentDeleted,
+		})
+		return &ActionResult{Status: "already_handled", RequestID: p.RequestID, Message: "conditional update failed, likely already revoked", AssignmentStatus: models.AssignmentDeleted}, nil
 	}
+	ReleaseGrantSlot(ctx, a.Handler.DB, req)
 
 	// Audit the expiration.
 	_ = a.Handler.Audit.Log(ctx, p.RequestID, models.EventExpired, req.AccountID, req.ChannelID,
-		"", "system", nil)
+		a.actor, nil)
 
 	slog.Info("access revoked via step function",
 		"request_id", p.RequestID,
 		"account_id", req.AccountID,
 	)
-	return &ActionResult{Status: "expired", RequestID: p.RequestID}, nil
+	return &ActionResult{Status: "expired", RequestID: p.RequestID, AssignmentStatus: models.AssignmentDeleted}, nil
 }
 
 // handleNotifyRevoked sends a webhook notification that access has been revoked/expired.
@@ -222,6 +506,7 @@ func (a *ActionHandler) handleNotifyRevoked(ctx context.Context, p StepFunctionA
 		AccountID: req.AccountID,
 		ChannelID: req.ChannelID,
 		Actor:     "system",
+		Details:   requestDetails(nil, req),
 	})
 
 	slog.Info("revoke notification sent",
@@ -230,7 +515,10 @@ func (a *ActionHandler) handleNotifyRevoked(ctx context.Context, p StepFunctionA
 	return &ActionResult{Status: "notified", RequestID: p.RequestID}, nil
 }
 
-// handleGrantError marks the request as ERROR when the grant step fails.
+// handleGrantError marks the request as ERROR when the grant step fails. A
+// request that still holds a grant slot, because it reached GRANTED or was
+// cut off while creating its assignment, has the assignment revoked so the
+// slot can be handed back.
 func (a *ActionHandler) handleGrantError(ctx context.Context, p StepFunctionActionPayload) (*ActionResult, error) {
 	req, err := a.Handler.DB.GetRequest(ctx, p.RequestID)
 	if err != nil {
@@ -240,30 +528,31 @@ func (a *ActionHandler) handleGrantError(ctx context.Context, p StepFunctionActi
 		return nil, fmt.Errorf("request %s not found", p.RequestID)
 	}
 
-	errorDetail := "grant step failed"
-	if p.Error != nil {
-		errorDetail = string(p.Error)
-	}
+	info := stepErrorInfo(models.ErrorPhaseGrant, p.Error, "grant step failed", a.Handler.now())
+	errorDetail := info.String()
 
 	// Update to ERROR status.
-	updates := map[string]interface{}{
-		"status":        models.StatusError,
-		"error_details": errorDetail,
-	}
+	updates := ErrorUpdates(info)
 	// Try from APPROVED (grant may not have updated status yet).
-	if err := a.Handler.DB.ConditionalUpdateStatus(ctx, p.RequestID, models.StatusApproved, updates); err != nil {
+	holdsSlot := req.AssignmentStatus == models.AssignmentCreating || req.AssignmentStatus == models.AssignmentCreated
+	if err := TransitionStatus(ctx, a.Handler.DB, p.RequestID, models.StatusApproved, updates); err != nil {
 		slog.Warn("conditional update to ERROR from APPROVED failed, trying from GRANTED",
 			"request_id", p.RequestID,
 			"error", err,
 		)
 		// Also try from GRANTED in case the grant partially succeeded.
-		_ = a.Handler.DB.ConditionalUpdateStatus(ctx, p.RequestID, models.StatusGranted, updates)
+		holdsSlot = TransitionStatus(ctx, a.Handler.DB, p.RequestID, models.StatusGranted, updates) == nil
+	}
+
+	details := errorAuditDetails(info)
+	if holdsSlot {
+		details["assignment_revoked"] = strconv.FormatBool(a.Handler.revokeFailedGrant(ctx, req))
 	}
 
 	// Audit the error.
 	_ = a.Handler.Audit.Log(ctx, p.RequestID, models.EventError, req.AccountID, req.ChannelID,
-		"", "system",
-		map[string]string{"error": errorDetail, "phase": "grant"},
+		a.actor,
+		details,
 	)
 
 	// Notify channel of the failure.
@@ -273,17 +562,21 @@ func (a *ActionHandler) handleGrantError(ctx context.Context, p StepFunctionActi
 		AccountID: req.AccountID,
 		ChannelID: req.ChannelID,
 		Actor:     "system",
-		Details:   map[string]string{"error": errorDetail, "phase": "grant"},
+		Details:   requestDetails(errorAuditDetails(info), req),
 	})
 
 	slog.Error("grant error handled",
 		"request_id", p.RequestID,
+		"phase", info.Phase,
+		"code", info.Code,
 		"error_detail", errorDetail,
 	)
 	return &ActionResult{Status: "error_handled", RequestID: p.RequestID, Message: errorDetail}, nil
 }
 
-// handleRevokeError marks the request as ERROR when the revoke step fails.
+// handleRevokeError marks the request as ERROR when the revoke step fails,
+// then tries the revoke once more so that, if it now succeeds, the grant
+// slot can be handed back.
 func (a *ActionHandler) handleRevokeError(ctx context.Context, p StepFunctionActionPayload) (*ActionResult, error) {
 	req, err := a.Handler.DB.GetRequest(ctx, p.RequestID)
 	if err != nil {
@@ -293,22 +586,20 @@ func (a *ActionHandler) handleRevokeError(ctx context.Context, p StepFunctionAct
 		return nil, fmt.Errorf("request %s not found", p.RequestID)
 	}
 
-	errorDetail := "revoke step failed"
-	if p.Error != nil {
-		errorDetail = string(p.Error)
-	}
+	info := stepErrorInfo(models.ErrorPhaseRevoke, p.Error, "revoke step failed", a.Handler.now())
+	errorDetail := info.String()
 
 	// Update to ERROR status from GRANTED.
-	updates := map[string]interface{}{
-		"status":        models.StatusError,
-		"error_details": errorDetail,
+	updates := ErrorUpdates(info)
+	details := errorAuditDetails(info)
+	if err := TransitionStatus(ctx, a.Handler.DB, p.RequestID, models.StatusGranted, updates); err == nil {
+		details["assignment_revoked"] = strconv.FormatBool(a.Handler.revokeFailedGrant(ctx, req))
 	}
-	_ = a.Handler.DB.ConditionalUpdateStatus(ctx, p.RequestID, models.StatusGranted, updates)
 
 	// Audit the error.
 	_ = a.Handler.Audit.Log(ctx, p.RequestID, models.EventError, req.AccountID, req.ChannelID,
-		"", "system",
-		map[string]string{"error": errorDetail, "phase": "revoke"},
+		a.actor,
+		details,
 	)
 
 	// Notify channel of the failure — reconciler will retry.
@@ -318,11 +609,13 @@ func (a *ActionHandler) handleRevokeError(ctx context.Context, p StepFunctionAct
 		AccountID: req.AccountID,
 		ChannelID: req.ChannelID,
 		Actor:     "system",
-		Details:   map[string]string{"error": errorDetail, "phase": "revoke"},
+		Details:   requestDetails(errorAuditDetails(info), req),
 	})
 
 	slog.Error("revoke error handled",
 		"request_id", p.RequestID,
+		"phase", info.Phase,
+		"code", info.Code,
 		"error_detail", errorDetail,
 	)
 	return &ActionResult{Status: "error_handled", RequestID: p.RequestID, Message: errorDetail}, nil
@@ -4,8 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/identity"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
@@ -46,15 +53,94 @@ func TestActionHandle_InvalidJSON(t *testing.T) {
 	}
 }
 
+func newSignedActionHandler() (*ActionHandler, *mockDB, *auth.ActionSigner) {
+	ah, db, _, _, _ := newTestActionHandler()
+	signer := auth.NewActionSigner(map[string]string{"k1": "action-secret"})
+	ah.Signer = signer
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusApproved,
+	}
+	return ah, db, signer
+}
+
+func TestActionHandle_SignedActionAccepted(t *testing.T) {
+	ah, _, signer := newSignedActionHandler()
+	sig, err := signer.SignAction("validate", "req-1")
+	if err != nil {
+		t.Fatalf("SignAction failed: %v", err)
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "validate", RequestID: "req-1", Signature: sig})
+	result, err := ah.Handle(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("expected signed action to be accepted, got: %v", err)
+	}
+	if result.Status != "validated" {
+		t.Errorf("expected validated, got %s", result.Status)
+	}
+}
+
+func TestActionHandle_RejectsBadSignatures(t *testing.T) {
+	_, _, signer := newSignedActionHandler()
+	validateSig, _ := signer.SignAction("validate", "req-1")
+	forged, _ := auth.NewActionSigner(map[string]string{"k1": "other-secret"}).SignAction("validate", "req-1")
+
+	tests := []struct {
+		name      string
+		action    string
+		signature string
+	}{
+		{"missing", "validate", ""},
+		{"wrong key", "validate", forged},
+		{"signed for another action", "grant", validateSig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah, db, _ := newSignedActionHandler()
+
+			raw := marshalPayload(t, StepFunctionActionPayload{Action: tt.action, RequestID: "req-1", Signature: tt.signature})
+			_, err := ah.Handle(context.Background(), raw)
+			if err == nil || !strings.Contains(err.Error(), "rejected") {
+				t.Fatalf("expected action to be rejected, got: %v", err)
+			}
+			if db.requests["req-1"].Status != models.StatusApproved {
+				t.Errorf("expected request untouched, got status %s", db.requests["req-1"].Status)
+			}
+		})
+	}
+}
+
+func TestActionHandle_UnsignedAcceptedWhenSigningDisabled(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusApproved,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "validate", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("expected unsigned action accepted with signing disabled, got: %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // handleValidate tests
 // ---------------------------------------------------------------------------
 
 func TestHandleValidate_Success(t *testing.T) {
 	ah, db, _, _, _ := newTestActionHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
 	db.requests["req-1"] = &models.JitRequest{
 		RequestID: "req-1",
 		AccountID: "acct1",
+		ChannelID: "ch1",
 		Status:    models.StatusApproved,
 	}
 
@@ -72,6 +158,57 @@ func TestHandleValidate_Success(t *testing.T) {
 	}
 }
 
+func TestHandleValidate_IdentityStoreUserIDUnchanged(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		RequesterEmail:      "user@example.com",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusApproved,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "validate", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := db.requests["req-1"].IdentityStoreUserID; got != "uid-123" {
+		t.Errorf("expected identity store user id kept, got %s", got)
+	}
+}
+
+func TestHandleValidate_RepairsStaleIdentityStoreUserID(t *testing.T) {
+	ah, db, id, _, _ := newTestActionHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		RequesterEmail:      "user@example.com",
+		IdentityStoreUserID: "uid-old",
+		Status:              models.StatusApproved,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "validate", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := db.requests["req-1"].IdentityStoreUserID; got != "uid-123" {
+		t.Fatalf("expected identity store user id repaired to uid-123, got %s", got)
+	}
+
+	// The grant then assigns access to the repaired user.
+	grant := marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), grant); err != nil {
+		t.Fatalf("grant failed: %v", err)
+	}
+	if id.granted != 1 {
+		t.Errorf("expected one grant, got %d", id.granted)
+	}
+}
+
 func TestHandleValidate_WrongStatus(t *testing.T) {
 	ah, db, _, _, _ := newTestActionHandler()
 	db.requests["req-1"] = &models.JitRequest{
@@ -90,6 +227,26 @@ func TestHandleValidate_WrongStatus(t *testing.T) {
 	}
 }
 
+func TestHandleValidate_BindingRemoved(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusApproved,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{
+		Action:    "validate",
+		RequestID: "req-1",
+	})
+
+	_, err := ah.Handle(context.Background(), raw)
+	if err == nil || !strings.Contains(err.Error(), "no binding found") {
+		t.Fatalf("expected validation to fail for an unbound account, got %v", err)
+	}
+}
+
 func TestHandleValidate_NotFound(t *testing.T) {
 	ah, _, _, _, _ := newTestActionHandler()
 
@@ -134,7 +291,10 @@ func TestHandleGrant_Success(t *testing.T) {
 		t.Errorf("expected GRANTED status in DB, got %s", db.requests["req-1"].Status)
 	}
 	if len(au.events) != 1 || au.events[0].eventType != models.EventGranted {
-		t.Errorf("expected GRANTED audit event")
+		t.Fatalf("expected GRANTED audit event")
+	}
+	if au.events[0].actorType != models.ActorStepFn {
+		t.Errorf("expected workflow grant audited as %s, got %q", models.ActorStepFn, au.events[0].actorType)
 	}
 }
 
@@ -160,6 +320,391 @@ func TestHandleGrant_IdentityError(t *testing.T) {
 	}
 }
 
+func TestHandleGrant_AssignmentStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		grantErr error
+		want     []string
+	}{
+		{"success", nil, []string{models.AssignmentCreating, models.AssignmentCreated}},
+		{"failure", fmt.Errorf("account assignment creation failed: quota"), []string{models.AssignmentCreating, models.AssignmentFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah, db, id, _, _ := newTestActionHandler()
+			id.grantErr = tt.grantErr
+			db.requests["req-1"] = &models.JitRequest{
+				RequestID:           "req-1",
+				AccountID:           "acct1",
+				ChannelID:           "ch1",
+				IdentityStoreUserID: "uid-123",
+				Status:              models.StatusApproved,
+			}
+
+			result, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+				Action:    "grant",
+				RequestID: "req-1",
+			}))
+			if (err != nil) != (tt.grantErr != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(db.assignmentStatuses, ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("expected assignment statuses %v, got %v", tt.want, db.assignmentStatuses)
+			}
+			final := tt.want[len(tt.want)-1]
+			if db.requests["req-1"].AssignmentStatus != final {
+				t.Errorf("expected stored assignment status %s, got %s", final, db.requests["req-1"].AssignmentStatus)
+			}
+			if result != nil && result.AssignmentStatus != final {
+				t.Errorf("expected result assignment status %s, got %s", final, result.AssignmentStatus)
+			}
+		})
+	}
+}
+
+func TestHandleGrant_PreGrantJitter(t *testing.T) {
+	orig := jitterSource
+	defer func() { jitterSource = orig }()
+	jitterSource = func() float64 { return 0.5 }
+
+	ah, db, id, _, _ := newTestActionHandler()
+	ah.PreGrantJitter = 40 * time.Millisecond
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusApproved,
+	}
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})
+
+	// A cancelled invocation gives up before touching Identity Center.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ah.Handle(ctx, raw); err == nil || !strings.Contains(err.Error(), "pre-grant delay") {
+		t.Fatalf("expected pre-grant delay to observe cancellation, got: %v", err)
+	}
+	if id.granted != 0 || db.requests["req-1"].Status != models.StatusApproved {
+		t.Fatalf("expected no grant after cancellation, got %d grants, status %s", id.granted, db.requests["req-1"].Status)
+	}
+
+	start := time.Now()
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected the grant to wait about 20ms, took %v", elapsed)
+	}
+	if db.requests["req-1"].Status != models.StatusGranted {
+		t.Errorf("expected GRANTED after the delay, got %s", db.requests["req-1"].Status)
+	}
+}
+
+func TestHandleGrant_EndTimeAlreadyPassed(t *testing.T) {
+	ah, db, id, wh, au := newTestActionHandler()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ah.Handler.Clock = clock.NewMock(now)
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusApproved,
+		EndTime:             now.Add(-time.Minute).Format(time.RFC3339),
+		PermissionSetARNs:   []string{"ps-readonly"},
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})
+	result, err := ah.Handle(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "not_granted" {
+		t.Errorf("expected not_granted, got %s", result.Status)
+	}
+	if len(id.grantedPS) != 0 {
+		t.Errorf("expected no access granted, got %v", id.grantedPS)
+	}
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError {
+		t.Errorf("expected ERROR, got %s", req.Status)
+	}
+	if !strings.Contains(req.ErrorDetails, "end_time") {
+		t.Errorf("expected error detail about end_time, got %q", req.ErrorDetails)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 0 {
+		t.Errorf("expected no grant slot taken, got %d", got)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventError {
+		t.Errorf("expected one ERROR audit event, got %+v", au.events)
+	}
+	if len(wh.payloads) != 1 || wh.payloads[0].Status != models.StatusError {
+		t.Errorf("expected one ERROR webhook, got %+v", wh.payloads)
+	}
+}
+
+func TestHandleGrant_PermissionSetBundle(t *testing.T) {
+	ah, db, id, _, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		Status:              models.StatusApproved,
+		IdentityStoreUserID: "uid-123",
+		PermissionSetARNs:   []string{"ps-readonly", "ps-breakglass"},
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})
+	result, err := ah.Handle(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "granted" {
+		t.Errorf("expected granted, got %s", result.Status)
+	}
+	if len(id.grantedPS) != 2 {
+		t.Errorf("expected 2 permission sets granted, got %v", id.grantedPS)
+	}
+	req := db.requests["req-1"]
+	if req.Status != models.StatusGranted {
+		t.Errorf("expected GRANTED, got %s", req.Status)
+	}
+	for _, arn := range []string{"ps-readonly", "ps-breakglass"} {
+		if req.PermissionSetStatus[arn] != models.PermissionSetGranted {
+			t.Errorf("expected %s GRANTED, got %q", arn, req.PermissionSetStatus[arn])
+		}
+	}
+}
+
+func TestHandleGrant_PermissionSetPartialFailureRollsBack(t *testing.T) {
+	ah, db, id, _, _ := newTestActionHandler()
+	id.grantPSErr = map[string]error{"ps-breakglass": fmt.Errorf("ConflictException")}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		Status:              models.StatusApproved,
+		IdentityStoreUserID: "uid-123",
+		PermissionSetARNs:   []string{"ps-readonly", "ps-breakglass", "ps-network"},
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})
+	_, err := ah.Handle(context.Background(), raw)
+	if err == nil {
+		t.Fatal("expected error for partial grant failure")
+	}
+
+	if len(id.grantedPS) != 1 || id.grantedPS[0] != "ps-readonly" {
+		t.Errorf("expected only ps-readonly granted before failure, got %v", id.grantedPS)
+	}
+	if len(id.revokedPS) != 1 || id.revokedPS[0] != "ps-readonly" {
+		t.Errorf("expected ps-readonly rolled back, got %v", id.revokedPS)
+	}
+
+	req := db.requests["req-1"]
+	if req.Status != models.StatusApproved {
+		t.Errorf("expected status to remain APPROVED for the error handler, got %s", req.Status)
+	}
+	if req.PermissionSetStatus["ps-readonly"] != models.PermissionSetRolledBack {
+		t.Errorf("expected ps-readonly ROLLED_BACK, got %q", req.PermissionSetStatus["ps-readonly"])
+	}
+	if req.PermissionSetStatus["ps-breakglass"] != models.PermissionSetFailed {
+		t.Errorf("expected ps-breakglass FAILED, got %q", req.PermissionSetStatus["ps-breakglass"])
+	}
+	if _, ok := req.PermissionSetStatus["ps-network"]; ok {
+		t.Error("expected ps-network not to be attempted")
+	}
+}
+
+func TestHandleRevoke_PermissionSetBundle(t *testing.T) {
+	ah, db, id, _, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+		PermissionSetARNs:   []string{"ps-readonly", "ps-breakglass"},
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "revoke", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(id.revokedPS) != 2 {
+		t.Errorf("expected 2 permission sets revoked, got %v", id.revokedPS)
+	}
+	if db.requests["req-1"].Status != models.StatusExpired {
+		t.Errorf("expected EXPIRED, got %s", db.requests["req-1"].Status)
+	}
+}
+
+func seedGrantCap(db *mockDB, limit, active int) {
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxConcurrentGrants: limit}
+	db.activeGrants = map[string]int{models.GrantCounterKey("ch1", "acct1"): active}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusApproved,
+	}
+}
+
+func TestHandleGrant_IncrementsAndRevokeDecrements(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	seedGrantCap(db, 2, 0)
+	key := models.GrantCounterKey("ch1", "acct1")
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})); err != nil {
+		t.Fatalf("grant: unexpected error: %v", err)
+	}
+	if db.activeGrants[key] != 1 {
+		t.Fatalf("expected 1 active grant after grant, got %d", db.activeGrants[key])
+	}
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{Action: "revoke", RequestID: "req-1"})); err != nil {
+		t.Fatalf("revoke: unexpected error: %v", err)
+	}
+	if db.activeGrants[key] != 0 {
+		t.Errorf("expected 0 active grants after revoke, got %d", db.activeGrants[key])
+	}
+
+	// A repeated revoke is already handled and must not decrement again.
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{Action: "revoke", RequestID: "req-1"})); err != nil {
+		t.Fatalf("repeat revoke: unexpected error: %v", err)
+	}
+	if db.activeGrants[key] != 0 {
+		t.Errorf("expected counter to stay at 0, got %d", db.activeGrants[key])
+	}
+}
+
+func TestHandleGrant_CapRejects(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	seedGrantCap(db, 1, 1)
+
+	_, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"}))
+	if err == nil || !strings.Contains(err.Error(), "concurrent grant limit") {
+		t.Fatalf("expected concurrent grant limit error, got %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected request to stay APPROVED, got %s", db.requests["req-1"].Status)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 1 {
+		t.Errorf("expected counter to stay at 1, got %d", got)
+	}
+}
+
+func TestHandleGrant_FailureReleasesSlot(t *testing.T) {
+	ah, db, id, _, _ := newTestActionHandler()
+	seedGrantCap(db, 1, 0)
+	id.grantErr = fmt.Errorf("SSO error")
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{Action: "grant", RequestID: "req-1"})); err == nil {
+		t.Fatal("expected grant error")
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 0 {
+		t.Errorf("expected slot to be released after a failed grant, got %d", got)
+	}
+}
+
+func TestHandleGrantError_CutOffGrantReleasesSlot(t *testing.T) {
+	ah, db, _, _, au := newTestActionHandler()
+	seedGrantCap(db, 1, 1)
+	db.requests["req-1"].AssignmentStatus = models.AssignmentCreating
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_grant_error",
+		RequestID: "req-1",
+		Error:     json.RawMessage(`"States.Timeout"`),
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError || req.AssignmentStatus != models.AssignmentDeleted {
+		t.Errorf("expected ERROR with the assignment deleted, got %s/%s", req.Status, req.AssignmentStatus)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 0 {
+		t.Errorf("expected the slot released, got %d", got)
+	}
+	if au.events[0].details["assignment_revoked"] != "true" {
+		t.Errorf("expected assignment_revoked in audit details, got %+v", au.events[0].details)
+	}
+}
+
+func TestHandleRevokeError_ReleasesSlotOnceRevoked(t *testing.T) {
+	ah, db, _, _, au := newTestActionHandler()
+	seedGrantCap(db, 1, 1)
+	db.requests["req-1"].Status = models.StatusGranted
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_revoke_error",
+		RequestID: "req-1",
+		Error:     json.RawMessage(`"DeleteAccountAssignment failed"`),
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError || req.AssignmentStatus != models.AssignmentDeleted {
+		t.Errorf("expected ERROR with the assignment deleted, got %s/%s", req.Status, req.AssignmentStatus)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 0 {
+		t.Errorf("expected the slot released, got %d", got)
+	}
+	if au.events[0].details["assignment_revoked"] != "true" {
+		t.Errorf("expected assignment_revoked in audit details, got %+v", au.events[0].details)
+	}
+}
+
+func TestHandleRevokeError_KeepsSlotWhileAssignmentRemains(t *testing.T) {
+	ah, db, id, _, au := newTestActionHandler()
+	seedGrantCap(db, 1, 1)
+	db.requests["req-1"].Status = models.StatusGranted
+	id.revokeErr = fmt.Errorf("SSO error")
+
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_revoke_error",
+		RequestID: "req-1",
+		Error:     json.RawMessage(`"DeleteAccountAssignment failed"`),
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError || req.AssignmentStatus != models.AssignmentFailed {
+		t.Errorf("expected ERROR with the assignment failed, got %s/%s", req.Status, req.AssignmentStatus)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 1 {
+		t.Errorf("expected the slot kept while access may remain, got %d", got)
+	}
+	if au.events[0].details["assignment_revoked"] != "false" {
+		t.Errorf("expected assignment_revoked false in audit details, got %+v", au.events[0].details)
+	}
+}
+
+func TestAcquireGrantSlot_ConcurrentCap(t *testing.T) {
+	db := newMockDB()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxConcurrentGrants: 3}
+	req := &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1"}
+
+	var wg sync.WaitGroup
+	var acquired atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := acquireGrantSlot(context.Background(), db, req); ok && err == nil {
+				acquired.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if acquired.Load() != 3 {
+		t.Errorf("expected exactly 3 slots acquired, got %d", acquired.Load())
+	}
+}
+
 // ---------------------------------------------------------------------------
 // handleNotifyGranted tests
 // ---------------------------------------------------------------------------
@@ -192,6 +737,51 @@ func TestHandleNotifyGranted_Success(t *testing.T) {
 	}
 }
 
+func TestHandleNotifyGranted_ActiveUntil(t *testing.T) {
+	ah, db, _, wh, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusGranted,
+		EndTime:   "2026-01-02T15:04:05Z",
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "notify_granted", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(wh.payloads) != 1 {
+		t.Fatalf("expected 1 webhook payload, got %d", len(wh.payloads))
+	}
+	got, ok := wh.payloads[0].Details["active_until"]
+	if !ok {
+		t.Fatal("expected active_until detail")
+	}
+	if got != "2026-01-02T15:04:05Z" {
+		t.Errorf("expected active_until to equal end_time, got %q", got)
+	}
+}
+
+func TestHandleNotifyGranted_IncludesPriority(t *testing.T) {
+	ah, db, _, wh, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusGranted,
+		Priority:  models.PriorityHigh,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{Action: "notify_granted", RequestID: "req-1"})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := wh.payloads[0].Details["priority"]; got != models.PriorityHigh {
+		t.Errorf("expected priority detail %q, got %q", models.PriorityHigh, got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // handleRevoke tests
 // ---------------------------------------------------------------------------
@@ -226,6 +816,49 @@ func TestHandleRevoke_Success(t *testing.T) {
 	}
 }
 
+func TestHandleRevoke_AssignmentStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		revokeErr error
+		want      []string
+	}{
+		{"success", nil, []string{models.AssignmentDeleting, models.AssignmentDeleted}},
+		{"failure", fmt.Errorf("account assignment deletion failed: denied"), []string{models.AssignmentDeleting, models.AssignmentFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah, db, id, _, _ := newTestActionHandler()
+			id.revokeErr = tt.revokeErr
+			db.requests["req-1"] = &models.JitRequest{
+				RequestID:           "req-1",
+				AccountID:           "acct1",
+				ChannelID:           "ch1",
+				IdentityStoreUserID: "uid-123",
+				Status:              models.StatusGranted,
+				AssignmentStatus:    models.AssignmentCreated,
+			}
+
+			result, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+				Action:    "revoke",
+				RequestID: "req-1",
+			}))
+			if (err != nil) != (tt.revokeErr != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(db.assignmentStatuses, ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("expected assignment statuses %v, got %v", tt.want, db.assignmentStatuses)
+			}
+			final := tt.want[len(tt.want)-1]
+			if db.requests["req-1"].AssignmentStatus != final {
+				t.Errorf("expected stored assignment status %s, got %s", final, db.requests["req-1"].AssignmentStatus)
+			}
+			if result != nil && result.AssignmentStatus != final {
+				t.Errorf("expected result assignment status %s, got %s", final, result.AssignmentStatus)
+			}
+		})
+	}
+}
+
 func TestHandleRevoke_AlreadyRevoked(t *testing.T) {
 	ah, db, _, _, _ := newTestActionHandler()
 	db.requests["req-1"] = &models.JitRequest{
@@ -314,6 +947,86 @@ func TestHandleGrantError_Success(t *testing.T) {
 	}
 }
 
+func TestHandleGrantError_RecordsErrorInfo(t *testing.T) {
+	ah, db, _, wh, au := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusApproved,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_grant_error",
+		RequestID: "req-1",
+		Error:     json.RawMessage(`{"Error":"States.Timeout","Cause":"grant task timed out"}`),
+	})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info := db.requests["req-1"].ErrorInfo
+	if info == nil {
+		t.Fatal("expected error info recorded")
+	}
+	if info.Phase != models.ErrorPhaseGrant || info.Code != "States.Timeout" || info.Message != "grant task timed out" {
+		t.Errorf("expected grant phase, code and cause captured, got %+v", info)
+	}
+	if info.Timestamp == "" {
+		t.Error("expected a timestamp")
+	}
+	if got := db.requests["req-1"].ErrorDetails; got != "States.Timeout: grant task timed out" {
+		t.Errorf("expected readable error_details, got %q", got)
+	}
+	if au.events[0].details["code"] != "States.Timeout" || wh.payloads[0].Details["phase"] != models.ErrorPhaseGrant {
+		t.Errorf("expected code and phase in audit and webhook details, got %+v and %+v",
+			au.events[0].details, wh.payloads[0].Details)
+	}
+}
+
+func TestHandleGrantError_PreservesFailureReason(t *testing.T) {
+	const reason = "PermissionSet is not provisioned in the account"
+	ah, db, id, wh, au := newTestActionHandler()
+	id.grantErr = fmt.Errorf("GrantAccess failed after retries: %w",
+		&identity.AssignmentFailedError{Operation: "creation", Reason: reason})
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusApproved,
+	}
+
+	_, grantErr := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "grant",
+		RequestID: "req-1",
+	}))
+	if grantErr == nil {
+		t.Fatal("expected the grant to fail")
+	}
+
+	// The Catch relays the Lambda error object as the cause.
+	cause, _ := json.Marshal(map[string]string{"errorMessage": grantErr.Error(), "errorType": "wrapError"})
+	caught, _ := json.Marshal(map[string]string{"Error": "wrapError", "Cause": string(cause)})
+	if _, err := ah.Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_grant_error",
+		RequestID: "req-1",
+		Error:     caught,
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info := db.requests["req-1"].ErrorInfo; info == nil || info.FailureReason != reason {
+		t.Errorf("expected failure reason %q recorded, got %+v", reason, info)
+	}
+	if got := au.events[0].details["failure_reason"]; got != reason {
+		t.Errorf("expected failure reason in audit details, got %q", got)
+	}
+	if got := wh.payloads[0].Details["failure_reason"]; got != reason {
+		t.Errorf("expected failure reason in webhook details, got %q", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // handleRevokeError tests
 // ---------------------------------------------------------------------------
@@ -347,3 +1060,33 @@ func TestHandleRevokeError_Success(t *testing.T) {
 		t.Errorf("expected ERROR webhook notification")
 	}
 }
+
+func TestHandleRevokeError_RecordsErrorInfo(t *testing.T) {
+	ah, db, _, _, _ := newTestActionHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusGranted,
+	}
+
+	raw := marshalPayload(t, StepFunctionActionPayload{
+		Action:    "handle_revoke_error",
+		RequestID: "req-1",
+		Error:     json.RawMessage(`"DeleteAccountAssignment failed"`),
+	})
+	if _, err := ah.Handle(context.Background(), raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info := db.requests["req-1"].ErrorInfo
+	if info == nil || info.Phase != models.ErrorPhaseRevoke || info.Message != "DeleteAccountAssignment failed" {
+		t.Fatalf("expected revoke phase and message captured, got %+v", info)
+	}
+	if info.Code != "" {
+		t.Errorf("expected no code for a plain error string, got %q", info.Code)
+	}
+	if got := db.requests["req-1"].ErrorDetails; got != "DeleteAccountAssignment failed" {
+		t.Errorf("expected error_details to be the message, got %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// batchStartWorkers is how many grants a batch approval starts at once.
+// Each start is a synchronous StartExecution call (or an inline grant), so
+// starting them one after another could take most of the Lambda's timeout.
+const batchStartWorkers = 5
+
+// defaultBatchGrantStarts is the grants one batch approval starts when
+// MaxBatchGrantStarts is unset. In reconciler mode each start is a whole
+// inline grant, so the default there is a single round of workers.
+const defaultBatchGrantStarts = 10
+
+// A batch approval only approves a request while there is time left to start
+// the grants of every request approved so far, including this one: at least
+// batchStartMargin plus batchStartReserve per round of batchStartWorkers
+// starts. In reconciler mode each round is a set of inline grants, which need
+// inlineGrantMinBudget. Tests change them.
+var (
+	batchStartMargin  = 5 * time.Second
+	batchStartReserve = 2 * time.Second
+)
+
+// HandleApproveBatch processes POST /requests/approve-batch.
+// Approves each request as HandleApproveRequest would, with the same
+// approver, self-approval and strong-auth checks, and carries on past
+// requests that cannot be approved so one stale entry does not block the rest.
+// Once MaxBatchGrantStarts requests are approved, or there would not be time
+// to start another grant before the invocation deadline, the rest are
+// deferred. The approved requests' grants are then all started by a small
+// worker pool: a request is never left approved without its grant started.
+func (h *Handler) HandleApproveBatch(ctx context.Context, input models.ApproveBatchInput) (*models.ApproveBatchResponse, error) {
+	if input.ApproverMMUserID == "" || input.ApproverEmail == "" {
+		return nil, fmt.Errorf("approver_mm_user_id and approver_email are required")
+	}
+	if len(input.RequestIDs) == 0 {
+		return nil, fmt.Errorf("request_ids is required")
+	}
+	if len(input.RequestIDs) > models.MaxApproveBatch {
+		return nil, fmt.Errorf("at most %d requests may be approved per call", models.MaxApproveBatch)
+	}
+
+	maxStarts := h.MaxBatchGrantStarts
+	if maxStarts <= 0 {
+		maxStarts = defaultBatchGrantStarts
+		if h.RevokeMode == RevokeModeReconciler {
+			maxStarts = batchStartWorkers
+		}
+	}
+
+	resp := &models.ApproveBatchResponse{Results: []models.ApproveBatchResult{}}
+	var approved []*models.JitRequest
+	var approvedAt []int // index in resp.Results of each approved request
+	seen := make(map[string]bool, len(input.RequestIDs))
+	for _, id := range input.RequestIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := models.ApproveBatchResult{RequestID: id}
+		if len(approved) >= maxStarts || !h.timeToStart(ctx, len(approved)+1) {
+			result.Status = models.StatusPending
+			result.Deferred = true
+			resp.Deferred++
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		req, err := h.approve(ctx, models.ApproveRequestInput{
+			RequestID:        id,
+			ApproverMMUserID: input.ApproverMMUserID,
+			ApproverEmail:    input.ApproverEmail,
+		})
+		if err != nil {
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			approved = append(approved, req)
+			approvedAt = append(approvedAt, len(resp.Results))
+			resp.Approved++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	for i, err := range h.startGrants(ctx, approved) {
+		result := &resp.Results[approvedAt[i]]
+		if err != nil {
+			result.StartError = err.Error()
+			resp.StartFailed++
+		} else {
+			resp.Started++
+		}
+		result.Status = models.StatusApproved
+		if req, _ := h.DB.GetRequest(ctx, result.RequestID); req != nil {
+			result.Status = req.Status
+		}
+	}
+
+	slog.Info("batch approval processed",
+		"approver", input.ApproverEmail,
+		"approved", resp.Approved,
+		"failed", resp.Failed,
+		"deferred", resp.Deferred,
+		"started", resp.Started,
+		"start_failed", resp.StartFailed,
+	)
+	return resp, nil
+}
+
+// startGrants starts the grant of each request, at most batchStartWorkers at
+// a time, and returns each start's error in request order.
+func (h *Handler) startGrants(ctx context.Context, reqs []*models.JitRequest) []error {
+	errs := make([]error, len(reqs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < batchStartWorkers && w < len(reqs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = h.startGrant(ctx, reqs[i])
+			}
+		}()
+	}
+	for i := range reqs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+// timeToStart reports whether enough of the invocation is left to start this
+// many grants, in rounds of batchStartWorkers, and keep batchStartMargin.
+func (h *Handler) timeToStart(ctx context.Context, starts int) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	perRound := batchStartReserve
+	if h.RevokeMode == RevokeModeReconciler {
+		perRound = inlineGrantMinBudget
+	}
+	rounds := (starts + batchStartWorkers - 1) / batchStartWorkers
+	return time.Until(deadline) >= batchStartMargin+time.Duration(rounds)*perRound
+}
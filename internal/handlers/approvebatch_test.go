@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func TestHandleApproveBatch_MixedResults(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"approver-1"}}
+	db.configs["ch1|acct2"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct2", ApproverMMUserIDs: []string{"someone-else"}}
+	for id, r := range map[string]struct{ account, status string }{
+		"req-ok":           {"acct1", models.StatusPending},
+		"req-unauthorized": {"acct2", models.StatusPending},
+		"req-approved":     {"acct1", models.StatusApproved},
+	} {
+		db.requests[id] = &models.JitRequest{
+			RequestID:           id,
+			AccountID:           r.account,
+			ChannelID:           "ch1",
+			RequesterMMUserID:   "mm-user-1",
+			IdentityStoreUserID: "uid-123",
+			Status:              r.status,
+		}
+	}
+
+	resp, err := h.HandleApproveBatch(context.Background(), models.ApproveBatchInput{
+		RequestIDs:       []string{"req-ok", "req-unauthorized", "req-approved", "req-missing", "req-ok"},
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Approved != 1 || resp.Failed != 3 || len(resp.Results) != 4 {
+		t.Fatalf("expected 1 approved and 3 failed across 4 results, got %+v", resp)
+	}
+	want := []struct{ id, status, err string }{
+		{"req-ok", models.StatusApproved, ""},
+		{"req-unauthorized", "", "not an authorized approver"},
+		{"req-approved", "", "expected PENDING"},
+		{"req-missing", "", "not found"},
+	}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.RequestID != w.id || got.Status != w.status {
+			t.Errorf("result %d: expected %s with status %q, got %+v", i, w.id, w.status, got)
+		}
+		if (w.err == "") != (got.Error == "") || !strings.Contains(got.Error, w.err) {
+			t.Errorf("result %d: expected error containing %q, got %q", i, w.err, got.Error)
+		}
+	}
+
+	if db.requests["req-ok"].Status != models.StatusApproved {
+		t.Errorf("expected req-ok APPROVED, got %s", db.requests["req-ok"].Status)
+	}
+	if db.requests["req-unauthorized"].Status != models.StatusPending {
+		t.Errorf("expected req-unauthorized left PENDING, got %s", db.requests["req-unauthorized"].Status)
+	}
+	if len(au.events) != 1 || len(sf.started) != 1 {
+		t.Errorf("expected one approval audited and granted, got %d events and %d executions", len(au.events), len(sf.started))
+	}
+}
+
+func TestHandleApproveBatch_Validation(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+	tooMany := make([]string, models.MaxApproveBatch+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("req-%d", i)
+	}
+
+	tests := []struct {
+		name  string
+		input models.ApproveBatchInput
+		want  string
+	}{
+		{"no approver", models.ApproveBatchInput{RequestIDs: []string{"req-1"}}, "approver_mm_user_id and approver_email are required"},
+		{"no requests", models.ApproveBatchInput{ApproverMMUserID: "approver-1", ApproverEmail: "a@example.com"}, "request_ids is required"},
+		{"too many", models.ApproveBatchInput{RequestIDs: tooMany, ApproverMMUserID: "approver-1", ApproverEmail: "a@example.com"}, "at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.HandleApproveBatch(context.Background(), tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// slowSFN takes a while to start each execution and records the most starts
+// in flight at once.
+type slowSFN struct {
+	mockSFN
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (m *slowSFN) StartExecution(ctx context.Context, input models.StepFunctionInput) (string, error) {
+	m.mu.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+	return m.mockSFN.StartExecution(ctx, input)
+}
+
+// seedBatch adds n PENDING requests approver-1 may approve and returns their IDs.
+func seedBatch(db *mockDB, n int) []string {
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"approver-1"}}
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("req-%d", i)
+		db.requests[ids[i]] = &models.JitRequest{
+			RequestID:         ids[i],
+			AccountID:         "acct1",
+			ChannelID:         "ch1",
+			RequesterMMUserID: "mm-user-1",
+			Status:            models.StatusPending,
+		}
+	}
+	return ids
+}
+
+func TestHandleApproveBatch_StartsGrantsWithBoundedWorkers(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	sf := &slowSFN{}
+	h.SFN = sf
+	h.MaxBatchGrantStarts = models.MaxApproveBatch
+	ids := seedBatch(db, models.MaxApproveBatch)
+
+	resp, err := h.HandleApproveBatch(context.Background(), models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Approved != len(ids) || resp.Started != len(ids) || len(sf.started) != len(ids) {
+		t.Fatalf("expected every request approved and started, got %+v with %d executions", resp, len(sf.started))
+	}
+	if sf.maxInFlight > batchStartWorkers || sf.maxInFlight < 2 {
+		t.Errorf("expected between 2 and %d starts in flight, got %d", batchStartWorkers, sf.maxInFlight)
+	}
+	for _, r := range resp.Results {
+		if db.requests[r.RequestID].ExecutionARN == "" {
+			t.Errorf("expected execution ARN recorded for %s", r.RequestID)
+		}
+	}
+}
+
+func TestHandleApproveBatch_DefersBeyondMaxStarts(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
+	h.MaxBatchGrantStarts = 2
+	ids := seedBatch(db, 4)
+	db.requests["req-0"].Status = models.StatusDenied
+
+	resp, err := h.HandleApproveBatch(context.Background(), models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The refused request does not use up a start.
+	if resp.Failed != 1 || resp.Approved != 2 || resp.Started != 2 || resp.Deferred != 1 {
+		t.Fatalf("expected 1 failed, 2 approved and started, 1 deferred, got %+v", resp)
+	}
+	if len(sf.started) != 2 || len(au.events) != 2 {
+		t.Errorf("expected 2 executions and 2 audited approvals, got %d and %d", len(sf.started), len(au.events))
+	}
+	last := resp.Results[3]
+	if last.RequestID != "req-3" || !last.Deferred || last.Status != models.StatusPending || last.Error != "" {
+		t.Errorf("expected req-3 deferred and PENDING, got %+v", last)
+	}
+	if db.requests["req-3"].Status != models.StatusPending {
+		t.Errorf("expected req-3 left PENDING, got %s", db.requests["req-3"].Status)
+	}
+}
+
+func TestHandleApproveBatch_ReportsStartFailures(t *testing.T) {
+	h, db, _, _, _, sf := newTestHandler()
+	sf.err = errors.New("throttled")
+	ids := seedBatch(db, 2)
+
+	resp, err := h.HandleApproveBatch(context.Background(), models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Approved != 2 || resp.Started != 0 || resp.StartFailed != 2 {
+		t.Fatalf("expected 2 approved with failed starts, got %+v", resp)
+	}
+	for _, r := range resp.Results {
+		if r.Status != models.StatusApproved || !strings.Contains(r.StartError, "throttled") {
+			t.Errorf("expected %s APPROVED with the start error, got %+v", r.RequestID, r)
+		}
+	}
+}
+
+func TestHandleApproveBatch_DefaultCap(t *testing.T) {
+	for _, tc := range []struct {
+		mode string
+		want int
+	}{
+		{RevokeModeStepFn, defaultBatchGrantStarts},
+		{RevokeModeReconciler, batchStartWorkers},
+	} {
+		h, db, _, _, _, _ := newTestHandler()
+		h.RevokeMode = tc.mode
+		ids := seedBatch(db, models.MaxApproveBatch)
+
+		resp, err := h.HandleApproveBatch(context.Background(), models.ApproveBatchInput{
+			RequestIDs:       ids,
+			ApproverMMUserID: "approver-1",
+			ApproverEmail:    "approver@example.com",
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.mode, err)
+		}
+		if resp.Approved != tc.want || resp.Deferred != len(ids)-tc.want {
+			t.Errorf("%s: expected %d approved and the rest deferred, got %+v", tc.mode, tc.want, resp)
+		}
+	}
+}
+
+func TestHandleApproveBatch_DefersNearDeadline(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
+	ids := seedBatch(db, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), batchStartMargin/2)
+	defer cancel()
+	resp, err := h.HandleApproveBatch(ctx, models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Approved != 0 || resp.Deferred != 3 {
+		t.Fatalf("expected every request deferred, got %+v", resp)
+	}
+	if len(sf.started) != 0 || len(au.events) != 0 {
+		t.Errorf("expected nothing approved or started, got %d executions and %d audit events", len(sf.started), len(au.events))
+	}
+	for _, id := range ids {
+		if db.requests[id].Status != models.StatusPending {
+			t.Errorf("expected %s left PENDING, got %s", id, db.requests[id].Status)
+		}
+	}
+}
+
+func TestHandleApproveBatch_ReservesTimeToStartGrants(t *testing.T) {
+	prevMargin, prevReserve := batchStartMargin, batchStartReserve
+	defer func() { batchStartMargin, batchStartReserve = prevMargin, prevReserve }()
+	// An hour left covers two rounds of starts but not a third.
+	batchStartMargin, batchStartReserve = 0, 25*time.Minute
+
+	h, db, _, _, _, sf := newTestHandler()
+	h.MaxBatchGrantStarts = models.MaxApproveBatch
+	ids := seedBatch(db, 3*batchStartWorkers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	resp, err := h.HandleApproveBatch(ctx, models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := 2 * batchStartWorkers
+	if resp.Approved != want || resp.Started != want || resp.Deferred != len(ids)-want {
+		t.Fatalf("expected %d approved and started and the rest deferred, got %+v", want, resp)
+	}
+	if len(sf.started) != want {
+		t.Errorf("expected %d executions, got %d", want, len(sf.started))
+	}
+	for _, r := range resp.Results[want:] {
+		if !r.Deferred || r.Status != models.StatusPending {
+			t.Errorf("expected %s deferred and PENDING, got %+v", r.RequestID, r)
+		}
+	}
+}
+
+// slowAudit delays each audit write, like a slow approval.
+type slowAudit struct {
+	*mockAudit
+	delay time.Duration
+}
+
+func (s *slowAudit) Log(ctx context.Context, requestID, eventType, accountID, channelID string, actor models.Actor, details map[string]string) error {
+	time.Sleep(s.delay)
+	return s.mockAudit.Log(ctx, requestID, eventType, accountID, channelID, actor, details)
+}
+
+func TestHandleApproveBatch_StartsApprovedGrantsPastDeadline(t *testing.T) {
+	prevMargin, prevReserve := batchStartMargin, batchStartReserve
+	defer func() { batchStartMargin, batchStartReserve = prevMargin, prevReserve }()
+
+	h, db, _, _, au, sf := newTestHandler()
+	// The approval takes the invocation past the start margin, so by the
+	// start phase there is less time left than the loop required.
+	h.Audit = &slowAudit{mockAudit: au, delay: 200 * time.Millisecond}
+	ids := seedBatch(db, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	batchStartMargin, batchStartReserve = time.Hour-100*time.Millisecond, 0
+	resp, err := h.HandleApproveBatch(ctx, models.ApproveBatchInput{
+		RequestIDs:       ids,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.timeToStart(ctx, 1) {
+		t.Fatal("expected the start margin to have passed during the approval")
+	}
+
+	if resp.Approved != 1 || resp.Started != 1 || resp.Deferred != 0 {
+		t.Fatalf("expected the approved request's grant started, not deferred, got %+v", resp)
+	}
+	if len(sf.started) != 1 || resp.Results[0].Deferred {
+		t.Errorf("expected one execution and no deferral, got %d executions and %+v", len(sf.started), resp.Results[0])
+	}
+}
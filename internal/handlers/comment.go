@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// HandleComment processes POST /requests/{id}/comment.
+// Records the comment as a COMMENT audit event, so the request's audit trail
+// doubles as its discussion log. Only the requester and the binding's
+// approvers may comment, and only while the request can still change.
+func (h *Handler) HandleComment(ctx context.Context, input models.CommentInput) (*models.CommentResponse, error) {
+	if input.RequestID == "" {
+		return nil, fmt.Errorf("request_id is required")
+	}
+	if input.ActorMMUserID == "" || input.ActorEmail == "" {
+		return nil, fmt.Errorf("actor_mm_user_id and actor_email are required")
+	}
+	comment := strings.TrimSpace(input.Comment)
+	if comment == "" {
+		return nil, fmt.Errorf("comment is required")
+	}
+	if utf8.RuneCountInString(comment) > models.MaxCommentLength {
+		return nil, fmt.Errorf("comment must be at most %d characters", models.MaxCommentLength)
+	}
+
+	req, err := h.DB.GetRequest(ctx, input.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request %s not found", input.RequestID)
+	}
+	if models.IsTerminal(req.Status) {
+		return nil, fmt.Errorf("request %s is in status %s, which is closed to comments", input.RequestID, req.Status)
+	}
+
+	if input.ActorMMUserID != req.RequesterMMUserID {
+		cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("lookup config for comment: %w", err)
+		}
+		if cfg == nil || !cfg.IsApprover(input.ActorMMUserID) {
+			return nil, fmt.Errorf("user %s is not the requester and is not an authorized approver", input.ActorMMUserID)
+		}
+	}
+
+	if err := h.Audit.Log(ctx, input.RequestID, models.EventComment, req.AccountID, req.ChannelID,
+		models.HumanActor(input.ActorMMUserID, input.ActorEmail), map[string]string{"comment": comment}); err != nil {
+		return nil, fmt.Errorf("record comment: %w", err)
+	}
+
+	slog.Info("request comment added",
+		"request_id", input.RequestID,
+		"actor", input.ActorEmail,
+	)
+	return &models.CommentResponse{
+		RequestID:  input.RequestID,
+		ActorEmail: input.ActorEmail,
+		Comment:    comment,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func newCommentHandler(status string) (*Handler, *mockAudit) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            status,
+	}
+	return h, au
+}
+
+func TestHandleComment_RecordsCommentEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor string
+	}{
+		{"requester", "user-1"},
+		{"approver", "approver-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, au := newCommentHandler(models.StatusPending)
+
+			resp, err := h.HandleComment(context.Background(), models.CommentInput{
+				RequestID:     "req-1",
+				ActorMMUserID: tt.actor,
+				ActorEmail:    tt.actor + "@example.com",
+				Comment:       "  why do you need 8 hours?  ",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Comment != "why do you need 8 hours?" {
+				t.Errorf("expected trimmed comment, got %q", resp.Comment)
+			}
+			if len(au.events) != 1 {
+				t.Fatalf("expected one audit event, got %d", len(au.events))
+			}
+			e := au.events[0]
+			if e.eventType != models.EventComment || e.actorMMUserID != tt.actor || e.details["comment"] != "why do you need 8 hours?" {
+				t.Errorf("expected COMMENT event by %s with text, got %+v", tt.actor, e)
+			}
+		})
+	}
+}
+
+func TestHandleComment_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		actor   string
+		comment string
+		want    string
+	}{
+		{"outsider", models.StatusPending, "someone-else", "hi", "is not an authorized approver"},
+		{"terminal request", models.StatusDenied, "user-1", "hi", "closed to comments"},
+		{"empty comment", models.StatusGranted, "user-1", "   ", "comment is required"},
+		{"too long", models.StatusGranted, "user-1", strings.Repeat("x", models.MaxCommentLength+1), "at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, au := newCommentHandler(tt.status)
+
+			_, err := h.HandleComment(context.Background(), models.CommentInput{
+				RequestID:     "req-1",
+				ActorMMUserID: tt.actor,
+				ActorEmail:    tt.actor + "@example.com",
+				Comment:       tt.comment,
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got: %v", tt.want, err)
+			}
+			if len(au.events) != 0 {
+				t.Errorf("expected no audit event, got %d", len(au.events))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aws/smithy-go"
+
+	"github.com/dgwhited/jit-aws-controller/internal/identity"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// NewErrorInfo describes a failure in the given phase. The code is taken
+// from the AWS API error in err's chain, if any, and the failure reason from
+// a failed SSO Admin assignment operation.
+func NewErrorInfo(phase string, err error, now time.Time) models.ErrorInfo {
+	info := models.ErrorInfo{
+		Phase:     phase,
+		Message:   err.Error(),
+		Timestamp: now.Format(time.RFC3339),
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		info.Code = apiErr.ErrorCode()
+	}
+	info.FailureReason, _ = identity.FailureReason(err)
+	return info
+}
+
+// stepErrorInfo describes a failure reported by a Step Functions Catch. The
+// error output is normally {"Error": ..., "Cause": ...}; anything else is
+// kept verbatim as the message, and fallback is used when there is none.
+func stepErrorInfo(phase string, raw json.RawMessage, fallback string, now time.Time) models.ErrorInfo {
+	info := models.ErrorInfo{
+		Phase:     phase,
+		Message:   fallback,
+		Timestamp: now.Format(time.RFC3339),
+	}
+	if len(raw) == 0 {
+		return info
+	}
+	var caught struct {
+		Error string `json:"Error"`
+		Cause string `json:"Cause"`
+	}
+	var text string
+	switch {
+	case json.Unmarshal(raw, &caught) == nil && (caught.Error != "" || caught.Cause != ""):
+		info.Code = caught.Error
+		if caught.Cause != "" {
+			info.Message = caught.Cause
+			info.FailureReason, _ = identity.FailureReasonFromMessage(causeMessage(caught.Cause))
+		}
+	case json.Unmarshal(raw, &text) == nil && text != "":
+		info.Message = text
+	default:
+		info.Message = string(raw)
+	}
+	return info
+}
+
+// causeMessage returns the error message from a Catch cause. Lambda failures
+// arrive as a JSON error object; other causes are returned unchanged.
+func causeMessage(cause string) string {
+	var lambdaErr struct {
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if json.Unmarshal([]byte(cause), &lambdaErr) == nil && lambdaErr.ErrorMessage != "" {
+		return lambdaErr.ErrorMessage
+	}
+	return cause
+}
+
+// ErrorUpdates returns the request updates that move a request to ERROR
+// with the given failure recorded in both error_details and error_info.
+func ErrorUpdates(info models.ErrorInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"status":        models.StatusError,
+		"error_details": info.String(),
+		"error_info":    info,
+	}
+}
+
+// errorAuditDetails returns the audit and webhook details for a failure.
+func errorAuditDetails(info models.ErrorInfo) map[string]string {
+	details := map[string]string{"error": info.String(), "phase": info.Phase}
+	if info.Code != "" {
+		details["code"] = info.Code
+	}
+	if info.FailureReason != "" {
+		details["failure_reason"] = info.FailureReason
+	}
+	return details
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// HandleExportRequest processes GET /requests/{id}/export.
+// Assembles the request and its full audit chain into a canonical bundle and
+// signs it with the export key, giving auditors a tamper-evident artifact.
+// Restricted to admins because the bundle carries every detail of the request.
+func (h *Handler) HandleExportRequest(ctx context.Context, requestID, actorMMUserID string) (*models.RequestExportResponse, error) {
+	if !h.isAdmin(actorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", actorMMUserID)
+	}
+	if h.Exports == nil {
+		return nil, fmt.Errorf("request export is not configured")
+	}
+
+	req, err := h.DB.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup request: %w", err)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request %s not found", requestID)
+	}
+
+	events, err := h.DB.QueryAuditByRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("query audit events: %w", err)
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+	// The sort key already orders events; sort anyway so the canonical form
+	// does not depend on how the store returned them.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventTimeEventID < events[j].EventTimeEventID
+	})
+
+	canonical, err := canonicalExport(models.RequestExportBundle{
+		ExportedAt:  h.now().Format(time.RFC3339),
+		ExportedBy:  actorMMUserID,
+		Request:     *req,
+		AuditEvents: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := h.Exports.SignExport(canonical)
+	if err != nil {
+		return nil, fmt.Errorf("sign export: %w", err)
+	}
+
+	slog.Info("request exported",
+		"request_id", requestID,
+		"actor", actorMMUserID,
+		"audit_events", len(events),
+	)
+	return &models.RequestExportResponse{Bundle: canonical, Signature: sig}, nil
+}
+
+// HandleAuditExport processes GET /admin/audit/export.
+// Pages through the audit events of every request recorded within a date
+// range, for evidence collection that is not scoped to one request.
+// start_date and end_date take the same forms as on GET /requests.
+func (h *Handler) HandleAuditExport(ctx context.Context, input models.AuditExportInput) (*models.AuditExportResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if input.StartDate == "" || input.EndDate == "" {
+		return nil, fmt.Errorf("start_date and end_date are required")
+	}
+	startDate, err := normalizeDateBound("start_date", input.StartDate, false)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := normalizeDateBound("end_date", input.EndDate, true)
+	if err != nil {
+		return nil, err
+	}
+	// Both are normalized RFC3339, so they parse.
+	start, _ := time.Parse(time.RFC3339, startDate)
+	end, _ := time.Parse(time.RFC3339, endDate)
+	if end.Before(start) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+	if end.Sub(start) >= models.MaxAuditExportDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must not exceed %d days", models.MaxAuditExportDays)
+	}
+
+	input.Limit = models.NormalizeLimit(input.Limit)
+
+	events, nextToken, err := h.DB.QueryAuditByTimeRange(ctx, start, end, int32(input.Limit), input.NextToken)
+	if err != nil {
+		return nil, fmt.Errorf("query audit events: %w", err)
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+
+	slog.Info("audit events exported",
+		"actor", input.ActorMMUserID,
+		"start_date", startDate,
+		"end_date", endDate,
+		"count", len(events),
+	)
+	return &models.AuditExportResponse{
+		Items:     events,
+		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(events),
+	}, nil
+}
+
+// canonicalExport encodes bundle as compact JSON. Struct fields encode in
+// declaration order and map keys sorted, so the bytes are deterministic for a
+// given bundle.
+func canonicalExport(bundle models.RequestExportBundle) ([]byte, error) {
+	b, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("encode export: %w", err)
+	}
+	return b, nil
+}
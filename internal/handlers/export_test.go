@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func newExportHandler() (*Handler, *mockDB, *auth.ExportSigner) {
+	h, db, _, _, _, _ := newTestHandler()
+	signer := auth.NewExportSigner(map[string]string{"k1": "export-secret"})
+	h.Exports = signer
+	h.AdminMMUserIDs = []string{"admin-1"}
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:      "req-1",
+		AccountID:      "acct1",
+		ChannelID:      "ch1",
+		RequesterEmail: "user@example.com",
+		Status:         models.StatusExpired,
+	}
+	db.auditEvents = map[string][]models.AuditEvent{"req-1": {
+		{RequestID: "req-1", EventTimeEventID: "2025-06-01T10:05:00Z#b", EventType: models.EventApproved, Hash: "h2", PrevHash: "h1"},
+		{RequestID: "req-1", EventTimeEventID: "2025-06-01T10:00:00Z#a", EventType: models.EventRequested, Hash: "h1"},
+	}}
+	return h, db, signer
+}
+
+func TestHandleExportRequest_SignedBundle(t *testing.T) {
+	h, _, signer := newExportHandler()
+
+	resp, err := h.HandleExportRequest(context.Background(), "req-1", "admin-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := signer.VerifyExport(resp.Bundle, resp.Signature); err != nil {
+		t.Fatalf("expected signature over the canonical bundle, got: %v", err)
+	}
+
+	var bundle models.RequestExportBundle
+	if err := json.Unmarshal(resp.Bundle, &bundle); err != nil {
+		t.Fatalf("bundle is not valid JSON: %v", err)
+	}
+	if bundle.Request.RequestID != "req-1" || bundle.Request.Status != models.StatusExpired {
+		t.Errorf("expected the stored request in the bundle, got %+v", bundle.Request)
+	}
+	if len(bundle.AuditEvents) != 2 || bundle.AuditEvents[0].EventType != models.EventRequested {
+		t.Fatalf("expected the audit chain in event order, got %+v", bundle.AuditEvents)
+	}
+	if bundle.ExportedBy != "admin-1" || bundle.ExportedAt != "2025-06-01T12:00:00Z" {
+		t.Errorf("unexpected export metadata: %q at %q", bundle.ExportedBy, bundle.ExportedAt)
+	}
+
+	// The bundle is canonical: re-encoding it yields the signed bytes.
+	again, err := canonicalExport(bundle)
+	if err != nil {
+		t.Fatalf("canonicalExport: %v", err)
+	}
+	if string(again) != string(resp.Bundle) {
+		t.Errorf("expected canonical encoding to be stable:\n%s\n%s", again, resp.Bundle)
+	}
+
+	tampered := strings.Replace(string(resp.Bundle), models.StatusExpired, models.StatusGranted, 1)
+	if err := signer.VerifyExport([]byte(tampered), resp.Signature); err == nil {
+		t.Error("expected tampered bundle to fail verification")
+	}
+}
+
+func TestHandleExportRequest_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		actor     string
+		noSigner  bool
+		want      string
+	}{
+		{"not admin", "req-1", "user-1", false, "is not an admin"},
+		{"unknown request", "missing", "admin-1", false, "request missing not found"},
+		{"not configured", "req-1", "admin-1", true, "request export is not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newExportHandler()
+			if tt.noSigner {
+				h.Exports = nil
+			}
+			_, err := h.HandleExportRequest(context.Background(), tt.requestID, tt.actor)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHandleAuditExport_DateRange(t *testing.T) {
+	h, db, _ := newExportHandler()
+	db.auditRange = []models.AuditEvent{{RequestID: "req-1", EventType: models.EventRequested}}
+	db.auditRangeToken = "event_date=2025-06-02"
+
+	resp, err := h.HandleAuditExport(context.Background(), models.AuditExportInput{
+		ActorMMUserID: "admin-1",
+		StartDate:     "2025-06-01",
+		EndDate:       "2025-06-02T12:00:00+02:00",
+		NextToken:     "tok",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantStart := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	wantEnd := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	if !db.lastAuditRange[0].Equal(wantStart) || !db.lastAuditRange[1].Equal(wantEnd) || db.lastAuditToken != "tok" {
+		t.Errorf("expected UTC bounds %s..%s with the token, got %v %q", wantStart, wantEnd, db.lastAuditRange, db.lastAuditToken)
+	}
+	if resp.Count != 1 || !resp.HasMore || resp.NextToken != "event_date=2025-06-02" {
+		t.Errorf("expected one event and the next page token, got %+v", resp)
+	}
+}
+
+func TestHandleAuditExport_Rejects(t *testing.T) {
+	h, _, _ := newExportHandler()
+
+	cases := []struct {
+		input models.AuditExportInput
+		want  string
+	}{
+		{models.AuditExportInput{ActorMMUserID: "user-1", StartDate: "2025-06-01", EndDate: "2025-06-02"}, "is not an admin"},
+		{models.AuditExportInput{ActorMMUserID: "admin-1", StartDate: "2025-06-01"}, "start_date and end_date are required"},
+		{models.AuditExportInput{ActorMMUserID: "admin-1", StartDate: "June", EndDate: "2025-06-02"}, "invalid start_date"},
+		{models.AuditExportInput{ActorMMUserID: "admin-1", StartDate: "2025-06-02", EndDate: "2025-06-01"}, "must not be before"},
+		{models.AuditExportInput{ActorMMUserID: "admin-1", StartDate: "2024-01-01", EndDate: "2025-01-01"}, "must not exceed 366 days"},
+	}
+	for _, tc := range cases {
+		_, err := h.HandleAuditExport(context.Background(), tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: expected error containing %q, got %v", tc.input, tc.want, err)
+		}
+	}
+
+	// A full leap year is within the limit.
+	if _, err := h.HandleAuditExport(context.Background(), models.AuditExportInput{
+		ActorMMUserID: "admin-1", StartDate: "2024-01-01", EndDate: "2024-12-31",
+	}); err != nil {
+		t.Errorf("expected a 366-day range accepted, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// acquireGrantSlot reserves a slot in the binding's active-grant counter,
+// failing if the binding's MaxConcurrentGrants cap is already reached. It
+// reports whether a slot was taken; requests whose binding no longer exists
+// are not counted.
+func acquireGrantSlot(ctx context.Context, db DBStore, req *models.JitRequest) (bool, error) {
+	cfg, err := db.GetConfig(ctx, req.ChannelID, req.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("lookup config for grant limit: %w", err)
+	}
+	if cfg == nil {
+		return false, nil
+	}
+	if err := db.IncrementActiveGrants(ctx, req.ChannelID, req.AccountID, cfg.MaxConcurrentGrants); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseGrantSlot returns a request's slot to its binding's active-grant
+// counter. Call it exactly once per grant, after the request has left GRANTED.
+// Failures are logged rather than returned: access is already removed, and a
+// stale count errs on the side of denying new grants.
+func ReleaseGrantSlot(ctx context.Context, counter GrantCounter, req *models.JitRequest) {
+	if err := counter.DecrementActiveGrants(ctx, req.ChannelID, req.AccountID); err != nil {
+		slog.Warn("failed to release active grant slot",
+			"request_id", req.RequestID,
+			"channel_id", req.ChannelID,
+			"account_id", req.AccountID,
+			"error", err,
+		)
+	}
+}
+
+// revokeFailedGrant revokes the assignment of a request that has moved to
+// ERROR while holding a grant slot and, once the assignment is confirmed
+// gone, hands the slot back. ERROR is terminal, so a slot not released here
+// stays taken until HandleRecountGrants. If the revoke fails the slot stays
+// held, since the user may still have access. It reports whether the
+// assignment was revoked.
+func (h *Handler) revokeFailedGrant(ctx context.Context, req *models.JitRequest) bool {
+	psStatus, err := RevokePermissionSets(ctx, h.Identity, req)
+	updates := map[string]interface{}{"assignment_status": models.AssignmentDeleted}
+	if err != nil {
+		updates["assignment_status"] = models.AssignmentFailed
+	}
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
+	}
+	if uerr := h.DB.UpdateRequestStatus(ctx, req.RequestID, updates); uerr != nil {
+		slog.Warn("failed to record assignment status",
+			"request_id", req.RequestID,
+			"assignment_status", updates["assignment_status"],
+			"error", uerr,
+		)
+	}
+	if err != nil {
+		slog.Error("could not revoke failed grant, its grant slot stays held",
+			"request_id", req.RequestID,
+			"account_id", req.AccountID,
+			"error", err,
+		)
+		return false
+	}
+	ReleaseGrantSlot(ctx, h.DB, req)
+	return true
+}
+
+// HandleRecountGrants processes POST /admin/active-grants/recount.
+// Rebuilds a binding's active-grant counter from its GRANTED requests on the
+// status index, for a counter left too high by grants that failed with their
+// assignment in place. A grant that starts or ends while the count runs can
+// leave it off by one; run it again once the binding is quiet.
+func (h *Handler) HandleRecountGrants(ctx context.Context, input models.RecountGrantsInput) (*models.RecountGrantsResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if err := models.ValidateID("channel_id", input.ChannelID); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateID("account_id", input.AccountID); err != nil {
+		return nil, err
+	}
+
+	cfg, err := h.DB.GetConfig(ctx, input.ChannelID, input.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("get config: %w", err)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", input.ChannelID, input.AccountID)
+	}
+
+	var count int
+	var token string
+	for {
+		requests, next, err := h.DB.QueryRequestsByStatusPage(ctx, models.StatusGranted, models.MaxQueryLimit, token)
+		if err != nil {
+			return nil, fmt.Errorf("query active grants: %w", err)
+		}
+		for _, req := range requests {
+			if req.ChannelID == input.ChannelID && req.AccountID == input.AccountID {
+				count++
+			}
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	if err := h.DB.SetActiveGrants(ctx, input.ChannelID, input.AccountID, count); err != nil {
+		return nil, fmt.Errorf("set active grants: %w", err)
+	}
+
+	slog.Info("active grants recounted",
+		"channel_id", input.ChannelID,
+		"account_id", input.AccountID,
+		"active_grants", count,
+		"actor", input.ActorEmail,
+	)
+	_ = h.Audit.Log(ctx, models.ConfigAuditKey(input.ChannelID, input.AccountID), models.EventGrantsRecount,
+		input.AccountID, input.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail),
+		map[string]string{"active_grants": strconv.Itoa(count)})
+
+	return &models.RecountGrantsResponse{
+		ChannelID:    input.ChannelID,
+		AccountID:    input.AccountID,
+		ActiveGrants: count,
+	}, nil
+}
@@ -3,12 +3,19 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
@@ -19,23 +26,99 @@ type Handler struct {
 	Webhook  WebhookNotifier
 	Audit    AuditLogger
 	SFN      SFNStarter
+
+	// ApprovalTokens mints and redeems one-time approval tokens for
+	// out-of-band (e.g. email) approvals. Optional.
+	ApprovalTokens ApprovalTokenIssuer
+
+	// Exports signs compliance exports of a request. Optional; without it
+	// GET /requests/{id}/export is unavailable.
+	Exports ExportSigner
+
+	// WebhookFailures is the log of undelivered webhook notifications and
+	// Redeliverer re-sends them. Optional; without both the
+	// /admin/webhook-failures endpoints are unavailable.
+	WebhookFailures WebhookFailureStore
+	Redeliverer     WebhookRedeliverer
+
+	// MinStrongAuthLevel is the auth level approvers must assert for bindings
+	// with RequireStrongAuth. Defaults to DefaultMinStrongAuthLevel when zero.
+	MinStrongAuthLevel int
+
+	// AdminMMUserIDs lists users allowed to call admin endpoints.
+	AdminMMUserIDs []string
+
+	// MaxApprovers caps the approver list set for a channel or account.
+	// Defaults to DefaultMaxApprovers when zero.
+	MaxApprovers int
+
+	// MaxWaitSeconds caps how long the Step Functions workflow waits before
+	// revoking. A request approved for longer has its end_time brought
+	// forward to match, so the reconciler agrees. Zero means no cap.
+	MaxWaitSeconds int
+
+	// MaxBatchGrantStarts caps the grants started by one batch approval, so a
+	// large batch cannot run the Lambda out of time. Requests beyond the cap
+	// are left PENDING and reported as deferred. Zero means 10, or
+	// batchStartWorkers in reconciler mode.
+	MaxBatchGrantStarts int
+
+	// NoSelfApprovalChannels lists channels where self-approval is refused
+	// even if the binding sets AllowSelfApproval.
+	NoSelfApprovalChannels []string
+
+	// RevokeMode selects how approved requests are granted and expired:
+	// RevokeModeStepFn (the default when empty) or RevokeModeReconciler.
+	RevokeMode string
+
+	// NotifyOnApprove sends an APPROVED webhook as soon as a request is
+	// approved, before access is granted.
+	NotifyOnApprove bool
+
+	// NotifyRequesterOnDeny sends a DENIED webhook addressed to the
+	// requester when a request is denied.
+	NotifyRequesterOnDeny bool
+
+	// Clock supplies the current time. Defaults to the system clock.
+	Clock clock.Clock
+}
+
+// now returns the current UTC time from h.Clock.
+func (h *Handler) now() time.Time {
+	if h.Clock == nil {
+		return time.Now().UTC()
+	}
+	return h.Clock.Now().UTC()
 }
 
+// Revoke modes. In RevokeModeStepFn approval starts the grant/revoke state
+// machine. In RevokeModeReconciler approval grants in-process and the
+// scheduled reconciler expires the grant once end_time passes.
+const (
+	RevokeModeStepFn     = "stepfn"
+	RevokeModeReconciler = "reconciler"
+)
+
+// DefaultMinStrongAuthLevel is the minimum asserted auth level accepted for
+// approvals on bindings that require strong authentication.
+const DefaultMinStrongAuthLevel = 2
+
+// DefaultMaxApprovers is the largest approver list accepted for a channel or
+// account when Handler.MaxApprovers is unset. Approvers are stored as a list
+// on every binding and scanned on each approval.
+const DefaultMaxApprovers = 50
+
+// maxRequestDurationMinutes is the hard cap on a request's duration (3 days),
+// applied before any binding limit. It also keeps end_time arithmetic far
+// from time.Duration overflow, whatever the binding allows.
+const maxRequestDurationMinutes = 3 * 24 * 60
+
 // HandleCreateRequest processes POST /requests.
 // Validates the binding, duration, jira/reason, looks up the user, creates the request, and audits.
 func (h *Handler) HandleCreateRequest(ctx context.Context, input models.CreateRequestInput) (*models.JitRequest, error) {
-	// Validate required fields.
-	if input.AccountID == "" || input.ChannelID == "" {
-		return nil, fmt.Errorf("account_id and channel_id are required")
-	}
-	if input.RequesterMMUserID == "" || input.RequesterEmail == "" {
-		return nil, fmt.Errorf("requester_mm_user_id and requester_email are required")
-	}
-	if input.Jira == "" && input.Reason == "" {
-		return nil, fmt.Errorf("either jira or reason must be provided")
-	}
-	if input.RequestedDurationMinutes <= 0 {
-		return nil, fmt.Errorf("requested_duration_minutes must be positive")
+	priority, metadata, err := validateCreateInput(input)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate binding exists.
@@ -43,14 +126,9 @@ func (h *Handler) HandleCreateRequest(ctx context.Context, input models.CreateRe
 	if err != nil {
 		return nil, fmt.Errorf("lookup config: %w", err)
 	}
-	if cfg == nil {
-		return nil, fmt.Errorf("no binding found for channel %s and account %s", input.ChannelID, input.AccountID)
-	}
-
-	// Validate duration against max.
-	maxMinutes := cfg.MaxRequestHours * 60
-	if maxMinutes > 0 && input.RequestedDurationMinutes > maxMinutes {
-		return nil, fmt.Errorf("requested duration %d minutes exceeds maximum %d minutes", input.RequestedDurationMinutes, maxMinutes)
+	permissionSets, err := validateCreateBinding(cfg, input)
+	if err != nil {
+		return nil, err
 	}
 
 	// Look up identity store user.
@@ -59,7 +137,7 @@ func (h *Handler) HandleCreateRequest(ctx context.Context, input models.CreateRe
 		return nil, fmt.Errorf("identity lookup: %w", err)
 	}
 
-	now := time.Now().UTC()
+	now := h.now()
 	requestID := uuid.New().String()
 	endTime := now.Add(time.Duration(input.RequestedDurationMinutes) * time.Minute)
 
@@ -76,6 +154,9 @@ func (h *Handler) HandleCreateRequest(ctx context.Context, input models.CreateRe
 		CreatedAt:                now.Format(time.RFC3339),
 		EndTime:                  endTime.Format(time.RFC3339),
 		IdentityStoreUserID:      userID,
+		PermissionSetARNs:        permissionSets,
+		Priority:                 priority,
+		Metadata:                 metadata,
 	}
 
 	if err := h.DB.CreateRequest(ctx, req); err != nil {
@@ -89,20 +170,192 @@ func (h *Handler) HandleCreateRequest(ctx context.Context, input models.CreateRe
 	)
 
 	// Audit the creation.
+	details := map[string]string{
+		"jira":                       input.Jira,
+		"reason":                     input.Reason,
+		"requested_duration_minutes": fmt.Sprintf("%d", input.RequestedDurationMinutes),
+		"priority":                   priority,
+	}
+	if len(permissionSets) > 0 {
+		details["permission_set_arns"] = strings.Join(permissionSets, ",")
+	}
 	_ = h.Audit.Log(ctx, requestID, models.EventRequested, input.AccountID, input.ChannelID,
-		input.RequesterMMUserID, input.RequesterEmail,
-		map[string]string{
-			"jira":                       input.Jira,
-			"reason":                     input.Reason,
-			"requested_duration_minutes": fmt.Sprintf("%d", input.RequestedDurationMinutes),
-		},
-	)
+		models.HumanActor(input.RequesterMMUserID, input.RequesterEmail), details)
 
+	if cfg.AutoApproves(input.RequestedDurationMinutes) {
+		return h.autoApprove(ctx, req, cfg), nil
+	}
 	return req, nil
 }
 
+// autoApprove approves a new request on behalf of the binding's auto-approve
+// policy and starts the grant. There is no approver, so the self-approval
+// check does not apply. If the request can no longer be approved it is
+// returned as is, still PENDING for the approvers.
+func (h *Handler) autoApprove(ctx context.Context, req *models.JitRequest, cfg *models.JitConfig) *models.JitRequest {
+	approval := models.ApprovalRecord{
+		ApproverEmail: models.PolicyActor.Email,
+		ApprovedAt:    h.now().Format(time.RFC3339),
+	}
+	if err := TransitionStatus(ctx, h.DB, req.RequestID, models.StatusPending, map[string]interface{}{
+		"status":         models.StatusApproved,
+		"approved_at":    approval.ApprovedAt,
+		"approver_email": approval.ApproverEmail,
+		"auto_approved":  true,
+		"approvals":      appendApproval(req.Approvals, approval),
+	}); err != nil {
+		slog.Warn("failed to auto-approve request",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return req
+	}
+
+	slog.Info("request auto-approved",
+		"request_id", req.RequestID,
+		"account_id", req.AccountID,
+		"duration_minutes", req.DurationMinutes(),
+	)
+
+	details := map[string]string{"policy": "auto_approve"}
+	if cfg.AutoApproveMaxMinutes > 0 {
+		details["auto_approve_max_minutes"] = strconv.Itoa(cfg.AutoApproveMaxMinutes)
+	}
+	_ = h.Audit.Log(ctx, req.RequestID, models.EventAutoApproved, req.AccountID, req.ChannelID,
+		models.PolicyActor, details)
+
+	_ = h.startGrant(ctx, req)
+
+	if refreshed, err := h.DB.GetRequest(ctx, req.RequestID); err == nil && refreshed != nil {
+		return refreshed
+	}
+	return req
+}
+
+// validateCreateInput checks the fields of a create request that need no
+// lookups, returning the normalized priority and metadata.
+func validateCreateInput(input models.CreateRequestInput) (string, map[string]string, error) {
+	if input.AccountID == "" || input.ChannelID == "" {
+		return "", nil, fmt.Errorf("account_id and channel_id are required")
+	}
+	if input.RequesterMMUserID == "" || input.RequesterEmail == "" {
+		return "", nil, fmt.Errorf("requester_mm_user_id and requester_email are required")
+	}
+	if input.Jira == "" && input.Reason == "" {
+		return "", nil, fmt.Errorf("either jira or reason must be provided")
+	}
+	if err := models.ValidateID("channel_id", input.ChannelID); err != nil {
+		return "", nil, err
+	}
+	if err := models.ValidateID("requester_mm_user_id", input.RequesterMMUserID); err != nil {
+		return "", nil, err
+	}
+	if input.RequestedDurationMinutes <= 0 {
+		return "", nil, fmt.Errorf("requested_duration_minutes must be positive")
+	}
+	if input.RequestedDurationMinutes > maxRequestDurationMinutes {
+		return "", nil, fmt.Errorf("requested duration %d minutes exceeds the hard limit of %d minutes",
+			input.RequestedDurationMinutes, maxRequestDurationMinutes)
+	}
+	priority, err := normalizePriority(input.Priority)
+	if err != nil {
+		return "", nil, err
+	}
+	metadata, err := validateMetadata(input.Metadata)
+	if err != nil {
+		return "", nil, err
+	}
+	return priority, metadata, nil
+}
+
+// validateCreateBinding checks a create request against its binding (nil if
+// the account is not bound to the channel), returning the permission sets to
+// grant.
+func validateCreateBinding(cfg *models.JitConfig, input models.CreateRequestInput) ([]string, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", input.ChannelID, input.AccountID)
+	}
+	if cfg.Paused {
+		return nil, pausedError(cfg)
+	}
+
+	// Validate duration against the binding's max and min.
+	maxMinutes := cfg.MaxRequestHours * 60
+	if maxMinutes > 0 && input.RequestedDurationMinutes > maxMinutes {
+		return nil, fmt.Errorf("requested duration %d minutes exceeds maximum %d minutes", input.RequestedDurationMinutes, maxMinutes)
+	}
+	if cfg.MinRequestMinutes > 0 && input.RequestedDurationMinutes < cfg.MinRequestMinutes {
+		return nil, fmt.Errorf("requested duration %d minutes is below minimum %d minutes", input.RequestedDurationMinutes, cfg.MinRequestMinutes)
+	}
+
+	// Validate any requested permission set bundle against the allowlist.
+	return validatePermissionSets(input.PermissionSetARNs, cfg.AllowedPermissionSetARNs)
+}
+
+// HandleValidateRequest processes POST /requests/validate.
+// Runs the checks HandleCreateRequest makes (fields, binding, duration cap,
+// identity lookup) without writing anything, so the plugin can show inline
+// errors before the user submits. Each independent check that fails adds an
+// entry to Errors; only a failed binding lookup is returned as an error.
+func (h *Handler) HandleValidateRequest(ctx context.Context, input models.CreateRequestInput) (*models.ValidateRequestResponse, error) {
+	resp := &models.ValidateRequestResponse{Errors: []string{}}
+	if _, _, err := validateCreateInput(input); err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+
+	maxMinutes := maxRequestDurationMinutes
+	if input.AccountID != "" && models.ValidateID("channel_id", input.ChannelID) == nil {
+		cfg, err := h.DB.GetConfig(ctx, input.ChannelID, input.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("lookup config: %w", err)
+		}
+		if _, err := validateCreateBinding(cfg, input); err != nil {
+			resp.Errors = append(resp.Errors, err.Error())
+		}
+		if cfg != nil && cfg.MaxRequestHours > 0 && cfg.MaxRequestHours*60 < maxMinutes {
+			maxMinutes = cfg.MaxRequestHours * 60
+		}
+	}
+
+	if input.RequesterEmail != "" {
+		if _, err := h.Identity.LookupUserByEmail(ctx, input.RequesterEmail); err != nil {
+			resp.Errors = append(resp.Errors, fmt.Sprintf("identity lookup: %v", err))
+		}
+	}
+
+	resp.Valid = len(resp.Errors) == 0
+	resp.EffectiveDurationMinutes = min(max(input.RequestedDurationMinutes, 0), maxMinutes)
+	return resp, nil
+}
+
+// HandleApproveWithModification processes POST /requests/{id}/approve with an
+// approved_duration_minutes. It approves the request for that shorter
+// duration, which may not exceed the requested duration or the binding's
+// maximum, and moves end_time forward to match.
+func (h *Handler) HandleApproveWithModification(ctx context.Context, input models.ApproveRequestInput) (*models.JitRequest, error) {
+	if input.ApprovedDurationMinutes <= 0 {
+		return nil, fmt.Errorf("approved_duration_minutes must be positive")
+	}
+	return h.HandleApproveRequest(ctx, input)
+}
+
 // HandleApproveRequest processes POST /requests/{id}/approve.
 func (h *Handler) HandleApproveRequest(ctx context.Context, input models.ApproveRequestInput) (*models.JitRequest, error) {
+	req, err := h.approve(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = h.startGrant(ctx, req)
+
+	// Refresh and return.
+	req, _ = h.DB.GetRequest(ctx, input.RequestID)
+	return req, nil
+}
+
+// approve checks and records an approval, audits it and sends any APPROVED
+// webhook, leaving the request APPROVED for the caller to grant.
+func (h *Handler) approve(ctx context.Context, input models.ApproveRequestInput) (*models.JitRequest, error) {
 	if input.RequestID == "" {
 		return nil, fmt.Errorf("request_id is required")
 	}
@@ -123,77 +376,316 @@ func (h *Handler) HandleApproveRequest(ctx context.Context, input models.Approve
 		return nil, fmt.Errorf("request %s is in status %s, expected PENDING", input.RequestID, req.Status)
 	}
 
-	// Load config for self-approval check.
+	// Re-check the binding: if the account was unbound while the request was
+	// pending, it is no longer governed by this channel and must not be granted.
 	cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
 	if err != nil {
 		return nil, fmt.Errorf("lookup config for approval: %w", err)
 	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", req.ChannelID, req.AccountID)
+	}
 
 	// Verify approver is authorized.
-	if cfg != nil {
-		isApprover := false
-		for _, uid := range cfg.ApproverMMUserIDs {
-			if uid == input.ApproverMMUserID {
-				isApprover = true
-				break
-			}
-		}
-		if !isApprover {
-			return nil, fmt.Errorf("user %s is not an authorized approver", input.ApproverMMUserID)
-		}
+	if !cfg.IsApprover(input.ApproverMMUserID) {
+		return nil, fmt.Errorf("user %s is not an authorized approver", input.ApproverMMUserID)
+	}
 
-		// Self-approval check.
-		if !cfg.AllowSelfApproval && input.ApproverMMUserID == req.RequesterMMUserID {
-			return nil, fmt.Errorf("self-approval is not allowed")
+	// Self-approval check.
+	if !h.selfApprovalAllowed(cfg) && input.ApproverMMUserID == req.RequesterMMUserID {
+		return nil, fmt.Errorf("self-approval is not allowed")
+	}
+
+	// Strong-auth check for high-risk accounts.
+	if cfg.RequireStrongAuth {
+		if err := h.checkStrongAuth(ctx); err != nil {
+			return nil, err
 		}
 	}
 
-	now := time.Now().UTC()
+	now := h.now()
 
-	// Conditional update to APPROVED.
+	// Conditional update to APPROVED. The condition on PENDING also makes
+	// the approval list safe to rewrite with the new record appended.
+	approval := models.ApprovalRecord{
+		ApproverMMUserID: input.ApproverMMUserID,
+		ApproverEmail:    input.ApproverEmail,
+		ApprovedAt:       now.Format(time.RFC3339),
+		Comment:          strings.TrimSpace(input.Comment),
+	}
 	updates := map[string]interface{}{
 		"status":              models.StatusApproved,
-		"approved_at":         now.Format(time.RFC3339),
+		"approved_at":         approval.ApprovedAt,
 		"approver_mm_user_id": input.ApproverMMUserID,
 		"approver_email":      input.ApproverEmail,
+		"approvals":           appendApproval(req.Approvals, approval),
 	}
-	if err := h.DB.ConditionalUpdateStatus(ctx, input.RequestID, models.StatusPending, updates); err != nil {
+	details := map[string]string{}
+	if approval.Comment != "" {
+		details["comment"] = approval.Comment
+	}
+	if input.ApprovedDurationMinutes != 0 {
+		endTime, err := approvedEndTime(req, cfg, input.ApprovedDurationMinutes, now)
+		if err != nil {
+			return nil, err
+		}
+		updates["approved_duration_minutes"] = input.ApprovedDurationMinutes
+		updates["end_time"] = endTime
+		details["requested_duration_minutes"] = fmt.Sprintf("%d", req.RequestedDurationMinutes)
+		details["approved_duration_minutes"] = fmt.Sprintf("%d", input.ApprovedDurationMinutes)
+		req.ApprovedDurationMinutes = input.ApprovedDurationMinutes
+		req.EndTime = endTime
+	}
+	if err := TransitionStatus(ctx, h.DB, input.RequestID, models.StatusPending, updates); err != nil {
 		return nil, fmt.Errorf("update to APPROVED: %w", err)
 	}
 
 	slog.Info("request approved",
 		"request_id", input.RequestID,
 		"approver", input.ApproverEmail,
+		"duration_minutes", req.DurationMinutes(),
 	)
 
 	// Audit the approval.
 	_ = h.Audit.Log(ctx, input.RequestID, models.EventApproved, req.AccountID, req.ChannelID,
-		input.ApproverMMUserID, input.ApproverEmail, nil)
+		models.HumanActor(input.ApproverMMUserID, input.ApproverEmail), details)
+
+	// Granting can take a while (Identity Center polling); let the plugin
+	// show the request as approved and provisioning in the meantime.
+	if h.NotifyOnApprove {
+		_ = h.Webhook.Notify(ctx, models.WebhookPayload{
+			RequestID: req.RequestID,
+			Status:    models.StatusApproved,
+			AccountID: req.AccountID,
+			ChannelID: req.ChannelID,
+			Actor:     input.ApproverEmail,
+			Details: requestDetails(map[string]string{
+				"requester_email":  req.RequesterEmail,
+				"duration_minutes": fmt.Sprintf("%d", req.DurationMinutes()),
+			}, req),
+		})
+	}
+
+	return req, nil
+}
+
+// startGrant grants an APPROVED request: in-process in RevokeModeReconciler,
+// otherwise by starting the Step Functions grant workflow. Failures never
+// undo the approval: an inline grant failure is recorded on the request
+// (ERROR) and notified, and a workflow that failed to start is logged,
+// returned for callers that report it, and caught by the reconciler.
+func (h *Handler) startGrant(ctx context.Context, req *models.JitRequest) error {
+	if h.RevokeMode == RevokeModeReconciler {
+		h.grantInline(ctx, req.RequestID)
+		return nil
+	}
 
-	// Start the Step Functions grant workflow.
-	sfInput := models.StepFunctionInput{
+	if h.SFN == nil {
+		return nil
+	}
+	arn, err := h.SFN.StartExecution(ctx, models.StepFunctionInput{
 		RequestID:           req.RequestID,
 		AccountID:           req.AccountID,
 		ChannelID:           req.ChannelID,
 		IdentityStoreUserID: req.IdentityStoreUserID,
-		DurationMinutes:     req.RequestedDurationMinutes,
+		DurationMinutes:     req.DurationMinutes(),
 		RequesterEmail:      req.RequesterEmail,
+		WaitSeconds:         h.capWait(ctx, req),
+	})
+	if err != nil {
+		slog.Error("failed to start grant workflow",
+			"request_id", req.RequestID,
+			"error", err,
+		)
+		return fmt.Errorf("start grant workflow: %w", err)
+	}
+	if arn != "" {
+		if err := h.DB.UpdateRequestStatus(ctx, req.RequestID, map[string]interface{}{
+			"execution_arn": arn,
+		}); err != nil {
+			slog.Warn("failed to record execution ARN",
+				"request_id", req.RequestID,
+				"execution_arn", arn,
+				"error", err,
+			)
+		}
 	}
-	if h.SFN != nil {
-		if err := h.SFN.StartExecution(ctx, sfInput); err != nil {
-			slog.Error("failed to start grant workflow",
-				"request_id", input.RequestID,
+	return nil
+}
+
+// capWait applies MaxWaitSeconds to a request about to start its workflow.
+// It returns the capped wait, or zero when the duration is within the cap.
+// When capped, end_time is brought forward to the end of the wait if that is
+// earlier, so the reconciler expires the grant no later than the workflow.
+func (h *Handler) capWait(ctx context.Context, req *models.JitRequest) int {
+	requested := req.DurationMinutes() * 60
+	if h.MaxWaitSeconds <= 0 || requested <= h.MaxWaitSeconds {
+		return 0
+	}
+
+	cappedEnd := h.now().Add(time.Duration(h.MaxWaitSeconds) * time.Second)
+	if end, err := time.Parse(time.RFC3339, req.EndTime); err != nil || cappedEnd.Before(end) {
+		capped := cappedEnd.Format(time.RFC3339)
+		if err := h.DB.UpdateRequestStatus(ctx, req.RequestID, map[string]interface{}{
+			"end_time": capped,
+		}); err != nil {
+			slog.Warn("failed to record capped end_time",
+				"request_id", req.RequestID,
+				"end_time", capped,
 				"error", err,
 			)
-			// Don't fail the approval — the reconciler will catch it.
+		} else {
+			req.EndTime = capped
 		}
 	}
 
-	// Refresh and return.
-	req, _ = h.DB.GetRequest(ctx, input.RequestID)
+	slog.Warn("grant workflow wait capped below requested duration",
+		"request_id", req.RequestID,
+		"duration_seconds", requested,
+		"wait_seconds", h.MaxWaitSeconds,
+		"end_time", req.EndTime,
+	)
+	return h.MaxWaitSeconds
+}
+
+// appendApproval returns a copy of approvals with record added at the end.
+func appendApproval(approvals []models.ApprovalRecord, record models.ApprovalRecord) []models.ApprovalRecord {
+	out := make([]models.ApprovalRecord, 0, len(approvals)+1)
+	return append(append(out, approvals...), record)
+}
+
+// approvedEndTime validates a reduced approval duration and returns the
+// request's new end_time, measured from creation like the original.
+func approvedEndTime(req *models.JitRequest, cfg *models.JitConfig, minutes int, now time.Time) (string, error) {
+	if minutes <= 0 {
+		return "", fmt.Errorf("approved_duration_minutes must be positive")
+	}
+	if minutes > req.RequestedDurationMinutes {
+		return "", fmt.Errorf("approved duration %d minutes exceeds requested duration %d minutes",
+			minutes, req.RequestedDurationMinutes)
+	}
+	if maxMinutes := cfg.MaxRequestHours * 60; maxMinutes > 0 && minutes > maxMinutes {
+		return "", fmt.Errorf("approved duration %d minutes exceeds maximum %d minutes", minutes, maxMinutes)
+	}
+	start, err := time.Parse(time.RFC3339, req.CreatedAt)
+	if err != nil {
+		start = now
+	}
+	return start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339), nil
+}
+
+// HandleCreateApprovalToken processes POST /requests/{id}/approval-token.
+// Mints a short-lived, single-use token that lets the named approver approve
+// the request without going through the plugin (e.g. from an email link).
+func (h *Handler) HandleCreateApprovalToken(ctx context.Context, input models.ApprovalTokenInput) (*models.ApprovalTokenResponse, error) {
+	if h.ApprovalTokens == nil {
+		return nil, fmt.Errorf("approval tokens are not enabled")
+	}
+	if input.RequestID == "" {
+		return nil, fmt.Errorf("request_id is required")
+	}
+	if input.ApproverMMUserID == "" || input.ApproverEmail == "" {
+		return nil, fmt.Errorf("approver_mm_user_id and approver_email are required")
+	}
+
+	req, err := h.DB.GetRequest(ctx, input.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request %s not found", input.RequestID)
+	}
+	if req.Status != models.StatusPending {
+		return nil, fmt.Errorf("request %s is in status %s, expected PENDING", input.RequestID, req.Status)
+	}
+
+	// Only mint tokens for users who could approve the request anyway.
+	cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup config for approval token: %w", err)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", req.ChannelID, req.AccountID)
+	}
+	if !cfg.IsApprover(input.ApproverMMUserID) {
+		return nil, fmt.Errorf("user %s is not an authorized approver", input.ApproverMMUserID)
+	}
+
+	token, expiresAt, err := h.ApprovalTokens.MintApprovalToken(input.RequestID, input.ApproverMMUserID, input.ApproverEmail, auth.DefaultApprovalTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("mint approval token: %w", err)
+	}
+
+	slog.Info("approval token minted",
+		"request_id", input.RequestID,
+		"approver", input.ApproverEmail,
+		"expires_at", expiresAt.UTC().Format(time.RFC3339),
+	)
+	return &models.ApprovalTokenResponse{
+		RequestID: input.RequestID,
+		Token:     token,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// HandleApproveWithToken processes POST /requests/{id}/approve-with-token.
+// The token is validated, then the request is approved on behalf of the
+// approver named in the token using the same rules as HandleApproveRequest.
+// The token is consumed only once the approval has been recorded, so an
+// approval rejected by those rules leaves it usable.
+func (h *Handler) HandleApproveWithToken(ctx context.Context, input models.ApproveWithTokenInput) (*models.JitRequest, error) {
+	if h.ApprovalTokens == nil {
+		return nil, fmt.Errorf("approval tokens are not enabled")
+	}
+	if input.RequestID == "" {
+		return nil, fmt.Errorf("request_id is required")
+	}
+	if input.Token == "" {
+		return nil, fmt.Errorf("token is required")
+	}
+
+	claims, err := h.ApprovalTokens.VerifyApprovalToken(ctx, input.Token, input.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid approval token: %w", err)
+	}
+
+	req, err := h.HandleApproveRequest(ctx, models.ApproveRequestInput{
+		RequestID:        claims.RequestID,
+		ApproverMMUserID: claims.ApproverMMUserID,
+		ApproverEmail:    claims.ApproverEmail,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The request has left PENDING, so a concurrent redemption of the same
+	// token cannot approve it again even if consuming the nonce fails here.
+	if err := h.ApprovalTokens.ConsumeApprovalToken(ctx, input.Token, claims); err != nil {
+		slog.Warn("failed to consume approval token after approval",
+			"request_id", input.RequestID,
+			"error", err,
+		)
+	}
 	return req, nil
 }
 
+// checkStrongAuth verifies the caller asserted a sufficient auth level in the
+// signed request headers.
+func (h *Handler) checkStrongAuth(ctx context.Context) error {
+	minLevel := h.MinStrongAuthLevel
+	if minLevel <= 0 {
+		minLevel = DefaultMinStrongAuthLevel
+	}
+	level, ok := auth.AuthLevelFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("strong authentication required: no auth level asserted")
+	}
+	if level < minLevel {
+		return fmt.Errorf("strong authentication required: auth level %d is below required %d", level, minLevel)
+	}
+	return nil
+}
+
 // HandleDenyRequest processes POST /requests/{id}/deny.
 func (h *Handler) HandleDenyRequest(ctx context.Context, input models.DenyRequestInput) (*models.JitRequest, error) {
 	if input.RequestID == "" {
@@ -215,46 +707,73 @@ func (h *Handler) HandleDenyRequest(ctx context.Context, input models.DenyReques
 		return nil, fmt.Errorf("request %s is in status %s, expected PENDING", input.RequestID, req.Status)
 	}
 
-	// Verify denier is an authorized approver.
+	// Verify denier is an authorized approver. Fail closed if the binding
+	// has no config: without an approver list nobody is authorized.
 	cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
 	if err != nil {
 		return nil, fmt.Errorf("lookup config for deny: %w", err)
 	}
-	if cfg != nil {
-		isApprover := false
-		for _, uid := range cfg.ApproverMMUserIDs {
-			if uid == input.DenierMMUserID {
-				isApprover = true
-				break
-			}
-		}
-		if !isApprover {
-			return nil, fmt.Errorf("user %s is not an authorized approver", input.DenierMMUserID)
-		}
+	if cfg == nil {
+		return nil, fmt.Errorf("no config found for channel %s and account %s", req.ChannelID, req.AccountID)
+	}
+	if !cfg.IsApprover(input.DenierMMUserID) {
+		return nil, fmt.Errorf("user %s is not an authorized approver", input.DenierMMUserID)
+	}
+
+	// Self-deny check.
+	selfDeny := input.DenierMMUserID == req.RequesterMMUserID
+	if selfDeny && !cfg.SelfDenyAllowed() {
+		return nil, fmt.Errorf("self-denial is not allowed")
 	}
 
-	now := time.Now().UTC()
+	now := h.now()
 	updates := map[string]interface{}{
 		"status":              models.StatusDenied,
 		"denied_at":           now.Format(time.RFC3339),
 		"approver_mm_user_id": input.DenierMMUserID,
 		"approver_email":      input.DenierEmail,
 	}
-	if err := h.DB.ConditionalUpdateStatus(ctx, input.RequestID, models.StatusPending, updates); err != nil {
+	if err := TransitionStatus(ctx, h.DB, input.RequestID, models.StatusPending, updates); err != nil {
 		return nil, fmt.Errorf("update to DENIED: %w", err)
 	}
 
 	slog.Info("request denied",
 		"request_id", input.RequestID,
 		"denier", input.DenierEmail,
+		"self_deny", selfDeny,
 	)
 
-	// Audit the denial.
+	// Audit the denial, distinguishing requesters who withdrew their own request.
+	var details map[string]string
+	if selfDeny || input.Reason != "" {
+		details = map[string]string{}
+		if selfDeny {
+			details["self_deny"] = "true"
+		}
+		if input.Reason != "" {
+			details["reason"] = input.Reason
+		}
+	}
 	_ = h.Audit.Log(ctx, input.RequestID, models.EventDenied, req.AccountID, req.ChannelID,
-		input.DenierMMUserID, input.DenierEmail, nil)
+		models.HumanActor(input.DenierMMUserID, input.DenierEmail), details)
 
-	// No webhook notification for denials — the plugin updates the approval
-	// card in-place when the deny dialog is submitted.
+	// The plugin updates the approval card in-place when the deny dialog is
+	// submitted; requesters are only told directly when opted in.
+	if h.NotifyRequesterOnDeny {
+		denyDetails := map[string]string{"denier_mm_user_id": input.DenierMMUserID}
+		if input.Reason != "" {
+			denyDetails["reason"] = input.Reason
+		}
+		_ = h.Webhook.Notify(ctx, models.WebhookPayload{
+			RequestID:         input.RequestID,
+			Status:            models.StatusDenied,
+			AccountID:         req.AccountID,
+			ChannelID:         req.ChannelID,
+			Actor:             input.DenierEmail,
+			Details:           requestDetails(denyDetails, req),
+			RequesterMMUserID: req.RequesterMMUserID,
+		})
+	}
 
 	req, _ = h.DB.GetRequest(ctx, input.RequestID)
 	return req, nil
@@ -281,29 +800,54 @@ func (h *Handler) HandleRevokeRequest(ctx context.Context, input models.RevokeRe
 		return nil, fmt.Errorf("request %s is in status %s, expected GRANTED", input.RequestID, req.Status)
 	}
 
+	// Self-revoke check. Only the requester's own revokes need the binding;
+	// anyone else may revoke as before.
+	if input.ActorMMUserID == req.RequesterMMUserID {
+		cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("lookup config for revoke: %w", err)
+		}
+		if cfg != nil && !cfg.SelfRevokeAllowed() {
+			slog.Warn("self-revocation rejected",
+				"request_id", input.RequestID,
+				"actor", input.ActorEmail,
+			)
+			_ = h.Audit.Log(ctx, input.RequestID, models.EventSelfRevokeRejected, req.AccountID, req.ChannelID,
+				models.HumanActor(input.ActorMMUserID, input.ActorEmail), nil)
+			return nil, fmt.Errorf("self-revocation is not allowed")
+		}
+	}
+
 	// Revoke IAM Identity Center access.
-	if err := h.Identity.RevokeAccess(ctx, req.AccountID, req.IdentityStoreUserID); err != nil {
+	psStatus, err := RevokePermissionSets(ctx, h.Identity, req)
+	if err != nil {
 		slog.Error("failed to revoke access",
 			"request_id", input.RequestID,
 			"error", err,
 		)
 		// Update to ERROR state with details.
-		errUpdates := map[string]interface{}{
-			"status":        models.StatusError,
-			"error_details": err.Error(),
+		errUpdates := ErrorUpdates(NewErrorInfo(models.ErrorPhaseRevoke, err, h.now()))
+		errUpdates["assignment_status"] = models.AssignmentFailed
+		if psStatus != nil {
+			errUpdates["permission_set_status"] = psStatus
 		}
-		_ = h.DB.ConditionalUpdateStatus(ctx, input.RequestID, models.StatusGranted, errUpdates)
+		_ = TransitionStatus(ctx, h.DB, input.RequestID, models.StatusGranted, errUpdates)
 		return nil, fmt.Errorf("revoke access: %w", err)
 	}
 
-	now := time.Now().UTC()
+	now := h.now()
 	updates := map[string]interface{}{
-		"status":     models.StatusRevoked,
-		"revoked_at": now.Format(time.RFC3339),
+		"status":            models.StatusRevoked,
+		"revoked_at":        now.Format(time.RFC3339),
+		"assignment_status": models.AssignmentDeleted,
 	}
-	if err := h.DB.ConditionalUpdateStatus(ctx, input.RequestID, models.StatusGranted, updates); err != nil {
+	if psStatus != nil {
+		updates["permission_set_status"] = psStatus
+	}
+	if err := TransitionStatus(ctx, h.DB, input.RequestID, models.StatusGranted, updates); err != nil {
 		return nil, fmt.Errorf("update to REVOKED: %w", err)
 	}
+	ReleaseGrantSlot(ctx, h.DB, req)
 
 	slog.Info("request revoked",
 		"request_id", input.RequestID,
@@ -312,7 +856,7 @@ func (h *Handler) HandleRevokeRequest(ctx context.Context, input models.RevokeRe
 
 	// Audit the revocation.
 	_ = h.Audit.Log(ctx, input.RequestID, models.EventRevoked, req.AccountID, req.ChannelID,
-		input.ActorMMUserID, input.ActorEmail, nil)
+		models.HumanActor(input.ActorMMUserID, input.ActorEmail), nil)
 
 	// Webhook notify.
 	_ = h.Webhook.Notify(ctx, models.WebhookPayload{
@@ -321,28 +865,75 @@ func (h *Handler) HandleRevokeRequest(ctx context.Context, input models.RevokeRe
 		AccountID: req.AccountID,
 		ChannelID: req.ChannelID,
 		Actor:     input.ActorEmail,
+		Details:   requestDetails(nil, req),
 	})
 
 	req, _ = h.DB.GetRequest(ctx, input.RequestID)
 	return req, nil
 }
 
+// dateOnlyLayout is the YYYY-MM-DD form accepted for date filters.
+const dateOnlyLayout = "2006-01-02"
+
+// normalizeDateBound rewrites a start_date or end_date filter in the UTC
+// RFC3339 form created_at is stored in, since the query compares them as
+// strings. A date-only value covers that whole UTC day: its first second for
+// a start bound, its last for an end bound.
+func normalizeDateBound(name, value string, end bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	day, err := time.Parse(dateOnlyLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: must be an RFC3339 timestamp with a timezone or a YYYY-MM-DD date", name, value)
+	}
+	if end {
+		day = day.Add(24*time.Hour - time.Second)
+	}
+	return day.Format(time.RFC3339), nil
+}
+
 // HandleListRequests processes GET /requests with filters.
 func (h *Handler) HandleListRequests(ctx context.Context, input models.ReportingInput) (*models.ReportingResponse, error) {
 	// D5/E4: Require at least one filter to prevent unfiltered table scans.
-	if input.ChannelID == "" && input.AccountID == "" && input.RequesterEmail == "" && input.Status == "" {
-		return nil, fmt.Errorf("at least one filter is required (channel_id, account_id, requester_email, or status)")
+	if input.ChannelID == "" && input.AccountID == "" && input.RequesterEmail == "" && input.Jira == "" && input.Status == "" {
+		return nil, fmt.Errorf("at least one filter is required (channel_id, account_id, requester_email, jira, or status)")
 	}
-
-	if input.Limit <= 0 {
-		input.Limit = 50
+	if input.Status != "" {
+		statuses, err := models.ParseStatuses(input.Status)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) == 0 {
+			return nil, fmt.Errorf("invalid status %q: no statuses given", input.Status)
+		}
+		input.Status = strings.Join(statuses, ",")
+	}
+	var fields []string
+	if input.Fields != "" {
+		var err error
+		if fields, err = models.ParseRequestFields(input.Fields); err != nil {
+			return nil, err
+		}
 	}
-	if input.Limit > 200 {
-		input.Limit = 200
+	var err error
+	if input.StartDate, err = normalizeDateBound("start_date", input.StartDate, false); err != nil {
+		return nil, err
 	}
+	if input.EndDate, err = normalizeDateBound("end_date", input.EndDate, true); err != nil {
+		return nil, err
+	}
+
+	input.Limit = models.NormalizeLimit(input.Limit)
 
 	requests, nextToken, err := h.DB.QueryRequests(ctx, input)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidNextToken) {
+			return nil, fmt.Errorf("%w: pass the next_token from the previous page of the same query unchanged", models.ErrInvalidNextToken)
+		}
 		return nil, fmt.Errorf("query requests: %w", err)
 	}
 
@@ -356,6 +947,9 @@ func (h *Handler) HandleListRequests(ctx context.Context, input models.Reporting
 	if input.RequesterEmail != "" {
 		filters["requester_email"] = input.RequesterEmail
 	}
+	if input.Jira != "" {
+		filters["jira"] = input.Jira
+	}
 	if input.Status != "" {
 		filters["status"] = input.Status
 	}
@@ -369,11 +963,117 @@ func (h *Handler) HandleListRequests(ctx context.Context, input models.Reporting
 	if requests == nil {
 		requests = []models.JitRequest{}
 	}
+	// Pending requests are surfaced most urgent first.
+	if input.Status == models.StatusPending {
+		sortByPriority(requests)
+	}
 
 	return &models.ReportingResponse{
 		Items:     requests,
 		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(requests),
 		Filters:   filters,
+		Fields:    fields,
+	}, nil
+}
+
+// HandleGetExecution processes GET /requests/{id}/execution.
+// Returns a simplified timeline of the request's Step Functions execution.
+func (h *Handler) HandleGetExecution(ctx context.Context, requestID string) (*models.ExecutionTimeline, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("request_id is required")
+	}
+
+	req, err := h.DB.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request %s not found", requestID)
+	}
+
+	events, err := h.SFN.GetExecution(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ExecutionTimeline{RequestID: requestID, Events: events}, nil
+}
+
+// HandleGetRequestApprovers processes GET /requests/{id}/approvers.
+// Returns who may approve the request under its binding's current approvers:
+// the account's own list when set, otherwise the channel's, without the
+// requester when self-approval is not allowed.
+func (h *Handler) HandleGetRequestApprovers(ctx context.Context, requestID string) (*models.RequestApproversResponse, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("request_id is required")
+	}
+
+	req, err := h.DB.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("request %s not found", requestID)
+	}
+
+	cfg, err := h.DB.GetConfig(ctx, req.ChannelID, req.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup config: %w", err)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", req.ChannelID, req.AccountID)
+	}
+
+	selfApproval := h.selfApprovalAllowed(cfg)
+	approvers := []string{}
+	for _, uid := range cfg.Approvers() {
+		if !selfApproval && uid == req.RequesterMMUserID {
+			continue
+		}
+		approvers = append(approvers, uid)
+	}
+	return &models.RequestApproversResponse{
+		RequestID:           requestID,
+		ApproverMMUserIDs:   approvers,
+		SelfApprovalAllowed: selfApproval,
+	}, nil
+}
+
+// HandleListMyRequests processes GET /requests/mine.
+// Returns one requester's requests across all channels, newest first, using
+// the requester GSI.
+func (h *Handler) HandleListMyRequests(ctx context.Context, input models.MyRequestsInput) (*models.ReportingResponse, error) {
+	email := strings.TrimSpace(input.RequesterEmail)
+	if email == "" {
+		return nil, fmt.Errorf("requester_email is required")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, fmt.Errorf("requester_email %q is not a valid email address", email)
+	}
+
+	limit := models.NormalizeLimit(input.Limit)
+
+	// Only the requester filter is set, so the query is served by
+	// gsi_requester_created in created_at descending order.
+	requests, nextToken, err := h.DB.QueryRequests(ctx, models.ReportingInput{
+		RequesterEmail: email,
+		NextToken:      input.NextToken,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query requests: %w", err)
+	}
+	if requests == nil {
+		requests = []models.JitRequest{}
+	}
+
+	return &models.ReportingResponse{
+		Items:     requests,
+		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(requests),
+		Filters:   map[string]string{"requester_email": email},
 	}, nil
 }
 
@@ -383,6 +1083,9 @@ func (h *Handler) HandleBindAccount(ctx context.Context, input models.BindAccoun
 	if input.ChannelID == "" || input.AccountID == "" {
 		return nil, fmt.Errorf("channel_id and account_id are required")
 	}
+	if err := models.ValidateID("channel_id", input.ChannelID); err != nil {
+		return nil, err
+	}
 
 	// Check if already bound to a different channel.
 	existing, err := h.DB.GetChannelForAccount(ctx, input.AccountID)
@@ -393,7 +1096,7 @@ func (h *Handler) HandleBindAccount(ctx context.Context, input models.BindAccoun
 		return nil, fmt.Errorf("account %s is already bound to channel %s", input.AccountID, existing.ChannelID)
 	}
 
-	now := time.Now().UTC()
+	now := h.now()
 	cfg := &models.JitConfig{
 		ChannelID:       input.ChannelID,
 		AccountID:       input.AccountID,
@@ -411,8 +1114,24 @@ func (h *Handler) HandleBindAccount(ctx context.Context, input models.BindAccoun
 		cfg.ApproverMMUserIDs = existingCfg.ApproverMMUserIDs
 		cfg.ApprovalPolicy = existingCfg.ApprovalPolicy
 		cfg.AllowSelfApproval = existingCfg.AllowSelfApproval
+		cfg.AllowSelfDeny = existingCfg.AllowSelfDeny
+		cfg.AllowSelfRevoke = existingCfg.AllowSelfRevoke
 		cfg.MaxRequestHours = existingCfg.MaxRequestHours
+		cfg.MinRequestMinutes = existingCfg.MinRequestMinutes
 		cfg.SessionDurationMinutes = existingCfg.SessionDurationMinutes
+		cfg.AllowedPermissionSetARNs = existingCfg.AllowedPermissionSetARNs
+		cfg.RequireStrongAuth = existingCfg.RequireStrongAuth
+		cfg.MaxConcurrentGrants = existingCfg.MaxConcurrentGrants
+		cfg.AccountApproverMMUserIDs = existingCfg.AccountApproverMMUserIDs
+		cfg.Paused = existingCfg.Paused
+		cfg.PauseReason = existingCfg.PauseReason
+		cfg.AutoApprove = existingCfg.AutoApprove
+		cfg.AutoApproveMaxMinutes = existingCfg.AutoApproveMaxMinutes
+		cfg.WebhookURL = existingCfg.WebhookURL
+		cfg.WebhookKeyID = existingCfg.WebhookKeyID
+	}
+	if len(input.AllowedPermissionSetARNs) > 0 {
+		cfg.AllowedPermissionSetARNs = input.AllowedPermissionSetARNs
 	}
 
 	if err := h.DB.PutConfig(ctx, cfg); err != nil {
@@ -422,19 +1141,340 @@ func (h *Handler) HandleBindAccount(ctx context.Context, input models.BindAccoun
 	slog.Info("account bound to channel",
 		"channel_id", input.ChannelID,
 		"account_id", input.AccountID,
+		"actor", input.ActorEmail,
 	)
+
+	details := map[string]string{"rebound": strconv.FormatBool(existingCfg != nil)}
+	if len(input.AllowedPermissionSetARNs) > 0 {
+		details["allowed_permission_set_arns"] = strings.Join(input.AllowedPermissionSetARNs, ",")
+	}
+	_ = h.Audit.Log(ctx, models.ConfigAuditKey(input.ChannelID, input.AccountID), models.EventConfigBound,
+		input.AccountID, input.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail), details)
+
+	return cfg, nil
+}
+
+// Allowed ranges for binding settings changed via HandleUpdateConfig.
+const (
+	minMaxRequestHours        = 1
+	maxMaxRequestHours        = 24
+	minSessionDurationMinutes = 60  // Identity Center permission set minimum (1h).
+	maxSessionDurationMinutes = 720 // Identity Center permission set maximum (12h).
+)
+
+// HandleUpdateConfig processes PATCH /config/bind.
+// Updates only the provided settings of an existing binding.
+func (h *Handler) HandleUpdateConfig(ctx context.Context, input models.UpdateConfigInput) (*models.JitConfig, error) {
+	if input.ChannelID == "" || input.AccountID == "" {
+		return nil, fmt.Errorf("channel_id and account_id are required")
+	}
+
+	updates := map[string]interface{}{}
+	details := map[string]string{}
+	if input.MaxRequestHours != nil {
+		v := *input.MaxRequestHours
+		if v < minMaxRequestHours || v > maxMaxRequestHours {
+			return nil, fmt.Errorf("max_request_hours must be between %d and %d", minMaxRequestHours, maxMaxRequestHours)
+		}
+		updates["max_request_hours"] = v
+		details["max_request_hours"] = strconv.Itoa(v)
+	}
+	if input.MinRequestMinutes != nil {
+		v := *input.MinRequestMinutes
+		if v < 0 || v > maxRequestDurationMinutes {
+			return nil, fmt.Errorf("min_request_minutes must be between 0 and %d", maxRequestDurationMinutes)
+		}
+		updates["min_request_minutes"] = v
+		details["min_request_minutes"] = strconv.Itoa(v)
+	}
+	if input.AllowSelfApproval != nil {
+		updates["allow_self_approval"] = *input.AllowSelfApproval
+		details["allow_self_approval"] = strconv.FormatBool(*input.AllowSelfApproval)
+	}
+	if input.AllowSelfDeny != nil {
+		updates["allow_self_deny"] = *input.AllowSelfDeny
+		details["allow_self_deny"] = strconv.FormatBool(*input.AllowSelfDeny)
+	}
+	if input.AllowSelfRevoke != nil {
+		updates["allow_self_revoke"] = *input.AllowSelfRevoke
+		details["allow_self_revoke"] = strconv.FormatBool(*input.AllowSelfRevoke)
+	}
+	if input.RequireStrongAuth != nil {
+		updates["require_strong_auth"] = *input.RequireStrongAuth
+		details["require_strong_auth"] = strconv.FormatBool(*input.RequireStrongAuth)
+	}
+	if input.SessionDurationMinutes != nil {
+		v := *input.SessionDurationMinutes
+		if v < minSessionDurationMinutes || v > maxSessionDurationMinutes {
+			return nil, fmt.Errorf("session_duration_minutes must be between %d and %d", minSessionDurationMinutes, maxSessionDurationMinutes)
+		}
+		updates["session_duration_minutes"] = v
+		details["session_duration_minutes"] = strconv.Itoa(v)
+	}
+	if input.MaxConcurrentGrants != nil {
+		v := *input.MaxConcurrentGrants
+		if v < 0 {
+			return nil, fmt.Errorf("max_concurrent_grants must not be negative")
+		}
+		updates["max_concurrent_grants"] = v
+		details["max_concurrent_grants"] = strconv.Itoa(v)
+	}
+	if input.AutoApprove != nil {
+		updates["auto_approve"] = *input.AutoApprove
+		details["auto_approve"] = strconv.FormatBool(*input.AutoApprove)
+	}
+	if input.AutoApproveMaxMinutes != nil {
+		v := *input.AutoApproveMaxMinutes
+		if v < 0 || v > maxRequestDurationMinutes {
+			return nil, fmt.Errorf("auto_approve_max_minutes must be between 0 and %d", maxRequestDurationMinutes)
+		}
+		updates["auto_approve_max_minutes"] = v
+		details["auto_approve_max_minutes"] = strconv.Itoa(v)
+	}
+	if input.WebhookURL != nil {
+		v := *input.WebhookURL
+		if v != "" {
+			if u, err := url.Parse(v); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return nil, fmt.Errorf("webhook_url must be an absolute http or https URL")
+			}
+		}
+		updates["webhook_url"] = v
+		details["webhook_url"] = v
+	}
+	if input.WebhookKeyID != nil {
+		updates["webhook_key_id"] = *input.WebhookKeyID
+		details["webhook_key_id"] = *input.WebhookKeyID
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("at least one of max_request_hours, min_request_minutes, allow_self_approval, allow_self_deny, allow_self_revoke, require_strong_auth, session_duration_minutes, max_concurrent_grants, auto_approve, auto_approve_max_minutes, webhook_url, or webhook_key_id is required")
+	}
+
+	existing, err := h.DB.GetConfig(ctx, input.ChannelID, input.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup config: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", input.ChannelID, input.AccountID)
+	}
+
+	// Turning strong auth off loosens the binding, so the caller must
+	// themselves meet the bar it removes.
+	if input.RequireStrongAuth != nil && !*input.RequireStrongAuth && existing.RequireStrongAuth {
+		if err := h.checkStrongAuth(ctx); err != nil {
+			return nil, fmt.Errorf("disable require_strong_auth: %w", err)
+		}
+	}
+
+	// The floor must leave room below the cap, whichever of the two changes.
+	minMinutes, maxHours := existing.MinRequestMinutes, existing.MaxRequestHours
+	if input.MinRequestMinutes != nil {
+		minMinutes = *input.MinRequestMinutes
+	}
+	if input.MaxRequestHours != nil {
+		maxHours = *input.MaxRequestHours
+	}
+	if minMinutes > 0 && maxHours > 0 && minMinutes > maxHours*60 {
+		return nil, fmt.Errorf("min_request_minutes %d exceeds max_request_hours (%d minutes)", minMinutes, maxHours*60)
+	}
+
+	updates["updated_at"] = h.now().Format(time.RFC3339)
+	cfg, err := h.DB.UpdateConfig(ctx, input.ChannelID, input.AccountID, updates)
+	if err != nil {
+		return nil, fmt.Errorf("update config: %w", err)
+	}
+
+	slog.Info("binding config updated",
+		"channel_id", input.ChannelID,
+		"account_id", input.AccountID,
+		"actor", input.ActorEmail,
+	)
+
+	_ = h.Audit.Log(ctx, models.ConfigAuditKey(input.ChannelID, input.AccountID), models.EventConfigUpdated,
+		input.AccountID, input.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail), details)
+
 	return cfg, nil
 }
 
+// selfApprovalAllowed reports whether the binding permits self-approval. The
+// global NoSelfApprovalChannels policy overrides the binding's setting.
+func (h *Handler) selfApprovalAllowed(cfg *models.JitConfig) bool {
+	for _, ch := range h.NoSelfApprovalChannels {
+		if ch == cfg.ChannelID {
+			return false
+		}
+	}
+	return cfg.AllowSelfApproval
+}
+
+// isAdmin reports whether the user is configured as an admin.
+func (h *Handler) isAdmin(mmUserID string) bool {
+	if mmUserID == "" {
+		return false
+	}
+	for _, uid := range h.AdminMMUserIDs {
+		if uid == mmUserID {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleConfigReport processes GET /admin/config-report.
+// Scans all bindings (paginated) and flags those that are misconfigured.
+// Restricted to admins because it reads the entire config table.
+func (h *Handler) HandleConfigReport(ctx context.Context, input models.ConfigReportInput) (*models.ConfigReportResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+
+	input.Limit = models.NormalizeLimit(input.Limit)
+
+	configs, nextToken, err := h.DB.ScanConfigs(ctx, int32(input.Limit), input.NextToken)
+	if err != nil {
+		return nil, fmt.Errorf("scan configs: %w", err)
+	}
+
+	items := make([]models.ConfigReportEntry, 0, len(configs))
+	for _, cfg := range configs {
+		entry := models.ConfigReportEntry{
+			ChannelID:       cfg.ChannelID,
+			AccountID:       cfg.AccountID,
+			ApproverCount:   len(cfg.Approvers()),
+			MaxRequestHours: cfg.MaxRequestHours,
+		}
+		if len(cfg.Approvers()) == 0 {
+			entry.Issues = append(entry.Issues, models.ConfigIssueNoApprovers)
+		}
+		if cfg.MaxRequestHours <= 0 {
+			entry.Issues = append(entry.Issues, models.ConfigIssueNoMaxRequestHrs)
+		}
+		items = append(items, entry)
+	}
+
+	return &models.ConfigReportResponse{
+		Items:     items,
+		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(items),
+	}, nil
+}
+
+// HandleAccountBindings processes GET /admin/account/{id}/bindings.
+// Returns every channel the account is bound to, so support can spot
+// duplicate bindings that GetChannelForAccount would silently pick one of.
+func (h *Handler) HandleAccountBindings(ctx context.Context, input models.AccountBindingsInput) (*models.AccountBindingsResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if input.AccountID == "" {
+		return nil, fmt.Errorf("account_id is required")
+	}
+
+	configs, err := h.DB.GetAllBindingsForAccount(ctx, input.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("query bindings: %w", err)
+	}
+	if configs == nil {
+		configs = []models.JitConfig{}
+	}
+	if len(configs) > 1 {
+		channels := make([]string, 0, len(configs))
+		for _, cfg := range configs {
+			channels = append(channels, cfg.ChannelID)
+		}
+		slog.Warn("account bound to multiple channels",
+			"account_id", input.AccountID,
+			"channels", channels,
+		)
+	}
+
+	return &models.AccountBindingsResponse{
+		AccountID: input.AccountID,
+		Bindings:  configs,
+		Count:     len(configs),
+		Duplicate: len(configs) > 1,
+	}, nil
+}
+
+// HandleActiveGrants processes GET /admin/active-grants.
+// Lists GRANTED requests across every channel, one page at a time, ordered
+// by end_time. Restricted to admins because it spans all bindings.
+func (h *Handler) HandleActiveGrants(ctx context.Context, input models.ActiveGrantsInput) (*models.ActiveGrantsResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+
+	input.Limit = models.NormalizeLimit(input.Limit)
+
+	requests, nextToken, err := h.DB.QueryRequestsByStatusPage(ctx, models.StatusGranted, int32(input.Limit), input.NextToken)
+	if err != nil {
+		return nil, fmt.Errorf("query active grants: %w", err)
+	}
+
+	items := make([]models.ActiveGrant, 0, len(requests))
+	for _, req := range requests {
+		items = append(items, models.ActiveGrant{
+			RequestID:         req.RequestID,
+			AccountID:         req.AccountID,
+			ChannelID:         req.ChannelID,
+			RequesterMMUserID: req.RequesterMMUserID,
+			RequesterEmail:    req.RequesterEmail,
+			ApproverEmail:     req.ApproverEmail,
+			GrantTime:         req.GrantTime,
+			EndTime:           req.EndTime,
+		})
+	}
+
+	return &models.ActiveGrantsResponse{
+		Items:     items,
+		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(items),
+	}, nil
+}
+
+// normalizeApproverIDs requires a non-empty approver list of well-formed IDs
+// and returns it without duplicates, in first-seen order. The de-duplicated
+// list must fit within the approver cap.
+func (h *Handler) normalizeApproverIDs(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one approver ID is required")
+	}
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for i, id := range ids {
+		if err := models.ValidateID(fmt.Sprintf("approver_ids[%d]", i), id); err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	limit := h.MaxApprovers
+	if limit <= 0 {
+		limit = DefaultMaxApprovers
+	}
+	if len(unique) > limit {
+		return nil, fmt.Errorf("too many approvers: %d exceeds the maximum of %d", len(unique), limit)
+	}
+	return unique, nil
+}
+
 // HandleSetApprovers processes POST /config/approvers.
-// Sets the approver list for all accounts bound to a channel.
+// Sets the approver list for all accounts bound to a channel. Accounts with
+// their own approver list (see HandleSetAccountApprovers) keep it.
 func (h *Handler) HandleSetApprovers(ctx context.Context, input models.SetApproversInput) ([]models.JitConfig, error) {
-	if input.ChannelID == "" {
-		return nil, fmt.Errorf("channel_id is required")
+	if err := models.ValidateID("channel_id", input.ChannelID); err != nil {
+		return nil, err
 	}
-	if len(input.ApproverIDs) == 0 {
-		return nil, fmt.Errorf("at least one approver ID is required")
+	approverIDs, err := h.normalizeApproverIDs(input.ApproverIDs)
+	if err != nil {
+		return nil, err
 	}
+	input.ApproverIDs = approverIDs
 
 	configs, err := h.DB.GetConfigsByChannel(ctx, input.ChannelID)
 	if err != nil {
@@ -444,7 +1484,7 @@ func (h *Handler) HandleSetApprovers(ctx context.Context, input models.SetApprov
 		return nil, fmt.Errorf("no accounts bound to channel %s", input.ChannelID)
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
+	now := h.now().Format(time.RFC3339)
 	updated := make([]models.JitConfig, 0, len(configs))
 	for _, cfg := range configs {
 		cfg.ApproverMMUserIDs = input.ApproverIDs
@@ -452,6 +1492,11 @@ func (h *Handler) HandleSetApprovers(ctx context.Context, input models.SetApprov
 		if err := h.DB.PutConfig(ctx, &cfg); err != nil {
 			return nil, fmt.Errorf("update config for account %s: %w", cfg.AccountID, err)
 		}
+		_ = h.Audit.Log(ctx, models.ConfigAuditKey(cfg.ChannelID, cfg.AccountID), models.EventApproversSet,
+			cfg.AccountID, cfg.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail), map[string]string{
+				"scope":        "channel",
+				"approver_ids": strings.Join(input.ApproverIDs, ","),
+			})
 		updated = append(updated, cfg)
 	}
 
@@ -463,6 +1508,118 @@ func (h *Handler) HandleSetApprovers(ctx context.Context, input models.SetApprov
 	return updated, nil
 }
 
+// HandlePauseChannel processes POST /config/pause.
+// Stops new requests for every account bound to a channel without unbinding
+// them. Requests already created still go through approval, grant and revoke.
+func (h *Handler) HandlePauseChannel(ctx context.Context, input models.PauseChannelInput) ([]models.JitConfig, error) {
+	return h.setChannelPaused(ctx, input, true)
+}
+
+// HandleResumeChannel processes POST /config/resume.
+// Accepts new requests for a paused channel again.
+func (h *Handler) HandleResumeChannel(ctx context.Context, input models.PauseChannelInput) ([]models.JitConfig, error) {
+	return h.setChannelPaused(ctx, input, false)
+}
+
+func (h *Handler) setChannelPaused(ctx context.Context, input models.PauseChannelInput, paused bool) ([]models.JitConfig, error) {
+	if input.ChannelID == "" {
+		return nil, fmt.Errorf("channel_id is required")
+	}
+
+	configs, err := h.DB.GetConfigsByChannel(ctx, input.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup configs: %w", err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no accounts bound to channel %s", input.ChannelID)
+	}
+
+	eventType := models.EventResumed
+	var reason string
+	var details map[string]string
+	if paused {
+		eventType = models.EventPaused
+		reason = input.Reason
+		if reason != "" {
+			details = map[string]string{"reason": reason}
+		}
+	}
+
+	now := h.now().Format(time.RFC3339)
+	updated := make([]models.JitConfig, 0, len(configs))
+	for _, cfg := range configs {
+		cfg.Paused = paused
+		cfg.PauseReason = reason
+		cfg.UpdatedAt = now
+		if err := h.DB.PutConfig(ctx, &cfg); err != nil {
+			return nil, fmt.Errorf("update config for account %s: %w", cfg.AccountID, err)
+		}
+		_ = h.Audit.Log(ctx, models.ConfigAuditKey(cfg.ChannelID, cfg.AccountID), eventType,
+			cfg.AccountID, cfg.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail), details)
+		updated = append(updated, cfg)
+	}
+
+	slog.Info("channel pause state changed",
+		"channel_id", input.ChannelID,
+		"paused", paused,
+		"account_count", len(updated),
+		"actor", input.ActorEmail,
+	)
+	return updated, nil
+}
+
+// pausedError is returned for new requests against a paused binding.
+func pausedError(cfg *models.JitConfig) error {
+	if cfg.PauseReason == "" {
+		return fmt.Errorf("channel %s is paused; new requests are not accepted", cfg.ChannelID)
+	}
+	return fmt.Errorf("channel %s is paused; new requests are not accepted: %s", cfg.ChannelID, cfg.PauseReason)
+}
+
+// HandleSetAccountApprovers processes POST /config/account/{id}/approvers.
+// Sets an approver list for a single binding that overrides the channel-wide
+// list during approval and denial.
+func (h *Handler) HandleSetAccountApprovers(ctx context.Context, input models.SetAccountApproversInput) (*models.JitConfig, error) {
+	if input.ChannelID == "" || input.AccountID == "" {
+		return nil, fmt.Errorf("channel_id and account_id are required")
+	}
+	if err := models.ValidateID("channel_id", input.ChannelID); err != nil {
+		return nil, err
+	}
+	approverIDs, err := h.normalizeApproverIDs(input.ApproverIDs)
+	if err != nil {
+		return nil, err
+	}
+	input.ApproverIDs = approverIDs
+
+	cfg, err := h.DB.GetConfig(ctx, input.ChannelID, input.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup config: %w", err)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no binding found for channel %s and account %s", input.ChannelID, input.AccountID)
+	}
+
+	cfg.AccountApproverMMUserIDs = input.ApproverIDs
+	cfg.UpdatedAt = h.now().Format(time.RFC3339)
+	if err := h.DB.PutConfig(ctx, cfg); err != nil {
+		return nil, fmt.Errorf("update config for account %s: %w", cfg.AccountID, err)
+	}
+
+	slog.Info("account approvers updated",
+		"channel_id", input.ChannelID,
+		"account_id", input.AccountID,
+		"approver_count", len(input.ApproverIDs),
+	)
+
+	_ = h.Audit.Log(ctx, models.ConfigAuditKey(input.ChannelID, input.AccountID), models.EventApproversSet,
+		input.AccountID, input.ChannelID, models.HumanActor(input.ActorMMUserID, input.ActorEmail), map[string]string{
+			"scope":        "account",
+			"approver_ids": strings.Join(input.ApproverIDs, ","),
+		})
+	return cfg, nil
+}
+
 // HandleGetBoundAccounts processes GET /config/accounts.
 // Returns all account bindings for a given channel.
 func (h *Handler) HandleGetBoundAccounts(ctx context.Context, channelID string) ([]models.JitConfig, error) {
@@ -2,9 +2,20 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/smithy-go"
+
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/clock"
+	"github.com/dgwhited/jit-aws-controller/internal/identity"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
@@ -23,6 +34,21 @@ type mockDB struct {
 	queryReqResult   []models.JitRequest
 	queryReqToken    string
 	queryReqErr      error
+	lastQuery        models.ReportingInput
+	scanConfigs      []models.JitConfig
+	auditEvents      map[string][]models.AuditEvent
+	auditRange       []models.AuditEvent
+	auditRangeToken  string
+	auditRangeErr    error
+	lastAuditRange   [2]time.Time
+	lastAuditToken   string
+
+	// assignmentStatuses records every assignment_status written, in order.
+	assignmentStatuses []string
+
+	// grantsMu guards activeGrants, emulating DynamoDB's atomic ADD.
+	grantsMu     sync.Mutex
+	activeGrants map[string]int
 }
 
 func newMockDB() *mockDB {
@@ -50,10 +76,82 @@ func (m *mockDB) PutConfig(_ context.Context, cfg *models.JitConfig) error {
 	return nil
 }
 
+func (m *mockDB) UpdateConfig(_ context.Context, channelID, accountID string, updates map[string]interface{}) (*models.JitConfig, error) {
+	cfg, ok := m.configs[channelID+"|"+accountID]
+	if !ok {
+		return nil, fmt.Errorf("config %s|%s not found", channelID, accountID)
+	}
+	if v, ok := updates["max_request_hours"].(int); ok {
+		cfg.MaxRequestHours = v
+	}
+	if v, ok := updates["min_request_minutes"].(int); ok {
+		cfg.MinRequestMinutes = v
+	}
+	if v, ok := updates["allow_self_approval"].(bool); ok {
+		cfg.AllowSelfApproval = v
+	}
+	if v, ok := updates["allow_self_deny"].(bool); ok {
+		cfg.AllowSelfDeny = &v
+	}
+	if v, ok := updates["allow_self_revoke"].(bool); ok {
+		cfg.AllowSelfRevoke = &v
+	}
+	if v, ok := updates["require_strong_auth"].(bool); ok {
+		cfg.RequireStrongAuth = v
+	}
+	if v, ok := updates["session_duration_minutes"].(int); ok {
+		cfg.SessionDurationMinutes = v
+	}
+	if v, ok := updates["auto_approve"].(bool); ok {
+		cfg.AutoApprove = v
+	}
+	if v, ok := updates["auto_approve_max_minutes"].(int); ok {
+		cfg.AutoApproveMaxMinutes = v
+	}
+	if v, ok := updates["webhook_url"].(string); ok {
+		cfg.WebhookURL = v
+	}
+	if v, ok := updates["webhook_key_id"].(string); ok {
+		cfg.WebhookKeyID = v
+	}
+	if v, ok := updates["updated_at"].(string); ok {
+		cfg.UpdatedAt = v
+	}
+	return cfg, nil
+}
+
+// ScanConfigs pages over scanConfigs; the token is the next offset.
+func (m *mockDB) ScanConfigs(_ context.Context, limit int32, nextToken string) ([]models.JitConfig, string, error) {
+	start := 0
+	if nextToken != "" {
+		fmt.Sscanf(nextToken, "%d", &start)
+	}
+	end := len(m.scanConfigs)
+	if limit > 0 && start+int(limit) < end {
+		end = start + int(limit)
+	}
+	token := ""
+	if end < len(m.scanConfigs) {
+		token = fmt.Sprintf("%d", end)
+	}
+	return m.scanConfigs[start:end], token, nil
+}
+
 func (m *mockDB) GetChannelForAccount(_ context.Context, accountID string) (*models.JitConfig, error) {
 	return m.channelForAcct[accountID], nil
 }
 
+func (m *mockDB) GetAllBindingsForAccount(_ context.Context, accountID string) ([]models.JitConfig, error) {
+	var out []models.JitConfig
+	for _, cfg := range m.configs {
+		if cfg.AccountID == accountID {
+			out = append(out, *cfg)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChannelID < out[j].ChannelID })
+	return out, nil
+}
+
 func (m *mockDB) CreateRequest(_ context.Context, req *models.JitRequest) error {
 	if m.createReqErr != nil {
 		return m.createReqErr
@@ -71,10 +169,30 @@ func (m *mockDB) UpdateRequestStatus(_ context.Context, requestID string, update
 		if s, ok := updates["status"].(string); ok {
 			req.Status = s
 		}
+		if ps, ok := updates["permission_set_status"].(map[string]string); ok {
+			req.PermissionSetStatus = ps
+		}
+		if arn, ok := updates["execution_arn"].(string); ok {
+			req.ExecutionARN = arn
+		}
+		if uid, ok := updates["identity_store_user_id"].(string); ok {
+			req.IdentityStoreUserID = uid
+		}
+		if endTime, ok := updates["end_time"].(string); ok {
+			req.EndTime = endTime
+		}
+		m.recordAssignmentStatus(req, updates)
 	}
 	return nil
 }
 
+func (m *mockDB) recordAssignmentStatus(req *models.JitRequest, updates map[string]interface{}) {
+	if as, ok := updates["assignment_status"].(string); ok {
+		req.AssignmentStatus = as
+		m.assignmentStatuses = append(m.assignmentStatuses, as)
+	}
+}
+
 func (m *mockDB) ConditionalUpdateStatus(_ context.Context, requestID, expectedStatus string, updates map[string]interface{}) error {
 	if m.condUpdateErr != nil {
 		return m.condUpdateErr
@@ -89,34 +207,188 @@ func (m *mockDB) ConditionalUpdateStatus(_ context.Context, requestID, expectedS
 	if s, ok := updates["status"].(string); ok {
 		req.Status = s
 	}
+	if ps, ok := updates["permission_set_status"].(map[string]string); ok {
+		req.PermissionSetStatus = ps
+	}
+	if d, ok := updates["error_details"].(string); ok {
+		req.ErrorDetails = d
+	}
+	if info, ok := updates["error_info"].(models.ErrorInfo); ok {
+		req.ErrorInfo = &info
+	}
+	m.recordAssignmentStatus(req, updates)
+	if et, ok := updates["end_time"].(string); ok {
+		req.EndTime = et
+	}
+	if d, ok := updates["approved_duration_minutes"].(int); ok {
+		req.ApprovedDurationMinutes = d
+	}
+	if e, ok := updates["approver_email"].(string); ok {
+		req.ApproverEmail = e
+	}
+	if a, ok := updates["auto_approved"].(bool); ok {
+		req.AutoApproved = a
+	}
+	if a, ok := updates["approvals"].([]models.ApprovalRecord); ok {
+		req.Approvals = a
+	}
+	return nil
+}
+
+func (m *mockDB) IncrementActiveGrants(_ context.Context, channelID, accountID string, limit int) error {
+	m.grantsMu.Lock()
+	defer m.grantsMu.Unlock()
+	if m.activeGrants == nil {
+		m.activeGrants = map[string]int{}
+	}
+	key := models.GrantCounterKey(channelID, accountID)
+	if limit > 0 && m.activeGrants[key] >= limit {
+		return fmt.Errorf("IncrementActiveGrants: concurrent grant limit %d reached for account %s", limit, accountID)
+	}
+	m.activeGrants[key]++
+	return nil
+}
+
+func (m *mockDB) DecrementActiveGrants(_ context.Context, channelID, accountID string) error {
+	m.grantsMu.Lock()
+	defer m.grantsMu.Unlock()
+	key := models.GrantCounterKey(channelID, accountID)
+	if m.activeGrants[key] <= 0 {
+		return fmt.Errorf("DecrementActiveGrants: counter already zero")
+	}
+	m.activeGrants[key]--
+	return nil
+}
+
+func (m *mockDB) SetActiveGrants(_ context.Context, channelID, accountID string, count int) error {
+	m.grantsMu.Lock()
+	defer m.grantsMu.Unlock()
+	if m.activeGrants == nil {
+		m.activeGrants = map[string]int{}
+	}
+	m.activeGrants[models.GrantCounterKey(channelID, accountID)] = count
 	return nil
 }
 
-func (m *mockDB) QueryRequests(_ context.Context, _ models.ReportingInput) ([]models.JitRequest, string, error) {
-	return m.queryReqResult, m.queryReqToken, m.queryReqErr
+func (m *mockDB) QueryRequests(_ context.Context, input models.ReportingInput) ([]models.JitRequest, string, error) {
+	m.lastQuery = input
+	if (input.RequesterEmail == "" && input.Jira == "") || m.queryReqResult == nil {
+		return m.queryReqResult, m.queryReqToken, m.queryReqErr
+	}
+	var out []models.JitRequest
+	for _, r := range m.queryReqResult {
+		if input.RequesterEmail != "" && r.RequesterEmail != input.RequesterEmail {
+			continue
+		}
+		if input.Jira != "" && r.Jira != input.Jira {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, m.queryReqToken, m.queryReqErr
+}
+
+// QueryRequestsByStatusPage pages over requests with the given status, ordered
+// by end_time; the token is the next offset.
+func (m *mockDB) QueryRequestsByStatusPage(_ context.Context, status string, limit int32, nextToken string) ([]models.JitRequest, string, error) {
+	var matched []models.JitRequest
+	for _, r := range m.requests {
+		if r.Status == status {
+			matched = append(matched, *r)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].EndTime != matched[j].EndTime {
+			return matched[i].EndTime < matched[j].EndTime
+		}
+		return matched[i].RequestID < matched[j].RequestID
+	})
+	start := 0
+	if nextToken != "" {
+		fmt.Sscanf(nextToken, "%d", &start)
+	}
+	end := len(matched)
+	if limit > 0 && start+int(limit) < end {
+		end = start + int(limit)
+	}
+	token := ""
+	if end < len(matched) {
+		token = fmt.Sprintf("%d", end)
+	}
+	return matched[start:end], token, nil
+}
+
+func (m *mockDB) QueryAuditByRequest(_ context.Context, requestID string) ([]models.AuditEvent, error) {
+	return m.auditEvents[requestID], nil
+}
+
+// QueryAuditByTimeRange records the range and serves auditRange with
+// auditRangeToken.
+func (m *mockDB) QueryAuditByTimeRange(_ context.Context, start, end time.Time, _ int32, nextToken string) ([]models.AuditEvent, string, error) {
+	m.lastAuditRange = [2]time.Time{start, end}
+	m.lastAuditToken = nextToken
+	return m.auditRange, m.auditRangeToken, m.auditRangeErr
 }
 
 type mockIdentity struct {
 	users     map[string]string // email -> userID
+	lookupErr error
 	grantErr  error
 	revokeErr error
+	granted   int
+	// grantBlocks makes GrantAccess hang until its context is done, like an
+	// SSO call outliving the invocation.
+	grantBlocks bool
+
+	// Per-permission-set behaviour and call tracking.
+	grantPSErr  map[string]error
+	revokePSErr map[string]error
+	grantedPS   []string
+	revokedPS   []string
 }
 
 func (m *mockIdentity) LookupUserByEmail(_ context.Context, email string) (string, error) {
+	if m.lookupErr != nil {
+		return "", m.lookupErr
+	}
 	if uid, ok := m.users[email]; ok {
 		return uid, nil
 	}
 	return "", fmt.Errorf("no user found for %s", email)
 }
 
-func (m *mockIdentity) GrantAccess(_ context.Context, _, _ string) error {
-	return m.grantErr
+func (m *mockIdentity) GrantAccess(ctx context.Context, _, _ string) error {
+	if m.grantBlocks {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if m.grantErr != nil {
+		return m.grantErr
+	}
+	m.granted++
+	return nil
 }
 
 func (m *mockIdentity) RevokeAccess(_ context.Context, _, _ string) error {
 	return m.revokeErr
 }
 
+func (m *mockIdentity) GrantPermissionSet(_ context.Context, _, _, permissionSetARN string) error {
+	if err := m.grantPSErr[permissionSetARN]; err != nil {
+		return err
+	}
+	m.grantedPS = append(m.grantedPS, permissionSetARN)
+	return nil
+}
+
+func (m *mockIdentity) RevokePermissionSet(_ context.Context, _, _, permissionSetARN string) error {
+	if err := m.revokePSErr[permissionSetARN]; err != nil {
+		return err
+	}
+	m.revokedPS = append(m.revokedPS, permissionSetARN)
+	return nil
+}
+
 type mockWebhook struct {
 	payloads []models.WebhookPayload
 	err      error
@@ -127,28 +399,80 @@ func (m *mockWebhook) Notify(_ context.Context, payload models.WebhookPayload) e
 	return m.err
 }
 
+func (m *mockWebhook) Deliver(_ context.Context, payload models.WebhookPayload) error {
+	m.payloads = append(m.payloads, payload)
+	return m.err
+}
+
 type mockAudit struct {
 	events []auditCall
 }
 
 type auditCall struct {
-	requestID string
-	eventType string
+	requestID     string
+	eventType     string
+	actorType     string
+	actorMMUserID string
+	details       map[string]string
 }
 
-func (m *mockAudit) Log(_ context.Context, requestID, eventType, _, _, _, _ string, _ map[string]string) error {
-	m.events = append(m.events, auditCall{requestID: requestID, eventType: eventType})
+func (m *mockAudit) Log(_ context.Context, requestID, eventType, _, _ string, actor models.Actor, details map[string]string) error {
+	m.events = append(m.events, auditCall{
+		requestID:     requestID,
+		eventType:     eventType,
+		actorType:     actor.Type,
+		actorMMUserID: actor.MMUserID,
+		details:       details,
+	})
 	return nil
 }
 
 type mockSFN struct {
+	mu      sync.Mutex
 	started []models.StepFunctionInput
 	err     error
+	history map[string][]models.ExecutionEvent
 }
 
-func (m *mockSFN) StartExecution(_ context.Context, input models.StepFunctionInput) error {
+func (m *mockSFN) StartExecution(_ context.Context, input models.StepFunctionInput) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.started = append(m.started, input)
-	return m.err
+	if m.err != nil {
+		return "", m.err
+	}
+	return "arn:aws:states:us-east-1:123456789012:execution:jit:" + input.RequestID, nil
+}
+
+func (m *mockSFN) GetExecution(_ context.Context, requestID string) ([]models.ExecutionEvent, error) {
+	events, ok := m.history[requestID]
+	if !ok {
+		return nil, fmt.Errorf("execution for request %s not found", requestID)
+	}
+	return events, nil
+}
+
+type mockNonceStore struct {
+	nonces map[string]bool
+	calls  int
+}
+
+func newMockNonceStore() *mockNonceStore {
+	return &mockNonceStore{nonces: map[string]bool{}}
+}
+
+func (m *mockNonceStore) StoreNonce(_ context.Context, keyID, nonce string, _ int64) error {
+	m.calls++
+	if m.nonces[keyID+"|"+nonce] {
+		return fmt.Errorf("nonce already exists")
+	}
+	m.nonces[keyID+"|"+nonce] = true
+	return nil
+}
+
+func (m *mockNonceStore) CheckNonce(_ context.Context, keyID, nonce string) (bool, error) {
+	m.calls++
+	return m.nonces[keyID+"|"+nonce], nil
 }
 
 // helper to build a Handler with mocks
@@ -208,6 +532,134 @@ func TestHandleCreateRequest_Success(t *testing.T) {
 	}
 }
 
+func TestHandleCreateRequest_EndTimeFromClock(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.Clock = clock.NewMock(time.Date(2025, 3, 9, 23, 30, 15, 0, time.FixedZone("PST", -8*60*60)))
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:       "ch1",
+		AccountID:       "acct1",
+		MaxRequestHours: 4,
+	}
+
+	req, err := h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 90,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.CreatedAt != "2025-03-10T07:30:15Z" {
+		t.Errorf("expected created_at in UTC from the clock, got %s", req.CreatedAt)
+	}
+	if req.EndTime != "2025-03-10T09:00:15Z" {
+		t.Errorf("expected end_time 90 minutes after creation, got %s", req.EndTime)
+	}
+}
+
+func autoApproveInput(minutes int) models.CreateRequestInput {
+	return models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "read-only debugging",
+		RequestedDurationMinutes: minutes,
+	}
+}
+
+func TestHandleCreateRequest_AutoApprove(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:             "ch1",
+		AccountID:             "acct1",
+		MaxRequestHours:       4,
+		AutoApprove:           true,
+		AutoApproveMaxMinutes: 60,
+	}
+
+	req, err := h.HandleCreateRequest(context.Background(), autoApproveInput(30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Status != models.StatusApproved || !req.AutoApproved {
+		t.Errorf("expected auto-approved APPROVED request, got status %s auto_approved %v", req.Status, req.AutoApproved)
+	}
+	if req.ApproverEmail != "policy" {
+		t.Errorf("expected approval attributed to the policy, got %q", req.ApproverEmail)
+	}
+	if len(sf.started) != 1 || sf.started[0].RequestID != req.RequestID {
+		t.Fatalf("expected grant workflow started for the request, got %+v", sf.started)
+	}
+	if req.ExecutionARN == "" {
+		t.Error("expected execution ARN recorded")
+	}
+	if len(au.events) != 2 || au.events[1].eventType != models.EventAutoApproved {
+		t.Fatalf("expected REQUESTED then AUTO_APPROVED audit events, got %+v", au.events)
+	}
+	if ev := au.events[1]; ev.actorType != models.ActorPolicy || ev.details["auto_approve_max_minutes"] != "60" {
+		t.Errorf("expected policy actor with the duration cap in details, got %+v", ev)
+	}
+	if len(req.Approvals) != 1 || req.Approvals[0].ApproverEmail != "policy" || req.Approvals[0].ApprovedAt == "" {
+		t.Errorf("expected one approval record for the policy, got %+v", req.Approvals)
+	}
+}
+
+func TestHandleCreateRequest_AutoApproveSkipped(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     models.JitConfig
+		minutes int
+	}{
+		{"disabled", models.JitConfig{}, 30},
+		{"over auto-approve cap", models.JitConfig{AutoApprove: true, AutoApproveMaxMinutes: 60}, 90},
+		{"strong auth required", models.JitConfig{AutoApprove: true, RequireStrongAuth: true}, 30},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, db, _, _, au, sf := newTestHandler()
+			cfg := tc.cfg
+			cfg.ChannelID, cfg.AccountID, cfg.MaxRequestHours = "ch1", "acct1", 4
+			db.configs["ch1|acct1"] = &cfg
+
+			req, err := h.HandleCreateRequest(context.Background(), autoApproveInput(tc.minutes))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.Status != models.StatusPending || req.AutoApproved {
+				t.Errorf("expected PENDING request, got status %s auto_approved %v", req.Status, req.AutoApproved)
+			}
+			if len(sf.started) != 0 {
+				t.Errorf("expected no grant workflow, got %d", len(sf.started))
+			}
+			if len(au.events) != 1 {
+				t.Errorf("expected only the REQUESTED audit event, got %+v", au.events)
+			}
+		})
+	}
+}
+
+func TestHandleCreateRequest_AutoApproveRespectsBindingCap(t *testing.T) {
+	h, db, _, _, _, sf := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:       "ch1",
+		AccountID:       "acct1",
+		MaxRequestHours: 1,
+		AutoApprove:     true,
+	}
+
+	_, err := h.HandleCreateRequest(context.Background(), autoApproveInput(90))
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum") {
+		t.Fatalf("expected duration cap error, got: %v", err)
+	}
+	if len(db.requests) != 0 || len(sf.started) != 0 {
+		t.Errorf("expected nothing created or granted, got %d requests and %d workflows", len(db.requests), len(sf.started))
+	}
+}
+
 func TestHandleCreateRequest_MissingFields(t *testing.T) {
 	h, _, _, _, _, _ := newTestHandler()
 
@@ -217,6 +669,39 @@ func TestHandleCreateRequest_MissingFields(t *testing.T) {
 	}
 }
 
+func TestHandleCreateRequest_RejectsMalformedIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*models.CreateRequestInput)
+		want   string
+	}{
+		{"channel with newline", func(in *models.CreateRequestInput) { in.ChannelID = "ch1\n" }, "channel_id must not contain whitespace"},
+		{"requester with space", func(in *models.CreateRequestInput) { in.RequesterMMUserID = "mm user" }, "requester_mm_user_id must not contain whitespace"},
+		{"oversized channel", func(in *models.CreateRequestInput) { in.ChannelID = strings.Repeat("c", models.MaxIDLength+1) }, "channel_id must be at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db, _, _, _, _ := newTestHandler()
+			input := models.CreateRequestInput{
+				AccountID:                "acct1",
+				ChannelID:                "ch1",
+				RequesterMMUserID:        "mm-user-1",
+				RequesterEmail:           "user@example.com",
+				Reason:                   "need access",
+				RequestedDurationMinutes: 60,
+			}
+			tt.mutate(&input)
+			_, err := h.HandleCreateRequest(context.Background(), input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+			if len(db.requests) != 0 {
+				t.Errorf("expected no request stored, got %d", len(db.requests))
+			}
+		})
+	}
+}
+
 func TestHandleCreateRequest_NoBinding(t *testing.T) {
 	h, _, _, _, _, _ := newTestHandler()
 	input := models.CreateRequestInput{
@@ -257,454 +742,2909 @@ func TestHandleCreateRequest_DurationExceedsMax(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// HandleApproveRequest tests
-// ---------------------------------------------------------------------------
-
-func TestHandleApproveRequest_Success(t *testing.T) {
-	h, db, _, _, au, sf := newTestHandler()
+func TestHandleCreateRequest_DurationBelowMin(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
 	db.configs["ch1|acct1"] = &models.JitConfig{
 		ChannelID:         "ch1",
 		AccountID:         "acct1",
-		ApproverMMUserIDs: []string{"approver-1"},
-	}
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID:           "req-1",
-		AccountID:           "acct1",
-		ChannelID:           "ch1",
-		RequesterMMUserID:   "mm-user-1",
-		Status:              models.StatusPending,
-		IdentityStoreUserID: "uid-123",
+		MaxRequestHours:   4,
+		MinRequestMinutes: 30,
 	}
 
-	input := models.ApproveRequestInput{
-		RequestID:        "req-1",
-		ApproverMMUserID: "approver-1",
-		ApproverEmail:    "approver@example.com",
+	input := models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "test",
+		RequestedDurationMinutes: 29,
 	}
 
-	_, err := h.HandleApproveRequest(context.Background(), input)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	_, err := h.HandleCreateRequest(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), "is below minimum 30 minutes") {
+		t.Fatalf("expected below-minimum error, got: %v", err)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected no request stored, got %d", len(db.requests))
 	}
 
-	if db.requests["req-1"].Status != models.StatusApproved {
-		t.Errorf("expected APPROVED status, got %s", db.requests["req-1"].Status)
+	input.RequestedDurationMinutes = 30
+	req, err := h.HandleCreateRequest(context.Background(), input)
+	if err != nil {
+		t.Fatalf("expected a request exactly at the minimum to be accepted, got: %v", err)
 	}
-	if len(au.events) != 1 || au.events[0].eventType != models.EventApproved {
-		t.Errorf("expected APPROVED audit event, got %+v", au.events)
+	if req.RequestedDurationMinutes != 30 {
+		t.Errorf("expected 30 minute request, got %d", req.RequestedDurationMinutes)
 	}
-	if len(sf.started) != 1 {
-		t.Errorf("expected 1 SFN execution started, got %d", len(sf.started))
+}
+
+func TestHandleCreateRequest_DurationHardCap(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes int
+	}{
+		{"just over the cap", maxRequestDurationMinutes + 1},
+		{"near time.Duration overflow", int(math.MaxInt64/int64(time.Minute)) + 1},
+		{"max int", math.MaxInt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db, _, _, _, _ := newTestHandler()
+			// An unlimited binding must not lift the hard cap.
+			db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+
+			_, err := h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+				AccountID:                "acct1",
+				ChannelID:                "ch1",
+				RequesterMMUserID:        "mm-user-1",
+				RequesterEmail:           "user@example.com",
+				Reason:                   "test",
+				RequestedDurationMinutes: tt.minutes,
+			})
+			if err == nil || !strings.Contains(err.Error(), "hard limit") {
+				t.Fatalf("expected hard limit error, got: %v", err)
+			}
+			if len(db.requests) != 0 {
+				t.Errorf("expected no request stored, got %d", len(db.requests))
+			}
+		})
 	}
 }
 
-func TestHandleApproveRequest_NotPending(t *testing.T) {
+func TestHandleCreateRequest_PermissionSetBundle(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID: "req-1",
-		AccountID: "acct1",
-		ChannelID: "ch1",
-		Status:    models.StatusGranted,
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:                "ch1",
+		AccountID:                "acct1",
+		MaxRequestHours:          4,
+		AllowedPermissionSetARNs: []string{"ps-readonly", "ps-breakglass"},
 	}
 
-	input := models.ApproveRequestInput{
-		RequestID:        "req-1",
-		ApproverMMUserID: "approver-1",
-		ApproverEmail:    "approver@example.com",
+	input := models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "incident",
+		RequestedDurationMinutes: 60,
+		PermissionSetARNs:        []string{"ps-readonly", "ps-breakglass", "ps-readonly"},
 	}
 
-	_, err := h.HandleApproveRequest(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error for non-PENDING request")
+	req, err := h.HandleCreateRequest(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.PermissionSetARNs) != 2 || req.PermissionSetARNs[0] != "ps-readonly" || req.PermissionSetARNs[1] != "ps-breakglass" {
+		t.Errorf("expected de-duplicated bundle, got %v", req.PermissionSetARNs)
 	}
 }
 
-func TestHandleApproveRequest_SelfApprovalDenied(t *testing.T) {
+func TestHandleCreateRequest_PermissionSetNotAllowed(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
 	db.configs["ch1|acct1"] = &models.JitConfig{
-		ChannelID:         "ch1",
-		AccountID:         "acct1",
-		ApproverMMUserIDs: []string{"mm-user-1"},
-		AllowSelfApproval: false,
-	}
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID:         "req-1",
-		AccountID:         "acct1",
-		ChannelID:         "ch1",
-		RequesterMMUserID: "mm-user-1",
-		Status:            models.StatusPending,
+		ChannelID:                "ch1",
+		AccountID:                "acct1",
+		MaxRequestHours:          4,
+		AllowedPermissionSetARNs: []string{"ps-readonly"},
 	}
 
-	input := models.ApproveRequestInput{
-		RequestID:        "req-1",
-		ApproverMMUserID: "mm-user-1",
-		ApproverEmail:    "user@example.com",
+	input := models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "incident",
+		RequestedDurationMinutes: 60,
+		PermissionSetARNs:        []string{"ps-readonly", "ps-admin"},
 	}
 
-	_, err := h.HandleApproveRequest(context.Background(), input)
+	_, err := h.HandleCreateRequest(context.Background(), input)
 	if err == nil {
-		t.Fatal("expected self-approval error")
+		t.Fatal("expected error for permission set outside the allowlist")
+	}
+	if !strings.Contains(err.Error(), "ps-admin") {
+		t.Errorf("expected error to mention ps-admin, got: %v", err)
 	}
 }
 
-func TestHandleApproveRequest_UnauthorizedApprover(t *testing.T) {
+func TestHandleCreateRequest_Priority(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+	cases := []struct {
+		priority string
+		want     string
+		wantErr  bool
+	}{
+		{"", models.PriorityNormal, false},
+		{models.PriorityLow, models.PriorityLow, false},
+		{models.PriorityNormal, models.PriorityNormal, false},
+		{models.PriorityHigh, models.PriorityHigh, false},
+		{"urgent", "", true},
+		{"HIGH", "", true},
+	}
+	for _, tc := range cases {
+		req, err := h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+			AccountID:                "acct1",
+			ChannelID:                "ch1",
+			RequesterMMUserID:        "mm-user-1",
+			RequesterEmail:           "user@example.com",
+			Reason:                   "incident",
+			RequestedDurationMinutes: 60,
+			Priority:                 tc.priority,
+		})
+		if tc.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "priority must be one of") {
+				t.Errorf("priority %q: expected validation error, got %v", tc.priority, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("priority %q: unexpected error: %v", tc.priority, err)
+		}
+		if req.Priority != tc.want {
+			t.Errorf("priority %q: expected %q, got %q", tc.priority, tc.want, req.Priority)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleValidateRequest tests
+// ---------------------------------------------------------------------------
+
+func validCreateInput() models.CreateRequestInput {
+	return models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 60,
+	}
+}
+
+func TestHandleValidateRequest_Valid(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+	resp, err := h.HandleValidateRequest(context.Background(), validCreateInput())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid || len(resp.Errors) != 0 {
+		t.Errorf("expected a valid request, got %+v", resp)
+	}
+	if resp.EffectiveDurationMinutes != 60 {
+		t.Errorf("expected effective duration 60, got %d", resp.EffectiveDurationMinutes)
+	}
+	if len(db.requests) != 0 || len(au.events) != 0 {
+		t.Errorf("expected nothing written, got %d requests and %d audit events", len(db.requests), len(au.events))
+	}
+}
+
+func TestHandleValidateRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		mutate        func(*models.CreateRequestInput)
+		unbound       bool
+		paused        bool
+		wantErrors    []string
+		wantEffective int
+	}{
+		{"missing reason", func(in *models.CreateRequestInput) { in.Reason = "" }, false, false,
+			[]string{"either jira or reason must be provided"}, 60},
+		{"no binding", func(in *models.CreateRequestInput) {}, true, false,
+			[]string{"no binding found"}, 60},
+		{"paused channel", func(in *models.CreateRequestInput) {}, false, true,
+			[]string{"is paused"}, 60},
+		{"exceeds binding max", func(in *models.CreateRequestInput) { in.RequestedDurationMinutes = 300 }, false, false,
+			[]string{"exceeds maximum 240 minutes"}, 240},
+		{"unknown user", func(in *models.CreateRequestInput) { in.RequesterEmail = "nobody@example.com" }, false, false,
+			[]string{"identity lookup: no user found"}, 60},
+		{"several problems", func(in *models.CreateRequestInput) {
+			in.RequestedDurationMinutes = 300
+			in.RequesterEmail = "nobody@example.com"
+		}, false, false, []string{"exceeds maximum", "identity lookup"}, 240},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db, _, _, au, _ := newTestHandler()
+			if !tt.unbound {
+				db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4, Paused: tt.paused}
+			}
+			input := validCreateInput()
+			tt.mutate(&input)
+
+			resp, err := h.HandleValidateRequest(context.Background(), input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Valid {
+				t.Fatal("expected the request to be invalid")
+			}
+			if len(resp.Errors) != len(tt.wantErrors) {
+				t.Fatalf("expected %d errors, got %q", len(tt.wantErrors), resp.Errors)
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(resp.Errors[i], want) {
+					t.Errorf("error %d: expected %q in %q", i, want, resp.Errors[i])
+				}
+			}
+			if resp.EffectiveDurationMinutes != tt.wantEffective {
+				t.Errorf("expected effective duration %d, got %d", tt.wantEffective, resp.EffectiveDurationMinutes)
+			}
+
+			// Create agrees with validate, and validate wrote nothing.
+			if _, err := h.HandleCreateRequest(context.Background(), input); err == nil || !strings.Contains(err.Error(), tt.wantErrors[0]) {
+				t.Errorf("expected create to fail with %q, got: %v", tt.wantErrors[0], err)
+			}
+			if len(db.requests) != 0 || len(au.events) != 0 {
+				t.Errorf("expected nothing written, got %d requests and %d audit events", len(db.requests), len(au.events))
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleApproveRequest tests
+// ---------------------------------------------------------------------------
+
+func TestHandleApproveRequest_Success(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
 	db.configs["ch1|acct1"] = &models.JitConfig{
 		ChannelID:         "ch1",
 		AccountID:         "acct1",
 		ApproverMMUserIDs: []string{"approver-1"},
 	}
 	db.requests["req-1"] = &models.JitRequest{
-		RequestID: "req-1",
-		AccountID: "acct1",
-		ChannelID: "ch1",
-		Status:    models.StatusPending,
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		RequesterMMUserID:   "mm-user-1",
+		Status:              models.StatusPending,
+		IdentityStoreUserID: "uid-123",
 	}
 
 	input := models.ApproveRequestInput{
 		RequestID:        "req-1",
-		ApproverMMUserID: "random-user",
-		ApproverEmail:    "random@example.com",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
 	}
 
 	_, err := h.HandleApproveRequest(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected unauthorized approver error")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected APPROVED status, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventApproved {
+		t.Errorf("expected APPROVED audit event, got %+v", au.events)
+	}
+	if len(sf.started) != 1 {
+		t.Errorf("expected 1 SFN execution started, got %d", len(sf.started))
 	}
 }
 
-// ---------------------------------------------------------------------------
-// HandleDenyRequest tests
-// ---------------------------------------------------------------------------
+func TestHandleApproveRequest_RecordsApproval(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC))
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	earlier := models.ApprovalRecord{ApproverMMUserID: "approver-0", ApproverEmail: "first@example.com", ApprovedAt: "2025-06-01T10:00:00Z"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "mm-user-1",
+		Status:            models.StatusPending,
+		Approvals:         []models.ApprovalRecord{earlier},
+	}
 
-func TestHandleDenyRequest_Success(t *testing.T) {
-	h, db, _, wh, au, _ := newTestHandler()
+	req, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+		Comment:          "  ticket checked ",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := models.ApprovalRecord{ApproverMMUserID: "approver-1", ApproverEmail: "approver@example.com", ApprovedAt: "2025-06-01T10:05:00Z", Comment: "ticket checked"}
+	if len(req.Approvals) != 2 || req.Approvals[0] != earlier || req.Approvals[1] != want {
+		t.Errorf("expected the approval appended after the earlier record, got %+v", req.Approvals)
+	}
+	if len(au.events) != 1 || au.events[0].details["comment"] != "ticket checked" {
+		t.Errorf("expected the comment in the APPROVED audit event, got %+v", au.events)
+	}
+}
+
+func newModifiableApproval() (*Handler, *mockDB, *mockAudit, *mockSFN) {
+	h, db, _, _, au, sf := newTestHandler()
 	db.configs["ch1|acct1"] = &models.JitConfig{
 		ChannelID:         "ch1",
 		AccountID:         "acct1",
 		ApproverMMUserIDs: []string{"approver-1"},
+		MaxRequestHours:   4,
 	}
 	db.requests["req-1"] = &models.JitRequest{
-		RequestID: "req-1",
-		AccountID: "acct1",
-		ChannelID: "ch1",
-		Status:    models.StatusPending,
+		RequestID:                "req-1",
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		Status:                   models.StatusPending,
+		RequestedDurationMinutes: 120,
+		CreatedAt:                "2025-06-01T10:00:00Z",
+		EndTime:                  "2025-06-01T12:00:00Z",
 	}
+	return h, db, au, sf
+}
 
-	input := models.DenyRequestInput{
-		RequestID:      "req-1",
-		DenierMMUserID: "approver-1",
-		DenierEmail:    "approver@example.com",
+func TestHandleApproveWithModification_ReducesDuration(t *testing.T) {
+	h, db, au, sf := newModifiableApproval()
+
+	_, err := h.HandleApproveWithModification(context.Background(), models.ApproveRequestInput{
+		RequestID:               "req-1",
+		ApproverMMUserID:        "approver-1",
+		ApproverEmail:           "approver@example.com",
+		ApprovedDurationMinutes: 30,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	_, err := h.HandleDenyRequest(context.Background(), input)
+	req := db.requests["req-1"]
+	if req.Status != models.StatusApproved || req.ApprovedDurationMinutes != 30 {
+		t.Errorf("expected APPROVED for 30 minutes, got %s for %d", req.Status, req.ApprovedDurationMinutes)
+	}
+	if req.EndTime != "2025-06-01T10:30:00Z" {
+		t.Errorf("expected end_time recomputed from created_at, got %s", req.EndTime)
+	}
+	if req.RequestedDurationMinutes != 120 {
+		t.Errorf("expected requested duration kept, got %d", req.RequestedDurationMinutes)
+	}
+	if len(sf.started) != 1 || sf.started[0].DurationMinutes != 30 {
+		t.Errorf("expected workflow started for 30 minutes, got %+v", sf.started)
+	}
+	if len(au.events) != 1 {
+		t.Fatalf("expected one audit event, got %d", len(au.events))
+	}
+	if d := au.events[0].details; d["requested_duration_minutes"] != "120" || d["approved_duration_minutes"] != "30" {
+		t.Errorf("expected both durations audited, got %v", d)
+	}
+}
+
+func TestHandleApproveRequest_MaxWaitSecondsCapsWorkflow(t *testing.T) {
+	h, db, _, sf := newModifiableApproval()
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC))
+	h.MaxWaitSeconds = 600
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sf.started) != 1 || sf.started[0].WaitSeconds != 600 || sf.started[0].DurationMinutes != 120 {
+		t.Fatalf("expected the 120 minute workflow capped to a 600s wait, got %+v", sf.started)
+	}
+	// The reconciler must expire the grant when the workflow revokes it.
+	if got := db.requests["req-1"].EndTime; got != "2025-06-01T10:15:00Z" {
+		t.Errorf("expected end_time brought forward to the end of the wait, got %s", got)
+	}
+}
+
+func TestHandleApproveRequest_MaxWaitSecondsWithinCap(t *testing.T) {
+	h, db, _, sf := newModifiableApproval()
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC))
+	h.MaxWaitSeconds = 7200
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sf.started) != 1 || sf.started[0].WaitSeconds != 0 {
+		t.Fatalf("expected the full duration within the cap, got %+v", sf.started)
+	}
+	if got := db.requests["req-1"].EndTime; got != "2025-06-01T12:00:00Z" {
+		t.Errorf("expected end_time unchanged, got %s", got)
+	}
+}
+
+func TestCapWait_KeepsEarlierEndTime(t *testing.T) {
+	h, db, _, _ := newModifiableApproval()
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 11, 55, 0, 0, time.UTC))
+	h.MaxWaitSeconds = 3600
+
+	// Approved late: the cap would end after the stored end_time.
+	req := db.requests["req-1"]
+	if wait := h.capWait(context.Background(), req); wait != 3600 {
+		t.Errorf("expected the wait capped to 3600s, got %d", wait)
+	}
+	if req.EndTime != "2025-06-01T12:00:00Z" {
+		t.Errorf("expected the earlier end_time kept, got %s", req.EndTime)
+	}
+}
+
+func TestHandleApproveWithModification_RejectsLongerThanRequested(t *testing.T) {
+	h, db, au, sf := newModifiableApproval()
+
+	_, err := h.HandleApproveWithModification(context.Background(), models.ApproveRequestInput{
+		RequestID:               "req-1",
+		ApproverMMUserID:        "approver-1",
+		ApproverEmail:           "approver@example.com",
+		ApprovedDurationMinutes: 180,
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeds requested duration") {
+		t.Fatalf("expected requested-duration error, got: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusPending || db.requests["req-1"].EndTime != "2025-06-01T12:00:00Z" {
+		t.Errorf("expected request left untouched, got %+v", db.requests["req-1"])
+	}
+	if len(au.events) != 0 || len(sf.started) != 0 {
+		t.Errorf("expected no audit event or workflow, got %d events and %d executions", len(au.events), len(sf.started))
+	}
+
+	if _, err := h.HandleApproveWithModification(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		t.Errorf("expected missing duration to be rejected, got: %v", err)
+	}
+}
+
+func TestHandleApproveRequest_StoresExecutionARN(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "mm-user-1",
+		Status:            models.StatusPending,
+	}
+
+	req, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if db.requests["req-1"].Status != models.StatusDenied {
-		t.Errorf("expected DENIED, got %s", db.requests["req-1"].Status)
+	want := "arn:aws:states:us-east-1:123456789012:execution:jit:req-1"
+	if db.requests["req-1"].ExecutionARN != want {
+		t.Errorf("expected stored execution ARN %s, got %q", want, db.requests["req-1"].ExecutionARN)
 	}
-	if len(au.events) != 1 || au.events[0].eventType != models.EventDenied {
-		t.Errorf("expected DENIED audit event")
+	if req.ExecutionARN != want {
+		t.Errorf("expected execution ARN in approve response, got %q", req.ExecutionARN)
 	}
-	// No webhook is sent for denials — the plugin updates the card in-place.
-	if len(wh.payloads) != 0 {
-		t.Errorf("expected no webhook notification for deny, got %d", len(wh.payloads))
+}
+
+func TestHandleApproveRequest_StartFailureLeavesNoARN(t *testing.T) {
+	h, db, _, _, _, sf := newTestHandler()
+	sf.err = fmt.Errorf("throttled")
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "mm-user-1",
+		Status:            models.StatusPending,
+	}
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests["req-1"].ExecutionARN != "" {
+		t.Errorf("expected no execution ARN, got %q", db.requests["req-1"].ExecutionARN)
 	}
 }
 
-func TestHandleDenyRequest_NotFound(t *testing.T) {
-	h, _, _, _, _, _ := newTestHandler()
+func newReconcilerModeApproval() (*Handler, *mockDB, *mockIdentity, *mockWebhook, *mockSFN) {
+	h, db, id, wh, _, sf := newTestHandler()
+	h.RevokeMode = RevokeModeReconciler
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		RequesterMMUserID:   "mm-user-1",
+		Status:              models.StatusPending,
+		IdentityStoreUserID: "uid-123",
+		EndTime:             time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+	}
+	return h, db, id, wh, sf
+}
 
-	input := models.DenyRequestInput{
-		RequestID:      "nonexistent",
-		DenierMMUserID: "approver-1",
-		DenierEmail:    "approver@example.com",
+func TestHandleApproveRequest_ReconcilerModeSkipsGrantNearDeadline(t *testing.T) {
+	h, db, id, wh, _ := newReconcilerModeApproval()
+
+	ctx, cancel := context.WithTimeout(context.Background(), inlineGrantMinBudget/2)
+	defer cancel()
+	if _, err := h.HandleApproveRequest(ctx, models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	_, err := h.HandleDenyRequest(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error for missing request")
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError {
+		t.Errorf("expected ERROR when too little time is left to grant, got %s", req.Status)
+	}
+	if req.AssignmentStatus != "" || id.granted != 0 {
+		t.Errorf("expected Identity Center untouched, got assignment_status %q and %d grants", req.AssignmentStatus, id.granted)
+	}
+	if n := len(wh.payloads); n == 0 || wh.payloads[n-1].Status != models.StatusError {
+		t.Errorf("expected an ERROR notification, got %+v", wh.payloads)
+	}
+}
+
+func TestHandleApproveRequest_ReconcilerModeCutOffGrantLeftForReconciler(t *testing.T) {
+	prevBudget, prevReserve := inlineGrantMinBudget, inlineGrantReserve
+	inlineGrantMinBudget, inlineGrantReserve = 100*time.Millisecond, 150*time.Millisecond
+	defer func() { inlineGrantMinBudget, inlineGrantReserve = prevBudget, prevReserve }()
+
+	h, db, id, _, _ := newReconcilerModeApproval()
+	id.grantBlocks = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := h.HandleApproveRequest(ctx, models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("expected the grant to be cut off before the invocation deadline")
+	}
+
+	// Marking ERROR here would strand an assignment that may exist; the
+	// reconciler's stalled-approval pass revokes it and fails the request.
+	req := db.requests["req-1"]
+	if req.Status != models.StatusApproved || req.AssignmentStatus != models.AssignmentCreating {
+		t.Errorf("expected APPROVED with assignment_status CREATING, got %s/%q", req.Status, req.AssignmentStatus)
+	}
+}
+
+func TestHandleApproveRequest_AuditsActorTypes(t *testing.T) {
+	h, _, _, _, _ := newReconcilerModeApproval()
+	au := &mockAudit{}
+	h.Audit = au
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(au.events) != 2 {
+		t.Fatalf("expected APPROVED and GRANTED audit events, got %+v", au.events)
+	}
+	approved, granted := au.events[0], au.events[1]
+	if approved.eventType != models.EventApproved || approved.actorType != models.ActorHuman || approved.actorMMUserID != "approver-1" {
+		t.Errorf("expected human approval by approver-1, got %+v", approved)
+	}
+	if granted.eventType != models.EventGranted || granted.actorType != models.ActorSystem || granted.actorMMUserID != "" {
+		t.Errorf("expected system grant, got %+v", granted)
+	}
+}
+
+func TestHandleApproveRequest_ReconcilerModeGrantsWithoutSFN(t *testing.T) {
+	h, _, id, wh, sf := newReconcilerModeApproval()
+
+	req, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sf.started) != 0 {
+		t.Errorf("expected no SFN execution in reconciler mode, got %d", len(sf.started))
+	}
+	if id.granted != 1 {
+		t.Errorf("expected access granted once, got %d", id.granted)
+	}
+	if req.Status != models.StatusGranted {
+		t.Errorf("expected GRANTED request, got %s", req.Status)
+	}
+	if len(wh.payloads) != 1 || wh.payloads[0].Status != models.StatusGranted {
+		t.Errorf("expected GRANTED webhook, got %+v", wh.payloads)
+	}
+}
+
+func TestHandleApproveRequest_NotifyOnApprovePrecedesGrant(t *testing.T) {
+	h, _, _, wh, _ := newReconcilerModeApproval()
+	h.NotifyOnApprove = true
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(wh.payloads) != 2 {
+		t.Fatalf("expected APPROVED and GRANTED webhooks, got %+v", wh.payloads)
+	}
+	if wh.payloads[0].Status != models.StatusApproved || wh.payloads[1].Status != models.StatusGranted {
+		t.Errorf("expected APPROVED before GRANTED, got %s then %s", wh.payloads[0].Status, wh.payloads[1].Status)
+	}
+	if wh.payloads[0].Actor != "approver@example.com" {
+		t.Errorf("expected approver as actor, got %q", wh.payloads[0].Actor)
+	}
+}
+
+// startObserver records how many webhooks had been sent when the grant
+// workflow was started.
+type startObserver struct {
+	mockSFN
+	wh          *mockWebhook
+	sentAtStart int
+}
+
+func (s *startObserver) StartExecution(ctx context.Context, input models.StepFunctionInput) (string, error) {
+	s.sentAtStart = len(s.wh.payloads)
+	return s.mockSFN.StartExecution(ctx, input)
+}
+
+func TestHandleApproveRequest_NotifyOnApproveBeforeWorkflow(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		h, _, _, wh, _ := newReconcilerModeApproval()
+		h.RevokeMode = RevokeModeStepFn
+		h.NotifyOnApprove = enabled
+		sf := &startObserver{wh: wh}
+		h.SFN = sf
+
+		if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+			RequestID:        "req-1",
+			ApproverMMUserID: "approver-1",
+			ApproverEmail:    "approver@example.com",
+		}); err != nil {
+			t.Fatalf("enabled=%v: unexpected error: %v", enabled, err)
+		}
+		if len(sf.started) != 1 {
+			t.Fatalf("enabled=%v: expected the workflow started, got %d", enabled, len(sf.started))
+		}
+		want := 0
+		if enabled {
+			want = 1
+		}
+		if sf.sentAtStart != want || len(wh.payloads) != want {
+			t.Errorf("enabled=%v: expected %d webhook before the workflow started, got %d (total %d)",
+				enabled, want, sf.sentAtStart, len(wh.payloads))
+		}
+		if enabled && wh.payloads[0].Status != models.StatusApproved {
+			t.Errorf("expected APPROVED webhook, got %s", wh.payloads[0].Status)
+		}
+	}
+}
+
+func TestHandleApproveRequest_ReconcilerModeGrantFailure(t *testing.T) {
+	h, db, id, wh, sf := newReconcilerModeApproval()
+	id.grantErr = fmt.Errorf("access denied")
+
+	req, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("expected the approval to stand, got: %v", err)
+	}
+	if len(sf.started) != 0 {
+		t.Errorf("expected no SFN execution in reconciler mode, got %d", len(sf.started))
+	}
+	if req.Status != models.StatusError || !strings.Contains(db.requests["req-1"].ErrorDetails, "access denied") {
+		t.Errorf("expected ERROR with the grant failure recorded, got %s %q", req.Status, db.requests["req-1"].ErrorDetails)
+	}
+	if len(wh.payloads) != 1 || wh.payloads[0].Status != models.StatusError {
+		t.Errorf("expected ERROR webhook, got %+v", wh.payloads)
+	}
+}
+
+func TestHandleApproveRequest_NotPending(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusGranted,
+	}
+
+	input := models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	}
+
+	_, err := h.HandleApproveRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for non-PENDING request")
+	}
+}
+
+func TestHandleApproveRequest_SelfApprovalDenied(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"mm-user-1"},
+		AllowSelfApproval: false,
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "mm-user-1",
+		Status:            models.StatusPending,
+	}
+
+	input := models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "mm-user-1",
+		ApproverEmail:    "user@example.com",
+	}
+
+	_, err := h.HandleApproveRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected self-approval error")
+	}
+}
+
+func TestHandleApproveRequest_GlobalNoSelfApprovalOverridesBinding(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.NoSelfApprovalChannels = []string{"ch-sensitive"}
+	for _, ch := range []string{"ch-sensitive", "ch-open"} {
+		db.configs[ch+"|acct1"] = &models.JitConfig{
+			ChannelID:         ch,
+			AccountID:         "acct1",
+			ApproverMMUserIDs: []string{"mm-user-1"},
+			AllowSelfApproval: true,
+		}
+		db.requests["req-"+ch] = &models.JitRequest{
+			RequestID:         "req-" + ch,
+			AccountID:         "acct1",
+			ChannelID:         ch,
+			RequesterMMUserID: "mm-user-1",
+			Status:            models.StatusPending,
+		}
+	}
+
+	_, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-ch-sensitive",
+		ApproverMMUserID: "mm-user-1",
+		ApproverEmail:    "user@example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "self-approval is not allowed") {
+		t.Fatalf("expected global policy to refuse self-approval, got: %v", err)
+	}
+	if db.requests["req-ch-sensitive"].Status != models.StatusPending {
+		t.Errorf("expected request to stay PENDING, got %s", db.requests["req-ch-sensitive"].Status)
+	}
+
+	if _, err := h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-ch-open",
+		ApproverMMUserID: "mm-user-1",
+		ApproverEmail:    "user@example.com",
+	}); err != nil {
+		t.Errorf("expected self-approval in an unlisted channel to follow the binding, got: %v", err)
+	}
+}
+
+func TestHandleApproveRequest_UnauthorizedApprover(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusPending,
+	}
+
+	input := models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "random-user",
+		ApproverEmail:    "random@example.com",
+	}
+
+	_, err := h.HandleApproveRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected unauthorized approver error")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Approval token tests
+// ---------------------------------------------------------------------------
+
+func seedPendingApproval(db *mockDB) {
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		RequesterMMUserID:   "mm-user-1",
+		Status:              models.StatusPending,
+		IdentityStoreUserID: "uid-123",
+	}
+}
+
+func TestHandleApproveRequest_BindingRemovedAfterCreate(t *testing.T) {
+	h, db, _, _, _, sf := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		MaxRequestHours:   4,
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+
+	req, err := h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 60,
+	})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	// The account is unbound while the request is pending.
+	delete(db.configs, "ch1|acct1")
+
+	_, err = h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        req.RequestID,
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "no binding found") {
+		t.Fatalf("expected approval to be rejected for an unbound account, got %v", err)
+	}
+	if db.requests[req.RequestID].Status != models.StatusPending {
+		t.Errorf("expected request to stay PENDING, got %s", db.requests[req.RequestID].Status)
+	}
+	if len(sf.started) != 0 {
+		t.Errorf("expected no grant workflow to start, got %d", len(sf.started))
+	}
+}
+
+func TestHandleApproveWithToken_Success(t *testing.T) {
+	h, db, _, _, au, sf := newTestHandler()
+	h.ApprovalTokens = auth.NewHMACValidator(map[string]string{"key-1": "secret"}, newMockNonceStore())
+	seedPendingApproval(db)
+
+	tok, err := h.HandleCreateApprovalToken(context.Background(), models.ApprovalTokenInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error minting token: %v", err)
+	}
+
+	_, err = h.HandleApproveWithToken(context.Background(), models.ApproveWithTokenInput{
+		RequestID: "req-1",
+		Token:     tok.Token,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected APPROVED status, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventApproved {
+		t.Errorf("expected APPROVED audit event, got %+v", au.events)
+	}
+	if len(sf.started) != 1 {
+		t.Errorf("expected 1 SFN execution started, got %d", len(sf.started))
+	}
+}
+
+func TestHandleApproveWithToken_Reused(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.ApprovalTokens = auth.NewHMACValidator(map[string]string{"key-1": "secret"}, newMockNonceStore())
+	seedPendingApproval(db)
+
+	tok, err := h.HandleCreateApprovalToken(context.Background(), models.ApprovalTokenInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error minting token: %v", err)
+	}
+
+	input := models.ApproveWithTokenInput{RequestID: "req-1", Token: tok.Token}
+	if _, err := h.HandleApproveWithToken(context.Background(), input); err != nil {
+		t.Fatalf("first use should succeed: %v", err)
+	}
+
+	// Reset to PENDING so only the token's single-use property can fail the retry.
+	db.requests["req-1"].Status = models.StatusPending
+	if _, err := h.HandleApproveWithToken(context.Background(), input); err == nil {
+		t.Fatal("expected error for reused approval token")
+	}
+}
+
+func TestHandleApproveWithToken_FailedApprovalKeepsToken(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.ApprovalTokens = auth.NewHMACValidator(map[string]string{"key-1": "secret"}, newMockNonceStore())
+	seedStrongAuthApproval(db)
+
+	tok, err := h.HandleCreateApprovalToken(context.Background(), models.ApprovalTokenInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error minting token: %v", err)
+	}
+
+	// Without a strong auth level the approval is rejected and the token
+	// must remain usable.
+	input := models.ApproveWithTokenInput{RequestID: "req-1", Token: tok.Token}
+	if _, err := h.HandleApproveWithToken(context.Background(), input); err == nil {
+		t.Fatal("expected strong-auth rejection")
+	}
+	if db.requests["req-1"].Status != models.StatusPending {
+		t.Fatalf("expected request to stay PENDING, got %s", db.requests["req-1"].Status)
+	}
+
+	ctx := auth.ContextWithAuthLevel(context.Background(), 2)
+	if _, err := h.HandleApproveWithToken(ctx, input); err != nil {
+		t.Fatalf("retry after a failed approval should succeed: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected APPROVED status, got %s", db.requests["req-1"].Status)
+	}
+
+	db.requests["req-1"].Status = models.StatusPending
+	if _, err := h.HandleApproveWithToken(ctx, input); err == nil {
+		t.Fatal("expected error for a token already used by a successful approval")
+	}
+}
+
+func TestHandleCreateApprovalToken_UnauthorizedApprover(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.ApprovalTokens = auth.NewHMACValidator(map[string]string{"key-1": "secret"}, newMockNonceStore())
+	seedPendingApproval(db)
+
+	_, err := h.HandleCreateApprovalToken(context.Background(), models.ApprovalTokenInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "random-user",
+		ApproverEmail:    "random@example.com",
+	})
+	if err == nil {
+		t.Fatal("expected error minting a token for a non-approver")
+	}
+}
+
+func seedStrongAuthApproval(db *mockDB) {
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+		RequireStrongAuth: true,
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusPending,
+	}
+}
+
+func TestHandleApproveRequest_StrongAuth(t *testing.T) {
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		wantErr bool
+	}{
+		{"missing", context.Background(), true},
+		{"insufficient", auth.ContextWithAuthLevel(context.Background(), 1), true},
+		{"sufficient", auth.ContextWithAuthLevel(context.Background(), 2), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, db, _, _, _, _ := newTestHandler()
+			seedStrongAuthApproval(db)
+
+			_, err := h.HandleApproveRequest(tc.ctx, models.ApproveRequestInput{
+				RequestID:        "req-1",
+				ApproverMMUserID: "approver-1",
+				ApproverEmail:    "approver@example.com",
+			})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected strong auth error, got nil")
+				}
+				if !strings.Contains(err.Error(), "strong authentication required") {
+					t.Errorf("expected strong auth error, got: %v", err)
+				}
+				if db.requests["req-1"].Status != models.StatusPending {
+					t.Errorf("expected request to remain PENDING, got %s", db.requests["req-1"].Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestHandleApproveRequest_StrongAuthCustomMinimum(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.MinStrongAuthLevel = 3
+	seedStrongAuthApproval(db)
+
+	_, err := h.HandleApproveRequest(auth.ContextWithAuthLevel(context.Background(), 2), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err == nil {
+		t.Fatal("expected error for auth level below custom minimum")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleDenyRequest tests
+// ---------------------------------------------------------------------------
+
+func TestHandleDenyRequest_Success(t *testing.T) {
+	h, db, _, wh, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusPending,
+	}
+
+	input := models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "approver-1",
+		DenierEmail:    "approver@example.com",
+	}
+
+	_, err := h.HandleDenyRequest(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.requests["req-1"].Status != models.StatusDenied {
+		t.Errorf("expected DENIED, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventDenied {
+		t.Errorf("expected DENIED audit event")
+	}
+	// By default no webhook is sent for denials — the plugin updates the card in-place.
+	if len(wh.payloads) != 0 {
+		t.Errorf("expected no webhook notification for deny, got %d", len(wh.payloads))
+	}
+}
+
+func TestHandleDenyRequest_NotifiesRequesterWhenEnabled(t *testing.T) {
+	h, db, _, wh, _, _ := newTestHandler()
+	h.NotifyRequesterOnDeny = true
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusPending,
+	}
+
+	_, err := h.HandleDenyRequest(context.Background(), models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "approver-1",
+		DenierEmail:    "approver@example.com",
+		Reason:         "not during the freeze",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(wh.payloads) != 1 {
+		t.Fatalf("expected one DENIED webhook, got %d", len(wh.payloads))
+	}
+	p := wh.payloads[0]
+	if p.Status != models.StatusDenied || p.RequesterMMUserID != "user-1" || p.Actor != "approver@example.com" {
+		t.Errorf("expected DENIED webhook for user-1 by approver@example.com, got %+v", p)
+	}
+	if p.Details["reason"] != "not during the freeze" || p.Details["denier_mm_user_id"] != "approver-1" {
+		t.Errorf("expected deny reason and denier in details, got %v", p.Details)
+	}
+}
+
+func TestHandleDenyRequest_NotFound(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	input := models.DenyRequestInput{
+		RequestID:      "nonexistent",
+		DenierMMUserID: "approver-1",
+		DenierEmail:    "approver@example.com",
+	}
+
+	_, err := h.HandleDenyRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for missing request")
+	}
+}
+
+func TestHandleDenyRequest_NilConfigRejected(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		AccountID: "acct1",
+		ChannelID: "ch1",
+		Status:    models.StatusPending,
+	}
+
+	input := models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "anyone",
+		DenierEmail:    "anyone@example.com",
+	}
+
+	_, err := h.HandleDenyRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error when no config exists")
+	}
+	if db.requests["req-1"].Status != models.StatusPending {
+		t.Errorf("expected request to remain PENDING, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 0 {
+		t.Errorf("expected no audit events, got %+v", au.events)
+	}
+}
+
+func TestHandleDenyRequest_SelfDenyAllowedByDefault(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"user-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusPending,
+	}
+
+	input := models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "user-1",
+		DenierEmail:    "user@example.com",
+		Reason:         "no longer needed",
+	}
+
+	if _, err := h.HandleDenyRequest(context.Background(), input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusDenied {
+		t.Errorf("expected DENIED, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(au.events))
+	}
+	if au.events[0].details["self_deny"] != "true" {
+		t.Errorf("expected self_deny audit detail, got %+v", au.events[0].details)
+	}
+	if au.events[0].details["reason"] != "no longer needed" {
+		t.Errorf("expected reason audit detail, got %+v", au.events[0].details)
+	}
+}
+
+func TestHandleDenyRequest_SelfDenyDisallowed(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	allow := false
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"user-1"},
+		AllowSelfDeny:     &allow,
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusPending,
+	}
+
+	input := models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "user-1",
+		DenierEmail:    "user@example.com",
+	}
+
+	_, err := h.HandleDenyRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for self-denial")
+	}
+	if !strings.Contains(err.Error(), "self-denial") {
+		t.Errorf("expected self-denial error, got: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusPending {
+		t.Errorf("expected request to remain PENDING, got %s", db.requests["req-1"].Status)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleRevokeRequest tests
+// ---------------------------------------------------------------------------
+
+func TestHandleRevokeRequest_Success(t *testing.T) {
+	h, db, _, wh, au, _ := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+	}
+
+	input := models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}
+
+	_, err := h.HandleRevokeRequest(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.requests["req-1"].Status != models.StatusRevoked {
+		t.Errorf("expected REVOKED, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventRevoked {
+		t.Errorf("expected REVOKED audit event")
+	}
+	if len(wh.payloads) != 1 || wh.payloads[0].Status != models.StatusRevoked {
+		t.Errorf("expected REVOKED webhook notification")
+	}
+}
+
+func TestHandleRevokeRequest_SelfRevokeAllowedByDefault(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusGranted,
+	}
+
+	_, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "user-1",
+		ActorEmail:    "user@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusRevoked {
+		t.Errorf("expected REVOKED, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventRevoked {
+		t.Errorf("expected only the REVOKED audit event, got %+v", au.events)
+	}
+}
+
+func TestHandleRevokeRequest_SelfRevokeDisallowed(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	allow := false
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"approver-1"},
+		AllowSelfRevoke:   &allow,
+	}
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:         "req-1",
+		AccountID:         "acct1",
+		ChannelID:         "ch1",
+		RequesterMMUserID: "user-1",
+		Status:            models.StatusGranted,
+	}
+
+	_, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "user-1",
+		ActorEmail:    "user@example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "self-revocation is not allowed") {
+		t.Fatalf("expected self-revocation error, got: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusGranted {
+		t.Errorf("expected request to remain GRANTED, got %s", db.requests["req-1"].Status)
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventSelfRevokeRejected || au.events[0].actorMMUserID != "user-1" {
+		t.Errorf("expected a SELF_REVOKE_REJECTED audit event for the requester, got %+v", au.events)
+	}
+
+	// An approver may still revoke the grant.
+	if _, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "approver-1",
+		ActorEmail:    "approver@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error revoking as approver: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusRevoked {
+		t.Errorf("expected REVOKED, got %s", db.requests["req-1"].Status)
+	}
+}
+
+func TestHandleRevokeRequest_NotGranted(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID: "req-1",
+		Status:    models.StatusPending,
+	}
+
+	input := models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}
+
+	_, err := h.HandleRevokeRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for non-GRANTED request")
+	}
+}
+
+func TestHandleRevokeRequest_IdentityError(t *testing.T) {
+	h, db, id, _, _, _ := newTestHandler()
+	id.revokeErr = fmt.Errorf("SSO unavailable")
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+	}
+
+	input := models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}
+
+	_, err := h.HandleRevokeRequest(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error when identity revoke fails")
+	}
+}
+
+func TestHandleRevokeRequest_IdentityErrorRecordsErrorInfo(t *testing.T) {
+	h, db, id, _, _, _ := newTestHandler()
+	h.Clock = clock.NewMock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	id.revokeErr = fmt.Errorf("delete assignment: %w",
+		&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"})
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+	}
+
+	if _, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}); err == nil {
+		t.Fatal("expected error when identity revoke fails")
+	}
+
+	req := db.requests["req-1"]
+	if req.Status != models.StatusError || req.ErrorInfo == nil {
+		t.Fatalf("expected ERROR with error info, got %s %+v", req.Status, req.ErrorInfo)
+	}
+	info := req.ErrorInfo
+	if info.Phase != models.ErrorPhaseRevoke || info.Code != "AccessDeniedException" {
+		t.Errorf("expected revoke phase with the AWS error code, got %+v", info)
+	}
+	if !strings.Contains(info.Message, "not authorized") || info.Timestamp != "2025-06-01T12:00:00Z" {
+		t.Errorf("expected message and timestamp captured, got %+v", info)
+	}
+	if req.ErrorDetails != info.String() {
+		t.Errorf("expected error_details to be the display form, got %q", req.ErrorDetails)
+	}
+}
+
+func TestHandleRevokeRequest_IdentityErrorRecordsFailureReason(t *testing.T) {
+	h, db, id, _, _, _ := newTestHandler()
+	id.revokeErr = fmt.Errorf("RevokeAccess failed after retries: %w",
+		&identity.AssignmentFailedError{Operation: "deletion", Reason: "assignment is in use"})
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+	}
+
+	if _, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}); err == nil {
+		t.Fatal("expected error when identity revoke fails")
+	}
+
+	if info := db.requests["req-1"].ErrorInfo; info == nil || info.FailureReason != "assignment is in use" {
+		t.Errorf("expected the SSO Admin failure reason recorded, got %+v", info)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleListRequests tests
+// ---------------------------------------------------------------------------
+
+func TestHandleListRequests_Success(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{
+		{RequestID: "req-1", Status: models.StatusPending},
+		{RequestID: "req-2", Status: models.StatusGranted},
+	}
+
+	input := models.ReportingInput{
+		ChannelID: "ch1",
+		Limit:     10,
+	}
+
+	resp, err := h.HandleListRequests(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items) != 2 {
+		t.Errorf("expected 2 items, got %d", len(resp.Items))
+	}
+	if resp.Filters["channel_id"] != "ch1" {
+		t.Errorf("expected channel_id filter echoed")
+	}
+}
+
+func TestHandleListRequests_ProjectsFields(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{{
+		RequestID:           "req-1",
+		Status:              models.StatusGranted,
+		EndTime:             "2025-06-01T13:00:00Z",
+		IdentityStoreUserID: "uid-123",
+		ErrorDetails:        "boom",
+	}}
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", Fields: "status, end_time,status"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(resp.Fields, ","); got != "request_id,status,end_time" {
+		t.Errorf("expected request_id plus the requested fields, got %s", got)
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded struct {
+		Items []map[string]interface{} `json:"items"`
+		Count int                      `json:"count"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Count != 1 || len(decoded.Items) != 1 {
+		t.Fatalf("expected one item, got %s", body)
+	}
+	item := decoded.Items[0]
+	if len(item) != 3 || item["request_id"] != "req-1" || item["status"] != models.StatusGranted || item["end_time"] != "2025-06-01T13:00:00Z" {
+		t.Errorf("expected only request_id, status and end_time, got %v", item)
+	}
+}
+
+func TestHandleListRequests_RejectsUnknownField(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	for _, fields := range []string{"status,identity_store_user_id", "bogus"} {
+		_, err := h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", Fields: fields})
+		if err == nil || !strings.Contains(err.Error(), "invalid field") {
+			t.Errorf("%q: expected invalid field error, got %v", fields, err)
+		}
+	}
+}
+
+func TestHandleListRequests_DefaultLimit(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	input := models.ReportingInput{Limit: 0, ChannelID: "ch1"}
+	resp, err := h.HandleListRequests(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Items == nil {
+		t.Errorf("expected non-nil items slice")
+	}
+}
+
+func TestHandleListRequests_MaxLimit(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	input := models.ReportingInput{Limit: 500, ChannelID: "ch1"}
+	_, err := h.HandleListRequests(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Limit is capped internally; no error expected.
+}
+
+func TestHandleListRequests_HasMore(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{{RequestID: "req-1"}, {RequestID: "req-2"}}
+	db.queryReqToken = "request_id=req-2"
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.HasMore {
+		t.Error("expected HasMore true when a next token is returned")
+	}
+	if resp.Count != 2 {
+		t.Errorf("expected Count 2, got %d", resp.Count)
+	}
+
+	db.queryReqToken = ""
+	resp, err = h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.HasMore {
+		t.Error("expected HasMore false without a next token")
+	}
+}
+
+func TestHandleListRequests_PendingSortedByPriority(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{
+		{RequestID: "req-1", Status: models.StatusPending, Priority: models.PriorityLow},
+		{RequestID: "req-2", Status: models.StatusPending, Priority: models.PriorityNormal},
+		{RequestID: "req-3", Status: models.StatusPending, Priority: models.PriorityHigh},
+		{RequestID: "req-4", Status: models.StatusPending},
+		{RequestID: "req-5", Status: models.StatusPending, Priority: models.PriorityHigh},
+	}
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{Status: models.StatusPending})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"req-3", "req-5", "req-2", "req-4", "req-1"}
+	for i, id := range want {
+		if resp.Items[i].RequestID != id {
+			t.Errorf("position %d: expected %s, got %s", i, id, resp.Items[i].RequestID)
+		}
+	}
+}
+
+func TestHandleListRequests_NonPendingKeepsOrder(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{
+		{RequestID: "req-1", Priority: models.PriorityLow},
+		{RequestID: "req-2", Priority: models.PriorityHigh},
+	}
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Items[0].RequestID != "req-1" || resp.Items[1].RequestID != "req-2" {
+		t.Errorf("expected query order preserved, got %s, %s", resp.Items[0].RequestID, resp.Items[1].RequestID)
+	}
+}
+
+func TestHandleListRequests_ByJira(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{
+		{RequestID: "req-1", Jira: "PROJ-123"},
+		{RequestID: "req-2", Jira: "PROJ-456"},
+		{RequestID: "req-3", Reason: "no ticket"},
+		{RequestID: "req-4", Jira: "PROJ-123"},
+	}
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{Jira: "PROJ-123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Count != 2 || resp.Items[0].RequestID != "req-1" || resp.Items[1].RequestID != "req-4" {
+		t.Errorf("expected only PROJ-123 requests, got %+v", resp.Items)
+	}
+	if resp.Filters["jira"] != "PROJ-123" {
+		t.Errorf("expected jira filter echoed, got %v", resp.Filters)
+	}
+	if db.lastQuery.Jira != "PROJ-123" {
+		t.Errorf("expected jira passed to the query, got %q", db.lastQuery.Jira)
+	}
+}
+
+func TestHandleListRequests_MultipleStatuses(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+
+	resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{
+		ChannelID: "ch1",
+		Status:    "PENDING, APPROVED,GRANTED,PENDING",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.lastQuery.Status != "PENDING,APPROVED,GRANTED" {
+		t.Errorf("expected normalized status list passed to the query, got %q", db.lastQuery.Status)
+	}
+	if resp.Filters["status"] != "PENDING,APPROVED,GRANTED" {
+		t.Errorf("expected status list echoed, got %v", resp.Filters)
+	}
+}
+
+func TestHandleListRequests_InvalidStatus(t *testing.T) {
+	for _, status := range []string{"PENDING,ACTIVE", "granted", " , "} {
+		h, db, _, _, _, _ := newTestHandler()
+		_, err := h.HandleListRequests(context.Background(), models.ReportingInput{ChannelID: "ch1", Status: status})
+		if err == nil || !strings.Contains(err.Error(), "invalid status") {
+			t.Errorf("status %q: expected invalid status error, got %v", status, err)
+		}
+		if db.lastQuery.ChannelID != "" {
+			t.Errorf("status %q: expected no query for an invalid status", status)
+		}
+	}
+}
+
+func TestHandleListRequests_DateBounds(t *testing.T) {
+	tests := []struct {
+		name               string
+		startDate, endDate string
+		wantStart, wantEnd string
+	}{
+		{"date only", "2024-01-01", "2024-01-31", "2024-01-01T00:00:00Z", "2024-01-31T23:59:59Z"},
+		{"same day", "2024-01-01", "2024-01-01", "2024-01-01T00:00:00Z", "2024-01-01T23:59:59Z"},
+		{"full timestamps", "2024-01-01T10:00:00Z", "2024-01-02T10:00:00Z", "2024-01-01T10:00:00Z", "2024-01-02T10:00:00Z"},
+		{"offset converted to UTC", "2024-01-01T10:00:00+02:00", "2024-01-01T18:30:00.250-05:00", "2024-01-01T08:00:00Z", "2024-01-01T23:30:00Z"},
+		{"start only", "2024-01-01", "", "2024-01-01T00:00:00Z", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db, _, _, _, _ := newTestHandler()
+			resp, err := h.HandleListRequests(context.Background(), models.ReportingInput{
+				ChannelID: "ch1",
+				StartDate: tt.startDate,
+				EndDate:   tt.endDate,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if db.lastQuery.StartDate != tt.wantStart || db.lastQuery.EndDate != tt.wantEnd {
+				t.Errorf("expected query bounds %q..%q, got %q..%q", tt.wantStart, tt.wantEnd, db.lastQuery.StartDate, db.lastQuery.EndDate)
+			}
+			if resp.Filters["start_date"] != tt.wantStart || resp.Filters["end_date"] != tt.wantEnd {
+				t.Errorf("expected normalized bounds echoed, got %v", resp.Filters)
+			}
+		})
+	}
+}
+
+func TestHandleListRequests_InvalidDate(t *testing.T) {
+	for _, input := range []models.ReportingInput{
+		{ChannelID: "ch1", StartDate: "2024-01-01T10:00:00"},
+		{ChannelID: "ch1", EndDate: "01/31/2024"},
+		{ChannelID: "ch1", StartDate: "2024-13-01"},
+	} {
+		h, db, _, _, _, _ := newTestHandler()
+		_, err := h.HandleListRequests(context.Background(), input)
+		if err == nil || (!strings.Contains(err.Error(), "invalid start_date") && !strings.Contains(err.Error(), "invalid end_date")) {
+			t.Errorf("%+v: expected invalid date error, got %v", input, err)
+		}
+		if db.lastQuery.ChannelID != "" {
+			t.Errorf("%+v: expected no query for an invalid date", input)
+		}
+	}
+}
+
+func TestHandleListMyRequests_OnlyRequesterItems(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.queryReqResult = []models.JitRequest{
+		{RequestID: "req-3", RequesterEmail: "alice@example.com", ChannelID: "ch2", CreatedAt: "2026-01-03T00:00:00Z"},
+		{RequestID: "req-2", RequesterEmail: "bob@example.com", ChannelID: "ch1", CreatedAt: "2026-01-02T00:00:00Z"},
+		{RequestID: "req-1", RequesterEmail: "alice@example.com", ChannelID: "ch1", CreatedAt: "2026-01-01T00:00:00Z"},
+	}
+
+	resp, err := h.HandleListMyRequests(context.Background(), models.MyRequestsInput{RequesterEmail: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Count != 2 {
+		t.Fatalf("expected 2 items, got %d", resp.Count)
+	}
+	for _, item := range resp.Items {
+		if item.RequesterEmail != "alice@example.com" {
+			t.Errorf("unexpected item for %s", item.RequesterEmail)
+		}
+	}
+	if resp.Items[0].RequestID != "req-3" {
+		t.Errorf("expected newest first, got %s", resp.Items[0].RequestID)
+	}
+
+	// Only the requester filter may be set so the requester GSI is used.
+	q := db.lastQuery
+	if q.RequesterEmail != "alice@example.com" || q.ChannelID != "" || q.AccountID != "" || q.Status != "" {
+		t.Errorf("expected requester-only query, got %+v", q)
+	}
+	if q.Limit != 50 {
+		t.Errorf("expected default limit 50, got %d", q.Limit)
+	}
+}
+
+func TestHandleListMyRequests_InvalidEmail(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	for _, email := range []string{"", "not-an-email", "Alice <alice@example.com>"} {
+		_, err := h.HandleListMyRequests(context.Background(), models.MyRequestsInput{RequesterEmail: email})
+		if err == nil || !strings.Contains(err.Error(), "requester_email") {
+			t.Errorf("email %q: expected requester_email validation error, got %v", email, err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleBindAccount tests
+// ---------------------------------------------------------------------------
+
+func TestHandleBindAccount_Success(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+
+	input := models.BindAccountInput{
+		ChannelID: "ch1",
+		AccountID: "123456789012",
+	}
+
+	cfg, err := h.HandleBindAccount(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ChannelID != "ch1" {
+		t.Errorf("expected channel_id ch1, got %s", cfg.ChannelID)
+	}
+	if cfg.MaxRequestHours != 4 {
+		t.Errorf("expected default max_request_hours 4, got %d", cfg.MaxRequestHours)
+	}
+	if _, ok := db.configs["ch1|123456789012"]; !ok {
+		t.Error("expected config to be stored")
+	}
+}
+
+func TestHandleBindAccount_AuditsActor(t *testing.T) {
+	h, _, _, _, au, _ := newTestHandler()
+
+	_, err := h.HandleBindAccount(context.Background(), models.BindAccountInput{
+		ChannelID:     "ch1",
+		AccountID:     "123456789012",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(au.events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(au.events))
+	}
+	ev := au.events[0]
+	if ev.eventType != models.EventConfigBound || ev.requestID != models.ConfigAuditKey("ch1", "123456789012") {
+		t.Errorf("expected BIND under the binding's config key, got %+v", ev)
+	}
+	if ev.actorMMUserID != "admin-1" {
+		t.Errorf("expected actor admin-1, got %q", ev.actorMMUserID)
+	}
+	if ev.details["rebound"] != "false" {
+		t.Errorf("expected a fresh binding, got details %+v", ev.details)
+	}
+}
+
+func TestHandleBindAccount_RebindKeepsDurationSettings(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|123456789012"] = &models.JitConfig{
+		ChannelID:             "ch1",
+		AccountID:             "123456789012",
+		MaxRequestHours:       8,
+		MinRequestMinutes:     30,
+		AutoApprove:           true,
+		AutoApproveMaxMinutes: 60,
+		WebhookURL:            "https://mm-east.example.com/plugins/jit",
+		WebhookKeyID:          "east",
+	}
+
+	cfg, err := h.HandleBindAccount(context.Background(), models.BindAccountInput{ChannelID: "ch1", AccountID: "123456789012"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxRequestHours != 8 || cfg.MinRequestMinutes != 30 || !cfg.AutoApprove || cfg.AutoApproveMaxMinutes != 60 {
+		t.Errorf("expected rebind to keep duration and auto-approve settings, got %+v", cfg)
+	}
+	if cfg.WebhookURL != "https://mm-east.example.com/plugins/jit" || cfg.WebhookKeyID != "east" {
+		t.Errorf("expected rebind to keep the webhook override, got %q %q", cfg.WebhookURL, cfg.WebhookKeyID)
+	}
+}
+
+func TestHandleBindAccount_AlreadyBoundDifferentChannel(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.channelForAcct["123456789012"] = &models.JitConfig{
+		ChannelID: "other-channel",
+		AccountID: "123456789012",
+	}
+
+	input := models.BindAccountInput{
+		ChannelID: "ch1",
+		AccountID: "123456789012",
+	}
+
+	_, err := h.HandleBindAccount(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for account bound to different channel")
+	}
+}
+
+func TestHandleBindAccount_MissingFields(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleBindAccount(context.Background(), models.BindAccountInput{})
+	if err == nil {
+		t.Fatal("expected error for missing fields")
+	}
+}
+
+func TestHandleBindAccount_RejectsMalformedChannelID(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleBindAccount(context.Background(), models.BindAccountInput{ChannelID: "ch\t1", AccountID: "acct1"})
+	if err == nil || !strings.Contains(err.Error(), "channel_id must not contain whitespace") {
+		t.Fatalf("expected channel_id validation error, got: %v", err)
+	}
+	if len(db.configs) != 0 {
+		t.Errorf("expected nothing bound, got %d configs", len(db.configs))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleGetExecution tests
+// ---------------------------------------------------------------------------
+
+func TestHandleGetExecution_ReturnsTimeline(t *testing.T) {
+	h, db, _, _, _, sf := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusGranted}
+	sf.history = map[string][]models.ExecutionEvent{
+		"req-1": {
+			{Step: "Grant", Status: "ENTERED", Timestamp: "2025-01-01T12:00:00Z"},
+			{Step: "Grant", Status: "FAILED", Timestamp: "2025-01-01T12:00:01Z", Error: "ConflictException"},
+		},
+	}
+
+	timeline, err := h.HandleGetExecution(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeline.RequestID != "req-1" || len(timeline.Events) != 2 {
+		t.Fatalf("expected 2 events for req-1, got %+v", timeline)
+	}
+	if timeline.Events[1].Error != "ConflictException" {
+		t.Errorf("expected failure error to be surfaced, got %+v", timeline.Events[1])
+	}
+}
+
+func TestHandleGetExecution_UnknownRequest(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleGetExecution(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "request missing not found") {
+		t.Fatalf("expected request not found, got %v", err)
+	}
+}
+
+func TestHandleGetRequestApprovers_ExcludesRequester(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		ApproverMMUserIDs: []string{"lead-1", "user-1", "lead-2"},
+	}
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1"}
+
+	resp, err := h.HandleGetRequestApprovers(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.SelfApprovalAllowed || strings.Join(resp.ApproverMMUserIDs, ",") != "lead-1,lead-2" {
+		t.Errorf("expected the requester excluded, got %+v", resp)
+	}
+
+	// The global policy overrides a binding that allows self-approval.
+	db.configs["ch1|acct1"].AllowSelfApproval = true
+	h.NoSelfApprovalChannels = []string{"ch1"}
+	if resp, err = h.HandleGetRequestApprovers(context.Background(), "req-1"); err != nil || len(resp.ApproverMMUserIDs) != 2 {
+		t.Errorf("expected the requester excluded in a no-self-approval channel, got %+v, %v", resp, err)
+	}
+}
+
+func TestHandleGetRequestApprovers_SelfApprovalAllowed(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:                "ch1",
+		AccountID:                "acct1",
+		AllowSelfApproval:        true,
+		ApproverMMUserIDs:        []string{"lead-1"},
+		AccountApproverMMUserIDs: []string{"prod-lead", "user-1"},
+	}
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1"}
+
+	resp, err := h.HandleGetRequestApprovers(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.SelfApprovalAllowed || strings.Join(resp.ApproverMMUserIDs, ",") != "prod-lead,user-1" {
+		t.Errorf("expected the account's approvers including the requester, got %+v", resp)
+	}
+}
+
+func TestHandleGetRequestApprovers_NotFound(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+
+	if _, err := h.HandleGetRequestApprovers(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "request missing not found") {
+		t.Errorf("expected request not found, got %v", err)
+	}
+
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1"}
+	if _, err := h.HandleGetRequestApprovers(context.Background(), "req-1"); err == nil || !strings.Contains(err.Error(), "no binding found") {
+		t.Errorf("expected no binding found for an unbound account, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleSetApprovers tests
+// ---------------------------------------------------------------------------
+
+func TestHandleSetApprovers_Success(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1"},
+		{ChannelID: "ch1", AccountID: "acct2"},
+	}
+
+	input := models.SetApproversInput{
+		ChannelID:   "ch1",
+		ApproverIDs: []string{"user1", "user2"},
+	}
+
+	updated, err := h.HandleSetApprovers(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(updated) != 2 {
+		t.Errorf("expected 2 updated configs, got %d", len(updated))
+	}
+	for _, cfg := range updated {
+		if len(cfg.ApproverMMUserIDs) != 2 {
+			t.Errorf("expected 2 approvers, got %d", len(cfg.ApproverMMUserIDs))
+		}
+	}
+}
+
+func TestHandleSetApprovers_AuditsEachBinding(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1"},
+		{ChannelID: "ch1", AccountID: "acct2"},
+	}
+
+	_, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+		ChannelID:     "ch1",
+		ApproverIDs:   []string{"user1", "user2"},
+		ActorMMUserID: "admin-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(au.events) != 2 {
+		t.Fatalf("expected one audit event per binding, got %d", len(au.events))
+	}
+	for i, acct := range []string{"acct1", "acct2"} {
+		ev := au.events[i]
+		if ev.eventType != models.EventApproversSet || ev.requestID != models.ConfigAuditKey("ch1", acct) {
+			t.Errorf("expected SET_APPROVERS for %s, got %+v", acct, ev)
+		}
+		if ev.actorMMUserID != "admin-1" || ev.details["approver_ids"] != "user1,user2" {
+			t.Errorf("expected actor and approver list in audit, got %+v", ev)
+		}
+	}
+}
+
+func newPausedChannel(t *testing.T, reason string) (*Handler, *mockDB, *mockAudit) {
+	t.Helper()
+	h, db, _, _, au, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4},
+		{ChannelID: "ch1", AccountID: "acct2", MaxRequestHours: 4},
+	}
+	if _, err := h.HandlePauseChannel(context.Background(), models.PauseChannelInput{
+		ChannelID:     "ch1",
+		Reason:        reason,
+		ActorMMUserID: "admin-1",
+	}); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	return h, db, au
+}
+
+func pauseTestRequest(accountID string) models.CreateRequestInput {
+	return models.CreateRequestInput{
+		AccountID:                accountID,
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 60,
+	}
+}
+
+func TestHandlePauseChannel_RejectsNewRequests(t *testing.T) {
+	h, db, au := newPausedChannel(t, "change freeze until Monday")
+
+	for _, acct := range []string{"acct1", "acct2"} {
+		cfg := db.configs["ch1|"+acct]
+		if cfg == nil || !cfg.Paused || cfg.PauseReason != "change freeze until Monday" {
+			t.Fatalf("expected %s paused with reason, got %+v", acct, cfg)
+		}
+	}
+	if len(au.events) != 2 || au.events[0].eventType != models.EventPaused {
+		t.Errorf("expected a PAUSE audit event per binding, got %+v", au.events)
+	}
+
+	_, err := h.HandleCreateRequest(context.Background(), pauseTestRequest("acct2"))
+	if err == nil || !strings.Contains(err.Error(), "is paused") || !strings.Contains(err.Error(), "change freeze until Monday") {
+		t.Fatalf("expected paused error with reason, got: %v", err)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected no request created, got %d", len(db.requests))
+	}
+}
+
+func TestHandleResumeChannel_AcceptsRequestsAgain(t *testing.T) {
+	h, db, _ := newPausedChannel(t, "")
+	db.configsByChannel["ch1"] = []models.JitConfig{*db.configs["ch1|acct1"], *db.configs["ch1|acct2"]}
+
+	if _, err := h.HandleResumeChannel(context.Background(), models.PauseChannelInput{ChannelID: "ch1"}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if cfg := db.configs["ch1|acct1"]; cfg.Paused || cfg.PauseReason != "" {
+		t.Errorf("expected binding resumed, got %+v", cfg)
+	}
+	if _, err := h.HandleCreateRequest(context.Background(), pauseTestRequest("acct1")); err != nil {
+		t.Fatalf("expected request accepted after resume, got: %v", err)
+	}
+}
+
+func TestHandlePauseChannel_ExistingGrantsStillRevoke(t *testing.T) {
+	h, db, _ := newPausedChannel(t, "freeze")
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "uid-123",
+		Status:              models.StatusGranted,
+	}
+
+	result, err := NewActionHandler(h).Handle(context.Background(), marshalPayload(t, StepFunctionActionPayload{
+		Action:    "revoke",
+		RequestID: "req-1",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "expired" || db.requests["req-1"].Status != models.StatusExpired {
+		t.Errorf("expected grant expired while paused, got %s / %s", result.Status, db.requests["req-1"].Status)
+	}
+}
+
+func TestHandleSetApprovers_NoAccounts(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	input := models.SetApproversInput{
+		ChannelID:   "ch1",
+		ApproverIDs: []string{"user1"},
+	}
+
+	_, err := h.HandleSetApprovers(context.Background(), input)
+	if err == nil {
+		t.Fatal("expected error for no bound accounts")
+	}
+}
+
+func TestHandleSetApprovers_MissingApprovers(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+		ChannelID: "ch1",
+	})
+	if err == nil {
+		t.Fatal("expected error for missing approvers")
+	}
+}
+
+func TestHandleSetApprovers_RejectsMalformedApproverIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{"empty", "", "approver_ids[1] is required"},
+		{"whitespace", " user2\n", "approver_ids[1] must not contain whitespace"},
+		{"control character", "user\x002", "approver_ids[1] must not contain whitespace"},
+		{"oversized", strings.Repeat("u", models.MaxIDLength+1), "approver_ids[1] must be at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db, _, _, _, _ := newTestHandler()
+			db.configsByChannel["ch1"] = []models.JitConfig{{ChannelID: "ch1", AccountID: "acct1"}}
+
+			_, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+				ChannelID:   "ch1",
+				ApproverIDs: []string{"user1", tt.id},
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+			if len(db.configsByChannel["ch1"][0].ApproverMMUserIDs) != 0 {
+				t.Error("expected approvers left unchanged")
+			}
+		})
+	}
+}
+
+func TestHandleSetApprovers_DeduplicatesApprovers(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{{ChannelID: "ch1", AccountID: "acct1"}}
+
+	updated, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+		ChannelID:   "ch1",
+		ApproverIDs: []string{"user2", "user1", "user2", "user1", "user3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(updated[0].ApproverMMUserIDs, ","); got != "user2,user1,user3" {
+		t.Errorf("expected de-duplicated approvers in first-seen order, got %s", got)
+	}
+}
+
+func TestHandleSetApprovers_EnforcesCap(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{{ChannelID: "ch1", AccountID: "acct1"}}
+
+	tooMany := make([]string, DefaultMaxApprovers+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("user%d", i)
+	}
+	_, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{ChannelID: "ch1", ApproverIDs: tooMany})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the maximum of 50") {
+		t.Errorf("expected the default cap to reject %d approvers, got: %v", len(tooMany), err)
+	}
+
+	// Duplicates do not count against the cap.
+	h.MaxApprovers = 2
+	if _, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+		ChannelID:   "ch1",
+		ApproverIDs: []string{"user1", "user2", "user1"},
+	}); err != nil {
+		t.Errorf("expected two distinct approvers within a cap of 2, got: %v", err)
+	}
+	if _, err := h.HandleSetAccountApprovers(context.Background(), models.SetAccountApproversInput{
+		ChannelID:   "ch1",
+		AccountID:   "acct1",
+		ApproverIDs: []string{"user1", "user2", "user3"},
+	}); err == nil || !strings.Contains(err.Error(), "exceeds the maximum of 2") {
+		t.Errorf("expected the configured cap to apply to account approvers, got: %v", err)
+	}
+}
+
+func TestHandleSetApprovers_KeepsAccountOverride(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "prod", AccountApproverMMUserIDs: []string{"prod-lead"}},
+		{ChannelID: "ch1", AccountID: "dev"},
+	}
+
+	updated, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
+		ChannelID:   "ch1",
+		ApproverIDs: []string{"dev-lead"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := updated[0].Approvers(); len(got) != 1 || got[0] != "prod-lead" {
+		t.Errorf("expected prod override to survive channel-wide update, got %v", got)
+	}
+	if got := updated[1].Approvers(); len(got) != 1 || got[0] != "dev-lead" {
+		t.Errorf("expected dev to use channel approvers, got %v", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleSetAccountApprovers tests
+// ---------------------------------------------------------------------------
+
+func TestHandleSetAccountApprovers_OverridesChannelApprovers(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	seedPendingApproval(db)
+
+	cfg, err := h.HandleSetAccountApprovers(context.Background(), models.SetAccountApproversInput{
+		ChannelID:   "ch1",
+		AccountID:   "acct1",
+		ApproverIDs: []string{"prod-lead"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ApproverMMUserIDs) != 1 || cfg.ApproverMMUserIDs[0] != "approver-1" {
+		t.Errorf("expected channel approvers to be left alone, got %v", cfg.ApproverMMUserIDs)
+	}
+
+	// The channel-wide approver no longer approves this account.
+	_, err = h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "approver-1",
+		ApproverEmail:    "approver@example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not an authorized approver") {
+		t.Fatalf("expected channel approver to be rejected, got %v", err)
+	}
+
+	_, err = h.HandleApproveRequest(context.Background(), models.ApproveRequestInput{
+		RequestID:        "req-1",
+		ApproverMMUserID: "prod-lead",
+		ApproverEmail:    "lead@example.com",
+	})
+	if err != nil {
+		t.Fatalf("expected account approver to approve, got %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected APPROVED status, got %s", db.requests["req-1"].Status)
+	}
+}
+
+func TestHandleSetAccountApprovers_DenyUsesAccountApprovers(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	seedPendingApproval(db)
+	db.configs["ch1|acct1"].AccountApproverMMUserIDs = []string{"prod-lead"}
+
+	_, err := h.HandleDenyRequest(context.Background(), models.DenyRequestInput{
+		RequestID:      "req-1",
+		DenierMMUserID: "approver-1",
+		DenierEmail:    "approver@example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not an authorized approver") {
+		t.Fatalf("expected channel approver to be rejected, got %v", err)
+	}
+}
+
+func TestHandleSetAccountApprovers_Validation(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleSetAccountApprovers(context.Background(), models.SetAccountApproversInput{
+		ChannelID: "ch1",
+		AccountID: "acct1",
+	})
+	if err == nil {
+		t.Fatal("expected error for missing approvers")
+	}
+
+	_, err = h.HandleSetAccountApprovers(context.Background(), models.SetAccountApproversInput{
+		ChannelID:   "ch1",
+		AccountID:   "missing",
+		ApproverIDs: []string{"u1"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no binding found") {
+		t.Fatalf("expected no binding found error, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HandleGetBoundAccounts tests
+// ---------------------------------------------------------------------------
+
+func TestHandleGetBoundAccounts_Success(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configsByChannel["ch1"] = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1"},
+	}
+
+	configs, err := h.HandleGetBoundAccounts(context.Background(), "ch1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 1 {
+		t.Errorf("expected 1 config, got %d", len(configs))
+	}
+}
+
+func TestHandleGetBoundAccounts_EmptyChannel(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	_, err := h.HandleGetBoundAccounts(context.Background(), "")
+	if err == nil {
+		t.Fatal("expected error for empty channel_id")
+	}
+}
+
+func TestHandleGetBoundAccounts_NilResult(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+
+	configs, err := h.HandleGetBoundAccounts(context.Background(), "ch-no-accounts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if configs == nil {
+		t.Error("expected non-nil (empty) slice")
 	}
 }
 
 // ---------------------------------------------------------------------------
-// HandleRevokeRequest tests
+// HandleUpdateConfig tests
 // ---------------------------------------------------------------------------
 
-func TestHandleRevokeRequest_Success(t *testing.T) {
-	h, db, _, wh, au, _ := newTestHandler()
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID:           "req-1",
-		AccountID:           "acct1",
-		ChannelID:           "ch1",
-		Status:              models.StatusGranted,
-		IdentityStoreUserID: "uid-123",
-	}
+func intPtr(v int) *int    { return &v }
+func boolPtr(v bool) *bool { return &v }
 
-	input := models.RevokeRequestInput{
-		RequestID:     "req-1",
-		ActorMMUserID: "admin-1",
-		ActorEmail:    "admin@example.com",
+func TestHandleUpdateConfig_PartialUpdate(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{
+		ChannelID:              "ch1",
+		AccountID:              "acct1",
+		ApproverMMUserIDs:      []string{"approver-1"},
+		MaxRequestHours:        4,
+		SessionDurationMinutes: 60,
 	}
 
-	_, err := h.HandleRevokeRequest(context.Background(), input)
+	cfg, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		MaxRequestHours:   intPtr(8),
+		AllowSelfApproval: boolPtr(true),
+		ActorMMUserID:     "admin-1",
+		ActorEmail:        "admin@example.com",
+	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if cfg.MaxRequestHours != 8 {
+		t.Errorf("expected MaxRequestHours 8, got %d", cfg.MaxRequestHours)
+	}
+	if !cfg.AllowSelfApproval {
+		t.Error("expected AllowSelfApproval true")
+	}
+	if cfg.SessionDurationMinutes != 60 {
+		t.Errorf("expected SessionDurationMinutes unchanged at 60, got %d", cfg.SessionDurationMinutes)
+	}
+	if len(cfg.ApproverMMUserIDs) != 1 {
+		t.Errorf("expected approvers unchanged, got %v", cfg.ApproverMMUserIDs)
+	}
 
-	if db.requests["req-1"].Status != models.StatusRevoked {
-		t.Errorf("expected REVOKED, got %s", db.requests["req-1"].Status)
+	if len(au.events) != 1 {
+		t.Fatalf("expected 1 audit event, got %d", len(au.events))
 	}
-	if len(au.events) != 1 || au.events[0].eventType != models.EventRevoked {
-		t.Errorf("expected REVOKED audit event")
+	ev := au.events[0]
+	if ev.eventType != models.EventConfigUpdated || ev.requestID != models.ConfigAuditKey("ch1", "acct1") {
+		t.Errorf("unexpected audit event: %+v", ev)
 	}
-	if len(wh.payloads) != 1 || wh.payloads[0].Status != models.StatusRevoked {
-		t.Errorf("expected REVOKED webhook notification")
+	if ev.actorMMUserID != "admin-1" {
+		t.Errorf("expected actor admin-1, got %q", ev.actorMMUserID)
+	}
+	if ev.details["max_request_hours"] != "8" || ev.details["allow_self_approval"] != "true" {
+		t.Errorf("expected changed fields in audit details, got %+v", ev.details)
+	}
+	if _, ok := ev.details["session_duration_minutes"]; ok {
+		t.Error("expected unchanged field to be absent from audit details")
 	}
 }
 
-func TestHandleRevokeRequest_NotGranted(t *testing.T) {
-	h, db, _, _, _, _ := newTestHandler()
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID: "req-1",
-		Status:    models.StatusPending,
+func TestHandleUpdateConfig_RangeValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		input models.UpdateConfigInput
+	}{
+		{"hours too low", models.UpdateConfigInput{MaxRequestHours: intPtr(0)}},
+		{"hours too high", models.UpdateConfigInput{MaxRequestHours: intPtr(25)}},
+		{"session too short", models.UpdateConfigInput{SessionDurationMinutes: intPtr(30)}},
+		{"session too long", models.UpdateConfigInput{SessionDurationMinutes: intPtr(721)}},
+		{"negative grant cap", models.UpdateConfigInput{MaxConcurrentGrants: intPtr(-1)}},
+		{"negative auto-approve cap", models.UpdateConfigInput{AutoApproveMaxMinutes: intPtr(-1)}},
+		{"auto-approve cap too high", models.UpdateConfigInput{AutoApproveMaxMinutes: intPtr(maxRequestDurationMinutes + 1)}},
+		{"negative min duration", models.UpdateConfigInput{MinRequestMinutes: intPtr(-1)}},
+		{"min duration above max hours", models.UpdateConfigInput{MinRequestMinutes: intPtr(241)}},
+		{"max hours below min duration", models.UpdateConfigInput{MaxRequestHours: intPtr(1), MinRequestMinutes: intPtr(90)}},
+		{"no fields", models.UpdateConfigInput{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, db, _, _, au, _ := newTestHandler()
+			db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+			tc.input.ChannelID = "ch1"
+			tc.input.AccountID = "acct1"
+			if _, err := h.HandleUpdateConfig(context.Background(), tc.input); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+			if db.configs["ch1|acct1"].MaxRequestHours != 4 {
+				t.Errorf("expected config unchanged, got MaxRequestHours %d", db.configs["ch1|acct1"].MaxRequestHours)
+			}
+			if len(au.events) != 0 {
+				t.Errorf("expected no audit events, got %d", len(au.events))
+			}
+		})
 	}
+}
 
-	input := models.RevokeRequestInput{
-		RequestID:     "req-1",
-		ActorMMUserID: "admin-1",
-		ActorEmail:    "admin@example.com",
-	}
+func TestHandleUpdateConfig_AutoApprove(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
 
-	_, err := h.HandleRevokeRequest(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error for non-GRANTED request")
+	cfg, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:             "ch1",
+		AccountID:             "acct1",
+		AutoApprove:           boolPtr(true),
+		AutoApproveMaxMinutes: intPtr(30),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.AutoApprove || cfg.AutoApproveMaxMinutes != 30 {
+		t.Errorf("expected auto-approve up to 30 minutes, got %v/%d", cfg.AutoApprove, cfg.AutoApproveMaxMinutes)
+	}
+	if ev := au.events[0]; ev.details["auto_approve"] != "true" || ev.details["auto_approve_max_minutes"] != "30" {
+		t.Errorf("expected auto-approve settings in audit details, got %+v", ev.details)
 	}
 }
 
-func TestHandleRevokeRequest_IdentityError(t *testing.T) {
-	h, db, id, _, _, _ := newTestHandler()
-	id.revokeErr = fmt.Errorf("SSO unavailable")
-	db.requests["req-1"] = &models.JitRequest{
-		RequestID:           "req-1",
-		AccountID:           "acct1",
-		ChannelID:           "ch1",
-		Status:              models.StatusGranted,
-		IdentityStoreUserID: "uid-123",
-	}
+func TestHandleUpdateConfig_MinRequestMinutes(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
 
-	input := models.RevokeRequestInput{
-		RequestID:     "req-1",
-		ActorMMUserID: "admin-1",
-		ActorEmail:    "admin@example.com",
+	cfg, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		MinRequestMinutes: intPtr(240),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinRequestMinutes != 240 {
+		t.Errorf("expected a 240 minute floor, got %d", cfg.MinRequestMinutes)
+	}
+	if au.events[0].details["min_request_minutes"] != "240" {
+		t.Errorf("expected floor in audit details, got %+v", au.events[0].details)
 	}
 
-	_, err := h.HandleRevokeRequest(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error when identity revoke fails")
+	// Lowering the cap below the stored floor is refused.
+	_, err = h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:       "ch1",
+		AccountID:       "acct1",
+		MaxRequestHours: intPtr(2),
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeds max_request_hours") {
+		t.Errorf("expected floor/cap conflict, got: %v", err)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// HandleListRequests tests
-// ---------------------------------------------------------------------------
+func TestHandleUpdateConfig_WebhookOverride(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+	url, keyID := "https://mm-east.example.com/plugins/jit", "east"
+	cfg, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:    "ch1",
+		AccountID:    "acct1",
+		WebhookURL:   &url,
+		WebhookKeyID: &keyID,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WebhookURL != url || cfg.WebhookKeyID != keyID {
+		t.Errorf("expected webhook override stored, got %q %q", cfg.WebhookURL, cfg.WebhookKeyID)
+	}
+	if au.events[0].details["webhook_url"] != url {
+		t.Errorf("expected webhook URL in audit details, got %+v", au.events[0].details)
+	}
 
-func TestHandleListRequests_Success(t *testing.T) {
-	h, db, _, _, _, _ := newTestHandler()
-	db.queryReqResult = []models.JitRequest{
-		{RequestID: "req-1", Status: models.StatusPending},
-		{RequestID: "req-2", Status: models.StatusGranted},
+	for _, bad := range []string{"mm-east.example.com/hook", "ftp://mm-east.example.com", "https://"} {
+		_, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+			ChannelID:  "ch1",
+			AccountID:  "acct1",
+			WebhookURL: &bad,
+		})
+		if err == nil || !strings.Contains(err.Error(), "webhook_url must be") {
+			t.Errorf("webhook_url %q: expected validation error, got: %v", bad, err)
+		}
 	}
 
-	input := models.ReportingInput{
-		ChannelID: "ch1",
-		Limit:     10,
+	// An empty URL clears the override.
+	empty := ""
+	if cfg, err = h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:  "ch1",
+		AccountID:  "acct1",
+		WebhookURL: &empty,
+	}); err != nil || cfg.WebhookURL != "" {
+		t.Errorf("expected override cleared, got %+v, %v", cfg, err)
 	}
+}
 
-	resp, err := h.HandleListRequests(context.Background(), input)
+func TestHandleUpdateConfig_SelfDenyAndStrongAuth(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+	cfg, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		AllowSelfDeny:     boolPtr(false),
+		RequireStrongAuth: boolPtr(true),
+	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(resp.Items) != 2 {
-		t.Errorf("expected 2 items, got %d", len(resp.Items))
+	if cfg.SelfDenyAllowed() {
+		t.Error("expected self-deny disabled")
 	}
-	if resp.Filters["channel_id"] != "ch1" {
-		t.Errorf("expected channel_id filter echoed")
+	if !cfg.RequireStrongAuth {
+		t.Error("expected strong auth required")
+	}
+	if ev := au.events[0]; ev.details["allow_self_deny"] != "false" || ev.details["require_strong_auth"] != "true" {
+		t.Errorf("expected both settings in audit details, got %+v", ev.details)
 	}
-}
 
-func TestHandleListRequests_DefaultLimit(t *testing.T) {
-	h, _, _, _, _, _ := newTestHandler()
+	// Turning strong auth off needs a strongly authenticated caller.
+	_, err = h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		RequireStrongAuth: boolPtr(false),
+	})
+	if err == nil || !strings.Contains(err.Error(), "strong authentication required") {
+		t.Fatalf("expected strong-auth error, got: %v", err)
+	}
+	if !db.configs["ch1|acct1"].RequireStrongAuth || len(au.events) != 1 {
+		t.Errorf("expected a refused change to leave the binding and audit log alone")
+	}
 
-	input := models.ReportingInput{Limit: 0, ChannelID: "ch1"}
-	resp, err := h.HandleListRequests(context.Background(), input)
+	ctx := auth.ContextWithAuthLevel(context.Background(), DefaultMinStrongAuthLevel)
+	cfg, err = h.HandleUpdateConfig(ctx, models.UpdateConfigInput{
+		ChannelID:         "ch1",
+		AccountID:         "acct1",
+		RequireStrongAuth: boolPtr(false),
+	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if resp.Items == nil {
-		t.Errorf("expected non-nil items slice")
+	if cfg.RequireStrongAuth {
+		t.Error("expected strong auth no longer required")
 	}
 }
 
-func TestHandleListRequests_MaxLimit(t *testing.T) {
+func TestHandleUpdateConfig_NotBound(t *testing.T) {
 	h, _, _, _, _, _ := newTestHandler()
 
-	input := models.ReportingInput{Limit: 500, ChannelID: "ch1"}
-	_, err := h.HandleListRequests(context.Background(), input)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	_, err := h.HandleUpdateConfig(context.Background(), models.UpdateConfigInput{
+		ChannelID:       "ch1",
+		AccountID:       "acct1",
+		MaxRequestHours: intPtr(8),
+	})
+	if err == nil {
+		t.Fatal("expected error for unbound account")
+	}
+	if !strings.Contains(err.Error(), "no binding found") {
+		t.Errorf("expected no binding error, got: %v", err)
 	}
-	// Limit is capped internally; no error expected.
 }
 
 // ---------------------------------------------------------------------------
-// HandleBindAccount tests
+// HandleConfigReport tests
 // ---------------------------------------------------------------------------
 
-func TestHandleBindAccount_Success(t *testing.T) {
+func TestHandleConfigReport_FlagsMisconfigured(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
-
-	input := models.BindAccountInput{
-		ChannelID: "ch1",
-		AccountID: "123456789012",
+	h.AdminMMUserIDs = []string{"admin-1"}
+	db.scanConfigs = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"a1", "a2"}, MaxRequestHours: 4},
+		{ChannelID: "ch1", AccountID: "acct2", MaxRequestHours: 4},
+		{ChannelID: "ch2", AccountID: "acct3"},
 	}
 
-	cfg, err := h.HandleBindAccount(context.Background(), input)
+	resp, err := h.HandleConfigReport(context.Background(), models.ConfigReportInput{ActorMMUserID: "admin-1"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if cfg.ChannelID != "ch1" {
-		t.Errorf("expected channel_id ch1, got %s", cfg.ChannelID)
+	if resp.Count != 3 || resp.HasMore {
+		t.Fatalf("expected 3 items and no more pages, got count=%d has_more=%v", resp.Count, resp.HasMore)
 	}
-	if cfg.MaxRequestHours != 4 {
-		t.Errorf("expected default max_request_hours 4, got %d", cfg.MaxRequestHours)
+	if len(resp.Items[0].Issues) != 0 {
+		t.Errorf("expected no issues for acct1, got %v", resp.Items[0].Issues)
 	}
-	if _, ok := db.configs["ch1|123456789012"]; !ok {
-		t.Error("expected config to be stored")
+	if len(resp.Items[1].Issues) != 1 || resp.Items[1].Issues[0] != models.ConfigIssueNoApprovers {
+		t.Errorf("expected no_approvers for acct2, got %v", resp.Items[1].Issues)
+	}
+	if len(resp.Items[2].Issues) != 2 {
+		t.Errorf("expected both issues for acct3, got %v", resp.Items[2].Issues)
 	}
 }
 
-func TestHandleBindAccount_AlreadyBoundDifferentChannel(t *testing.T) {
+func TestHandleConfigReport_Paginates(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
-	db.channelForAcct["123456789012"] = &models.JitConfig{
-		ChannelID: "other-channel",
-		AccountID: "123456789012",
+	h.AdminMMUserIDs = []string{"admin-1"}
+	db.scanConfigs = []models.JitConfig{
+		{ChannelID: "ch1", AccountID: "acct1"},
+		{ChannelID: "ch1", AccountID: "acct2"},
+		{ChannelID: "ch2", AccountID: "acct3"},
 	}
 
-	input := models.BindAccountInput{
-		ChannelID: "ch1",
-		AccountID: "123456789012",
+	resp, err := h.HandleConfigReport(context.Background(), models.ConfigReportInput{ActorMMUserID: "admin-1", Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Count != 2 || !resp.HasMore {
+		t.Fatalf("expected first page of 2 with more, got count=%d has_more=%v", resp.Count, resp.HasMore)
 	}
 
-	_, err := h.HandleBindAccount(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error for account bound to different channel")
+	resp, err = h.HandleConfigReport(context.Background(), models.ConfigReportInput{ActorMMUserID: "admin-1", Limit: 2, NextToken: resp.NextToken})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Count != 1 || resp.HasMore || resp.Items[0].AccountID != "acct3" {
+		t.Errorf("expected final page with acct3, got %+v", resp)
 	}
 }
 
-func TestHandleBindAccount_MissingFields(t *testing.T) {
+func TestHandleConfigReport_NonAdminRejected(t *testing.T) {
 	h, _, _, _, _, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
 
-	_, err := h.HandleBindAccount(context.Background(), models.BindAccountInput{})
+	_, err := h.HandleConfigReport(context.Background(), models.ConfigReportInput{ActorMMUserID: "user-1"})
 	if err == nil {
-		t.Fatal("expected error for missing fields")
+		t.Fatal("expected error for non-admin")
+	}
+	if !strings.Contains(err.Error(), "not an admin") {
+		t.Errorf("expected admin error, got: %v", err)
 	}
 }
 
 // ---------------------------------------------------------------------------
-// HandleSetApprovers tests
+// HandleActiveGrants tests
 // ---------------------------------------------------------------------------
 
-func TestHandleSetApprovers_Success(t *testing.T) {
+func TestHandleAccountBindings_ReportsDuplicates(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
-	db.configsByChannel["ch1"] = []models.JitConfig{
-		{ChannelID: "ch1", AccountID: "acct1"},
-		{ChannelID: "ch1", AccountID: "acct2"},
-	}
-
-	input := models.SetApproversInput{
-		ChannelID:   "ch1",
-		ApproverIDs: []string{"user1", "user2"},
-	}
+	h.AdminMMUserIDs = []string{"admin-1"}
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.configs["ch2|acct1"] = &models.JitConfig{ChannelID: "ch2", AccountID: "acct1"}
+	db.configs["ch1|acct2"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct2"}
 
-	updated, err := h.HandleSetApprovers(context.Background(), input)
+	resp, err := h.HandleAccountBindings(context.Background(), models.AccountBindingsInput{ActorMMUserID: "admin-1", AccountID: "acct1"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(updated) != 2 {
-		t.Errorf("expected 2 updated configs, got %d", len(updated))
+	if resp.Count != 2 || !resp.Duplicate {
+		t.Fatalf("expected 2 bindings flagged as duplicate, got count=%d duplicate=%v", resp.Count, resp.Duplicate)
 	}
-	for _, cfg := range updated {
-		if len(cfg.ApproverMMUserIDs) != 2 {
-			t.Errorf("expected 2 approvers, got %d", len(cfg.ApproverMMUserIDs))
-		}
+	if resp.Bindings[0].ChannelID != "ch1" || resp.Bindings[1].ChannelID != "ch2" {
+		t.Errorf("expected both channels returned, got %+v", resp.Bindings)
 	}
-}
 
-func TestHandleSetApprovers_NoAccounts(t *testing.T) {
-	h, _, _, _, _, _ := newTestHandler()
-
-	input := models.SetApproversInput{
-		ChannelID:   "ch1",
-		ApproverIDs: []string{"user1"},
+	single, err := h.HandleAccountBindings(context.Background(), models.AccountBindingsInput{ActorMMUserID: "admin-1", AccountID: "acct2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-
-	_, err := h.HandleSetApprovers(context.Background(), input)
-	if err == nil {
-		t.Fatal("expected error for no bound accounts")
+	if single.Count != 1 || single.Duplicate {
+		t.Errorf("expected a single binding, got count=%d duplicate=%v", single.Count, single.Duplicate)
 	}
 }
 
-func TestHandleSetApprovers_MissingApprovers(t *testing.T) {
+func TestHandleAccountBindings_RequiresAdmin(t *testing.T) {
 	h, _, _, _, _, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
 
-	_, err := h.HandleSetApprovers(context.Background(), models.SetApproversInput{
-		ChannelID: "ch1",
-	})
-	if err == nil {
-		t.Fatal("expected error for missing approvers")
+	_, err := h.HandleAccountBindings(context.Background(), models.AccountBindingsInput{ActorMMUserID: "user-1", AccountID: "acct1"})
+	if err == nil || !strings.Contains(err.Error(), "is not an admin") {
+		t.Fatalf("expected admin error, got: %v", err)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// HandleGetBoundAccounts tests
-// ---------------------------------------------------------------------------
-
-func TestHandleGetBoundAccounts_Success(t *testing.T) {
+func TestHandleActiveGrants_OnlyGranted(t *testing.T) {
 	h, db, _, _, _, _ := newTestHandler()
-	db.configsByChannel["ch1"] = []models.JitConfig{
-		{ChannelID: "ch1", AccountID: "acct1"},
+	h.AdminMMUserIDs = []string{"admin-1"}
+	seed := []models.JitRequest{
+		{RequestID: "g-late", ChannelID: "ch1", AccountID: "acct1", RequesterEmail: "a@example.com", Status: models.StatusGranted, EndTime: "2025-06-01T14:00:00Z"},
+		{RequestID: "g-soon", ChannelID: "ch2", AccountID: "acct2", RequesterEmail: "b@example.com", Status: models.StatusGranted, EndTime: "2025-06-01T12:00:00Z"},
+		{RequestID: "pending", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusPending},
+		{RequestID: "approved", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusApproved},
+		{RequestID: "revoked", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusRevoked, EndTime: "2025-06-01T11:00:00Z"},
+		{RequestID: "expired", ChannelID: "ch2", AccountID: "acct2", Status: models.StatusExpired, EndTime: "2025-06-01T10:00:00Z"},
+	}
+	for i := range seed {
+		db.requests[seed[i].RequestID] = &seed[i]
 	}
 
-	configs, err := h.HandleGetBoundAccounts(context.Background(), "ch1")
+	resp, err := h.HandleActiveGrants(context.Background(), models.ActiveGrantsInput{ActorMMUserID: "admin-1"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(configs) != 1 {
-		t.Errorf("expected 1 config, got %d", len(configs))
+	if resp.Count != 2 || resp.HasMore {
+		t.Fatalf("expected 2 grants and no more pages, got count=%d has_more=%v", resp.Count, resp.HasMore)
+	}
+	if resp.Items[0].RequestID != "g-soon" || resp.Items[1].RequestID != "g-late" {
+		t.Errorf("expected grants ordered by end_time, got %s, %s", resp.Items[0].RequestID, resp.Items[1].RequestID)
+	}
+	if resp.Items[0].EndTime != "2025-06-01T12:00:00Z" || resp.Items[0].RequesterEmail != "b@example.com" {
+		t.Errorf("expected end_time and requester on each grant, got %+v", resp.Items[0])
 	}
 }
 
-func TestHandleGetBoundAccounts_EmptyChannel(t *testing.T) {
-	h, _, _, _, _, _ := newTestHandler()
+func TestHandleRecountGrants(t *testing.T) {
+	h, db, _, _, au, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxConcurrentGrants: 3}
+	db.activeGrants = map[string]int{models.GrantCounterKey("ch1", "acct1"): 3}
+	for _, r := range []models.JitRequest{
+		{RequestID: "g-1", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusGranted},
+		{RequestID: "g-2", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusGranted},
+		{RequestID: "other", ChannelID: "ch2", AccountID: "acct1", Status: models.StatusGranted},
+		{RequestID: "failed", ChannelID: "ch1", AccountID: "acct1", Status: models.StatusError},
+	} {
+		r := r
+		db.requests[r.RequestID] = &r
+	}
+
+	input := models.RecountGrantsInput{ActorMMUserID: "admin-1", ActorEmail: "admin@example.com", ChannelID: "ch1", AccountID: "acct1"}
+	resp, err := h.HandleRecountGrants(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ActiveGrants != 2 || db.activeGrants[models.GrantCounterKey("ch1", "acct1")] != 2 {
+		t.Errorf("expected the counter rebuilt to 2, got %d (stored %d)", resp.ActiveGrants, db.activeGrants[models.GrantCounterKey("ch1", "acct1")])
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventGrantsRecount || au.events[0].requestID != models.ConfigAuditKey("ch1", "acct1") {
+		t.Errorf("expected a RECOUNT_GRANTS config audit event, got %+v", au.events)
+	}
 
-	_, err := h.HandleGetBoundAccounts(context.Background(), "")
-	if err == nil {
-		t.Fatal("expected error for empty channel_id")
+	input.ActorMMUserID = "mm-user-1"
+	if _, err := h.HandleRecountGrants(context.Background(), input); err == nil || !strings.Contains(err.Error(), "is not an admin") {
+		t.Errorf("expected non-admins refused, got %v", err)
+	}
+	input.ActorMMUserID, input.ChannelID = "admin-1", "ch9"
+	if _, err := h.HandleRecountGrants(context.Background(), input); err == nil || !strings.Contains(err.Error(), "no binding found") {
+		t.Errorf("expected an unbound channel refused, got %v", err)
 	}
 }
 
-func TestHandleGetBoundAccounts_NilResult(t *testing.T) {
-	h, _, _, _, _, _ := newTestHandler()
+func TestHandleActiveGrants_Paginates(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("g-%d", i)
+		db.requests[id] = &models.JitRequest{RequestID: id, Status: models.StatusGranted, EndTime: fmt.Sprintf("2025-06-01T1%d:00:00Z", i)}
+	}
 
-	configs, err := h.HandleGetBoundAccounts(context.Background(), "ch-no-accounts")
+	first, err := h.HandleActiveGrants(context.Background(), models.ActiveGrantsInput{ActorMMUserID: "admin-1", Limit: 2})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if configs == nil {
-		t.Error("expected non-nil (empty) slice")
+	if first.Count != 2 || !first.HasMore {
+		t.Fatalf("expected first page of 2 with more, got count=%d has_more=%v", first.Count, first.HasMore)
+	}
+
+	second, err := h.HandleActiveGrants(context.Background(), models.ActiveGrantsInput{ActorMMUserID: "admin-1", Limit: 2, NextToken: first.NextToken})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Count != 1 || second.HasMore || second.Items[0].RequestID != "g-3" {
+		t.Errorf("expected final page with g-3, got %+v", second)
+	}
+}
+
+func TestHandleActiveGrants_NonAdminRejected(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
+
+	_, err := h.HandleActiveGrants(context.Background(), models.ActiveGrantsInput{ActorMMUserID: "user-1"})
+	if err == nil || !strings.Contains(err.Error(), "not an admin") {
+		t.Fatalf("expected admin error, got: %v", err)
 	}
 }
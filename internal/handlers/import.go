@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// importableStatuses are the statuses an imported grant may carry. Imports
+// record access that was already granted elsewhere, so pre-grant states are
+// not accepted.
+var importableStatuses = map[string]bool{
+	models.StatusGranted: true,
+	models.StatusRevoked: true,
+	models.StatusExpired: true,
+}
+
+// HandleImportGrants processes POST /admin/import.
+// Writes pre-existing grants from another approval system directly as
+// requests, bypassing approval and Step Functions. The whole batch is
+// validated before anything is written; grants whose request ID was already
+// imported are skipped so the call can be retried safely. Active imports are
+// left to the reconciler, which revokes them at end_time.
+func (h *Handler) HandleImportGrants(ctx context.Context, input models.ImportGrantsInput) (*models.ImportGrantsResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if len(input.Grants) == 0 {
+		return nil, fmt.Errorf("grants is required")
+	}
+	if len(input.Grants) > models.MaxImportBatch {
+		return nil, fmt.Errorf("at most %d grants may be imported per call", models.MaxImportBatch)
+	}
+
+	seen := make(map[string]bool, len(input.Grants))
+	for i, g := range input.Grants {
+		if err := validateImportedGrant(g); err != nil {
+			return nil, fmt.Errorf("grant %d: %w", i, err)
+		}
+		if seen[g.RequestID] {
+			return nil, fmt.Errorf("grant %d: duplicate request_id %s", i, g.RequestID)
+		}
+		seen[g.RequestID] = true
+	}
+
+	// Resolve every binding, and the Identity Center user of every active
+	// grant, before writing so a bad record cannot leave a partial import
+	// behind.
+	channels := make([]string, len(input.Grants))
+	userIDs := make([]string, len(input.Grants))
+	resolved := map[string]string{} // requester email -> Identity Store user ID
+	for i, g := range input.Grants {
+		channelID, err := h.importBinding(ctx, g)
+		if err != nil {
+			return nil, fmt.Errorf("grant %d: %w", i, err)
+		}
+		channels[i] = channelID
+
+		if g.Status != models.StatusGranted {
+			continue
+		}
+		userID, ok := resolved[g.RequesterEmail]
+		if !ok {
+			userID, err = h.Identity.LookupUserByEmail(ctx, g.RequesterEmail)
+			if err != nil {
+				return nil, fmt.Errorf("grant %d: lookup identity store user: %w", i, err)
+			}
+			resolved[g.RequesterEmail] = userID
+		}
+		userIDs[i] = userID
+	}
+
+	resp := &models.ImportGrantsResponse{Imported: []string{}, Skipped: []string{}}
+	for i, g := range input.Grants {
+		existing, err := h.DB.GetRequest(ctx, g.RequestID)
+		if err != nil {
+			return resp, fmt.Errorf("lookup request %s: %w", g.RequestID, err)
+		}
+		if existing != nil {
+			if !existing.Imported {
+				return resp, fmt.Errorf("request %s already exists and was not imported", g.RequestID)
+			}
+			resp.Skipped = append(resp.Skipped, g.RequestID)
+			continue
+		}
+
+		req := h.importedRequest(g, channels[i], userIDs[i])
+		if err := h.DB.CreateRequest(ctx, req); err != nil {
+			return resp, fmt.Errorf("import request %s: %w", g.RequestID, err)
+		}
+		if req.Status == models.StatusGranted {
+			// Imported grants bypass the concurrency cap but still hold a
+			// slot, which the reconciler releases when it expires them.
+			if err := h.DB.IncrementActiveGrants(ctx, req.ChannelID, req.AccountID, 0); err != nil {
+				slog.Warn("failed to count imported grant",
+					"request_id", req.RequestID,
+					"error", err,
+				)
+			}
+		}
+
+		_ = h.Audit.Log(ctx, req.RequestID, models.EventImported, req.AccountID, req.ChannelID,
+			models.HumanActor(input.ActorMMUserID, input.ActorEmail), map[string]string{
+				"status":         req.Status,
+				"grant_time":     req.GrantTime,
+				"end_time":       req.EndTime,
+				"approver_email": req.ApproverEmail,
+			})
+		resp.Imported = append(resp.Imported, req.RequestID)
+	}
+
+	slog.Info("grants imported",
+		"actor", input.ActorEmail,
+		"imported", len(resp.Imported),
+		"skipped", len(resp.Skipped),
+	)
+	return resp, nil
+}
+
+// validateImportedGrant checks the shape of a single imported grant.
+func validateImportedGrant(g models.ImportedGrant) error {
+	if g.RequestID == "" {
+		return fmt.Errorf("request_id is required")
+	}
+	if g.AccountID == "" {
+		return fmt.Errorf("account_id is required")
+	}
+	for field, email := range map[string]string{"requester_email": g.RequesterEmail, "approver_email": g.ApproverEmail} {
+		if email == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return fmt.Errorf("%s %q is not a valid email address", field, email)
+		}
+	}
+	if !importableStatuses[g.Status] {
+		return fmt.Errorf("status %q cannot be imported; must be GRANTED, REVOKED or EXPIRED", g.Status)
+	}
+	grantTime, err := time.Parse(time.RFC3339, g.GrantTime)
+	if err != nil {
+		return fmt.Errorf("grant_time must be RFC3339: %w", err)
+	}
+	endTime, err := time.Parse(time.RFC3339, g.EndTime)
+	if err != nil {
+		return fmt.Errorf("end_time must be RFC3339: %w", err)
+	}
+	if !endTime.After(grantTime) {
+		return fmt.Errorf("end_time must be after grant_time")
+	}
+	return nil
+}
+
+// importBinding returns the channel of the binding an imported grant belongs
+// to, failing if the account is not bound (to the given channel, if set).
+func (h *Handler) importBinding(ctx context.Context, g models.ImportedGrant) (string, error) {
+	if g.ChannelID == "" {
+		cfg, err := h.DB.GetChannelForAccount(ctx, g.AccountID)
+		if err != nil {
+			return "", fmt.Errorf("lookup binding: %w", err)
+		}
+		if cfg == nil {
+			return "", fmt.Errorf("no binding found for account %s", g.AccountID)
+		}
+		return cfg.ChannelID, nil
+	}
+	cfg, err := h.DB.GetConfig(ctx, g.ChannelID, g.AccountID)
+	if err != nil {
+		return "", fmt.Errorf("lookup binding: %w", err)
+	}
+	if cfg == nil {
+		return "", fmt.Errorf("no binding found for channel %s and account %s", g.ChannelID, g.AccountID)
+	}
+	return g.ChannelID, nil
+}
+
+// importedRequest builds the stored request for an imported grant. Active
+// grants carry identityUserID, the Identity Center user, so the reconciler
+// can revoke them.
+func (h *Handler) importedRequest(g models.ImportedGrant, channelID, identityUserID string) *models.JitRequest {
+	grantTime, _ := time.Parse(time.RFC3339, g.GrantTime)
+	endTime, _ := time.Parse(time.RFC3339, g.EndTime)
+
+	req := &models.JitRequest{
+		RequestID:                g.RequestID,
+		AccountID:                g.AccountID,
+		ChannelID:                channelID,
+		RequesterMMUserID:        g.RequesterMMUserID,
+		RequesterEmail:           g.RequesterEmail,
+		Reason:                   g.Reason,
+		RequestedDurationMinutes: int(endTime.Sub(grantTime).Minutes()),
+		Status:                   g.Status,
+		CreatedAt:                h.now().Format(time.RFC3339),
+		ApprovedAt:               g.GrantTime,
+		GrantTime:                g.GrantTime,
+		EndTime:                  g.EndTime,
+		ApproverEmail:            g.ApproverEmail,
+		Imported:                 true,
+	}
+	switch g.Status {
+	case models.StatusGranted:
+		req.IdentityStoreUserID = identityUserID
+	case models.StatusRevoked:
+		req.RevokedAt = g.EndTime
+	case models.StatusExpired:
+		req.ExpiredAt = g.EndTime
+	}
+	return req
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func newImportHandler() (*Handler, *mockDB, *mockAudit) {
+	h, db, _, _, au, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
+	cfg := &models.JitConfig{ChannelID: "ch1", AccountID: "acct1"}
+	db.configs["ch1|acct1"] = cfg
+	db.channelForAcct["acct1"] = cfg
+	return h, db, au
+}
+
+func importInput(grants ...models.ImportedGrant) models.ImportGrantsInput {
+	return models.ImportGrantsInput{ActorMMUserID: "admin-1", ActorEmail: "admin@example.com", Grants: grants}
+}
+
+func TestHandleImportGrants_Success(t *testing.T) {
+	h, db, au := newImportHandler()
+	input := importInput(
+		models.ImportedGrant{
+			RequestID:      "legacy-1",
+			AccountID:      "acct1",
+			RequesterEmail: "user@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "2025-06-01T10:00:00Z",
+			EndTime:        "2025-06-01T12:00:00Z",
+			Status:         models.StatusGranted,
+		},
+		models.ImportedGrant{
+			RequestID:      "legacy-2",
+			ChannelID:      "ch1",
+			AccountID:      "acct1",
+			RequesterEmail: "other@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "2025-05-01T10:00:00Z",
+			EndTime:        "2025-05-01T11:00:00Z",
+			Status:         models.StatusExpired,
+		},
+	)
+
+	resp, err := h.HandleImportGrants(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Imported) != 2 || len(resp.Skipped) != 0 {
+		t.Fatalf("expected 2 imported and 0 skipped, got %+v", resp)
+	}
+
+	active := db.requests["legacy-1"]
+	if active == nil || !active.Imported || active.Status != models.StatusGranted {
+		t.Fatalf("expected imported GRANTED request, got %+v", active)
+	}
+	if active.ChannelID != "ch1" {
+		t.Errorf("expected channel resolved from binding, got %q", active.ChannelID)
+	}
+	if active.IdentityStoreUserID != "uid-123" {
+		t.Errorf("expected identity store user for active grant, got %q", active.IdentityStoreUserID)
+	}
+	if active.RequestedDurationMinutes != 120 {
+		t.Errorf("expected 120 minute duration, got %d", active.RequestedDurationMinutes)
+	}
+	if got := db.requests["legacy-2"]; got == nil || got.ExpiredAt != "2025-05-01T11:00:00Z" {
+		t.Errorf("expected expired_at from end_time, got %+v", got)
+	}
+	if got := db.activeGrants[models.GrantCounterKey("ch1", "acct1")]; got != 1 {
+		t.Errorf("expected only the active import to hold a grant slot, got %d", got)
+	}
+	if len(au.events) != 2 || au.events[0].eventType != models.EventImported {
+		t.Errorf("expected two IMPORTED audit events, got %+v", au.events)
+	}
+
+	// Re-running the same batch is a no-op.
+	resp, err = h.HandleImportGrants(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if len(resp.Imported) != 0 || len(resp.Skipped) != 2 {
+		t.Errorf("expected retry to skip both grants, got %+v", resp)
+	}
+	if len(au.events) != 2 {
+		t.Errorf("expected no new audit events on retry, got %d", len(au.events))
+	}
+}
+
+func TestHandleImportGrants_RejectsMalformedRecord(t *testing.T) {
+	h, db, _ := newImportHandler()
+	input := importInput(
+		models.ImportedGrant{
+			RequestID:      "legacy-1",
+			AccountID:      "acct1",
+			RequesterEmail: "user@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "2025-06-01T10:00:00Z",
+			EndTime:        "2025-06-01T12:00:00Z",
+			Status:         models.StatusGranted,
+		},
+		models.ImportedGrant{
+			RequestID:      "legacy-2",
+			AccountID:      "acct1",
+			RequesterEmail: "user@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "yesterday",
+			EndTime:        "2025-06-01T12:00:00Z",
+			Status:         models.StatusGranted,
+		},
+	)
+
+	_, err := h.HandleImportGrants(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), "grant 1: grant_time") {
+		t.Fatalf("expected grant_time error for record 1, got: %v", err)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected nothing written for a rejected batch, got %d requests", len(db.requests))
+	}
+}
+
+func TestHandleImportGrants_UnknownIdentityWritesNothing(t *testing.T) {
+	h, db, au := newImportHandler()
+	input := importInput(
+		models.ImportedGrant{
+			RequestID:      "legacy-1",
+			AccountID:      "acct1",
+			RequesterEmail: "user@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "2025-06-01T10:00:00Z",
+			EndTime:        "2025-06-01T12:00:00Z",
+			Status:         models.StatusGranted,
+		},
+		models.ImportedGrant{
+			RequestID:      "legacy-2",
+			AccountID:      "acct1",
+			RequesterEmail: "stranger@example.com",
+			ApproverEmail:  "boss@example.com",
+			GrantTime:      "2025-06-01T10:00:00Z",
+			EndTime:        "2025-06-01T12:00:00Z",
+			Status:         models.StatusGranted,
+		},
+	)
+
+	_, err := h.HandleImportGrants(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), "grant 1: lookup identity store user") {
+		t.Fatalf("expected identity lookup error for record 1, got: %v", err)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected nothing written when an active grant's user is unknown, got %d requests", len(db.requests))
+	}
+	if len(au.events) != 0 {
+		t.Errorf("expected no audit events, got %d", len(au.events))
+	}
+}
+
+func TestHandleImportGrants_Validation(t *testing.T) {
+	valid := models.ImportedGrant{
+		RequestID:      "legacy-1",
+		AccountID:      "acct1",
+		RequesterEmail: "user@example.com",
+		ApproverEmail:  "boss@example.com",
+		GrantTime:      "2025-06-01T10:00:00Z",
+		EndTime:        "2025-06-01T12:00:00Z",
+		Status:         models.StatusGranted,
+	}
+	tests := []struct {
+		name   string
+		mutate func(*models.ImportedGrant)
+		want   string
+	}{
+		{"missing request id", func(g *models.ImportedGrant) { g.RequestID = "" }, "request_id is required"},
+		{"bad approver email", func(g *models.ImportedGrant) { g.ApproverEmail = "boss" }, "approver_email"},
+		{"pending status", func(g *models.ImportedGrant) { g.Status = models.StatusPending }, "cannot be imported"},
+		{"end before grant", func(g *models.ImportedGrant) { g.EndTime = g.GrantTime }, "end_time must be after"},
+		{"unbound account", func(g *models.ImportedGrant) { g.AccountID = "acct9" }, "no binding found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newImportHandler()
+			g := valid
+			tt.mutate(&g)
+			_, err := h.HandleImportGrants(context.Background(), importInput(g))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHandleImportGrants_RequiresAdmin(t *testing.T) {
+	h, _, _ := newImportHandler()
+	input := importInput()
+	input.ActorMMUserID = "user-1"
+
+	_, err := h.HandleImportGrants(context.Background(), input)
+	if err == nil || !strings.Contains(err.Error(), "is not an admin") {
+		t.Fatalf("expected admin error, got: %v", err)
+	}
+}
+
+func TestHandleImportGrants_RefusesNonImportedRequest(t *testing.T) {
+	h, db, _ := newImportHandler()
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusGranted}
+
+	_, err := h.HandleImportGrants(context.Background(), importInput(models.ImportedGrant{
+		RequestID:      "req-1",
+		AccountID:      "acct1",
+		RequesterEmail: "user@example.com",
+		ApproverEmail:  "boss@example.com",
+		GrantTime:      "2025-06-01T10:00:00Z",
+		EndTime:        "2025-06-01T12:00:00Z",
+		Status:         models.StatusRevoked,
+	}))
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected conflict with existing request, got: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"time"
 
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
@@ -11,7 +13,10 @@ type DBStore interface {
 	GetConfig(ctx context.Context, channelID, accountID string) (*models.JitConfig, error)
 	GetConfigsByChannel(ctx context.Context, channelID string) ([]models.JitConfig, error)
 	PutConfig(ctx context.Context, cfg *models.JitConfig) error
+	UpdateConfig(ctx context.Context, channelID, accountID string, updates map[string]interface{}) (*models.JitConfig, error)
 	GetChannelForAccount(ctx context.Context, accountID string) (*models.JitConfig, error)
+	GetAllBindingsForAccount(ctx context.Context, accountID string) ([]models.JitConfig, error)
+	ScanConfigs(ctx context.Context, limit int32, nextToken string) ([]models.JitConfig, string, error)
 
 	CreateRequest(ctx context.Context, req *models.JitRequest) error
 	GetRequest(ctx context.Context, requestID string) (*models.JitRequest, error)
@@ -19,6 +24,24 @@ type DBStore interface {
 	ConditionalUpdateStatus(ctx context.Context, requestID, expectedStatus string, updates map[string]interface{}) error
 
 	QueryRequests(ctx context.Context, input models.ReportingInput) ([]models.JitRequest, string, error)
+	QueryRequestsByStatusPage(ctx context.Context, status string, limit int32, nextToken string) ([]models.JitRequest, string, error)
+
+	QueryAuditByRequest(ctx context.Context, requestID string) ([]models.AuditEvent, error)
+	QueryAuditByTimeRange(ctx context.Context, start, end time.Time, limit int32, nextToken string) ([]models.AuditEvent, string, error)
+
+	GrantCounter
+	SetActiveGrants(ctx context.Context, channelID, accountID string, count int) error
+}
+
+// StatusUpdater is the conditional status write used by TransitionStatus.
+type StatusUpdater interface {
+	ConditionalUpdateStatus(ctx context.Context, requestID, expectedStatus string, updates map[string]interface{}) error
+}
+
+// GrantCounter abstracts the atomic per-binding active-grant counter.
+type GrantCounter interface {
+	IncrementActiveGrants(ctx context.Context, channelID, accountID string, limit int) error
+	DecrementActiveGrants(ctx context.Context, channelID, accountID string) error
 }
 
 // IdentityProvider abstracts IAM Identity Center operations.
@@ -26,6 +49,8 @@ type IdentityProvider interface {
 	LookupUserByEmail(ctx context.Context, email string) (string, error)
 	GrantAccess(ctx context.Context, accountID, userID string) error
 	RevokeAccess(ctx context.Context, accountID, userID string) error
+	GrantPermissionSet(ctx context.Context, accountID, userID, permissionSetARN string) error
+	RevokePermissionSet(ctx context.Context, accountID, userID, permissionSetARN string) error
 }
 
 // WebhookNotifier abstracts webhook delivery to the plugin.
@@ -33,12 +58,49 @@ type WebhookNotifier interface {
 	Notify(ctx context.Context, payload models.WebhookPayload) error
 }
 
+// WebhookRedeliverer re-sends a recorded webhook payload without recording
+// it again on failure.
+type WebhookRedeliverer interface {
+	Deliver(ctx context.Context, payload models.WebhookPayload) error
+}
+
+// WebhookFailureStore abstracts the log of undelivered webhook notifications.
+type WebhookFailureStore interface {
+	PutWebhookFailure(ctx context.Context, failure *models.WebhookFailure) error
+	GetWebhookFailure(ctx context.Context, failureID string) (*models.WebhookFailure, error)
+	ScanWebhookFailures(ctx context.Context, limit int32, nextToken string) ([]models.WebhookFailure, string, error)
+	DeleteWebhookFailure(ctx context.Context, failureID string) error
+}
+
 // AuditLogger abstracts audit event recording.
 type AuditLogger interface {
-	Log(ctx context.Context, requestID, eventType, accountID, channelID, actorMMUserID, actorEmail string, details map[string]string) error
+	Log(ctx context.Context, requestID, eventType, accountID, channelID string, actor models.Actor, details map[string]string) error
 }
 
-// SFNStarter abstracts Step Functions execution starting.
+// SFNStarter abstracts Step Functions execution starting and inspection.
 type SFNStarter interface {
-	StartExecution(ctx context.Context, input models.StepFunctionInput) error
+	// StartExecution starts the grant workflow and returns the execution ARN.
+	StartExecution(ctx context.Context, input models.StepFunctionInput) (string, error)
+	// GetExecution returns the simplified history of the execution started
+	// for requestID.
+	GetExecution(ctx context.Context, requestID string) ([]models.ExecutionEvent, error)
+}
+
+// ActionSigner signs Step Functions action payloads when a workflow starts and
+// verifies them when the state machine invokes an action.
+type ActionSigner interface {
+	SignAction(action, requestID string) (string, error)
+	VerifyAction(action, requestID, signature string) error
+}
+
+// ExportSigner signs the canonical bytes of a request compliance export.
+type ExportSigner interface {
+	SignExport(canonical []byte) (string, error)
+}
+
+// ApprovalTokenIssuer abstracts minting and redeeming single-use approval tokens.
+type ApprovalTokenIssuer interface {
+	MintApprovalToken(requestID, approverMMUserID, approverEmail string, ttl time.Duration) (string, time.Time, error)
+	VerifyApprovalToken(ctx context.Context, token, requestID string) (*auth.ApprovalTokenClaims, error)
+	ConsumeApprovalToken(ctx context.Context, token string, claims *auth.ApprovalTokenClaims) error
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// Limits on caller-supplied request metadata.
+const (
+	maxMetadataEntries     = 10
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 256
+)
+
+// metadataDetailPrefix namespaces metadata in webhook details so it can never
+// shadow a detail set by the controller.
+const metadataDetailPrefix = "metadata."
+
+// reservedMetadataKeys are the JitRequest field names; metadata may not reuse
+// them so reports never show two values under one name.
+var reservedMetadataKeys = jsonFieldNames(reflect.TypeOf(models.JitRequest{}))
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// validateMetadata enforces the metadata count and size limits and rejects
+// empty or reserved keys. An empty map is normalized to nil.
+func validateMetadata(metadata map[string]string) (map[string]string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	if len(metadata) > maxMetadataEntries {
+		return nil, fmt.Errorf("metadata may have at most %d entries, got %d", maxMetadataEntries, len(metadata))
+	}
+	for k, v := range metadata {
+		if strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("metadata keys must not be empty")
+		}
+		if len(k) > maxMetadataKeyLength {
+			return nil, fmt.Errorf("metadata key %q exceeds %d characters", k, maxMetadataKeyLength)
+		}
+		if len(v) > maxMetadataValueLength {
+			return nil, fmt.Errorf("metadata value for %q exceeds %d characters", k, maxMetadataValueLength)
+		}
+		if reservedMetadataKeys[strings.ToLower(k)] {
+			return nil, fmt.Errorf("metadata key %q is reserved", k)
+		}
+	}
+	return metadata, nil
+}
+
+// requestDetails adds the request's priority and metadata to webhook details.
+func requestDetails(details map[string]string, req *models.JitRequest) map[string]string {
+	details = withPriority(details, req)
+	if len(req.Metadata) == 0 {
+		return details
+	}
+	if details == nil {
+		details = map[string]string{}
+	}
+	for k, v := range req.Metadata {
+		details[metadataDetailPrefix+k] = v
+	}
+	return details
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func TestValidateMetadata_Limits(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= maxMetadataEntries; i++ {
+		tooMany[fmt.Sprintf("k%d", i)] = "v"
+	}
+
+	cases := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  string
+	}{
+		{"nil", nil, ""},
+		{"valid", map[string]string{"cost_center": "cc-42", "environment": "prod"}, ""},
+		{"too many entries", tooMany, "at most 10 entries"},
+		{"empty key", map[string]string{" ": "v"}, "must not be empty"},
+		{"long key", map[string]string{strings.Repeat("k", maxMetadataKeyLength+1): "v"}, "exceeds 64 characters"},
+		{"long value", map[string]string{"k": strings.Repeat("v", maxMetadataValueLength+1)}, "exceeds 256 characters"},
+		{"reserved key", map[string]string{"status": "GRANTED"}, `"status" is reserved`},
+		{"reserved key any case", map[string]string{"Requester_Email": "x"}, "is reserved"},
+	}
+	for _, tc := range cases {
+		_, err := validateMetadata(tc.metadata)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+func TestHandleCreateRequest_StoresMetadata(t *testing.T) {
+	h, db, _, _, _, _ := newTestHandler()
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+
+	req, err := h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 60,
+		Metadata:                 map[string]string{"cost_center": "cc-42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests[req.RequestID].Metadata["cost_center"] != "cc-42" {
+		t.Errorf("expected metadata to be stored, got %v", db.requests[req.RequestID].Metadata)
+	}
+
+	_, err = h.HandleCreateRequest(context.Background(), models.CreateRequestInput{
+		AccountID:                "acct1",
+		ChannelID:                "ch1",
+		RequesterMMUserID:        "mm-user-1",
+		RequesterEmail:           "user@example.com",
+		Reason:                   "need access",
+		RequestedDurationMinutes: 60,
+		Metadata:                 map[string]string{"end_time": "never"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Fatalf("expected reserved key to be rejected, got %v", err)
+	}
+}
+
+func TestRequestDetails_IncludesMetadata(t *testing.T) {
+	h, db, _, wh, _, _ := newTestHandler()
+	db.requests["req-1"] = &models.JitRequest{
+		RequestID:           "req-1",
+		AccountID:           "acct1",
+		ChannelID:           "ch1",
+		Status:              models.StatusGranted,
+		IdentityStoreUserID: "uid-123",
+		Metadata:            map[string]string{"cost_center": "cc-42", "priority": "low"},
+		Priority:            models.PriorityHigh,
+	}
+
+	if _, err := h.HandleRevokeRequest(context.Background(), models.RevokeRequestInput{
+		RequestID:     "req-1",
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	details := wh.payloads[0].Details
+	if details["metadata.cost_center"] != "cc-42" {
+		t.Errorf("expected metadata in webhook details, got %v", details)
+	}
+	if details["priority"] != models.PriorityHigh {
+		t.Errorf("expected metadata not to shadow priority, got %q", details["priority"])
+	}
+}
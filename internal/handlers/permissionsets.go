@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// validatePermissionSets checks requested permission sets against the binding's
+// allowlist and returns them de-duplicated in request order.
+func validatePermissionSets(requested, allowed []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, nil
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("permission set bundles are not enabled for this binding")
+	}
+
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, arn := range allowed {
+		allowedSet[arn] = true
+	}
+
+	seen := make(map[string]bool, len(requested))
+	var result []string
+	for _, arn := range requested {
+		if arn == "" {
+			return nil, fmt.Errorf("permission set ARN must not be empty")
+		}
+		if !allowedSet[arn] {
+			return nil, fmt.Errorf("permission set %s is not allowed for this binding", arn)
+		}
+		if seen[arn] {
+			continue
+		}
+		seen[arn] = true
+		result = append(result, arn)
+	}
+	return result, nil
+}
+
+// grantPermissionSets grants every permission set on the request. If any set
+// fails, the sets already granted are revoked so the user is never left holding
+// a partial bundle. The returned map records the per-set outcome and is nil
+// for requests that use the default permission set.
+func grantPermissionSets(ctx context.Context, idp IdentityProvider, req *models.JitRequest) (map[string]string, error) {
+	if len(req.PermissionSetARNs) == 0 {
+		return nil, idp.GrantAccess(ctx, req.AccountID, req.IdentityStoreUserID)
+	}
+
+	status := make(map[string]string, len(req.PermissionSetARNs))
+	var granted []string
+	for _, arn := range req.PermissionSetARNs {
+		err := idp.GrantPermissionSet(ctx, req.AccountID, req.IdentityStoreUserID, arn)
+		if err == nil {
+			status[arn] = models.PermissionSetGranted
+			granted = append(granted, arn)
+			continue
+		}
+
+		status[arn] = models.PermissionSetFailed
+		slog.Error("permission set grant failed, rolling back bundle",
+			"request_id", req.RequestID,
+			"permission_set_arn", arn,
+			"error", err,
+		)
+
+		// Roll back in reverse order.
+		for i := len(granted) - 1; i >= 0; i-- {
+			g := granted[i]
+			if rbErr := idp.RevokePermissionSet(ctx, req.AccountID, req.IdentityStoreUserID, g); rbErr != nil {
+				slog.Error("permission set rollback failed",
+					"request_id", req.RequestID,
+					"permission_set_arn", g,
+					"error", rbErr,
+				)
+				status[g] = models.PermissionSetRollbackFailed
+				continue
+			}
+			status[g] = models.PermissionSetRolledBack
+		}
+		return status, fmt.Errorf("grant permission set %s: %w", arn, err)
+	}
+	return status, nil
+}
+
+// RevokePermissionSets revokes every permission set on the request, continuing
+// past individual failures so that as much access as possible is removed. The
+// returned map records the per-set outcome and is nil for requests that use the
+// default permission set.
+func RevokePermissionSets(ctx context.Context, idp IdentityProvider, req *models.JitRequest) (map[string]string, error) {
+	if len(req.PermissionSetARNs) == 0 {
+		return nil, idp.RevokeAccess(ctx, req.AccountID, req.IdentityStoreUserID)
+	}
+
+	status := make(map[string]string, len(req.PermissionSetARNs))
+	var errs []error
+	for _, arn := range req.PermissionSetARNs {
+		if err := idp.RevokePermissionSet(ctx, req.AccountID, req.IdentityStoreUserID, arn); err != nil {
+			status[arn] = models.PermissionSetFailed
+			errs = append(errs, fmt.Errorf("revoke permission set %s: %w", arn, err))
+			continue
+		}
+		status[arn] = models.PermissionSetRevoked
+	}
+	return status, errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// normalizePriority validates a requested priority and defaults it to normal.
+func normalizePriority(priority string) (string, error) {
+	switch priority {
+	case "":
+		return models.PriorityNormal, nil
+	case models.PriorityLow, models.PriorityNormal, models.PriorityHigh:
+		return priority, nil
+	default:
+		return "", fmt.Errorf("priority must be one of %s, %s, or %s",
+			models.PriorityLow, models.PriorityNormal, models.PriorityHigh)
+	}
+}
+
+// priorityRank orders priorities high first. Requests created before priority
+// existed are ranked as normal.
+func priorityRank(priority string) int {
+	switch priority {
+	case models.PriorityHigh:
+		return 0
+	case models.PriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// sortByPriority orders requests high priority first, preserving the query
+// order within each priority.
+func sortByPriority(requests []models.JitRequest) {
+	sort.SliceStable(requests, func(i, j int) bool {
+		return priorityRank(requests[i].Priority) < priorityRank(requests[j].Priority)
+	})
+}
+
+// withPriority adds the request priority to webhook details so the plugin can
+// sort its cards.
+func withPriority(details map[string]string, req *models.JitRequest) map[string]string {
+	if req.Priority == "" {
+		return details
+	}
+	if details == nil {
+		details = map[string]string{}
+	}
+	details["priority"] = req.Priority
+	return details
+}
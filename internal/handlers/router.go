@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,14 +12,61 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 
+	"github.com/dgwhited/jit-aws-controller/internal/apierr"
 	"github.com/dgwhited/jit-aws-controller/internal/auth"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
+// Route is a method and canonical path pattern served by the Router.
+// Path segments written as {name} match any single non-empty segment.
+type Route struct {
+	Method  string
+	Pattern string
+}
+
+// DefaultRoutes is the canonical list of routes served by the Router. Requests
+// that match none of these are rejected before HMAC validation.
+var DefaultRoutes = []Route{
+	{Method: "POST", Pattern: "/requests"},
+	{Method: "POST", Pattern: "/requests/validate"},
+	{Method: "POST", Pattern: "/requests/approve-batch"},
+	{Method: "GET", Pattern: "/requests"},
+	{Method: "GET", Pattern: "/requests/mine"},
+	{Method: "GET", Pattern: "/requests/{id}"},
+	{Method: "GET", Pattern: "/requests/{id}/execution"},
+	{Method: "GET", Pattern: "/requests/{id}/approvers"},
+	{Method: "GET", Pattern: "/requests/{id}/export"},
+	{Method: "POST", Pattern: "/requests/{id}/approve"},
+	{Method: "POST", Pattern: "/requests/{id}/approval-token"},
+	{Method: "POST", Pattern: "/requests/{id}/approve-with-token"},
+	{Method: "POST", Pattern: "/requests/{id}/deny"},
+	{Method: "POST", Pattern: "/requests/{id}/revoke"},
+	{Method: "POST", Pattern: "/requests/{id}/comment"},
+	{Method: "POST", Pattern: "/config/bind"},
+	{Method: "PATCH", Pattern: "/config/bind"},
+	{Method: "POST", Pattern: "/config/approvers"},
+	{Method: "POST", Pattern: "/config/pause"},
+	{Method: "POST", Pattern: "/config/resume"},
+	{Method: "POST", Pattern: "/config/account/{id}/approvers"},
+	{Method: "GET", Pattern: "/config/accounts"},
+	{Method: "GET", Pattern: "/admin/config-report"},
+	{Method: "GET", Pattern: "/admin/active-grants"},
+	{Method: "POST", Pattern: "/admin/active-grants/recount"},
+	{Method: "GET", Pattern: "/admin/account/{id}/bindings"},
+	{Method: "POST", Pattern: "/admin/import"},
+	{Method: "GET", Pattern: "/admin/webhook-failures"},
+	{Method: "POST", Pattern: "/admin/webhook-failures/redrive"},
+	{Method: "GET", Pattern: "/admin/audit/export"},
+}
+
 // Router handles API Gateway V2 HTTP events and dispatches to the appropriate handler.
 type Router struct {
 	Handler   *Handler
 	Validator *auth.HMACValidator
+
+	// Routes is the canonical route list checked before HMAC validation.
+	// Defaults to DefaultRoutes.
+	Routes []Route
 }
 
 // NewRouter creates a new Lambda event router.
@@ -26,6 +74,7 @@ func NewRouter(handler *Handler, validator *auth.HMACValidator) *Router {
 	return &Router{
 		Handler:   handler,
 		Validator: validator,
+		Routes:    DefaultRoutes,
 	}
 }
 
@@ -39,6 +88,16 @@ func (r *Router) Route(ctx context.Context, event events.APIGatewayV2HTTPRequest
 		"path", path,
 	)
 
+	// Reject unknown routes cheaply so they cannot consume nonces or crypto work.
+	if !r.isKnownRoute(method, path) {
+		if allowed := r.allowedMethods(path); len(allowed) > 0 {
+			resp := errorResponse(http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", method))
+			resp.Headers["Allow"] = strings.Join(allowed, ", ")
+			return resp, nil
+		}
+		return errorResponse(http.StatusNotFound, "not found"), nil
+	}
+
 	// Validate HMAC signature.
 	headers := make(map[string]string)
 	for k, v := range event.Headers {
@@ -47,23 +106,54 @@ func (r *Router) Route(ctx context.Context, event events.APIGatewayV2HTTPRequest
 
 	body := []byte(event.Body)
 	if err := r.Validator.ValidateRequest(ctx, method, path, headers, body); err != nil {
+		if errors.Is(err, auth.ErrNoSigningKeys) {
+			slog.Error("HMAC validator has no signing keys; check the signing secret")
+			return errorResponse(http.StatusInternalServerError, "server misconfigured: "+err.Error()), nil
+		}
 		slog.Warn("HMAC validation failed",
 			"method", method,
 			"path", path,
 			"error", err,
 		)
+		var verr *auth.ValidationError
+		if errors.As(err, &verr) {
+			return apiErrorResponse(http.StatusUnauthorized, apierr.Error{
+				Code:    apierr.CodeInvalidSignature,
+				Message: "unauthorized: " + verr.Message,
+				Reason:  verr.Reason,
+			}), nil
+		}
 		return errorResponse(http.StatusUnauthorized, "unauthorized: "+err.Error()), nil
 	}
 
+	// Surface the signed auth level assertion to handlers.
+	if level, ok, _ := auth.ParseAuthLevel(headers); ok {
+		ctx = auth.ContextWithAuthLevel(ctx, level)
+	}
+
 	// Route to appropriate handler based on method + path.
 	switch {
 	case method == "POST" && path == "/requests":
 		return r.handleCreateRequest(ctx, body)
 
+	case method == "POST" && path == "/requests/validate":
+		return r.handleValidateRequest(ctx, body)
+
+	case method == "POST" && path == "/requests/approve-batch":
+		return r.handleApproveBatch(ctx, body)
+
 	case method == "POST" && matchPath(path, "/requests/", "/approve"):
 		requestID := extractPathParam(path, "/requests/", "/approve")
 		return r.handleApproveRequest(ctx, requestID, body)
 
+	case method == "POST" && matchPath(path, "/requests/", "/approval-token"):
+		requestID := extractPathParam(path, "/requests/", "/approval-token")
+		return r.handleCreateApprovalToken(ctx, requestID, body)
+
+	case method == "POST" && matchPath(path, "/requests/", "/approve-with-token"):
+		requestID := extractPathParam(path, "/requests/", "/approve-with-token")
+		return r.handleApproveWithToken(ctx, requestID, body)
+
 	case method == "POST" && matchPath(path, "/requests/", "/deny"):
 		requestID := extractPathParam(path, "/requests/", "/deny")
 		return r.handleDenyRequest(ctx, requestID, body)
@@ -72,9 +162,28 @@ func (r *Router) Route(ctx context.Context, event events.APIGatewayV2HTTPRequest
 		requestID := extractPathParam(path, "/requests/", "/revoke")
 		return r.handleRevokeRequest(ctx, requestID, body)
 
+	case method == "POST" && matchPath(path, "/requests/", "/comment"):
+		requestID := extractPathParam(path, "/requests/", "/comment")
+		return r.handleComment(ctx, requestID, body)
+
+	case method == "GET" && matchPath(path, "/requests/", "/execution"):
+		requestID := extractPathParam(path, "/requests/", "/execution")
+		return r.handleGetExecution(ctx, requestID)
+
+	case method == "GET" && matchPath(path, "/requests/", "/approvers"):
+		requestID := extractPathParam(path, "/requests/", "/approvers")
+		return r.handleGetRequestApprovers(ctx, requestID)
+
+	case method == "GET" && matchPath(path, "/requests/", "/export"):
+		requestID := extractPathParam(path, "/requests/", "/export")
+		return r.handleExportRequest(ctx, requestID, event.QueryStringParameters)
+
 	case method == "GET" && path == "/requests":
 		return r.handleListRequests(ctx, event.QueryStringParameters)
 
+	case method == "GET" && path == "/requests/mine":
+		return r.handleListMyRequests(ctx, event.QueryStringParameters)
+
 	case method == "GET" && strings.HasPrefix(path, "/requests/") && !strings.Contains(path[len("/requests/"):], "/"):
 		requestID := path[len("/requests/"):]
 		return r.handleGetRequest(ctx, requestID)
@@ -82,12 +191,50 @@ func (r *Router) Route(ctx context.Context, event events.APIGatewayV2HTTPRequest
 	case method == "POST" && path == "/config/bind":
 		return r.handleBindAccount(ctx, body)
 
+	case method == "PATCH" && path == "/config/bind":
+		return r.handleUpdateConfig(ctx, body)
+
 	case method == "POST" && path == "/config/approvers":
 		return r.handleSetApprovers(ctx, body)
 
+	case method == "POST" && path == "/config/pause":
+		return r.handleSetChannelPaused(ctx, body, true)
+
+	case method == "POST" && path == "/config/resume":
+		return r.handleSetChannelPaused(ctx, body, false)
+
+	case method == "POST" && matchPath(path, "/config/account/", "/approvers"):
+		accountID := extractPathParam(path, "/config/account/", "/approvers")
+		return r.handleSetAccountApprovers(ctx, accountID, body)
+
 	case method == "GET" && path == "/config/accounts":
 		return r.handleGetBoundAccounts(ctx, event.QueryStringParameters)
 
+	case method == "GET" && path == "/admin/config-report":
+		return r.handleConfigReport(ctx, event.QueryStringParameters)
+
+	case method == "GET" && path == "/admin/active-grants":
+		return r.handleActiveGrants(ctx, event.QueryStringParameters)
+
+	case method == "POST" && path == "/admin/active-grants/recount":
+		return r.handleRecountGrants(ctx, body)
+
+	case method == "GET" && matchPath(path, "/admin/account/", "/bindings"):
+		accountID := extractPathParam(path, "/admin/account/", "/bindings")
+		return r.handleAccountBindings(ctx, accountID, event.QueryStringParameters)
+
+	case method == "POST" && path == "/admin/import":
+		return r.handleImportGrants(ctx, body)
+
+	case method == "GET" && path == "/admin/webhook-failures":
+		return r.handleListWebhookFailures(ctx, event.QueryStringParameters)
+
+	case method == "GET" && path == "/admin/audit/export":
+		return r.handleAuditExport(ctx, event.QueryStringParameters)
+
+	case method == "POST" && path == "/admin/webhook-failures/redrive":
+		return r.handleRedriveWebhooks(ctx, body)
+
 	default:
 		return errorResponse(http.StatusNotFound, "not found"), nil
 	}
@@ -102,26 +249,108 @@ func (r *Router) handleCreateRequest(ctx context.Context, body []byte) (events.A
 	req, err := r.Handler.HandleCreateRequest(ctx, input)
 	if err != nil {
 		slog.Error("create request failed", "error", err)
-		return errorResponse(http.StatusBadRequest, err.Error()), nil
+		code := http.StatusBadRequest
+		if strings.Contains(err.Error(), "is paused") {
+			code = http.StatusConflict
+		}
+		return errorResponse(code, err.Error()), nil
 	}
 	return jsonResponse(http.StatusCreated, req), nil
 }
 
+func (r *Router) handleValidateRequest(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.CreateRequestInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	resp, err := r.Handler.HandleValidateRequest(ctx, input)
+	if err != nil {
+		slog.Error("validate request failed", "error", err)
+		return errorResponse(http.StatusInternalServerError, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
 func (r *Router) handleApproveRequest(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
 	var input models.ApproveRequestInput
 	if err := json.Unmarshal(body, &input); err != nil {
-		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
 	}
 	input.RequestID = requestID
 
-	req, err := r.Handler.HandleApproveRequest(ctx, input)
+	approve := r.Handler.HandleApproveRequest
+	if input.ApprovedDurationMinutes != 0 {
+		approve = r.Handler.HandleApproveWithModification
+	}
+	req, err := approve(ctx, input)
 	if err != nil {
 		slog.Error("approve request failed", "error", err)
 		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "strong authentication required"):
+			code = http.StatusForbidden
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, req), nil
+}
+
+func (r *Router) handleApproveBatch(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.ApproveBatchInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	resp, err := r.Handler.HandleApproveBatch(ctx, input)
+	if err != nil {
+		slog.Error("approve batch failed", "error", err)
+		return errorResponse(http.StatusBadRequest, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleCreateApprovalToken(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.ApprovalTokenInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
+	}
+	input.RequestID = requestID
+
+	resp, err := r.Handler.HandleCreateApprovalToken(ctx, input)
+	if err != nil {
+		slog.Error("create approval token failed", "error", err)
+		code := http.StatusBadRequest
 		if strings.Contains(err.Error(), "not found") {
 			code = http.StatusNotFound
 		}
-		return errorResponse(code, err.Error()), nil
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusCreated, resp), nil
+}
+
+func (r *Router) handleApproveWithToken(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.ApproveWithTokenInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
+	}
+	input.RequestID = requestID
+
+	req, err := r.Handler.HandleApproveWithToken(ctx, input)
+	if err != nil {
+		slog.Error("approve with token failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "invalid approval token"):
+			code = http.StatusUnauthorized
+		case strings.Contains(err.Error(), "not found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "strong authentication required"):
+			code = http.StatusForbidden
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
 	}
 	return jsonResponse(http.StatusOK, req), nil
 }
@@ -129,7 +358,7 @@ func (r *Router) handleApproveRequest(ctx context.Context, requestID string, bod
 func (r *Router) handleDenyRequest(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
 	var input models.DenyRequestInput
 	if err := json.Unmarshal(body, &input); err != nil {
-		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
 	}
 	input.RequestID = requestID
 
@@ -140,7 +369,7 @@ func (r *Router) handleDenyRequest(ctx context.Context, requestID string, body [
 		if strings.Contains(err.Error(), "not found") {
 			code = http.StatusNotFound
 		}
-		return errorResponse(code, err.Error()), nil
+		return requestErrorResponse(code, requestID, err.Error()), nil
 	}
 	return jsonResponse(http.StatusOK, req), nil
 }
@@ -148,7 +377,7 @@ func (r *Router) handleDenyRequest(ctx context.Context, requestID string, body [
 func (r *Router) handleRevokeRequest(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
 	var input models.RevokeRequestInput
 	if err := json.Unmarshal(body, &input); err != nil {
-		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
 	}
 	input.RequestID = requestID
 
@@ -156,23 +385,52 @@ func (r *Router) handleRevokeRequest(ctx context.Context, requestID string, body
 	if err != nil {
 		slog.Error("revoke request failed", "error", err)
 		code := http.StatusBadRequest
-		if strings.Contains(err.Error(), "not found") {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
 			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "self-revocation is not allowed"):
+			code = http.StatusForbidden
 		}
-		return errorResponse(code, err.Error()), nil
+		return requestErrorResponse(code, requestID, err.Error()), nil
 	}
 	return jsonResponse(http.StatusOK, req), nil
 }
 
+func (r *Router) handleComment(ctx context.Context, requestID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.CommentInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return requestErrorResponse(http.StatusBadRequest, requestID, "invalid request body: "+err.Error()), nil
+	}
+	input.RequestID = requestID
+
+	resp, err := r.Handler.HandleComment(ctx, input)
+	if err != nil {
+		slog.Error("comment failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "is not an authorized approver"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "closed to comments"):
+			code = http.StatusConflict
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusCreated, resp), nil
+}
+
 func (r *Router) handleListRequests(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
 	input := models.ReportingInput{
 		ChannelID:      queryParams["channel_id"],
 		AccountID:      queryParams["account_id"],
 		RequesterEmail: queryParams["requester_email"],
+		Jira:           queryParams["jira"],
 		Status:         queryParams["status"],
 		StartDate:      queryParams["start_date"],
 		EndDate:        queryParams["end_date"],
 		NextToken:      queryParams["next_token"],
+		Fields:         queryParams["fields"],
 	}
 	if limitStr, ok := queryParams["limit"]; ok {
 		if l, err := strconv.Atoi(limitStr); err == nil {
@@ -183,27 +441,312 @@ func (r *Router) handleListRequests(ctx context.Context, queryParams map[string]
 	resp, err := r.Handler.HandleListRequests(ctx, input)
 	if err != nil {
 		slog.Error("list requests failed", "error", err)
-		return errorResponse(http.StatusInternalServerError, err.Error()), nil
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "jira search is unavailable"):
+			code = http.StatusNotImplemented
+		case strings.Contains(err.Error(), "invalid status"), strings.Contains(err.Error(), "invalid field"),
+			strings.Contains(err.Error(), "invalid start_date"), strings.Contains(err.Error(), "invalid end_date"),
+			errors.Is(err, models.ErrInvalidNextToken):
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleListMyRequests(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	input := models.MyRequestsInput{
+		RequesterEmail: queryParams["requester_email"],
+		NextToken:      queryParams["next_token"],
+	}
+	if limitStr, ok := queryParams["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			input.Limit = l
+		}
+	}
+
+	resp, err := r.Handler.HandleListMyRequests(ctx, input)
+	if err != nil {
+		slog.Error("list my requests failed", "error", err)
+		code := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "requester_email") {
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleConfigReport(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	input := models.ConfigReportInput{
+		ActorMMUserID: queryParams["actor_mm_user_id"],
+		NextToken:     queryParams["next_token"],
+	}
+	if limitStr, ok := queryParams["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			input.Limit = l
+		}
+	}
+
+	resp, err := r.Handler.HandleConfigReport(ctx, input)
+	if err != nil {
+		slog.Error("config report failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case errors.Is(err, models.ErrInvalidNextToken):
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleActiveGrants(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	input := models.ActiveGrantsInput{
+		ActorMMUserID: queryParams["actor_mm_user_id"],
+		NextToken:     queryParams["next_token"],
+	}
+	if limitStr, ok := queryParams["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			input.Limit = l
+		}
+	}
+
+	resp, err := r.Handler.HandleActiveGrants(ctx, input)
+	if err != nil {
+		slog.Error("active grants failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case errors.Is(err, models.ErrInvalidNextToken):
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleRecountGrants(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.RecountGrantsInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	resp, err := r.Handler.HandleRecountGrants(ctx, input)
+	if err != nil {
+		slog.Error("recount grants failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "no binding found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "query active grants"), strings.Contains(err.Error(), "set active grants"),
+			strings.Contains(err.Error(), "get config"):
+			code = http.StatusInternalServerError
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleAccountBindings(ctx context.Context, accountID string, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	resp, err := r.Handler.HandleAccountBindings(ctx, models.AccountBindingsInput{
+		ActorMMUserID: queryParams["actor_mm_user_id"],
+		AccountID:     accountID,
+	})
+	if err != nil {
+		slog.Error("account bindings failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "is required"):
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleImportGrants(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.ImportGrantsInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	resp, err := r.Handler.HandleImportGrants(ctx, input)
+	if err != nil {
+		slog.Error("import grants failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "already exists"):
+			code = http.StatusConflict
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleListWebhookFailures(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	input := models.WebhookFailuresInput{
+		ActorMMUserID: queryParams["actor_mm_user_id"],
+		NextToken:     queryParams["next_token"],
+	}
+	if limitStr, ok := queryParams["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			input.Limit = l
+		}
+	}
+
+	resp, err := r.Handler.HandleListWebhookFailures(ctx, input)
+	if err != nil {
+		slog.Error("list webhook failures failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case errors.Is(err, models.ErrInvalidNextToken):
+			code = http.StatusBadRequest
+		case strings.Contains(err.Error(), "is not configured"):
+			code = http.StatusNotImplemented
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleAuditExport(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	input := models.AuditExportInput{
+		ActorMMUserID: queryParams["actor_mm_user_id"],
+		StartDate:     queryParams["start_date"],
+		EndDate:       queryParams["end_date"],
+		NextToken:     queryParams["next_token"],
+	}
+	if limitStr, ok := queryParams["limit"]; ok {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			input.Limit = l
+		}
+	}
+	format := queryParams["format"]
+	if format != "" && format != "json" && format != "ndjson" {
+		return errorResponse(http.StatusBadRequest, fmt.Sprintf("invalid format %q: must be json or ndjson", format)), nil
+	}
+
+	resp, err := r.Handler.HandleAuditExport(ctx, input)
+	if err != nil {
+		slog.Error("audit export failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "audit export is unavailable"):
+			code = http.StatusNotImplemented
+		case strings.Contains(err.Error(), "start_date"), strings.Contains(err.Error(), "end_date"),
+			strings.Contains(err.Error(), "date range"), errors.Is(err, models.ErrInvalidNextToken):
+			code = http.StatusBadRequest
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	if format == "ndjson" {
+		return ndjsonResponse(resp), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleRedriveWebhooks(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.RedriveWebhooksInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	resp, err := r.Handler.HandleRedriveWebhooks(ctx, input)
+	if err != nil {
+		slog.Error("redrive webhooks failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "is not configured"):
+			code = http.StatusNotImplemented
+		}
+		return errorResponse(code, err.Error()), nil
 	}
 	return jsonResponse(http.StatusOK, resp), nil
 }
 
 func (r *Router) handleGetRequest(ctx context.Context, requestID string) (events.APIGatewayV2HTTPResponse, error) {
 	if requestID == "" {
-		return errorResponse(http.StatusBadRequest, "request_id is required"), nil
+		return requestErrorResponse(http.StatusBadRequest, requestID, "request_id is required"), nil
 	}
 
 	req, err := r.Handler.DB.GetRequest(ctx, requestID)
 	if err != nil {
 		slog.Error("get request failed", "error", err)
-		return errorResponse(http.StatusInternalServerError, err.Error()), nil
+		return requestErrorResponse(http.StatusInternalServerError, requestID, err.Error()), nil
 	}
 	if req == nil {
-		return errorResponse(http.StatusNotFound, fmt.Sprintf("request %s not found", requestID)), nil
+		return requestErrorResponse(http.StatusNotFound, requestID, fmt.Sprintf("request %s not found", requestID)), nil
 	}
 	return jsonResponse(http.StatusOK, req), nil
 }
 
+func (r *Router) handleGetExecution(ctx context.Context, requestID string) (events.APIGatewayV2HTTPResponse, error) {
+	timeline, err := r.Handler.HandleGetExecution(ctx, requestID)
+	if err != nil {
+		slog.Error("get execution failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "is required"):
+			code = http.StatusBadRequest
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, timeline), nil
+}
+
+func (r *Router) handleGetRequestApprovers(ctx context.Context, requestID string) (events.APIGatewayV2HTTPResponse, error) {
+	resp, err := r.Handler.HandleGetRequestApprovers(ctx, requestID)
+	if err != nil {
+		slog.Error("get request approvers failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "not found"), strings.Contains(err.Error(), "no binding found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "is required"):
+			code = http.StatusBadRequest
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
+func (r *Router) handleExportRequest(ctx context.Context, requestID string, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
+	resp, err := r.Handler.HandleExportRequest(ctx, requestID, queryParams["actor_mm_user_id"])
+	if err != nil {
+		slog.Error("export request failed", "error", err)
+		code := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "is not an admin"):
+			code = http.StatusForbidden
+		case strings.Contains(err.Error(), "not found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "is not configured"):
+			code = http.StatusNotImplemented
+		}
+		return requestErrorResponse(code, requestID, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, resp), nil
+}
+
 func (r *Router) handleBindAccount(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
 	var input models.BindAccountInput
 	if err := json.Unmarshal(body, &input); err != nil {
@@ -218,6 +761,27 @@ func (r *Router) handleBindAccount(ctx context.Context, body []byte) (events.API
 	return jsonResponse(http.StatusOK, cfg), nil
 }
 
+func (r *Router) handleUpdateConfig(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.UpdateConfigInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	cfg, err := r.Handler.HandleUpdateConfig(ctx, input)
+	if err != nil {
+		slog.Error("update config failed", "error", err)
+		code := http.StatusBadRequest
+		switch {
+		case strings.Contains(err.Error(), "no binding found"):
+			code = http.StatusNotFound
+		case strings.Contains(err.Error(), "strong authentication required"):
+			code = http.StatusForbidden
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, cfg), nil
+}
+
 func (r *Router) handleSetApprovers(ctx context.Context, body []byte) (events.APIGatewayV2HTTPResponse, error) {
 	var input models.SetApproversInput
 	if err := json.Unmarshal(body, &input); err != nil {
@@ -232,6 +796,43 @@ func (r *Router) handleSetApprovers(ctx context.Context, body []byte) (events.AP
 	return jsonResponse(http.StatusOK, configs), nil
 }
 
+func (r *Router) handleSetChannelPaused(ctx context.Context, body []byte, paused bool) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.PauseChannelInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+
+	handle := r.Handler.HandleResumeChannel
+	if paused {
+		handle = r.Handler.HandlePauseChannel
+	}
+	configs, err := handle(ctx, input)
+	if err != nil {
+		slog.Error("set channel pause state failed", "paused", paused, "error", err)
+		return errorResponse(http.StatusBadRequest, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, configs), nil
+}
+
+func (r *Router) handleSetAccountApprovers(ctx context.Context, accountID string, body []byte) (events.APIGatewayV2HTTPResponse, error) {
+	var input models.SetAccountApproversInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return errorResponse(http.StatusBadRequest, "invalid request body: "+err.Error()), nil
+	}
+	input.AccountID = accountID
+
+	cfg, err := r.Handler.HandleSetAccountApprovers(ctx, input)
+	if err != nil {
+		slog.Error("set account approvers failed", "error", err)
+		code := http.StatusBadRequest
+		if strings.Contains(err.Error(), "no binding found") {
+			code = http.StatusNotFound
+		}
+		return errorResponse(code, err.Error()), nil
+	}
+	return jsonResponse(http.StatusOK, cfg), nil
+}
+
 func (r *Router) handleGetBoundAccounts(ctx context.Context, queryParams map[string]string) (events.APIGatewayV2HTTPResponse, error) {
 	channelID := queryParams["channel_id"]
 	configs, err := r.Handler.HandleGetBoundAccounts(ctx, channelID)
@@ -255,6 +856,60 @@ func matchPath(path, prefix, suffix string) bool {
 	return len(middle) > 0
 }
 
+// isKnownRoute reports whether method and path match one of the router's canonical routes.
+func (r *Router) isKnownRoute(method, path string) bool {
+	routes := r.Routes
+	if routes == nil {
+		routes = DefaultRoutes
+	}
+	for _, route := range routes {
+		if route.Method == method && matchPattern(route.Pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedMethods returns the methods served for path, in route order. It is
+// empty when path matches no canonical route.
+func (r *Router) allowedMethods(path string) []string {
+	routes := r.Routes
+	if routes == nil {
+		routes = DefaultRoutes
+	}
+	var methods []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		if !seen[route.Method] && matchPattern(route.Pattern, path) {
+			seen[route.Method] = true
+			methods = append(methods, route.Method)
+		}
+	}
+	return methods
+}
+
+// matchPattern matches path against a pattern such as /requests/{id}/approve,
+// where {name} segments match any single non-empty path segment.
+func matchPattern(pattern, path string) bool {
+	patternSegs := strings.Split(pattern, "/")
+	pathSegs := strings.Split(path, "/")
+	if len(patternSegs) != len(pathSegs) {
+		return false
+	}
+	for i, seg := range patternSegs {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if pathSegs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != pathSegs[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // extractPathParam extracts the ID from /prefix{id}/suffix.
 func extractPathParam(path, prefix, suffix string) string {
 	return path[len(prefix) : len(path)-len(suffix)]
@@ -275,14 +930,59 @@ func jsonResponse(statusCode int, body interface{}) events.APIGatewayV2HTTPRespo
 	}
 }
 
-// errorResponse creates an API Gateway error response.
+// ndjsonResponse creates an API Gateway response with one audit event per
+// line. The next page's token, if any, is in the X-Next-Token header.
+func ndjsonResponse(resp *models.AuditExportResponse) events.APIGatewayV2HTTPResponse {
+	var b strings.Builder
+	for _, e := range resp.Items {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return errorResponse(http.StatusInternalServerError, "failed to marshal response")
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-ndjson",
+	}
+	if resp.NextToken != "" {
+		headers["X-Next-Token"] = resp.NextToken
+	}
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       b.String(),
+	}
+}
+
+// errorResponse creates an API Gateway error response, classifying the message
+// into a stable apierr code.
 func errorResponse(statusCode int, message string) events.APIGatewayV2HTTPResponse {
-	body := fmt.Sprintf(`{"message":%q}`, message)
+	return apiErrorResponse(statusCode, apierr.Error{
+		Code:    apierr.Classify(statusCode, message),
+		Message: message,
+	})
+}
+
+// requestErrorResponse creates an error response for a request-scoped route,
+// tagged with the JIT request ID.
+func requestErrorResponse(statusCode int, requestID, message string) events.APIGatewayV2HTTPResponse {
+	return apiErrorResponse(statusCode, apierr.Error{
+		Code:      apierr.Classify(statusCode, message),
+		Message:   message,
+		RequestID: requestID,
+	})
+}
+
+// apiErrorResponse creates an API Gateway response with the standard error body.
+func apiErrorResponse(statusCode int, apiErr apierr.Error) events.APIGatewayV2HTTPResponse {
+	// A struct of strings always marshals.
+	b, _ := json.Marshal(apierr.Body{Error: apiErr})
 	return events.APIGatewayV2HTTPResponse{
 		StatusCode: statusCode,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Body: body,
+		Body: string(b),
 	}
 }
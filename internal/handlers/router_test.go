@@ -0,0 +1,682 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/dgwhited/jit-aws-controller/internal/apierr"
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/identity"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+const (
+	testRouterKeyID  = "key-1"
+	testRouterSecret = "test-secret-key-very-long-and-secure-1234567890"
+)
+
+func newTestRouter() (*Router, *mockNonceStore) {
+	h, _, _, _, _, _ := newTestHandler()
+	store := newMockNonceStore()
+	validator := auth.NewHMACValidator(map[string]string{testRouterKeyID: testRouterSecret}, store)
+	return NewRouter(h, validator), store
+}
+
+func signedEvent(t *testing.T, method, path, body string) events.APIGatewayV2HTTPRequest {
+	t.Helper()
+	headers, err := auth.SignPayload(testRouterKeyID, testRouterSecret, method, path, []byte(body))
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+	event := events.APIGatewayV2HTTPRequest{
+		Headers: headers,
+		Body:    body,
+	}
+	event.RequestContext.HTTP.Method = method
+	event.RequestContext.HTTP.Path = path
+	return event
+}
+
+func TestRoute_EmptySigningKeysIsServerError(t *testing.T) {
+	h, _, _, _, _, _ := newTestHandler()
+	router := NewRouter(h, auth.NewHMACValidator(map[string]string{}, newMockNonceStore()))
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "GET", "/requests/req-1", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Errorf("expected 500 for missing signing keys, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(resp.Body, "server misconfigured") {
+		t.Errorf("expected misconfiguration message, got %s", resp.Body)
+	}
+}
+
+func TestRoute_UnknownPathSkipsHMAC(t *testing.T) {
+	router, store := newTestRouter()
+
+	for _, tc := range []struct{ method, path string }{
+		{"POST", "/random"},
+		{"POST", "/requests//approve"},
+		{"POST", "/requests/req-1/approve/extra"},
+	} {
+		resp, err := router.Route(context.Background(), signedEvent(t, tc.method, tc.path, "{}"))
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != 404 {
+			t.Errorf("%s %s: expected 404, got %d", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+	if store.calls != 0 {
+		t.Errorf("expected nonce store not to be called for unknown routes, got %d calls", store.calls)
+	}
+}
+
+func TestRoute_WrongMethodOnKnownPath(t *testing.T) {
+	router, store := newTestRouter()
+
+	for _, tc := range []struct{ method, path, allow string }{
+		{"DELETE", "/requests", "POST, GET"},
+		{"PUT", "/config/bind", "POST, PATCH"},
+		{"GET", "/requests/req-1/approve", "POST"},
+	} {
+		resp, err := router.Route(context.Background(), signedEvent(t, tc.method, tc.path, ""))
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != 405 {
+			t.Errorf("%s %s: expected 405, got %d", tc.method, tc.path, resp.StatusCode)
+		}
+		if got := resp.Headers["Allow"]; got != tc.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tc.method, tc.path, tc.allow, got)
+		}
+		if code := decodeAPIError(t, resp).Code; code != apierr.CodeMethodNotAllowed {
+			t.Errorf("%s %s: expected code %s, got %s", tc.method, tc.path, apierr.CodeMethodNotAllowed, code)
+		}
+	}
+
+	// A genuinely unknown path stays 404 without an Allow header.
+	resp, err := router.Route(context.Background(), signedEvent(t, "DELETE", "/nope", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 for unknown path, got %d", resp.StatusCode)
+	}
+	if _, ok := resp.Headers["Allow"]; ok {
+		t.Error("expected no Allow header for unknown path")
+	}
+	if store.calls != 0 {
+		t.Errorf("expected method checks to skip HMAC, got %d nonce calls", store.calls)
+	}
+}
+
+func TestRoute_KnownPathRequiresSignature(t *testing.T) {
+	router, store := newTestRouter()
+
+	event := events.APIGatewayV2HTTPRequest{Body: "{}"}
+	event.RequestContext.HTTP.Method = "POST"
+	event.RequestContext.HTTP.Path = "/requests/req-1/approve"
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Errorf("expected 401 for unsigned request to known route, got %d", resp.StatusCode)
+	}
+
+	// A correctly signed request to a known route reaches the nonce store.
+	resp, err = router.Route(context.Background(), signedEvent(t, "GET", "/requests/req-1", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode == 401 {
+		t.Errorf("expected signed request to pass HMAC validation, got 401: %s", resp.Body)
+	}
+	if store.calls == 0 {
+		t.Error("expected nonce store to be consulted for a known route")
+	}
+}
+
+func TestRoute_SignatureMismatchReason(t *testing.T) {
+	router, _ := newTestRouter()
+
+	event := signedEvent(t, "POST", "/requests", `{"reason":"top-secret-incident"}`)
+	event.Body = `{"reason": "top-secret-incident"}`
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(resp.Body, `"reason":"body_or_sig_mismatch"`) {
+		t.Errorf("expected mismatch reason in response, got %s", resp.Body)
+	}
+	if strings.Contains(resp.Body, "top-secret-incident") {
+		t.Errorf("response leaked body contents: %s", resp.Body)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/requests", "/requests", true},
+		{"/requests/{id}", "/requests/abc", true},
+		{"/requests/{id}", "/requests/", false},
+		{"/requests/{id}/deny", "/requests/abc/deny", true},
+		{"/requests/{id}/deny", "/requests/abc/revoke", false},
+		{"/config/bind", "/config/bind/x", false},
+	}
+	for _, tc := range cases {
+		if got := matchPattern(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestRoute_AuthLevelReachesHandler(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	seedStrongAuthApproval(db)
+
+	body := `{"approver_mm_user_id":"approver-1","approver_email":"approver@example.com"}`
+	path := "/requests/req-1/approve"
+
+	headers, err := auth.SignPayloadWithAuthLevel(testRouterKeyID, testRouterSecret, "POST", path, []byte(body), 2)
+	if err != nil {
+		t.Fatalf("SignPayloadWithAuthLevel failed: %v", err)
+	}
+	event := events.APIGatewayV2HTTPRequest{Headers: headers, Body: body}
+	event.RequestContext.HTTP.Method = "POST"
+	event.RequestContext.HTTP.Path = path
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_StrongAuthMissingIsForbidden(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	seedStrongAuthApproval(db)
+
+	body := `{"approver_mm_user_id":"approver-1","approver_email":"approver@example.com"}`
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/requests/req-1/approve", body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 403 {
+		t.Errorf("expected 403, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func decodeAPIError(t *testing.T, resp events.APIGatewayV2HTTPResponse) apierr.Error {
+	t.Helper()
+	var body apierr.Body
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		t.Fatalf("invalid error body %q: %v", resp.Body, err)
+	}
+	return body.Error
+}
+
+func TestRoute_ErrorCodes(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	seedPendingApproval(db)
+	db.configs["ch1|acct1"].ApproverMMUserIDs = []string{"mm-user-1"}
+	noSelfRevoke := false
+	db.configs["ch1|acct1"].AllowSelfRevoke = &noSelfRevoke
+	db.requests["req-2"] = &models.JitRequest{RequestID: "req-2", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "mm-user-1", Status: models.StatusGranted}
+
+	unsigned := events.APIGatewayV2HTTPRequest{Body: "{}"}
+	unsigned.RequestContext.HTTP.Method = "POST"
+	unsigned.RequestContext.HTTP.Path = "/requests"
+
+	cases := []struct {
+		name          string
+		event         events.APIGatewayV2HTTPRequest
+		wantStatus    int
+		wantCode      apierr.Code
+		wantRequestID string
+	}{
+		{
+			name:       "unknown route",
+			event:      signedEvent(t, "GET", "/nope", ""),
+			wantStatus: 404,
+			wantCode:   apierr.CodeNotFound,
+		},
+		{
+			name:          "request not found",
+			event:         signedEvent(t, "GET", "/requests/missing", ""),
+			wantStatus:    404,
+			wantCode:      apierr.CodeRequestNotFound,
+			wantRequestID: "missing",
+		},
+		{
+			name:       "validation",
+			event:      signedEvent(t, "POST", "/requests", "{}"),
+			wantStatus: 400,
+			wantCode:   apierr.CodeValidationFailed,
+		},
+		{
+			name:       "invalid body",
+			event:      signedEvent(t, "POST", "/requests", "{"),
+			wantStatus: 400,
+			wantCode:   apierr.CodeInvalidBody,
+		},
+		{
+			name:       "unauthorized",
+			event:      unsigned,
+			wantStatus: 401,
+			wantCode:   apierr.CodeUnauthorized,
+		},
+		{
+			name: "self approval",
+			event: signedEvent(t, "POST", "/requests/req-1/approve",
+				`{"approver_mm_user_id":"mm-user-1","approver_email":"user@example.com"}`),
+			wantStatus:    400,
+			wantCode:      apierr.CodeSelfApprovalDenied,
+			wantRequestID: "req-1",
+		},
+		{
+			name: "self revocation",
+			event: signedEvent(t, "POST", "/requests/req-2/revoke",
+				`{"actor_mm_user_id":"mm-user-1","actor_email":"user@example.com"}`),
+			wantStatus:    403,
+			wantCode:      apierr.CodeSelfRevokeDenied,
+			wantRequestID: "req-2",
+		},
+		{
+			name: "comment by outsider",
+			event: signedEvent(t, "POST", "/requests/req-1/comment",
+				`{"actor_mm_user_id":"outsider","actor_email":"outsider@example.com","comment":"hi"}`),
+			wantStatus:    403,
+			wantCode:      apierr.CodeNotApprover,
+			wantRequestID: "req-1",
+		},
+		{
+			name:          "export by non-admin",
+			event:         signedEvent(t, "GET", "/requests/req-1/export", ""),
+			wantStatus:    403,
+			wantCode:      apierr.CodeNotAdmin,
+			wantRequestID: "req-1",
+		},
+	}
+	for _, tc := range cases {
+		resp, err := router.Route(context.Background(), tc.event)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.wantStatus, resp.StatusCode)
+		}
+		got := decodeAPIError(t, resp)
+		if got.Code != tc.wantCode {
+			t.Errorf("%s: expected code %s, got %s", tc.name, tc.wantCode, got.Code)
+		}
+		if got.Message == "" {
+			t.Errorf("%s: expected a message", tc.name)
+		}
+		if got.RequestID != tc.wantRequestID {
+			t.Errorf("%s: expected request_id %q, got %q", tc.name, tc.wantRequestID, got.RequestID)
+		}
+	}
+}
+
+func TestRoute_RequestsMineNotTreatedAsID(t *testing.T) {
+	router, _ := newTestRouter()
+
+	event := signedEvent(t, "GET", "/requests/mine", "")
+	event.QueryStringParameters = map[string]string{"requester_email": "user@example.com"}
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("expected 200 from /requests/mine, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ListRequestsInvalidStatus(t *testing.T) {
+	router, _ := newTestRouter()
+
+	event := signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1", "status": "PENDING,ACTIVE"}
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unknown status, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ListRequestsInvalidDate(t *testing.T) {
+	router, _ := newTestRouter()
+
+	event := signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1", "start_date": "yesterday"}
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unparseable start_date, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ListRequestsFields(t *testing.T) {
+	router, _ := newTestRouter()
+	router.Handler.DB.(*mockDB).queryReqResult = []models.JitRequest{{RequestID: "req-1", Status: models.StatusPending, Reason: "deploy"}}
+
+	event := signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1", "fields": "status"}
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"items":[{"request_id":"req-1","status":"PENDING"}]`) {
+		t.Errorf("expected projected items, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	event = signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1", "fields": "status,execution_arn"}
+	resp, err = router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unlistable field, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ListRequestsInvalidNextToken(t *testing.T) {
+	router, _ := newTestRouter()
+	// What the DynamoDB client returns for a token it cannot decode.
+	router.Handler.DB.(*mockDB).queryReqErr = fmt.Errorf("QueryRequests: %w: invalid token part: garbage", models.ErrInvalidNextToken)
+
+	event := signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1", "next_token": "garbage"}
+
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400 for a malformed next_token, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	if got := decodeAPIError(t, resp); !strings.Contains(got.Message, "invalid next_token") {
+		t.Errorf("expected an invalid next_token message, got %q", got.Message)
+	}
+
+	// Other query failures are still server errors.
+	router.Handler.DB.(*mockDB).queryReqErr = errors.New("dynamodb unavailable")
+	event = signedEvent(t, "GET", "/requests", "")
+	event.QueryStringParameters = map[string]string{"channel_id": "ch1"}
+	resp, err = router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Errorf("expected 500 for a storage failure, got %d", resp.StatusCode)
+	}
+}
+
+func TestRoute_ValidateRequest(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/requests/validate",
+		`{"account_id":"acct1","channel_id":"ch1","requester_mm_user_id":"mm-user-1","requester_email":"user@example.com","reason":"x","requested_duration_minutes":60}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 for an invalid request, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	var got models.ValidateRequestResponse
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got.Valid || len(got.Errors) != 1 || !strings.Contains(got.Errors[0], "no binding found") {
+		t.Errorf("expected a single binding error, got %+v", got)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected nothing stored, got %d requests", len(db.requests))
+	}
+}
+
+func TestRoute_AmbiguousIdentity(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", MaxRequestHours: 4}
+	router.Handler.Identity.(*mockIdentity).lookupErr = fmt.Errorf("%w: 2 Identity Store users match email user@example.com (user IDs: u1, u2)",
+		identity.ErrAmbiguousUser)
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/requests",
+		`{"account_id":"acct1","channel_id":"ch1","requester_mm_user_id":"mm-user-1","requester_email":"user@example.com","reason":"x","requested_duration_minutes":60}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	got := decodeAPIError(t, resp)
+	if got.Code != apierr.CodeAmbiguousIdentity || !strings.Contains(got.Message, "u1, u2") {
+		t.Errorf("expected AMBIGUOUS_IDENTITY listing the candidates, got %+v", got)
+	}
+	if len(db.requests) != 0 {
+		t.Errorf("expected no request created, got %d", len(db.requests))
+	}
+}
+
+func TestRoute_SetAccountApprovers(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	seedPendingApproval(db)
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/config/account/acct1/approvers",
+		`{"channel_id":"ch1","approver_ids":["prod-lead"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	if got := db.configs["ch1|acct1"].AccountApproverMMUserIDs; len(got) != 1 || got[0] != "prod-lead" {
+		t.Errorf("expected account approvers to be stored, got %v", got)
+	}
+
+	resp, err = router.Route(context.Background(), signedEvent(t, "POST", "/config/account/nope/approvers",
+		`{"channel_id":"ch1","approver_ids":["prod-lead"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("expected 404 for unbound account, got %d", resp.StatusCode)
+	}
+}
+
+func TestRoute_GetExecution(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusPending}
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "GET", "/requests/req-1/execution", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Fatalf("expected 404 for a request with no execution, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	if got := decodeAPIError(t, resp); got.RequestID != "req-1" {
+		t.Errorf("expected request_id in error, got %+v", got)
+	}
+
+	router.Handler.SFN.(*mockSFN).history = map[string][]models.ExecutionEvent{
+		"req-1": {{Step: "Execution", Status: "STARTED", Timestamp: "2025-01-01T12:00:00Z"}},
+	}
+	resp, err = router.Route(context.Background(), signedEvent(t, "GET", "/requests/req-1/execution", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"status":"STARTED"`) {
+		t.Errorf("expected timeline, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_GetRequestApprovers(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "GET", "/requests/missing/approvers", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Fatalf("expected 404 for an unknown request, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"lead-1", "user-1"}}
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1"}
+	resp, err = router.Route(context.Background(), signedEvent(t, "GET", "/requests/req-1/approvers", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"approver_mm_user_ids":["lead-1"]`) {
+		t.Errorf("expected approvers without the requester, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ApproveReturnsApprovals(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"approver-1"}}
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1", Status: models.StatusPending}
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/requests/req-1/approve", `{"approver_mm_user_id":"approver-1","approver_email":"a@example.com","comment":"lgtm"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got models.JitRequest
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatalf("response is not a request: %v", err)
+	}
+	if resp.StatusCode != 200 || len(got.Approvals) != 1 || got.Approvals[0].ApproverMMUserID != "approver-1" || got.Approvals[0].Comment != "lgtm" {
+		t.Errorf("expected the approval record in the response, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_ApproveBatch(t *testing.T) {
+	router, _ := newTestRouter()
+	db := router.Handler.DB.(*mockDB)
+	db.configs["ch1|acct1"] = &models.JitConfig{ChannelID: "ch1", AccountID: "acct1", ApproverMMUserIDs: []string{"approver-1"}}
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", ChannelID: "ch1", AccountID: "acct1", RequesterMMUserID: "user-1", Status: models.StatusPending}
+
+	resp, err := router.Route(context.Background(), signedEvent(t, "POST", "/requests/approve-batch", `{"approver_mm_user_id":"approver-1","approver_email":"a@example.com"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400 without request_ids, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	resp, err = router.Route(context.Background(), signedEvent(t, "POST", "/requests/approve-batch", `{"request_ids":["req-1","req-2"],"approver_mm_user_id":"approver-1","approver_email":"a@example.com"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"approved":1`) || !strings.Contains(resp.Body, `"failed":1`) {
+		t.Errorf("expected per-request results with one approval, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_WebhookFailures(t *testing.T) {
+	router, _ := newTestRouter()
+	router.Handler.AdminMMUserIDs = []string{"admin-1"}
+
+	event := signedEvent(t, "GET", "/admin/webhook-failures", "")
+	event.QueryStringParameters = map[string]string{"actor_mm_user_id": "admin-1"}
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 501 {
+		t.Fatalf("expected 501 without a failure log, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	router.Handler.WebhookFailures = &mockFailureStore{failures: map[string]*models.WebhookFailure{
+		"fail-1": {FailureID: "fail-1", RequestID: "req-1"},
+	}}
+	event = signedEvent(t, "GET", "/admin/webhook-failures", "")
+	event.QueryStringParameters = map[string]string{"actor_mm_user_id": "admin-1"}
+	resp, err = router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"failure_id":"fail-1"`) {
+		t.Errorf("expected recorded failure listed, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestRoute_AuditExport(t *testing.T) {
+	router, _ := newTestRouter()
+	router.Handler.AdminMMUserIDs = []string{"admin-1"}
+	db := router.Handler.DB.(*mockDB)
+	db.auditRange = []models.AuditEvent{
+		{RequestID: "req-1", EventType: models.EventRequested},
+		{RequestID: "req-2", EventType: models.EventGranted},
+	}
+	db.auditRangeToken = "event_date=2025-06-02"
+
+	event := signedEvent(t, "GET", "/admin/audit/export", "")
+	event.QueryStringParameters = map[string]string{
+		"actor_mm_user_id": "admin-1",
+		"start_date":       "2025-06-01",
+		"end_date":         "2025-06-02",
+		"format":           "ndjson",
+	}
+	resp, err := router.Route(context.Background(), event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != 200 || resp.Headers["Content-Type"] != "application/x-ndjson" || resp.Headers["X-Next-Token"] != "event_date=2025-06-02" {
+		t.Fatalf("expected an NDJSON page with the next token, got %d %v", resp.StatusCode, resp.Headers)
+	}
+	lines := strings.Split(strings.TrimSuffix(resp.Body, "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"request_id":"req-1"`) || !strings.Contains(lines[1], `"request_id":"req-2"`) {
+		t.Errorf("expected one event per line, got %q", resp.Body)
+	}
+
+	event = signedEvent(t, "GET", "/admin/audit/export", "")
+	event.QueryStringParameters = map[string]string{"actor_mm_user_id": "admin-1", "start_date": "2025-06-01", "end_date": "2025-06-02", "format": "csv"}
+	if resp, _ := router.Route(context.Background(), event); resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unknown format, got %d", resp.StatusCode)
+	}
+
+	db.auditRangeErr = fmt.Errorf("QueryAuditByTimeRange: audit export is unavailable: the audit table has no gsi_date_event index")
+	event = signedEvent(t, "GET", "/admin/audit/export", "")
+	event.QueryStringParameters = map[string]string{"actor_mm_user_id": "admin-1", "start_date": "2025-06-01", "end_date": "2025-06-02"}
+	if resp, _ := router.Route(context.Background(), event); resp.StatusCode != 501 {
+		t.Errorf("expected 501 without the date index, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
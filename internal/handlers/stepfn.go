@@ -3,28 +3,148 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
 
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
+// SFNAPI is the subset of the Step Functions client used to start and
+// inspect workflows.
+type SFNAPI interface {
+	StartExecution(ctx context.Context, params *sfn.StartExecutionInput, optFns ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error)
+	GetExecutionHistory(ctx context.Context, params *sfn.GetExecutionHistoryInput, optFns ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error)
+}
+
 // SFNClient implements SFNStarter using the real AWS Step Functions client.
 type SFNClient struct {
-	Client          *sfn.Client
+	Client          SFNAPI
 	StateMachineARN string
+
+	// Signer, when set, signs every workflow action for the request so the
+	// state machine can pass the signatures back with each action.
+	Signer ActionSigner
 }
 
-// StartExecution starts a Step Functions execution for the grant-wait-revoke workflow.
-func (s *SFNClient) StartExecution(ctx context.Context, input models.StepFunctionInput) error {
+// StartExecution starts a Step Functions execution for the grant-wait-revoke
+// workflow and returns its ARN.
+func (s *SFNClient) StartExecution(ctx context.Context, input models.StepFunctionInput) (string, error) {
+	if s.Signer != nil {
+		input.ActionSignatures = make(map[string]string, len(workflowActions))
+		for _, action := range workflowActions {
+			sig, err := s.Signer.SignAction(action, input.RequestID)
+			if err != nil {
+				return "", fmt.Errorf("sign %s action: %w", action, err)
+			}
+			input.ActionSignatures[action] = sig
+		}
+	}
 	return StartGrantWorkflow(ctx, s.Client, s.StateMachineARN, input)
 }
 
-// StartGrantWorkflow starts a Step Functions execution for the grant-wait-revoke workflow.
-func StartGrantWorkflow(ctx context.Context, sfnClient *sfn.Client, stateMachineARN string, input models.StepFunctionInput) error {
+// GetExecution fetches the full history of the execution named after
+// requestID and reduces it to a step timeline.
+func (s *SFNClient) GetExecution(ctx context.Context, requestID string) ([]models.ExecutionEvent, error) {
+	input := &sfn.GetExecutionHistoryInput{
+		ExecutionArn: aws.String(executionARN(s.StateMachineARN, requestID)),
+	}
+
+	var history []sfntypes.HistoryEvent
+	for {
+		out, err := s.Client.GetExecutionHistory(ctx, input)
+		if err != nil {
+			var notFound *sfntypes.ExecutionDoesNotExist
+			if errors.As(err, &notFound) {
+				return nil, fmt.Errorf("execution for request %s not found", requestID)
+			}
+			return nil, fmt.Errorf("get execution history: %w", err)
+		}
+		history = append(history, out.Events...)
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	return simplifyHistory(history), nil
+}
+
+// executionARN derives an execution's ARN from its state machine ARN and name.
+func executionARN(stateMachineARN, name string) string {
+	return strings.Replace(stateMachineARN, ":stateMachine:", ":execution:", 1) + ":" + name
+}
+
+// simplifyHistory keeps the events operators care about: the execution
+// starting and finishing, each state being entered and exited, and failures,
+// which are attributed to the state that was running.
+func simplifyHistory(history []sfntypes.HistoryEvent) []models.ExecutionEvent {
+	events := make([]models.ExecutionEvent, 0, len(history))
+	var current string
+	for _, h := range history {
+		ev := models.ExecutionEvent{Step: current}
+		if h.Timestamp != nil {
+			ev.Timestamp = h.Timestamp.UTC().Format(time.RFC3339)
+		}
+
+		switch {
+		case h.StateEnteredEventDetails != nil:
+			current = aws.ToString(h.StateEnteredEventDetails.Name)
+			ev.Step, ev.Status = current, "ENTERED"
+		case h.StateExitedEventDetails != nil:
+			ev.Step, ev.Status = aws.ToString(h.StateExitedEventDetails.Name), "EXITED"
+		case h.Type == sfntypes.HistoryEventTypeExecutionStarted:
+			ev.Step, ev.Status = "Execution", "STARTED"
+		case h.Type == sfntypes.HistoryEventTypeExecutionSucceeded:
+			ev.Step, ev.Status = "Execution", "SUCCEEDED"
+		case h.ExecutionFailedEventDetails != nil:
+			ev.Step, ev.Status = "Execution", "FAILED"
+			ev.Error = failureText(h.ExecutionFailedEventDetails.Error, h.ExecutionFailedEventDetails.Cause)
+		case h.ExecutionTimedOutEventDetails != nil:
+			ev.Step, ev.Status = "Execution", "TIMED_OUT"
+			ev.Error = failureText(h.ExecutionTimedOutEventDetails.Error, h.ExecutionTimedOutEventDetails.Cause)
+		case h.ExecutionAbortedEventDetails != nil:
+			ev.Step, ev.Status = "Execution", "ABORTED"
+			ev.Error = failureText(h.ExecutionAbortedEventDetails.Error, h.ExecutionAbortedEventDetails.Cause)
+		case h.TaskFailedEventDetails != nil:
+			ev.Status = "FAILED"
+			ev.Error = failureText(h.TaskFailedEventDetails.Error, h.TaskFailedEventDetails.Cause)
+		case h.LambdaFunctionFailedEventDetails != nil:
+			ev.Status = "FAILED"
+			ev.Error = failureText(h.LambdaFunctionFailedEventDetails.Error, h.LambdaFunctionFailedEventDetails.Cause)
+		case h.TaskTimedOutEventDetails != nil:
+			ev.Status = "TIMED_OUT"
+			ev.Error = failureText(h.TaskTimedOutEventDetails.Error, h.TaskTimedOutEventDetails.Cause)
+		default:
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func failureText(errName, cause *string) string {
+	e, c := aws.ToString(errName), aws.ToString(cause)
+	switch {
+	case e == "":
+		return c
+	case c == "":
+		return e
+	default:
+		return e + ": " + c
+	}
+}
+
+// StartGrantWorkflow starts a Step Functions execution for the grant-wait-revoke workflow
+// and returns its ARN. The execution is named after the request ID, so a
+// retried approval that finds the execution already running is treated as
+// success and gets the existing execution's ARN.
+func StartGrantWorkflow(ctx context.Context, sfnClient SFNAPI, stateMachineARN string, input models.StepFunctionInput) (string, error) {
 	// Convert duration to seconds for the Step Functions Wait state.
 	type sfnPayload struct {
 		RequestID           string `json:"request_id"`
@@ -33,6 +153,13 @@ func StartGrantWorkflow(ctx context.Context, sfnClient *sfn.Client, stateMachine
 		IdentityStoreUserID string `json:"identity_store_user_id"`
 		DurationSeconds     int    `json:"duration_seconds"`
 		RequesterEmail      string `json:"requester_email"`
+
+		ActionSignatures map[string]string `json:"action_signatures,omitempty"`
+	}
+
+	durationSeconds := input.DurationMinutes * 60
+	if input.WaitSeconds > 0 {
+		durationSeconds = input.WaitSeconds
 	}
 
 	payload := sfnPayload{
@@ -40,29 +167,40 @@ func StartGrantWorkflow(ctx context.Context, sfnClient *sfn.Client, stateMachine
 		AccountID:           input.AccountID,
 		ChannelID:           input.ChannelID,
 		IdentityStoreUserID: input.IdentityStoreUserID,
-		DurationSeconds:     input.DurationMinutes * 60,
+		DurationSeconds:     durationSeconds,
 		RequesterEmail:      input.RequesterEmail,
+		ActionSignatures:    input.ActionSignatures,
 	}
 
 	inputJSON, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("marshal step function input: %w", err)
+		return "", fmt.Errorf("marshal step function input: %w", err)
 	}
 
 	execName := input.RequestID
 
-	_, err = sfnClient.StartExecution(ctx, &sfn.StartExecutionInput{
+	out, err := sfnClient.StartExecution(ctx, &sfn.StartExecutionInput{
 		StateMachineArn: &stateMachineARN,
 		Name:            &execName,
 		Input:           aws.String(string(inputJSON)),
 	})
 	if err != nil {
-		return fmt.Errorf("start step function execution: %w", err)
+		var alreadyExists *sfntypes.ExecutionAlreadyExists
+		if errors.As(err, &alreadyExists) {
+			slog.Info("step function execution already exists",
+				"request_id", input.RequestID,
+				"state_machine", stateMachineARN,
+			)
+			return executionARN(stateMachineARN, execName), nil
+		}
+		return "", fmt.Errorf("start step function execution: %w", err)
 	}
 
+	arn := aws.ToString(out.ExecutionArn)
 	slog.Info("step function execution started",
 		"request_id", input.RequestID,
 		"state_machine", stateMachineARN,
+		"execution_arn", arn,
 	)
-	return nil
+	return arn, nil
 }
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfntypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+type mockSFNAPI struct {
+	err     error
+	names   []string
+	inputs  []string
+	pages   []*sfn.GetExecutionHistoryOutput
+	arns    []string
+	tokens  []string
+	histErr error
+}
+
+func (m *mockSFNAPI) GetExecutionHistory(_ context.Context, params *sfn.GetExecutionHistoryInput, _ ...func(*sfn.Options)) (*sfn.GetExecutionHistoryOutput, error) {
+	m.arns = append(m.arns, aws.ToString(params.ExecutionArn))
+	m.tokens = append(m.tokens, aws.ToString(params.NextToken))
+	if m.histErr != nil {
+		return nil, m.histErr
+	}
+	page := m.pages[0]
+	m.pages = m.pages[1:]
+	return page, nil
+}
+
+func (m *mockSFNAPI) StartExecution(_ context.Context, params *sfn.StartExecutionInput, _ ...func(*sfn.Options)) (*sfn.StartExecutionOutput, error) {
+	m.names = append(m.names, aws.ToString(params.Name))
+	m.inputs = append(m.inputs, aws.ToString(params.Input))
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sfn.StartExecutionOutput{
+		ExecutionArn: aws.String("arn:aws:states:us-east-1:123456789012:execution:jit:" + aws.ToString(params.Name)),
+	}, nil
+}
+
+func testSFNInput() models.StepFunctionInput {
+	return models.StepFunctionInput{
+		RequestID:           "req-1",
+		AccountID:           "123456789012",
+		ChannelID:           "ch1",
+		IdentityStoreUserID: "user-123",
+		DurationMinutes:     60,
+	}
+}
+
+func TestStartGrantWorkflow_NamesExecutionAfterRequest(t *testing.T) {
+	client := &mockSFNAPI{}
+
+	arn, err := StartGrantWorkflow(context.Background(), client, "arn:sm", testSFNInput())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.names) != 1 || client.names[0] != "req-1" {
+		t.Errorf("expected execution named req-1, got %v", client.names)
+	}
+	if arn != "arn:aws:states:us-east-1:123456789012:execution:jit:req-1" {
+		t.Errorf("expected execution ARN returned, got %q", arn)
+	}
+}
+
+func TestStartGrantWorkflow_WaitSecondsOverridesDuration(t *testing.T) {
+	for _, tt := range []struct {
+		wait int
+		want int
+	}{{0, 3600}, {600, 600}} {
+		client := &mockSFNAPI{}
+		input := testSFNInput()
+		input.WaitSeconds = tt.wait
+		if _, err := StartGrantWorkflow(context.Background(), client, "arn:sm", input); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var payload struct {
+			DurationSeconds int `json:"duration_seconds"`
+		}
+		if err := json.Unmarshal([]byte(client.inputs[0]), &payload); err != nil {
+			t.Fatalf("input is not JSON: %v", err)
+		}
+		if payload.DurationSeconds != tt.want {
+			t.Errorf("wait_seconds %d: expected duration_seconds %d, got %d", tt.wait, tt.want, payload.DurationSeconds)
+		}
+	}
+}
+
+func TestStartGrantWorkflow_ExecutionAlreadyExistsIsIdempotent(t *testing.T) {
+	client := &mockSFNAPI{err: &sfntypes.ExecutionAlreadyExists{Message: aws.String("execution already exists")}}
+	starter := &SFNClient{Client: client, StateMachineARN: "arn:aws:states:us-east-1:123456789012:stateMachine:jit"}
+
+	arn, err := starter.StartExecution(context.Background(), testSFNInput())
+	if err != nil {
+		t.Fatalf("expected ExecutionAlreadyExists to be treated as success, got: %v", err)
+	}
+	if arn != "arn:aws:states:us-east-1:123456789012:execution:jit:req-1" {
+		t.Errorf("expected the existing execution's ARN, got %q", arn)
+	}
+}
+
+func TestStartGrantWorkflow_OtherErrorsPropagate(t *testing.T) {
+	client := &mockSFNAPI{err: errors.New("throttled")}
+
+	if _, err := StartGrantWorkflow(context.Background(), client, "arn:sm", testSFNInput()); err == nil {
+		t.Fatal("expected error to propagate")
+	}
+}
+
+func TestSFNClient_SignsWorkflowActions(t *testing.T) {
+	client := &mockSFNAPI{}
+	signer := auth.NewActionSigner(map[string]string{"k1": "action-secret"})
+	starter := &SFNClient{Client: client, StateMachineARN: "arn:sm", Signer: signer}
+
+	if _, err := starter.StartExecution(context.Background(), testSFNInput()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var input struct {
+		ActionSignatures map[string]string `json:"action_signatures"`
+	}
+	if err := json.Unmarshal([]byte(client.inputs[0]), &input); err != nil {
+		t.Fatalf("failed to decode execution input: %v", err)
+	}
+	if len(input.ActionSignatures) != len(workflowActions) {
+		t.Fatalf("expected a signature per workflow action, got %v", input.ActionSignatures)
+	}
+	for _, action := range workflowActions {
+		if err := signer.VerifyAction(action, "req-1", input.ActionSignatures[action]); err != nil {
+			t.Errorf("%s: expected valid signature, got: %v", action, err)
+		}
+	}
+}
+
+func TestSFNClient_UnsignedWithoutSigner(t *testing.T) {
+	client := &mockSFNAPI{}
+	starter := &SFNClient{Client: client, StateMachineARN: "arn:sm"}
+
+	if _, err := starter.StartExecution(context.Background(), testSFNInput()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(client.inputs[0], "action_signatures") {
+		t.Errorf("expected no action signatures without a signer, got %s", client.inputs[0])
+	}
+}
+
+func TestSFNClient_GetExecution(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := &mockSFNAPI{pages: []*sfn.GetExecutionHistoryOutput{
+		{
+			Events: []sfntypes.HistoryEvent{
+				{Type: sfntypes.HistoryEventTypeExecutionStarted, Timestamp: aws.Time(ts)},
+				{Type: sfntypes.HistoryEventTypeTaskStateEntered, Timestamp: aws.Time(ts.Add(time.Second)),
+					StateEnteredEventDetails: &sfntypes.StateEnteredEventDetails{Name: aws.String("Grant")}},
+			},
+			NextToken: aws.String("page-2"),
+		},
+		{
+			Events: []sfntypes.HistoryEvent{
+				{Type: sfntypes.HistoryEventTypeLambdaFunctionScheduled, Timestamp: aws.Time(ts.Add(2 * time.Second))},
+				{Type: sfntypes.HistoryEventTypeLambdaFunctionFailed, Timestamp: aws.Time(ts.Add(3 * time.Second)),
+					LambdaFunctionFailedEventDetails: &sfntypes.LambdaFunctionFailedEventDetails{
+						Error: aws.String("ConflictException"), Cause: aws.String("assignment in progress"),
+					}},
+				{Type: sfntypes.HistoryEventTypeTaskStateExited, Timestamp: aws.Time(ts.Add(4 * time.Second)),
+					StateExitedEventDetails: &sfntypes.StateExitedEventDetails{Name: aws.String("Grant")}},
+			},
+		},
+	}}
+	starter := &SFNClient{Client: client, StateMachineARN: "arn:aws:states:us-east-1:123456789012:stateMachine:prod-jit-grant-revoke"}
+
+	events, err := starter.GetExecution(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+
+	wantARN := "arn:aws:states:us-east-1:123456789012:execution:prod-jit-grant-revoke:req-1"
+	if client.arns[0] != wantARN {
+		t.Errorf("expected execution ARN %s, got %s", wantARN, client.arns[0])
+	}
+	if len(client.tokens) != 2 || client.tokens[1] != "page-2" {
+		t.Errorf("expected second page to be fetched with its token, got %v", client.tokens)
+	}
+
+	want := []models.ExecutionEvent{
+		{Step: "Execution", Status: "STARTED", Timestamp: "2025-01-01T12:00:00Z"},
+		{Step: "Grant", Status: "ENTERED", Timestamp: "2025-01-01T12:00:01Z"},
+		{Step: "Grant", Status: "FAILED", Timestamp: "2025-01-01T12:00:03Z", Error: "ConflictException: assignment in progress"},
+		{Step: "Grant", Status: "EXITED", Timestamp: "2025-01-01T12:00:04Z"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+}
+
+func TestSFNClient_GetExecutionNotFound(t *testing.T) {
+	client := &mockSFNAPI{histErr: &sfntypes.ExecutionDoesNotExist{Message: aws.String("nope")}}
+	starter := &SFNClient{Client: client, StateMachineARN: "arn:sm"}
+
+	_, err := starter.GetExecution(context.Background(), "req-1")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
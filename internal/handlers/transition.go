@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// TransitionStatus moves a request from the status `from` to updates["status"],
+// refusing any move models.CanTransition does not allow before touching the
+// table. The write itself stays conditional on the request still being in
+// `from`, so concurrent transitions cannot both succeed.
+func TransitionStatus(ctx context.Context, db StatusUpdater, requestID, from string, updates map[string]interface{}) error {
+	to, _ := updates["status"].(string)
+	if !models.CanTransition(from, to) {
+		return fmt.Errorf("illegal transition from %s to %q for request %s", from, to, requestID)
+	}
+	return db.ConditionalUpdateStatus(ctx, requestID, from, updates)
+}
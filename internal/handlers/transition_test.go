@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+func TestTransitionStatus_RejectsIllegalMove(t *testing.T) {
+	db := newMockDB()
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusDenied}
+
+	err := TransitionStatus(context.Background(), db, "req-1", models.StatusDenied,
+		map[string]interface{}{"status": models.StatusGranted})
+	if err == nil || !strings.Contains(err.Error(), "illegal transition from DENIED to \"GRANTED\"") {
+		t.Fatalf("expected illegal transition error, got %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusDenied {
+		t.Errorf("expected request to be left DENIED, got %s", db.requests["req-1"].Status)
+	}
+}
+
+func TestTransitionStatus_RequiresTargetStatus(t *testing.T) {
+	db := newMockDB()
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusGranted}
+
+	err := TransitionStatus(context.Background(), db, "req-1", models.StatusGranted,
+		map[string]interface{}{"permission_set_status": map[string]string{}})
+	if err == nil || !strings.Contains(err.Error(), "illegal transition") {
+		t.Fatalf("expected updates without a status to be rejected, got %v", err)
+	}
+}
+
+func TestTransitionStatus_AllowsLegalMove(t *testing.T) {
+	db := newMockDB()
+	db.requests["req-1"] = &models.JitRequest{RequestID: "req-1", Status: models.StatusPending}
+
+	err := TransitionStatus(context.Background(), db, "req-1", models.StatusPending,
+		map[string]interface{}{"status": models.StatusApproved})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.requests["req-1"].Status != models.StatusApproved {
+		t.Errorf("expected APPROVED, got %s", db.requests["req-1"].Status)
+	}
+}
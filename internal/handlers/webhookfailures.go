@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// HandleListWebhookFailures processes GET /admin/webhook-failures.
+// Lists notifications that were still undelivered after retries, one page at
+// a time, so an operator can see what the plugin missed during an outage.
+func (h *Handler) HandleListWebhookFailures(ctx context.Context, input models.WebhookFailuresInput) (*models.WebhookFailuresResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if h.WebhookFailures == nil {
+		return nil, fmt.Errorf("webhook failure log is not configured")
+	}
+
+	input.Limit = models.NormalizeLimit(input.Limit)
+
+	failures, nextToken, err := h.WebhookFailures.ScanWebhookFailures(ctx, int32(input.Limit), input.NextToken)
+	if err != nil {
+		return nil, fmt.Errorf("scan webhook failures: %w", err)
+	}
+	if failures == nil {
+		failures = []models.WebhookFailure{}
+	}
+
+	return &models.WebhookFailuresResponse{
+		Items:     failures,
+		NextToken: nextToken,
+		HasMore:   nextToken != "",
+		Count:     len(failures),
+	}, nil
+}
+
+// HandleRedriveWebhooks processes POST /admin/webhook-failures/redrive.
+// Re-sends each recorded notification once. Delivered notifications are
+// removed from the log and audited on their request; the rest stay recorded
+// with the new error and an incremented attempt count.
+func (h *Handler) HandleRedriveWebhooks(ctx context.Context, input models.RedriveWebhooksInput) (*models.RedriveWebhooksResponse, error) {
+	if !h.isAdmin(input.ActorMMUserID) {
+		return nil, fmt.Errorf("user %s is not an admin", input.ActorMMUserID)
+	}
+	if h.WebhookFailures == nil || h.Redeliverer == nil {
+		return nil, fmt.Errorf("webhook failure log is not configured")
+	}
+	if len(input.FailureIDs) == 0 {
+		return nil, fmt.Errorf("failure_ids is required")
+	}
+	if len(input.FailureIDs) > models.MaxRedriveBatch {
+		return nil, fmt.Errorf("at most %d failures may be redriven per call", models.MaxRedriveBatch)
+	}
+
+	resp := &models.RedriveWebhooksResponse{Redelivered: []string{}, Failed: []models.RedriveFailure{}}
+	seen := make(map[string]bool, len(input.FailureIDs))
+	for _, id := range input.FailureIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if err := h.redriveWebhook(ctx, id, input); err != nil {
+			resp.Failed = append(resp.Failed, models.RedriveFailure{FailureID: id, Error: err.Error()})
+			continue
+		}
+		resp.Redelivered = append(resp.Redelivered, id)
+	}
+
+	slog.Info("webhook failures redriven",
+		"actor", input.ActorEmail,
+		"redelivered", len(resp.Redelivered),
+		"failed", len(resp.Failed),
+	)
+	return resp, nil
+}
+
+// redriveWebhook re-sends one recorded notification and updates the log.
+func (h *Handler) redriveWebhook(ctx context.Context, failureID string, input models.RedriveWebhooksInput) error {
+	failure, err := h.WebhookFailures.GetWebhookFailure(ctx, failureID)
+	if err != nil {
+		return fmt.Errorf("lookup failure: %w", err)
+	}
+	if failure == nil {
+		return fmt.Errorf("failure %s not found", failureID)
+	}
+
+	if err := h.Redeliverer.Deliver(ctx, failure.Payload); err != nil {
+		failure.Attempts++
+		failure.LastError = err.Error()
+		failure.FailedAt = h.now().Format(time.RFC3339)
+		if putErr := h.WebhookFailures.PutWebhookFailure(ctx, failure); putErr != nil {
+			slog.Error("failed to update webhook failure",
+				"failure_id", failureID,
+				"error", putErr,
+			)
+		}
+		return err
+	}
+
+	if err := h.WebhookFailures.DeleteWebhookFailure(ctx, failureID); err != nil {
+		// The notification went out; a stale record only risks a duplicate
+		// notification if it is redriven again.
+		slog.Warn("failed to delete redriven webhook failure",
+			"failure_id", failureID,
+			"error", err,
+		)
+	}
+
+	p := failure.Payload
+	_ = h.Audit.Log(ctx, p.RequestID, models.EventWebhookRedriven, p.AccountID, p.ChannelID,
+		models.HumanActor(input.ActorMMUserID, input.ActorEmail), map[string]string{
+			"failure_id": failureID,
+			"status":     p.Status,
+			"attempts":   strconv.Itoa(failure.Attempts + 1),
+			"failed_at":  failure.FailedAt,
+		})
+	return nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dgwhited/jit-aws-controller/internal/models"
+)
+
+// mockFailureStore is an in-memory WebhookFailureStore.
+type mockFailureStore struct {
+	failures map[string]*models.WebhookFailure
+}
+
+func (m *mockFailureStore) PutWebhookFailure(_ context.Context, failure *models.WebhookFailure) error {
+	cp := *failure
+	m.failures[failure.FailureID] = &cp
+	return nil
+}
+
+func (m *mockFailureStore) GetWebhookFailure(_ context.Context, failureID string) (*models.WebhookFailure, error) {
+	f, ok := m.failures[failureID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *f
+	return &cp, nil
+}
+
+func (m *mockFailureStore) ScanWebhookFailures(_ context.Context, _ int32, _ string) ([]models.WebhookFailure, string, error) {
+	var out []models.WebhookFailure
+	for _, f := range m.failures {
+		out = append(out, *f)
+	}
+	return out, "", nil
+}
+
+func (m *mockFailureStore) DeleteWebhookFailure(_ context.Context, failureID string) error {
+	delete(m.failures, failureID)
+	return nil
+}
+
+func newWebhookFailuresHandler() (*Handler, *mockFailureStore, *mockWebhook, *mockAudit) {
+	h, _, _, wh, au, _ := newTestHandler()
+	h.AdminMMUserIDs = []string{"admin-1"}
+	store := &mockFailureStore{failures: map[string]*models.WebhookFailure{
+		"fail-1": {
+			FailureID: "fail-1",
+			RequestID: "req-1",
+			Payload:   models.WebhookPayload{RequestID: "req-1", Status: models.StatusGranted, AccountID: "acct1", ChannelID: "ch1"},
+			LastError: "webhook notify: failed after retries: webhook returned status 503",
+			FailedAt:  "2025-06-01T10:00:00Z",
+			Attempts:  1,
+		},
+	}}
+	h.WebhookFailures = store
+	h.Redeliverer = wh
+	return h, store, wh, au
+}
+
+func TestHandleListWebhookFailures(t *testing.T) {
+	h, _, _, _ := newWebhookFailuresHandler()
+
+	resp, err := h.HandleListWebhookFailures(context.Background(), models.WebhookFailuresInput{ActorMMUserID: "admin-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Count != 1 || resp.Items[0].FailureID != "fail-1" || resp.HasMore {
+		t.Errorf("expected the one recorded failure, got %+v", resp)
+	}
+
+	_, err = h.HandleListWebhookFailures(context.Background(), models.WebhookFailuresInput{ActorMMUserID: "user-1"})
+	if err == nil || !strings.Contains(err.Error(), "is not an admin") {
+		t.Errorf("expected admin error, got: %v", err)
+	}
+}
+
+func TestHandleRedriveWebhooks_Success(t *testing.T) {
+	h, store, wh, au := newWebhookFailuresHandler()
+
+	resp, err := h.HandleRedriveWebhooks(context.Background(), models.RedriveWebhooksInput{
+		ActorMMUserID: "admin-1",
+		ActorEmail:    "admin@example.com",
+		FailureIDs:    []string{"fail-1", "fail-9"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Redelivered) != 1 || resp.Redelivered[0] != "fail-1" {
+		t.Errorf("expected fail-1 redelivered, got %+v", resp.Redelivered)
+	}
+	if len(resp.Failed) != 1 || !strings.Contains(resp.Failed[0].Error, "not found") {
+		t.Errorf("expected unknown failure reported, got %+v", resp.Failed)
+	}
+	if len(wh.payloads) != 1 || wh.payloads[0].RequestID != "req-1" || wh.payloads[0].Status != models.StatusGranted {
+		t.Errorf("expected the recorded payload re-sent, got %+v", wh.payloads)
+	}
+	if _, ok := store.failures["fail-1"]; ok {
+		t.Error("expected redelivered failure removed from the log")
+	}
+	if len(au.events) != 1 || au.events[0].eventType != models.EventWebhookRedriven || au.events[0].requestID != "req-1" {
+		t.Fatalf("expected WEBHOOK_REDRIVEN audited on req-1, got %+v", au.events)
+	}
+	if au.events[0].details["failure_id"] != "fail-1" || au.events[0].details["attempts"] != "2" {
+		t.Errorf("unexpected audit details: %+v", au.events[0].details)
+	}
+}
+
+func TestHandleRedriveWebhooks_FailureKeepsRecord(t *testing.T) {
+	h, store, wh, au := newWebhookFailuresHandler()
+	wh.err = errors.New("webhook notify: connection refused")
+
+	resp, err := h.HandleRedriveWebhooks(context.Background(), models.RedriveWebhooksInput{
+		ActorMMUserID: "admin-1",
+		FailureIDs:    []string{"fail-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Redelivered) != 0 || len(resp.Failed) != 1 {
+		t.Fatalf("expected the redrive to fail, got %+v", resp)
+	}
+	got := store.failures["fail-1"]
+	if got == nil || got.Attempts != 2 || got.LastError != "webhook notify: connection refused" {
+		t.Errorf("expected record kept with attempts bumped and new error, got %+v", got)
+	}
+	if len(au.events) != 0 {
+		t.Errorf("expected no audit event for a failed redrive, got %+v", au.events)
+	}
+}
+
+func TestHandleRedriveWebhooks_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input models.RedriveWebhooksInput
+		setup func(*Handler)
+		want  string
+	}{
+		{"not admin", models.RedriveWebhooksInput{ActorMMUserID: "user-1", FailureIDs: []string{"fail-1"}}, nil, "is not an admin"},
+		{"no ids", models.RedriveWebhooksInput{ActorMMUserID: "admin-1"}, nil, "failure_ids is required"},
+		{"too many", models.RedriveWebhooksInput{ActorMMUserID: "admin-1", FailureIDs: make([]string, models.MaxRedriveBatch+1)}, nil, "at most"},
+		{"not configured", models.RedriveWebhooksInput{ActorMMUserID: "admin-1", FailureIDs: []string{"fail-1"}},
+			func(h *Handler) { h.WebhookFailures = nil }, "is not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _, _ := newWebhookFailuresHandler()
+			if tt.setup != nil {
+				tt.setup(h)
+			}
+			_, err := h.HandleRedriveWebhooks(context.Background(), tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package identity
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"strings"
 	"time"
 
@@ -13,32 +15,132 @@ import (
 	idtypes "github.com/aws/aws-sdk-go-v2/service/identitystore/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
 	ssotypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/dgwhited/jit-aws-controller/internal/retry"
 )
 
+// SSOAdminAPI is the subset of the SSO Admin client used by Client.
+type SSOAdminAPI interface {
+	CreateAccountAssignment(ctx context.Context, params *ssoadmin.CreateAccountAssignmentInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.CreateAccountAssignmentOutput, error)
+	DescribeAccountAssignmentCreationStatus(ctx context.Context, params *ssoadmin.DescribeAccountAssignmentCreationStatusInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DescribeAccountAssignmentCreationStatusOutput, error)
+	DeleteAccountAssignment(ctx context.Context, params *ssoadmin.DeleteAccountAssignmentInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DeleteAccountAssignmentOutput, error)
+	DescribeAccountAssignmentDeletionStatus(ctx context.Context, params *ssoadmin.DescribeAccountAssignmentDeletionStatusInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DescribeAccountAssignmentDeletionStatusOutput, error)
+}
+
+// IdentityStoreAPI is the subset of the Identity Store client used by Client.
+type IdentityStoreAPI interface {
+	ListUsers(ctx context.Context, params *identitystore.ListUsersInput, optFns ...func(*identitystore.Options)) (*identitystore.ListUsersOutput, error)
+	GetUserId(ctx context.Context, params *identitystore.GetUserIdInput, optFns ...func(*identitystore.Options)) (*identitystore.GetUserIdOutput, error)
+	ListGroups(ctx context.Context, params *identitystore.ListGroupsInput, optFns ...func(*identitystore.Options)) (*identitystore.ListGroupsOutput, error)
+}
+
 // Client wraps IAM Identity Center operations for JIT access.
 type Client struct {
-	ssoAdmin         *ssoadmin.Client
-	identityStore    *identitystore.Client
+	ssoAdmin         SSOAdminAPI
+	identityStore    IdentityStoreAPI
 	ssoInstanceARN   string
 	identityStoreID  string
 	permissionSetARN string
+	retryBackoffs    []time.Duration
+	pollInterval     time.Duration
+	principalType    ssotypes.PrincipalType
+}
+
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithRetryBackoffs overrides the sleep durations between GrantAccess/RevokeAccess
+// attempts. The number of attempts is len(backoffs)+1.
+func WithRetryBackoffs(backoffs []time.Duration) Option {
+	return func(c *Client) {
+		c.retryBackoffs = backoffs
+	}
+}
+
+// WithPrincipalType sets the principal type of account assignments. With
+// PrincipalTypeGroup each requester is granted through the group whose display
+// name is their email, which LookupUserByEmail then resolves instead of the
+// user. Defaults to PrincipalTypeUser.
+func WithPrincipalType(pt ssotypes.PrincipalType) Option {
+	return func(c *Client) {
+		c.principalType = pt
+	}
 }
 
 // NewClient creates a new Identity Center client.
-func NewClient(ssoAdmin *ssoadmin.Client, identityStore *identitystore.Client, ssoInstanceARN, identityStoreID, permissionSetARN string) *Client {
-	return &Client{
+func NewClient(ssoAdmin SSOAdminAPI, identityStore IdentityStoreAPI, ssoInstanceARN, identityStoreID, permissionSetARN string, opts ...Option) *Client {
+	c := &Client{
 		ssoAdmin:         ssoAdmin,
 		identityStore:    identityStore,
 		ssoInstanceARN:   ssoInstanceARN,
 		identityStoreID:  identityStoreID,
 		permissionSetARN: permissionSetARN,
+		retryBackoffs:    defaultRetryBackoffs,
+		pollInterval:     defaultPollInterval,
+		principalType:    ssotypes.PrincipalTypeUser,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Validate checks that the default permission set belongs to the client's
+// SSO instance. SSO Admin rejects an assignment across instances only when a
+// grant runs, with an error that does not name the mismatch.
+func (c *Client) Validate() error {
+	return ValidatePermissionSetARN(c.ssoInstanceARN, c.permissionSetARN)
 }
 
+// ValidatePermissionSetARN reports an error unless permissionSetARN is a
+// permission set of the SSO instance instanceARN.
+func ValidatePermissionSetARN(instanceARN, permissionSetARN string) error {
+	instanceID, ok := ssoResourceID(instanceARN, "instance/")
+	if !ok {
+		return fmt.Errorf("invalid SSO instance ARN %q: expected arn:<partition>:sso:::instance/<instance-id>", instanceARN)
+	}
+	psPath, ok := ssoResourceID(permissionSetARN, "permissionSet/")
+	if !ok {
+		return fmt.Errorf("invalid permission set ARN %q: expected arn:<partition>:sso:::permissionSet/<instance-id>/<permission-set-id>", permissionSetARN)
+	}
+	psInstanceID, psID, ok := strings.Cut(psPath, "/")
+	if !ok || psInstanceID == "" || psID == "" {
+		return fmt.Errorf("invalid permission set ARN %q: expected arn:<partition>:sso:::permissionSet/<instance-id>/<permission-set-id>", permissionSetARN)
+	}
+	if psInstanceID != instanceID {
+		return fmt.Errorf("permission set %s belongs to SSO instance %s, not %s", permissionSetARN, psInstanceID, instanceID)
+	}
+	return nil
+}
+
+// ssoResourceID returns the part of an SSO ARN's resource after prefix.
+func ssoResourceID(arn, prefix string) (string, bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sso" {
+		return "", false
+	}
+	id, ok := strings.CutPrefix(parts[5], prefix)
+	return id, ok && id != ""
+}
+
+// ErrAmbiguousUser is returned (wrapped) by LookupUserByEmail when more than
+// one Identity Store user matches the email, e.g. when the same address exists
+// in two directories.
+var ErrAmbiguousUser = errors.New("ambiguous identity")
+
 // LookupUserByEmail finds the Identity Store user ID for the given email address.
 // It first tries to match by UserName (common when UserName is set to email),
 // then falls back to matching by the unique email attribute via GetUserId.
+// A UserName match whose email addresses do not include email is a different
+// user and is skipped. If more than one user matches, it returns an error
+// wrapping ErrAmbiguousUser that lists the candidates rather than picking one.
+// When assigning to groups it returns the ID of the requester's group instead.
 func (c *Client) LookupUserByEmail(ctx context.Context, email string) (string, error) {
+	if c.principalType == ssotypes.PrincipalTypeGroup {
+		return c.lookupGroupByName(ctx, email)
+	}
+
 	// First attempt: look up by UserName (many orgs set UserName = email).
 	listOut, err := c.identityStore.ListUsers(ctx, &identitystore.ListUsersInput{
 		IdentityStoreId: &c.identityStoreID,
@@ -51,13 +153,28 @@ func (c *Client) LookupUserByEmail(ctx context.Context, email string) (string, e
 	})
 	if err != nil {
 		slog.Warn("ListUsers by UserName failed, will try by email", "email", email, "error", err)
-	} else if len(listOut.Users) > 0 {
-		userID := aws.ToString(listOut.Users[0].UserId)
-		slog.Info("looked up identity store user by UserName",
-			"email", email,
-			"user_id", userID,
-		)
-		return userID, nil
+	} else {
+		var candidates []string
+		for _, user := range listOut.Users {
+			if !hasOtherEmails(user, email) {
+				candidates = append(candidates, aws.ToString(user.UserId))
+			}
+		}
+		if len(candidates) > 1 {
+			slog.Warn("multiple identity store users match email",
+				"email", email,
+				"user_ids", candidates,
+			)
+			return "", fmt.Errorf("%w: %d Identity Store users match email %s (user IDs: %s); an admin must remove the duplicate",
+				ErrAmbiguousUser, len(candidates), email, strings.Join(candidates, ", "))
+		}
+		if len(candidates) == 1 {
+			slog.Info("looked up identity store user by UserName",
+				"email", email,
+				"user_id", candidates[0],
+			)
+			return candidates[0], nil
+		}
 	}
 
 	// Second attempt: look up by email attribute using GetUserId.
@@ -82,50 +199,199 @@ func (c *Client) LookupUserByEmail(ctx context.Context, email string) (string, e
 	return userID, nil
 }
 
-// retryBackoffs defines the sleep durations between retries: 1s, 4s, 16s.
-var retryBackoffs = []time.Duration{
+// hasOtherEmails reports whether user lists email addresses, none of which is
+// email. Users without email addresses are taken at their UserName.
+func hasOtherEmails(user idtypes.User, email string) bool {
+	if len(user.Emails) == 0 {
+		return false
+	}
+	for _, e := range user.Emails {
+		if strings.EqualFold(aws.ToString(e.Value), email) {
+			return false
+		}
+	}
+	slog.Warn("identity store user name matches email but its email addresses do not",
+		"email", email,
+		"user_id", aws.ToString(user.UserId),
+	)
+	return true
+}
+
+// lookupGroupByName finds the Identity Store group ID whose DisplayName is name.
+func (c *Client) lookupGroupByName(ctx context.Context, name string) (string, error) {
+	out, err := c.identityStore.ListGroups(ctx, &identitystore.ListGroupsInput{
+		IdentityStoreId: &c.identityStoreID,
+		Filters: []idtypes.Filter{
+			{
+				AttributePath:  aws.String("DisplayName"),
+				AttributeValue: aws.String(name),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("ListGroups by DisplayName %s: %w", name, err)
+	}
+	if len(out.Groups) == 0 {
+		return "", fmt.Errorf("no Identity Store group found with DisplayName %s", name)
+	}
+
+	groupID := aws.ToString(out.Groups[0].GroupId)
+	slog.Info("looked up identity store group by DisplayName",
+		"display_name", name,
+		"group_id", groupID,
+	)
+	return groupID, nil
+}
+
+// defaultRetryBackoffs defines the default sleep durations between retries: 1s, 4s, 16s.
+var defaultRetryBackoffs = []time.Duration{
 	1 * time.Second,
 	4 * time.Second,
 	16 * time.Second,
 }
 
-// GrantAccess creates a permission set assignment for a user to an AWS account.
-// It polls for completion and retries up to 3 times with exponential backoff.
+// defaultPollInterval is the wait between assignment status polls.
+const defaultPollInterval = 2 * time.Second
+
+// contentionCodes are the SSO Admin error codes returned when Identity Center
+// is throttling callers or is still applying another assignment change. SSO
+// Admin serializes assignment changes per instance, so a burst of approvals
+// surfaces as these codes rather than as a real failure of the grant.
+var contentionCodes = map[string]bool{
+	"ThrottlingException":      true,
+	"ConflictException":        true,
+	"TooManyRequestsException": true,
+}
+
+// IsContention reports whether err (or anything it wraps) is an SSO Admin
+// throttling or concurrent-modification error that a later attempt can clear.
+func IsContention(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && contentionCodes[apiErr.ErrorCode()]
+}
+
+// AssignmentFailedError reports an account assignment operation that SSO
+// Admin accepted but then marked FAILED. Reason is SSO Admin's FailureReason,
+// unmodified.
+type AssignmentFailedError struct {
+	Operation string // "creation" or "deletion"
+	Reason    string
+}
+
+func (e *AssignmentFailedError) Error() string {
+	return fmt.Sprintf("account assignment %s failed: %s", e.Operation, e.Reason)
+}
+
+// FailureReason returns the SSO Admin failure reason carried by err (or
+// anything it wraps), if any.
+func FailureReason(err error) (string, bool) {
+	var failed *AssignmentFailedError
+	if errors.As(err, &failed) {
+		return failed.Reason, true
+	}
+	return "", false
+}
+
+// FailureReasonFromMessage recovers the SSO Admin failure reason from the
+// text of an AssignmentFailedError that has lost its type, such as a Lambda
+// error relayed by a Step Functions Catch. The reason runs to the end of the
+// line, since joined errors are separated by newlines.
+func FailureReasonFromMessage(msg string) (string, bool) {
+	best := -1
+	for _, op := range []string{"creation", "deletion"} {
+		marker := (&AssignmentFailedError{Operation: op}).Error()
+		if i := strings.LastIndex(msg, marker); i >= 0 && i+len(marker) > best {
+			best = i + len(marker)
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	reason := msg[best:]
+	if i := strings.IndexByte(reason, '\n'); i >= 0 {
+		reason = reason[:i]
+	}
+	return reason, true
+}
+
+// jitterSource returns a value in [0, 1). Tests replace it for determinism.
+var jitterSource = rand.Float64
+
+// contentionBackoff stretches d by up to half again at random after a
+// contention error, so grants that collided do not retry in lockstep.
+func contentionBackoff(d time.Duration, err error) time.Duration {
+	if !IsContention(err) {
+		return d
+	}
+	return d + time.Duration(float64(d)*0.5*jitterSource())
+}
+
+// BackoffSchedule builds a retry schedule for maxAttempts total attempts,
+// growing by a factor of 4 from base (base, 4*base, 16*base, ...).
+// It returns an empty schedule (a single attempt) when maxAttempts <= 1.
+func BackoffSchedule(maxAttempts int, base time.Duration) []time.Duration {
+	if maxAttempts <= 1 {
+		return []time.Duration{}
+	}
+	backoffs := make([]time.Duration, maxAttempts-1)
+	d := base
+	for i := range backoffs {
+		backoffs[i] = d
+		d *= 4
+	}
+	return backoffs
+}
+
+// GrantAccess creates the default permission set assignment for a user to an AWS account.
+// It polls for completion and retries with exponential backoff according to
+// the client's retry schedule (by default 3 retries: 1s, 4s, 16s).
 func (c *Client) GrantAccess(ctx context.Context, accountID, userID string) error {
-	var lastErr error
-	for attempt := 0; attempt <= len(retryBackoffs); attempt++ {
-		if attempt > 0 {
-			slog.Warn("retrying GrantAccess",
+	return c.GrantPermissionSet(ctx, accountID, userID, c.permissionSetARN)
+}
+
+// GrantPermissionSet creates an assignment of the given permission set for a
+// user to an AWS account, with the same polling and retry behaviour as GrantAccess.
+func (c *Client) GrantPermissionSet(ctx context.Context, accountID, userID, permissionSetARN string) error {
+	err := retry.Do(ctx, c.retryPolicy("GrantAccess", accountID, userID), func() error {
+		return c.grantAccessOnce(ctx, accountID, userID, permissionSetARN)
+	})
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) {
+		return fmt.Errorf("GrantAccess failed after retries: %w", exhausted.Err)
+	}
+	return err
+}
+
+// retryPolicy retries every failed assignment change on the client's
+// schedule, stretching the wait after contention. op names the operation in
+// logs.
+func (c *Client) retryPolicy(op, accountID, userID string) retry.Policy {
+	return retry.Policy{
+		Backoffs: c.retryBackoffs,
+		Delay:    contentionBackoff,
+		OnError: func(attempt int, err error, _ bool) {
+			slog.Error(op+" attempt failed",
+				"attempt", attempt,
+				"error", err,
+			)
+		},
+		OnRetry: func(attempt int, err error, _ time.Duration) {
+			slog.Warn("retrying "+op,
 				"attempt", attempt,
 				"account_id", accountID,
 				"user_id", userID,
+				"contention", IsContention(err),
 			)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(retryBackoffs[attempt-1]):
-			}
-		}
-
-		err := c.grantAccessOnce(ctx, accountID, userID)
-		if err == nil {
-			return nil
-		}
-		lastErr = err
-		slog.Error("GrantAccess attempt failed",
-			"attempt", attempt,
-			"error", err,
-		)
+		},
 	}
-	return fmt.Errorf("GrantAccess failed after retries: %w", lastErr)
 }
 
-func (c *Client) grantAccessOnce(ctx context.Context, accountID, userID string) error {
+func (c *Client) grantAccessOnce(ctx context.Context, accountID, userID, permissionSetARN string) error {
 	out, err := c.ssoAdmin.CreateAccountAssignment(ctx, &ssoadmin.CreateAccountAssignmentInput{
 		InstanceArn:      &c.ssoInstanceARN,
-		PermissionSetArn: &c.permissionSetARN,
+		PermissionSetArn: &permissionSetARN,
 		PrincipalId:      &userID,
-		PrincipalType:    ssotypes.PrincipalTypeUser,
+		PrincipalType:    c.principalType,
 		TargetId:         &accountID,
 		TargetType:       ssotypes.TargetTypeAwsAccount,
 	})
@@ -147,72 +413,65 @@ func (c *Client) pollCreationStatus(ctx context.Context, requestID string) error
 			InstanceArn:                        &c.ssoInstanceARN,
 			AccountAssignmentCreationRequestId: &requestID,
 		})
-		if err != nil {
+		switch {
+		case IsContention(err):
+			// The assignment is still being created; failing the attempt would
+			// only re-create it into a ConflictException, so keep polling.
+			slog.Warn("creation status poll throttled", "request_id", requestID, "error", err)
+		case err != nil:
 			return fmt.Errorf("DescribeAccountAssignmentCreationStatus: %w", err)
-		}
-		if out.AccountAssignmentCreationStatus == nil {
+		case out.AccountAssignmentCreationStatus == nil:
 			return fmt.Errorf("nil creation status in poll response")
-		}
-
-		status := out.AccountAssignmentCreationStatus.Status
-		switch status {
-		case ssotypes.StatusValuesSucceeded:
-			slog.Info("account assignment creation succeeded", "request_id", requestID)
-			return nil
-		case ssotypes.StatusValuesFailed:
-			reason := aws.ToString(out.AccountAssignmentCreationStatus.FailureReason)
-			return fmt.Errorf("account assignment creation failed: %s", reason)
-		case ssotypes.StatusValuesInProgress:
-			// Continue polling.
+		default:
+			switch out.AccountAssignmentCreationStatus.Status {
+			case ssotypes.StatusValuesSucceeded:
+				slog.Info("account assignment creation succeeded", "request_id", requestID)
+				return nil
+			case ssotypes.StatusValuesFailed:
+				reason := aws.ToString(out.AccountAssignmentCreationStatus.FailureReason)
+				return &AssignmentFailedError{Operation: "creation", Reason: reason}
+			case ssotypes.StatusValuesInProgress:
+				// Continue polling.
+			}
 		}
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(2 * time.Second):
+		case <-time.After(c.pollInterval):
 		}
 	}
 	return fmt.Errorf("account assignment creation timed out for request %s", requestID)
 }
 
-// RevokeAccess deletes a permission set assignment for a user from an AWS account.
-// It polls for completion and retries up to 3 times with exponential backoff.
+// RevokeAccess deletes the default permission set assignment for a user from an AWS account.
+// It polls for completion and retries with exponential backoff according to
+// the client's retry schedule.
 // The operation is idempotent: if the assignment doesn't exist, it returns nil.
 func (c *Client) RevokeAccess(ctx context.Context, accountID, userID string) error {
-	var lastErr error
-	for attempt := 0; attempt <= len(retryBackoffs); attempt++ {
-		if attempt > 0 {
-			slog.Warn("retrying RevokeAccess",
-				"attempt", attempt,
-				"account_id", accountID,
-				"user_id", userID,
-			)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(retryBackoffs[attempt-1]):
-			}
-		}
+	return c.RevokePermissionSet(ctx, accountID, userID, c.permissionSetARN)
+}
 
-		err := c.revokeAccessOnce(ctx, accountID, userID)
-		if err == nil {
-			return nil
-		}
-		lastErr = err
-		slog.Error("RevokeAccess attempt failed",
-			"attempt", attempt,
-			"error", err,
-		)
+// RevokePermissionSet deletes an assignment of the given permission set for a
+// user from an AWS account, with the same polling, retry, and idempotency
+// behaviour as RevokeAccess.
+func (c *Client) RevokePermissionSet(ctx context.Context, accountID, userID, permissionSetARN string) error {
+	err := retry.Do(ctx, c.retryPolicy("RevokeAccess", accountID, userID), func() error {
+		return c.revokeAccessOnce(ctx, accountID, userID, permissionSetARN)
+	})
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) {
+		return fmt.Errorf("RevokeAccess failed after retries: %w", exhausted.Err)
 	}
-	return fmt.Errorf("RevokeAccess failed after retries: %w", lastErr)
+	return err
 }
 
-func (c *Client) revokeAccessOnce(ctx context.Context, accountID, userID string) error {
+func (c *Client) revokeAccessOnce(ctx context.Context, accountID, userID, permissionSetARN string) error {
 	out, err := c.ssoAdmin.DeleteAccountAssignment(ctx, &ssoadmin.DeleteAccountAssignmentInput{
 		InstanceArn:      &c.ssoInstanceARN,
-		PermissionSetArn: &c.permissionSetARN,
+		PermissionSetArn: &permissionSetARN,
 		PrincipalId:      &userID,
-		PrincipalType:    ssotypes.PrincipalTypeUser,
+		PrincipalType:    c.principalType,
 		TargetId:         &accountID,
 		TargetType:       ssotypes.TargetTypeAwsAccount,
 	})
@@ -247,29 +506,30 @@ func (c *Client) pollDeletionStatus(ctx context.Context, requestID string) error
 			InstanceArn:                        &c.ssoInstanceARN,
 			AccountAssignmentDeletionRequestId: &requestID,
 		})
-		if err != nil {
+		switch {
+		case IsContention(err):
+			slog.Warn("deletion status poll throttled", "request_id", requestID, "error", err)
+		case err != nil:
 			return fmt.Errorf("DescribeAccountAssignmentDeletionStatus: %w", err)
-		}
-		if out.AccountAssignmentDeletionStatus == nil {
+		case out.AccountAssignmentDeletionStatus == nil:
 			return fmt.Errorf("nil deletion status in poll response")
-		}
-
-		status := out.AccountAssignmentDeletionStatus.Status
-		switch status {
-		case ssotypes.StatusValuesSucceeded:
-			slog.Info("account assignment deletion succeeded", "request_id", requestID)
-			return nil
-		case ssotypes.StatusValuesFailed:
-			reason := aws.ToString(out.AccountAssignmentDeletionStatus.FailureReason)
-			return fmt.Errorf("account assignment deletion failed: %s", reason)
-		case ssotypes.StatusValuesInProgress:
-			// Continue polling.
+		default:
+			switch out.AccountAssignmentDeletionStatus.Status {
+			case ssotypes.StatusValuesSucceeded:
+				slog.Info("account assignment deletion succeeded", "request_id", requestID)
+				return nil
+			case ssotypes.StatusValuesFailed:
+				reason := aws.ToString(out.AccountAssignmentDeletionStatus.FailureReason)
+				return &AssignmentFailedError{Operation: "deletion", Reason: reason}
+			case ssotypes.StatusValuesInProgress:
+				// Continue polling.
+			}
 		}
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(2 * time.Second):
+		case <-time.After(c.pollInterval):
 		}
 	}
 	return fmt.Errorf("account assignment deletion timed out for request %s", requestID)
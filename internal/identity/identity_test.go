@@ -0,0 +1,460 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/identitystore"
+	idtypes "github.com/aws/aws-sdk-go-v2/service/identitystore/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+)
+
+// mockSSOAdmin implements SSOAdminAPI, failing the first failCount
+// create/delete calls (with failErr, if set) and the first pollFailCount
+// creation status polls before succeeding. It records the principal of the
+// last create and delete. A non-empty failureReason marks every creation
+// FAILED with that reason.
+type mockSSOAdmin struct {
+	failCount   int
+	failErr     error
+	createCalls int
+	deleteCalls int
+
+	pollFailCount int
+	pollErr       error
+	pollCalls     int
+	failureReason string
+
+	created ssotypes.PrincipalType
+	deleted ssotypes.PrincipalType
+}
+
+func (m *mockSSOAdmin) CreateAccountAssignment(_ context.Context, params *ssoadmin.CreateAccountAssignmentInput, _ ...func(*ssoadmin.Options)) (*ssoadmin.CreateAccountAssignmentOutput, error) {
+	m.createCalls++
+	m.created = params.PrincipalType
+	if m.createCalls <= m.failCount {
+		if m.failErr != nil {
+			return nil, m.failErr
+		}
+		return nil, errors.New("throttled")
+	}
+	return &ssoadmin.CreateAccountAssignmentOutput{
+		AccountAssignmentCreationStatus: &ssotypes.AccountAssignmentOperationStatus{
+			RequestId: aws.String("create-1"),
+		},
+	}, nil
+}
+
+func (m *mockSSOAdmin) DescribeAccountAssignmentCreationStatus(_ context.Context, _ *ssoadmin.DescribeAccountAssignmentCreationStatusInput, _ ...func(*ssoadmin.Options)) (*ssoadmin.DescribeAccountAssignmentCreationStatusOutput, error) {
+	m.pollCalls++
+	if m.pollCalls <= m.pollFailCount {
+		return nil, m.pollErr
+	}
+	if m.failureReason != "" {
+		return &ssoadmin.DescribeAccountAssignmentCreationStatusOutput{
+			AccountAssignmentCreationStatus: &ssotypes.AccountAssignmentOperationStatus{
+				Status:        ssotypes.StatusValuesFailed,
+				FailureReason: aws.String(m.failureReason),
+			},
+		}, nil
+	}
+	return &ssoadmin.DescribeAccountAssignmentCreationStatusOutput{
+		AccountAssignmentCreationStatus: &ssotypes.AccountAssignmentOperationStatus{
+			Status: ssotypes.StatusValuesSucceeded,
+		},
+	}, nil
+}
+
+func (m *mockSSOAdmin) DeleteAccountAssignment(_ context.Context, params *ssoadmin.DeleteAccountAssignmentInput, _ ...func(*ssoadmin.Options)) (*ssoadmin.DeleteAccountAssignmentOutput, error) {
+	m.deleteCalls++
+	m.deleted = params.PrincipalType
+	if m.deleteCalls <= m.failCount {
+		return nil, errors.New("throttled")
+	}
+	return &ssoadmin.DeleteAccountAssignmentOutput{
+		AccountAssignmentDeletionStatus: &ssotypes.AccountAssignmentOperationStatus{
+			RequestId: aws.String("delete-1"),
+		},
+	}, nil
+}
+
+func (m *mockSSOAdmin) DescribeAccountAssignmentDeletionStatus(_ context.Context, _ *ssoadmin.DescribeAccountAssignmentDeletionStatusInput, _ ...func(*ssoadmin.Options)) (*ssoadmin.DescribeAccountAssignmentDeletionStatusOutput, error) {
+	return &ssoadmin.DescribeAccountAssignmentDeletionStatusOutput{
+		AccountAssignmentDeletionStatus: &ssotypes.AccountAssignmentOperationStatus{
+			Status: ssotypes.StatusValuesSucceeded,
+		},
+	}, nil
+}
+
+// mockIdentityStore implements IdentityStoreAPI over users keyed by UserName
+// and groups keyed by DisplayName.
+type mockIdentityStore struct {
+	users  map[string]string
+	groups map[string]string
+
+	// emails holds each user ID's email addresses; byEmail maps an email
+	// attribute to the user GetUserId resolves it to.
+	emails  map[string][]string
+	byEmail map[string]string
+
+	// duplicates lists further user IDs ListUsers returns for a UserName.
+	duplicates map[string][]string
+}
+
+func (m *mockIdentityStore) ListUsers(_ context.Context, params *identitystore.ListUsersInput, _ ...func(*identitystore.Options)) (*identitystore.ListUsersOutput, error) {
+	out := &identitystore.ListUsersOutput{}
+	name := aws.ToString(params.Filters[0].AttributeValue)
+	if id, ok := m.users[name]; ok {
+		for _, id := range append([]string{id}, m.duplicates[name]...) {
+			user := idtypes.User{UserId: aws.String(id)}
+			for _, e := range m.emails[id] {
+				user.Emails = append(user.Emails, idtypes.Email{Value: aws.String(e)})
+			}
+			out.Users = append(out.Users, user)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockIdentityStore) GetUserId(_ context.Context, params *identitystore.GetUserIdInput, _ ...func(*identitystore.Options)) (*identitystore.GetUserIdOutput, error) {
+	if alt, ok := params.AlternateIdentifier.(*idtypes.AlternateIdentifierMemberUniqueAttribute); ok {
+		var email string
+		if b, err := alt.Value.AttributeValue.MarshalSmithyDocument(); err == nil && json.Unmarshal(b, &email) == nil {
+			if id, ok := m.byEmail[email]; ok {
+				return &identitystore.GetUserIdOutput{UserId: aws.String(id)}, nil
+			}
+		}
+	}
+	return nil, errors.New("ResourceNotFoundException")
+}
+
+func (m *mockIdentityStore) ListGroups(_ context.Context, params *identitystore.ListGroupsInput, _ ...func(*identitystore.Options)) (*identitystore.ListGroupsOutput, error) {
+	out := &identitystore.ListGroupsOutput{}
+	if id, ok := m.groups[aws.ToString(params.Filters[0].AttributeValue)]; ok {
+		out.Groups = []idtypes.Group{{GroupId: aws.String(id)}}
+	}
+	return out, nil
+}
+
+func newTestClient(sso SSOAdminAPI, backoffs []time.Duration) *Client {
+	return NewClient(sso, nil, "arn:aws:sso:::instance/ssoins-1", "d-1", "arn:aws:sso:::permissionSet/ssoins-1/ps-1",
+		WithRetryBackoffs(backoffs))
+}
+
+func TestGrantAccess_RetriesUntilSuccess(t *testing.T) {
+	sso := &mockSSOAdmin{failCount: 2}
+	client := newTestClient(sso, []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond})
+
+	if err := client.GrantAccess(context.Background(), "acct1", "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sso.createCalls != 3 {
+		t.Errorf("expected 3 attempts, got %d", sso.createCalls)
+	}
+}
+
+func TestGrantAccess_ExhaustsSchedule(t *testing.T) {
+	sso := &mockSSOAdmin{failCount: 100}
+	client := newTestClient(sso, BackoffSchedule(5, time.Microsecond))
+
+	err := client.GrantAccess(context.Background(), "acct1", "user-1")
+	if err == nil {
+		t.Fatal("expected error after exhausting retries, got nil")
+	}
+	if !strings.Contains(err.Error(), "after retries") {
+		t.Errorf("expected retry exhaustion error, got: %v", err)
+	}
+	if sso.createCalls != 5 {
+		t.Errorf("expected 5 attempts, got %d", sso.createCalls)
+	}
+}
+
+func TestGrantAccess_ThrottleThenSucceed(t *testing.T) {
+	sso := &mockSSOAdmin{
+		failCount:     1,
+		failErr:       &ssotypes.ThrottlingException{Message: aws.String("Rate exceeded")},
+		pollFailCount: 1,
+		pollErr:       &ssotypes.ThrottlingException{Message: aws.String("Rate exceeded")},
+	}
+	client := newTestClient(sso, []time.Duration{time.Millisecond})
+	client.pollInterval = time.Millisecond
+
+	if err := client.GrantAccess(context.Background(), "acct1", "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sso.createCalls != 2 {
+		t.Errorf("expected the throttled create to be retried once, got %d creates", sso.createCalls)
+	}
+	if sso.pollCalls != 2 {
+		t.Errorf("expected a throttled poll to keep polling the same assignment, got %d polls", sso.pollCalls)
+	}
+}
+
+func TestGrantAccess_PollErrorFailsAttempt(t *testing.T) {
+	sso := &mockSSOAdmin{pollFailCount: 100, pollErr: errors.New("access denied")}
+	client := newTestClient(sso, nil)
+	client.pollInterval = time.Millisecond
+
+	if err := client.GrantAccess(context.Background(), "acct1", "user-1"); err == nil {
+		t.Fatal("expected a non-contention poll error to fail the grant")
+	}
+	if sso.pollCalls != 1 {
+		t.Errorf("expected polling to stop at the first non-contention error, got %d polls", sso.pollCalls)
+	}
+}
+
+func TestGrantAccess_PreservesFailureReason(t *testing.T) {
+	const reason = "Received a 404 status error: Not supported PermissionSet is not provisioned in the account"
+	sso := &mockSSOAdmin{failureReason: reason}
+	client := newTestClient(sso, []time.Duration{time.Millisecond})
+
+	err := client.GrantAccess(context.Background(), "acct1", "user-1")
+	if err == nil {
+		t.Fatal("expected a FAILED assignment to fail the grant")
+	}
+	got, ok := FailureReason(err)
+	if !ok || got != reason {
+		t.Errorf("expected failure reason %q through the retry wrapping, got %q (found %v)", reason, got, ok)
+	}
+	if got, ok := FailureReasonFromMessage(fmt.Errorf("grant access: %w", err).Error()); !ok || got != reason {
+		t.Errorf("expected failure reason %q recovered from the message, got %q (found %v)", reason, got, ok)
+	}
+}
+
+func TestFailureReasonFromMessage(t *testing.T) {
+	tests := []struct {
+		msg    string
+		reason string
+		found  bool
+	}{
+		{"account assignment creation failed: quota exceeded", "quota exceeded", true},
+		{"revoke permission set ps-1: account assignment deletion failed: in use\nrevoke permission set ps-2: timed out", "in use", true},
+		{"account assignment creation failed: ", "", true},
+		{"CreateAccountAssignment: throttled", "", false},
+	}
+	for _, tt := range tests {
+		got, found := FailureReasonFromMessage(tt.msg)
+		if got != tt.reason || found != tt.found {
+			t.Errorf("FailureReasonFromMessage(%q) = %q, %v; want %q, %v", tt.msg, got, found, tt.reason, tt.found)
+		}
+	}
+}
+
+func TestIsContention(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throttling", &ssotypes.ThrottlingException{}, true},
+		{"conflict", &ssotypes.ConflictException{}, true},
+		{"wrapped conflict", fmt.Errorf("CreateAccountAssignment: %w", &ssotypes.ConflictException{}), true},
+		{"access denied", &ssotypes.AccessDeniedException{}, false},
+		{"plain error", errors.New("ThrottlingException"), false},
+		{"nil", nil, false},
+	}
+	for _, tc := range cases {
+		if got := IsContention(tc.err); got != tc.want {
+			t.Errorf("%s: IsContention = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestContentionBackoff(t *testing.T) {
+	orig := jitterSource
+	defer func() { jitterSource = orig }()
+	jitterSource = func() float64 { return 0.5 }
+
+	if got := contentionBackoff(time.Second, &ssotypes.ThrottlingException{}); got != 1250*time.Millisecond {
+		t.Errorf("expected jittered backoff after contention, got %v", got)
+	}
+	if got := contentionBackoff(time.Second, errors.New("boom")); got != time.Second {
+		t.Errorf("expected unchanged backoff for other errors, got %v", got)
+	}
+}
+
+func TestRevokeAccess_ExhaustsSchedule(t *testing.T) {
+	sso := &mockSSOAdmin{failCount: 100}
+	client := newTestClient(sso, []time.Duration{time.Millisecond})
+
+	if err := client.RevokeAccess(context.Background(), "acct1", "user-1"); err == nil {
+		t.Fatal("expected error after exhausting retries, got nil")
+	}
+	if sso.deleteCalls != 2 {
+		t.Errorf("expected 2 attempts, got %d", sso.deleteCalls)
+	}
+}
+
+func TestNewClient_DefaultBackoffs(t *testing.T) {
+	client := NewClient(&mockSSOAdmin{}, nil, "inst", "store", "ps")
+	if len(client.retryBackoffs) != 3 {
+		t.Fatalf("expected 3 default backoffs, got %d", len(client.retryBackoffs))
+	}
+	want := []time.Duration{time.Second, 4 * time.Second, 16 * time.Second}
+	for i, d := range want {
+		if client.retryBackoffs[i] != d {
+			t.Errorf("backoff[%d]: expected %v, got %v", i, d, client.retryBackoffs[i])
+		}
+	}
+}
+
+func TestBackoffSchedule(t *testing.T) {
+	got := BackoffSchedule(4, time.Second)
+	want := []time.Duration{time.Second, 4 * time.Second, 16 * time.Second}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d backoffs, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("backoff[%d]: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	if n := len(BackoffSchedule(1, time.Second)); n != 0 {
+		t.Errorf("expected no backoffs for a single attempt, got %d", n)
+	}
+}
+
+func TestPrincipalType(t *testing.T) {
+	store := &mockIdentityStore{
+		users:  map[string]string{"user@example.com": "user-1"},
+		groups: map[string]string{"user@example.com": "group-1"},
+	}
+	tests := []struct {
+		name      string
+		opts      []Option
+		principal ssotypes.PrincipalType
+		wantID    string
+	}{
+		{"default user", nil, ssotypes.PrincipalTypeUser, "user-1"},
+		{"group", []Option{WithPrincipalType(ssotypes.PrincipalTypeGroup)}, ssotypes.PrincipalTypeGroup, "group-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sso := &mockSSOAdmin{}
+			opts := append([]Option{WithRetryBackoffs(nil)}, tt.opts...)
+			client := NewClient(sso, store, "inst", "store", "ps", opts...)
+			ctx := context.Background()
+
+			id, err := client.LookupUserByEmail(ctx, "user@example.com")
+			if err != nil {
+				t.Fatalf("lookup: %v", err)
+			}
+			if id != tt.wantID {
+				t.Errorf("expected principal %s, got %s", tt.wantID, id)
+			}
+			if err := client.GrantAccess(ctx, "acct1", id); err != nil {
+				t.Fatalf("grant: %v", err)
+			}
+			if err := client.RevokeAccess(ctx, "acct1", id); err != nil {
+				t.Fatalf("revoke: %v", err)
+			}
+			if sso.created != tt.principal || sso.deleted != tt.principal {
+				t.Errorf("expected %s assignments, got create %s and delete %s", tt.principal, sso.created, sso.deleted)
+			}
+		})
+	}
+}
+
+func TestLookupUserByEmail_SkipsUserNameMatchWithOtherEmail(t *testing.T) {
+	store := &mockIdentityStore{
+		users:   map[string]string{"user@example.com": "impostor"},
+		emails:  map[string][]string{"impostor": {"someone-else@example.com"}, "user-1": {"user@example.com"}},
+		byEmail: map[string]string{"user@example.com": "user-1"},
+	}
+	client := NewClient(&mockSSOAdmin{}, store, "inst", "store", "ps")
+
+	id, err := client.LookupUserByEmail(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if id != "user-1" {
+		t.Errorf("expected the user owning the email, got %s", id)
+	}
+
+	// A UserName match that lists the email (in any case) is accepted.
+	store.emails["impostor"] = []string{"User@Example.com"}
+	if id, _ := client.LookupUserByEmail(context.Background(), "user@example.com"); id != "impostor" {
+		t.Errorf("expected UserName match with matching email, got %s", id)
+	}
+}
+
+func TestLookupUserByEmail_AmbiguousMatch(t *testing.T) {
+	store := &mockIdentityStore{
+		users:      map[string]string{"user@example.com": "user-1"},
+		duplicates: map[string][]string{"user@example.com": {"user-2"}},
+		emails:     map[string][]string{"user-1": {"user@example.com"}, "user-2": {"user@example.com"}},
+		byEmail:    map[string]string{"user@example.com": "user-1"},
+	}
+	client := NewClient(&mockSSOAdmin{}, store, "inst", "store", "ps")
+
+	id, err := client.LookupUserByEmail(context.Background(), "user@example.com")
+	if !errors.Is(err, ErrAmbiguousUser) {
+		t.Fatalf("expected ErrAmbiguousUser, got id %q err %v", id, err)
+	}
+	if !strings.Contains(err.Error(), "user-1, user-2") {
+		t.Errorf("expected the candidate user IDs in the error, got: %v", err)
+	}
+
+	// A second UserName match that belongs to another email is not a candidate.
+	store.emails["user-2"] = []string{"other@example.com"}
+	if id, err := client.LookupUserByEmail(context.Background(), "user@example.com"); err != nil || id != "user-1" {
+		t.Errorf("expected the single real match, got id %q err %v", id, err)
+	}
+}
+
+func TestLookupGroup_NotFound(t *testing.T) {
+	client := NewClient(&mockSSOAdmin{}, &mockIdentityStore{}, "inst", "store", "ps",
+		WithPrincipalType(ssotypes.PrincipalTypeGroup))
+
+	_, err := client.LookupUserByEmail(context.Background(), "nobody@example.com")
+	if err == nil || !strings.Contains(err.Error(), "no Identity Store group") {
+		t.Fatalf("expected missing group error, got: %v", err)
+	}
+}
+
+func TestValidatePermissionSetARN(t *testing.T) {
+	tests := []struct {
+		name        string
+		instanceARN string
+		psARN       string
+		wantErr     string
+	}{
+		{"matching", "arn:aws:sso:::instance/ssoins-1", "arn:aws:sso:::permissionSet/ssoins-1/ps-1", ""},
+		{"other partition", "arn:aws-us-gov:sso:::instance/ssoins-1", "arn:aws-us-gov:sso:::permissionSet/ssoins-1/ps-1", ""},
+		{"mismatched instance", "arn:aws:sso:::instance/ssoins-1", "arn:aws:sso:::permissionSet/ssoins-2/ps-1", "belongs to SSO instance ssoins-2, not ssoins-1"},
+		{"bad instance ARN", "ssoins-1", "arn:aws:sso:::permissionSet/ssoins-1/ps-1", "invalid SSO instance ARN"},
+		{"instance ARN for permission set", "arn:aws:sso:::instance/ssoins-1", "arn:aws:sso:::instance/ssoins-1", "invalid permission set ARN"},
+		{"missing permission set ID", "arn:aws:sso:::instance/ssoins-1", "arn:aws:sso:::permissionSet/ssoins-1", "invalid permission set ARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePermissionSetARN(tt.instanceARN, tt.psARN)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestClientValidate(t *testing.T) {
+	if err := newTestClient(nil, nil).Validate(); err != nil {
+		t.Errorf("expected the test client's ARNs to match, got %v", err)
+	}
+	c := NewClient(nil, nil, "arn:aws:sso:::instance/ssoins-1", "d-1", "arn:aws:sso:::permissionSet/ssoins-9/ps-1")
+	if err := c.Validate(); err == nil {
+		t.Error("expected a permission set from another instance to be rejected")
+	}
+}
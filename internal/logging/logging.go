@@ -0,0 +1,64 @@
+// Package logging configures the process-wide slog logger from the
+// environment.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Output formats accepted in LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options selects the log handler.
+type Options struct {
+	Format string
+	Level  slog.Level
+}
+
+// ParseOptions parses a LOG_FORMAT (json|text) and LOG_LEVEL (debug, info,
+// warn or error, as understood by slog.Level) pair. Both are case-insensitive
+// and default to JSON at Info level when empty.
+func ParseOptions(format, level string) (Options, error) {
+	opts := Options{Format: FormatJSON, Level: slog.LevelInfo}
+
+	if format != "" {
+		opts.Format = strings.ToLower(format)
+		if opts.Format != FormatJSON && opts.Format != FormatText {
+			return Options{}, fmt.Errorf("LOG_FORMAT must be %q or %q, got %q", FormatJSON, FormatText, format)
+		}
+	}
+	if level != "" {
+		if err := opts.Level.UnmarshalText([]byte(level)); err != nil {
+			return Options{}, fmt.Errorf("LOG_LEVEL %q: %w", level, err)
+		}
+	}
+	return opts, nil
+}
+
+// New returns a logger writing to w as described by opts.
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.Format == FormatText {
+		return slog.New(slog.NewTextHandler(w, handlerOpts))
+	}
+	return slog.New(slog.NewJSONHandler(w, handlerOpts))
+}
+
+// Setup installs a stdout logger configured by LOG_FORMAT and LOG_LEVEL as
+// the slog default. Invalid values fall back to JSON at Info level; the
+// returned error describes them so the caller can log it.
+func Setup() error {
+	opts, err := ParseOptions(os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		opts = Options{Format: FormatJSON, Level: slog.LevelInfo}
+	}
+	slog.SetDefault(New(os.Stdout, opts))
+	return err
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		format, level string
+		want          Options
+	}{
+		{"", "", Options{Format: FormatJSON, Level: slog.LevelInfo}},
+		{"text", "debug", Options{Format: FormatText, Level: slog.LevelDebug}},
+		{"JSON", "WARN", Options{Format: FormatJSON, Level: slog.LevelWarn}},
+		{"Text", "error", Options{Format: FormatText, Level: slog.LevelError}},
+	}
+	for _, tt := range tests {
+		got, err := ParseOptions(tt.format, tt.level)
+		if err != nil {
+			t.Errorf("ParseOptions(%q, %q): unexpected error: %v", tt.format, tt.level, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseOptions(%q, %q) = %+v, want %+v", tt.format, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestParseOptions_Invalid(t *testing.T) {
+	if _, err := ParseOptions("logfmt", ""); err == nil || !strings.Contains(err.Error(), "LOG_FORMAT") {
+		t.Errorf("expected LOG_FORMAT error, got: %v", err)
+	}
+	if _, err := ParseOptions("", "verbose"); err == nil || !strings.Contains(err.Error(), "LOG_LEVEL") {
+		t.Errorf("expected LOG_LEVEL error, got: %v", err)
+	}
+}
+
+func TestNew_FormatAndLevel(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, Options{Format: FormatJSON, Level: slog.LevelInfo}).Debug("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected debug suppressed at info level, got %q", buf.String())
+	}
+
+	New(&buf, Options{Format: FormatJSON, Level: slog.LevelInfo}).Info("shown")
+	if !json.Valid(bytes.TrimSpace(buf.Bytes())) {
+		t.Errorf("expected JSON output, got %q", buf.String())
+	}
+
+	buf.Reset()
+	New(&buf, Options{Format: FormatText, Level: slog.LevelDebug}).Debug("shown", "k", "v")
+	if out := buf.String(); !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "k=v") {
+		t.Errorf("expected text debug output, got %q", out)
+	}
+}
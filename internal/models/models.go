@@ -1,5 +1,15 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
 // Status constants
 const (
 	StatusPending  = "PENDING"
@@ -11,6 +21,68 @@ const (
 	StatusError    = "ERROR"
 )
 
+// requestStatuses are the statuses a stored request can have.
+var requestStatuses = map[string]bool{
+	StatusPending:  true,
+	StatusApproved: true,
+	StatusDenied:   true,
+	StatusGranted:  true,
+	StatusRevoked:  true,
+	StatusExpired:  true,
+	StatusError:    true,
+}
+
+// ParseStatuses splits a comma-separated status filter such as
+// "PENDING,APPROVED" into its statuses, dropping duplicates and rejecting any
+// that is not a request status.
+func ParseStatuses(s string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		status := strings.TrimSpace(part)
+		if status == "" || seen[status] {
+			continue
+		}
+		if !requestStatuses[status] {
+			return nil, fmt.Errorf("invalid status %q: must be one of PENDING, APPROVED, DENIED, GRANTED, REVOKED, EXPIRED or ERROR", status)
+		}
+		seen[status] = true
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+// transitions is the request lifecycle: each status maps to the statuses it
+// may move to. DENIED, REVOKED, EXPIRED and ERROR are terminal.
+var transitions = map[string][]string{
+	StatusPending:  {StatusApproved, StatusDenied},
+	StatusApproved: {StatusGranted, StatusError},
+	StatusGranted:  {StatusRevoked, StatusExpired, StatusError},
+}
+
+// IsTerminal reports whether a request in status can no longer change.
+func IsTerminal(status string) bool {
+	return len(transitions[status]) == 0
+}
+
+// CanTransition reports whether a request may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusExpiringSoon is a webhook-only status sent when a GRANTED request is
+// about to expire. It is never stored on a request.
+const StatusExpiringSoon = "EXPIRING_SOON"
+
+// StatusApprovalReminder is a webhook-only status sent to remind approvers of
+// a request that is still PENDING. It is never stored on a request.
+const StatusApprovalReminder = "APPROVAL_REMINDER"
+
 // Event type constants
 const (
 	EventRequested = "REQUESTED"
@@ -20,43 +92,298 @@ const (
 	EventRevoked   = "REVOKED"
 	EventExpired   = "EXPIRED"
 	EventError     = "ERROR"
+	EventImported  = "IMPORTED"
+	EventComment   = "COMMENT"
+
+	// EventAutoApproved records a request approved by the binding's
+	// auto-approve policy rather than by an approver.
+	EventAutoApproved = "AUTO_APPROVED"
+
+	// EventSelfRevokeRejected records a requester refused revocation of their
+	// own grant because the binding does not allow self-revocation.
+	EventSelfRevokeRejected = "SELF_REVOKE_REJECTED"
+
+	// EventWebhookRedriven records an admin re-sending a notification that
+	// had failed delivery.
+	EventWebhookRedriven = "WEBHOOK_REDRIVEN"
+
+	// Configuration events, recorded under ConfigAuditKey.
+	EventConfigBound   = "BIND"
+	EventConfigUpdated = "UPDATE_CONFIG"
+	EventApproversSet  = "SET_APPROVERS"
+	EventPaused        = "PAUSE"
+	EventResumed       = "RESUME"
+	EventGrantsRecount = "RECOUNT_GRANTS"
+)
+
+// Actor types recorded on audit events.
+const (
+	ActorHuman      = "human"
+	ActorSystem     = "system"
+	ActorReconciler = "reconciler"
+	ActorStepFn     = "stepfn"
+	ActorPolicy     = "policy"
+)
+
+// Actor identifies who performed an audited action. Automated actors carry
+// no MM user ID; their Email is the legacy actor_email marker.
+type Actor struct {
+	Type     string
+	MMUserID string
+	Email    string
+}
+
+// Automated actors.
+var (
+	SystemActor     = Actor{Type: ActorSystem, Email: "system"}
+	ReconcilerActor = Actor{Type: ActorReconciler, Email: "reconciler"}
+	StepFnActor     = Actor{Type: ActorStepFn, Email: "system"}
+	PolicyActor     = Actor{Type: ActorPolicy, Email: "policy"}
+)
+
+// HumanActor returns the actor for a Mattermost user.
+func HumanActor(mmUserID, email string) Actor {
+	return Actor{Type: ActorHuman, MMUserID: mmUserID, Email: email}
+}
+
+// Per-permission-set assignment status values, recorded in JitRequest.PermissionSetStatus
+const (
+	PermissionSetGranted        = "GRANTED"
+	PermissionSetFailed         = "FAILED"
+	PermissionSetRolledBack     = "ROLLED_BACK"
+	PermissionSetRollbackFailed = "ROLLBACK_FAILED"
+	PermissionSetRevoked        = "REVOKED"
+)
+
+// SSO Admin account assignment states, recorded in JitRequest.AssignmentStatus.
+// They track the assignment itself, which can lag the request status: a
+// grant that dies mid-creation leaves the request APPROVED and the
+// assignment CREATING.
+const (
+	AssignmentCreating = "CREATING"
+	AssignmentCreated  = "CREATED"
+	AssignmentDeleting = "DELETING"
+	AssignmentDeleted  = "DELETED"
+	AssignmentFailed   = "FAILED"
+)
+
+// Request priority values. Priority only affects ordering and visibility,
+// never grant mechanics.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
 )
 
 // JitConfig represents an account binding configuration
 type JitConfig struct {
-	ChannelID              string   `dynamodbav:"channel_id" json:"channel_id"`
-	AccountID              string   `dynamodbav:"account_id" json:"account_id"`
-	ApproverMMUserIDs      []string `dynamodbav:"approver_mm_user_ids,stringset" json:"approver_mm_user_ids"`
-	ApprovalPolicy         string   `dynamodbav:"approval_policy" json:"approval_policy"`
-	AllowSelfApproval      bool     `dynamodbav:"allow_self_approval" json:"allow_self_approval"`
-	MaxRequestHours        int      `dynamodbav:"max_request_hours" json:"max_request_hours"`
-	SessionDurationMinutes int      `dynamodbav:"session_duration_minutes" json:"session_duration_minutes"`
-	UpdatedAt              string   `dynamodbav:"updated_at" json:"updated_at"`
+	ChannelID                string   `dynamodbav:"channel_id" json:"channel_id"`
+	AccountID                string   `dynamodbav:"account_id" json:"account_id"`
+	ApproverMMUserIDs        []string `dynamodbav:"approver_mm_user_ids,stringset" json:"approver_mm_user_ids"`
+	ApprovalPolicy           string   `dynamodbav:"approval_policy" json:"approval_policy"`
+	AllowSelfApproval        bool     `dynamodbav:"allow_self_approval" json:"allow_self_approval"`
+	AllowSelfDeny            *bool    `dynamodbav:"allow_self_deny,omitempty" json:"allow_self_deny,omitempty"`
+	AllowSelfRevoke          *bool    `dynamodbav:"allow_self_revoke,omitempty" json:"allow_self_revoke,omitempty"`
+	MaxRequestHours          int      `dynamodbav:"max_request_hours" json:"max_request_hours"`
+	MinRequestMinutes        int      `dynamodbav:"min_request_minutes,omitempty" json:"min_request_minutes,omitempty"`
+	SessionDurationMinutes   int      `dynamodbav:"session_duration_minutes" json:"session_duration_minutes"`
+	AllowedPermissionSetARNs []string `dynamodbav:"allowed_permission_set_arns,stringset,omitempty" json:"allowed_permission_set_arns,omitempty"`
+	RequireStrongAuth        bool     `dynamodbav:"require_strong_auth" json:"require_strong_auth"`
+	MaxConcurrentGrants      int      `dynamodbav:"max_concurrent_grants,omitempty" json:"max_concurrent_grants,omitempty"`
+	AccountApproverMMUserIDs []string `dynamodbav:"account_approver_mm_user_ids,stringset,omitempty" json:"account_approver_mm_user_ids,omitempty"`
+	Paused                   bool     `dynamodbav:"paused,omitempty" json:"paused,omitempty"`
+	PauseReason              string   `dynamodbav:"pause_reason,omitempty" json:"pause_reason,omitempty"`
+	AutoApprove              bool     `dynamodbav:"auto_approve,omitempty" json:"auto_approve,omitempty"`
+	AutoApproveMaxMinutes    int      `dynamodbav:"auto_approve_max_minutes,omitempty" json:"auto_approve_max_minutes,omitempty"`
+	WebhookURL               string   `dynamodbav:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	WebhookKeyID             string   `dynamodbav:"webhook_key_id,omitempty" json:"webhook_key_id,omitempty"`
+	UpdatedAt                string   `dynamodbav:"updated_at" json:"updated_at"`
+}
+
+// Approvers returns the users allowed to approve or deny requests for this
+// binding. An account-specific list, when set, overrides the channel-wide one.
+func (c *JitConfig) Approvers() []string {
+	if len(c.AccountApproverMMUserIDs) > 0 {
+		return c.AccountApproverMMUserIDs
+	}
+	return c.ApproverMMUserIDs
+}
+
+// IsApprover reports whether userID may approve or deny requests for this binding.
+func (c *JitConfig) IsApprover(userID string) bool {
+	for _, uid := range c.Approvers() {
+		if uid == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoApproves reports whether a request for durationMinutes is approved by
+// the binding's auto-approve policy. AutoApproveMaxMinutes, when set, limits
+// auto-approval to shorter requests; longer ones go to the approvers. Bindings
+// that require strong auth always need a human approver.
+func (c *JitConfig) AutoApproves(durationMinutes int) bool {
+	if !c.AutoApprove || c.RequireStrongAuth {
+		return false
+	}
+	return c.AutoApproveMaxMinutes <= 0 || durationMinutes <= c.AutoApproveMaxMinutes
+}
+
+// SelfDenyAllowed reports whether a requester who is also an approver may deny
+// their own request. It defaults to true when the binding does not set it.
+func (c *JitConfig) SelfDenyAllowed() bool {
+	return c.AllowSelfDeny == nil || *c.AllowSelfDeny
+}
+
+// SelfRevokeAllowed reports whether a requester may revoke their own active
+// grant. It defaults to true when the binding does not set it.
+func (c *JitConfig) SelfRevokeAllowed() bool {
+	return c.AllowSelfRevoke == nil || *c.AllowSelfRevoke
 }
 
 // JitRequest represents an access request
 type JitRequest struct {
-	RequestID                string `dynamodbav:"request_id" json:"request_id"`
-	AccountID                string `dynamodbav:"account_id" json:"account_id"`
-	ChannelID                string `dynamodbav:"channel_id" json:"channel_id"`
-	RequesterMMUserID        string `dynamodbav:"requester_mm_user_id" json:"requester_mm_user_id"`
-	RequesterEmail           string `dynamodbav:"requester_email" json:"requester_email"`
-	Jira                     string `dynamodbav:"jira" json:"jira"`
-	Reason                   string `dynamodbav:"reason" json:"reason"`
-	RequestedDurationMinutes int    `dynamodbav:"requested_duration_minutes" json:"requested_duration_minutes"`
-	Status                   string `dynamodbav:"status" json:"status"`
-	CreatedAt                string `dynamodbav:"created_at" json:"created_at"`
-	ApprovedAt               string `dynamodbav:"approved_at,omitempty" json:"approved_at,omitempty"`
-	DeniedAt                 string `dynamodbav:"denied_at,omitempty" json:"denied_at,omitempty"`
-	GrantTime                string `dynamodbav:"grant_time,omitempty" json:"grant_time,omitempty"`
-	RevokedAt                string `dynamodbav:"revoked_at,omitempty" json:"revoked_at,omitempty"`
-	ExpiredAt                string `dynamodbav:"expired_at,omitempty" json:"expired_at,omitempty"`
-	EndTime                  string `dynamodbav:"end_time" json:"end_time"`
-	ApproverMMUserID         string `dynamodbav:"approver_mm_user_id,omitempty" json:"approver_mm_user_id,omitempty"`
-	ApproverEmail            string `dynamodbav:"approver_email,omitempty" json:"approver_email,omitempty"`
-	IdentityStoreUserID      string `dynamodbav:"identity_store_user_id" json:"identity_store_user_id"`
-	AssignmentStatus         string `dynamodbav:"assignment_status,omitempty" json:"assignment_status,omitempty"`
-	ErrorDetails             string `dynamodbav:"error_details,omitempty" json:"error_details,omitempty"`
+	RequestID                string            `dynamodbav:"request_id" json:"request_id"`
+	AccountID                string            `dynamodbav:"account_id" json:"account_id"`
+	ChannelID                string            `dynamodbav:"channel_id" json:"channel_id"`
+	RequesterMMUserID        string            `dynamodbav:"requester_mm_user_id" json:"requester_mm_user_id"`
+	RequesterEmail           string            `dynamodbav:"requester_email" json:"requester_email"`
+	Jira                     string            `dynamodbav:"jira,omitempty" json:"jira"`
+	Reason                   string            `dynamodbav:"reason" json:"reason"`
+	RequestedDurationMinutes int               `dynamodbav:"requested_duration_minutes" json:"requested_duration_minutes"`
+	ApprovedDurationMinutes  int               `dynamodbav:"approved_duration_minutes,omitempty" json:"approved_duration_minutes,omitempty"`
+	Status                   string            `dynamodbav:"status" json:"status"`
+	CreatedAt                string            `dynamodbav:"created_at" json:"created_at"`
+	ApprovedAt               string            `dynamodbav:"approved_at,omitempty" json:"approved_at,omitempty"`
+	DeniedAt                 string            `dynamodbav:"denied_at,omitempty" json:"denied_at,omitempty"`
+	GrantTime                string            `dynamodbav:"grant_time,omitempty" json:"grant_time,omitempty"`
+	RevokedAt                string            `dynamodbav:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	ExpiredAt                string            `dynamodbav:"expired_at,omitempty" json:"expired_at,omitempty"`
+	WarnedAt                 string            `dynamodbav:"warned_at,omitempty" json:"warned_at,omitempty"`
+	LastRemindedAt           string            `dynamodbav:"last_reminded_at,omitempty" json:"last_reminded_at,omitempty"`
+	EndTime                  string            `dynamodbav:"end_time" json:"end_time"`
+	EffectiveEndTime         string            `dynamodbav:"effective_end_time,omitempty" json:"effective_end_time,omitempty"`
+	PausedAt                 string            `dynamodbav:"paused_at,omitempty" json:"paused_at,omitempty"`
+	PausedSeconds            int               `dynamodbav:"paused_seconds,omitempty" json:"paused_seconds,omitempty"`
+	ApproverMMUserID         string            `dynamodbav:"approver_mm_user_id,omitempty" json:"approver_mm_user_id,omitempty"`
+	ApproverEmail            string            `dynamodbav:"approver_email,omitempty" json:"approver_email,omitempty"`
+	Approvals                []ApprovalRecord  `dynamodbav:"approvals,omitempty" json:"approvals,omitempty"`
+	IdentityStoreUserID      string            `dynamodbav:"identity_store_user_id" json:"identity_store_user_id"`
+	AssignmentStatus         string            `dynamodbav:"assignment_status,omitempty" json:"assignment_status,omitempty"`
+	RevokeAttempts           int               `dynamodbav:"revoke_attempts,omitempty" json:"revoke_attempts,omitempty"`
+	ErrorDetails             string            `dynamodbav:"error_details,omitempty" json:"error_details,omitempty"`
+	ErrorInfo                *ErrorInfo        `dynamodbav:"error_info,omitempty" json:"error_info,omitempty"`
+	ExecutionARN             string            `dynamodbav:"execution_arn,omitempty" json:"execution_arn,omitempty"`
+	PermissionSetARNs        []string          `dynamodbav:"permission_set_arns,omitempty" json:"permission_set_arns,omitempty"`
+	PermissionSetStatus      map[string]string `dynamodbav:"permission_set_status,omitempty" json:"permission_set_status,omitempty"`
+	Priority                 string            `dynamodbav:"priority,omitempty" json:"priority,omitempty"`
+	Metadata                 map[string]string `dynamodbav:"metadata,omitempty" json:"metadata,omitempty"`
+	Imported                 bool              `dynamodbav:"imported,omitempty" json:"imported,omitempty"`
+	AutoApproved             bool              `dynamodbav:"auto_approved,omitempty" json:"auto_approved,omitempty"`
+}
+
+// ApprovalRecord is one approval of a request, kept in order on the request
+// so its approval history can be shown without reading the audit log. An
+// auto-approval is recorded with the policy actor's email and no MM user ID.
+type ApprovalRecord struct {
+	ApproverMMUserID string `dynamodbav:"approver_mm_user_id,omitempty" json:"approver_mm_user_id,omitempty"`
+	ApproverEmail    string `dynamodbav:"approver_email" json:"approver_email"`
+	ApprovedAt       string `dynamodbav:"approved_at" json:"approved_at"`
+	Comment          string `dynamodbav:"comment,omitempty" json:"comment,omitempty"`
+}
+
+// Failure phases recorded in ErrorInfo.Phase.
+const (
+	ErrorPhaseGrant  = "grant"
+	ErrorPhaseRevoke = "revoke"
+)
+
+// ErrorInfo is the structured form of a request's failure, stored alongside
+// the human-readable error_details so alerting can match on phase and code.
+// Code is the AWS or Step Functions error code, when the failure had one.
+// FailureReason is SSO Admin's own explanation when it marked an account
+// assignment operation FAILED, kept verbatim.
+type ErrorInfo struct {
+	Phase         string `dynamodbav:"phase" json:"phase"`
+	Code          string `dynamodbav:"code,omitempty" json:"code,omitempty"`
+	Message       string `dynamodbav:"message" json:"message"`
+	FailureReason string `dynamodbav:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	Timestamp     string `dynamodbav:"timestamp" json:"timestamp"`
+}
+
+// String returns the display form stored in error_details.
+func (e ErrorInfo) String() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// DurationMinutes is the granted duration: the approved duration if the
+// approver reduced it, otherwise the requested one.
+func (r *JitRequest) DurationMinutes() int {
+	if r.ApprovedDurationMinutes > 0 {
+		return r.ApprovedDurationMinutes
+	}
+	return r.RequestedDurationMinutes
+}
+
+// ExpiresAt returns when the grant expires: effective_end_time, which is
+// end_time pushed back by the time the grant's clock was paused, or end_time
+// for grants never paused. end_time itself keeps the requested window, and
+// is never later than ExpiresAt.
+func (r *JitRequest) ExpiresAt() string {
+	if r.EffectiveEndTime != "" {
+		return r.EffectiveEndTime
+	}
+	return r.EndTime
+}
+
+// ClockPaused reports whether the grant's clock is paused. A paused grant
+// does not expire until it is resumed.
+func (r *JitRequest) ClockPaused() bool {
+	return r.PausedAt != ""
+}
+
+// PauseClockUpdates returns the updates that pause the grant's clock at now.
+// It is groundwork for a future grant-pause feature and has no production
+// caller yet.
+func (r *JitRequest) PauseClockUpdates(now time.Time) (map[string]interface{}, error) {
+	if r.ClockPaused() {
+		return nil, fmt.Errorf("request %s is already paused", r.RequestID)
+	}
+	return map[string]interface{}{
+		"paused_at": now.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// ResumeClockUpdates returns the updates that restart the grant's paused
+// clock at now: the pause is added to paused_seconds and effective_end_time
+// becomes end_time plus the total time paused. Like PauseClockUpdates, it
+// has no production caller yet.
+func (r *JitRequest) ResumeClockUpdates(now time.Time) (map[string]interface{}, error) {
+	if !r.ClockPaused() {
+		return nil, fmt.Errorf("request %s is not paused", r.RequestID)
+	}
+	pausedAt, err := time.Parse(time.RFC3339, r.PausedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse paused_at: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, r.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("parse end_time: %w", err)
+	}
+
+	paused := r.PausedSeconds
+	if d := now.Sub(pausedAt); d > 0 {
+		paused += int(d / time.Second)
+	}
+	return map[string]interface{}{
+		"paused_at":          "",
+		"paused_seconds":     paused,
+		"effective_end_time": end.Add(time.Duration(paused) * time.Second).UTC().Format(time.RFC3339),
+	}, nil
 }
 
 // AuditEvent records state transitions for audit trail
@@ -65,14 +392,39 @@ type AuditEvent struct {
 	EventTimeEventID string            `dynamodbav:"event_time_event_id" json:"event_time_event_id"`
 	EventID          string            `dynamodbav:"event_id" json:"event_id"`
 	EventTime        string            `dynamodbav:"event_time" json:"event_time"`
+	EventDate        string            `dynamodbav:"event_date,omitempty" json:"event_date,omitempty"`
 	EventType        string            `dynamodbav:"event_type" json:"event_type"`
 	AccountID        string            `dynamodbav:"account_id" json:"account_id"`
 	ChannelID        string            `dynamodbav:"channel_id" json:"channel_id"`
+	ActorType        string            `dynamodbav:"actor_type,omitempty" json:"actor_type,omitempty"`
 	ActorMMUserID    string            `dynamodbav:"actor_mm_user_id,omitempty" json:"actor_mm_user_id,omitempty"`
 	ActorEmail       string            `dynamodbav:"actor_email,omitempty" json:"actor_email,omitempty"`
 	Details          map[string]string `dynamodbav:"details,omitempty" json:"details,omitempty"`
+	PrevHash         string            `dynamodbav:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash             string            `dynamodbav:"hash,omitempty" json:"hash,omitempty"`
 }
 
+// ErrAuditChainConflict means another audit event already holds the chain
+// position a writer tried to claim, so the writer read a stale chain tip.
+var ErrAuditChainConflict = errors.New("audit chain position already taken")
+
+// ErrAuditEventExists means an audit event written with a unique event ID
+// has already been recorded.
+var ErrAuditEventExists = errors.New("audit event already recorded")
+
+// AuditSortKeyTimeFormat is the timestamp format at the start of an audit
+// event's event_time_event_id: RFC3339 with fixed-width nanoseconds, so keys
+// compare lexically in time order.
+const AuditSortKeyTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// AuditEventDateLayout is the format of an audit event's event_date, the UTC
+// day that partitions the audit table's date index.
+const AuditEventDateLayout = "2006-01-02"
+
+// NonceTTLAttribute is the nonce attribute DynamoDB TTL must be configured
+// on. It has to match NonceEntry.ExpiresAt's attribute name.
+const NonceTTLAttribute = "expires_at"
+
 // NonceEntry for replay protection
 type NonceEntry struct {
 	KeyID     string `dynamodbav:"key_id" json:"key_id"`
@@ -83,30 +435,133 @@ type NonceEntry struct {
 
 // WebhookPayload for backend -> plugin notifications
 type WebhookPayload struct {
-	RequestID string            `json:"request_id"`
-	Status    string            `json:"status"`
-	AccountID string            `json:"account_id"`
-	ChannelID string            `json:"channel_id"`
-	Actor     string            `json:"actor"`
-	Details   map[string]string `json:"details,omitempty"`
+	RequestID string            `dynamodbav:"request_id" json:"request_id"`
+	Status    string            `dynamodbav:"status" json:"status"`
+	AccountID string            `dynamodbav:"account_id" json:"account_id"`
+	ChannelID string            `dynamodbav:"channel_id" json:"channel_id"`
+	Actor     string            `dynamodbav:"actor" json:"actor"`
+	Details   map[string]string `dynamodbav:"details,omitempty" json:"details,omitempty"`
+
+	// RequesterMMUserID is set on notifications the plugin should deliver
+	// to the requester directly, such as denials.
+	RequesterMMUserID string `dynamodbav:"requester_mm_user_id,omitempty" json:"requester_mm_user_id,omitempty"`
 }
 
-// ReportingResponse is the response shape for GET /requests
+// WebhookFailure is a notification that could not be delivered after
+// retries, kept so it can be inspected and re-sent. ExpiresAt is the TTL
+// attribute (Unix seconds).
+type WebhookFailure struct {
+	FailureID string         `dynamodbav:"failure_id" json:"failure_id"`
+	RequestID string         `dynamodbav:"request_id" json:"request_id"`
+	Payload   WebhookPayload `dynamodbav:"payload" json:"payload"`
+	LastError string         `dynamodbav:"last_error" json:"last_error"`
+	FailedAt  string         `dynamodbav:"failed_at" json:"failed_at"`
+	Attempts  int            `dynamodbav:"attempts" json:"attempts"`
+	ExpiresAt int64          `dynamodbav:"expires_at" json:"expires_at"`
+}
+
+// ReportingResponse is the response shape for GET /requests.
+// HasMore reports whether a continuation token is available; it is not
+// derived from a total count, which is never computed. Count is the number
+// of items in this page.
 type ReportingResponse struct {
 	Items     []JitRequest      `json:"items"`
 	NextToken string            `json:"next_token,omitempty"`
+	HasMore   bool              `json:"has_more"`
+	Count     int               `json:"count"`
 	Filters   map[string]string `json:"filters,omitempty"`
+
+	// Fields, when set, projects each item to these JSON fields.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// MarshalJSON writes each item with only its Fields, when Fields is set.
+func (r ReportingResponse) MarshalJSON() ([]byte, error) {
+	type plain ReportingResponse
+	if len(r.Fields) == 0 {
+		return json.Marshal(plain(r))
+	}
+
+	items := make([]map[string]json.RawMessage, 0, len(r.Items))
+	for _, item := range r.Items {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &all); err != nil {
+			return nil, err
+		}
+		projected := make(map[string]json.RawMessage, len(r.Fields))
+		for _, f := range r.Fields {
+			if v, ok := all[f]; ok {
+				projected[f] = v
+			}
+		}
+		items = append(items, projected)
+	}
+	return json.Marshal(struct {
+		plain
+		Items []map[string]json.RawMessage `json:"items"`
+	}{plain(r), items})
+}
+
+// projectableRequestFields are the request fields a list may be projected
+// to. Identity Store IDs, error detail and execution ARNs are not listable
+// on their own; fetch the request for those.
+var projectableRequestFields = map[string]bool{
+	"request_id": true, "account_id": true, "channel_id": true,
+	"requester_mm_user_id": true, "requester_email": true, "jira": true, "reason": true,
+	"requested_duration_minutes": true, "approved_duration_minutes": true,
+	"status": true, "created_at": true, "approved_at": true, "denied_at": true,
+	"grant_time": true, "revoked_at": true, "expired_at": true, "end_time": true,
+	"approver_mm_user_id": true, "approver_email": true, "approvals": true, "assignment_status": true,
+	"permission_set_arns": true, "priority": true, "auto_approved": true, "imported": true,
+}
+
+// ParseRequestFields splits a comma-separated field list such as
+// "status,end_time" into its fields, dropping duplicates and rejecting any
+// that cannot be projected. request_id is always included first so items
+// stay identifiable.
+func ParseRequestFields(s string) ([]string, error) {
+	out := []string{"request_id"}
+	seen := map[string]bool{"request_id": true}
+	for _, part := range strings.Split(s, ",") {
+		field := strings.TrimSpace(part)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !projectableRequestFields[field] {
+			return nil, fmt.Errorf("invalid field %q: not a listable request field", field)
+		}
+		seen[field] = true
+		out = append(out, field)
+	}
+	return out, nil
 }
 
 // CreateRequestInput for POST /requests
 type CreateRequestInput struct {
-	AccountID                string `json:"account_id"`
-	ChannelID                string `json:"channel_id"`
-	RequesterMMUserID        string `json:"requester_mm_user_id"`
-	RequesterEmail           string `json:"requester_email"`
-	Jira                     string `json:"jira"`
-	Reason                   string `json:"reason"`
-	RequestedDurationMinutes int    `json:"requested_duration_minutes"`
+	AccountID                string            `json:"account_id"`
+	ChannelID                string            `json:"channel_id"`
+	RequesterMMUserID        string            `json:"requester_mm_user_id"`
+	RequesterEmail           string            `json:"requester_email"`
+	Jira                     string            `json:"jira"`
+	Reason                   string            `json:"reason"`
+	RequestedDurationMinutes int               `json:"requested_duration_minutes"`
+	PermissionSetARNs        []string          `json:"permission_set_arns,omitempty"`
+	Priority                 string            `json:"priority,omitempty"`
+	Metadata                 map[string]string `json:"metadata,omitempty"`
+}
+
+// ValidateRequestResponse is the response shape for POST /requests/validate.
+// EffectiveDurationMinutes is the requested duration capped at the binding's
+// maximum and the hard limit, so the form can offer a duration that will be
+// accepted.
+type ValidateRequestResponse struct {
+	Valid                    bool     `json:"valid"`
+	Errors                   []string `json:"errors"`
+	EffectiveDurationMinutes int      `json:"effective_duration_minutes"`
 }
 
 // ApproveRequestInput for POST /requests/{id}/approve
@@ -114,6 +569,66 @@ type ApproveRequestInput struct {
 	RequestID        string `json:"request_id"`
 	ApproverMMUserID string `json:"approver_mm_user_id"`
 	ApproverEmail    string `json:"approver_email"`
+	// ApprovedDurationMinutes, when set, grants less time than was requested.
+	ApprovedDurationMinutes int `json:"approved_duration_minutes,omitempty"`
+	// Comment is an optional note kept with the approval record.
+	Comment string `json:"comment,omitempty"`
+}
+
+// MaxApproveBatch is the largest number of requests approved by one
+// POST /requests/approve-batch.
+const MaxApproveBatch = 25
+
+// ApproveBatchInput for POST /requests/approve-batch
+type ApproveBatchInput struct {
+	RequestIDs       []string `json:"request_ids"`
+	ApproverMMUserID string   `json:"approver_mm_user_id"`
+	ApproverEmail    string   `json:"approver_email"`
+}
+
+// ApproveBatchResult is the outcome of approving one request in a batch:
+// the request's status afterwards, or the reason the approval was refused.
+// A deferred request was not attempted because the batch had already started
+// as many grants as allowed, or the invocation was nearly out of time; it is
+// still PENDING. StartError reports an approved request whose grant workflow
+// failed to start.
+type ApproveBatchResult struct {
+	RequestID  string `json:"request_id"`
+	Status     string `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Deferred   bool   `json:"deferred,omitempty"`
+	StartError string `json:"start_error,omitempty"`
+}
+
+// ApproveBatchResponse is the response shape for POST /requests/approve-batch,
+// with one result per distinct request ID in input order.
+type ApproveBatchResponse struct {
+	Results     []ApproveBatchResult `json:"results"`
+	Approved    int                  `json:"approved"`
+	Failed      int                  `json:"failed"`
+	Deferred    int                  `json:"deferred"`
+	Started     int                  `json:"started"`
+	StartFailed int                  `json:"start_failed"`
+}
+
+// ApprovalTokenInput for POST /requests/{id}/approval-token
+type ApprovalTokenInput struct {
+	RequestID        string `json:"request_id"`
+	ApproverMMUserID string `json:"approver_mm_user_id"`
+	ApproverEmail    string `json:"approver_email"`
+}
+
+// ApprovalTokenResponse is the response shape for POST /requests/{id}/approval-token
+type ApprovalTokenResponse struct {
+	RequestID string `json:"request_id"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ApproveWithTokenInput for POST /requests/{id}/approve-with-token
+type ApproveWithTokenInput struct {
+	RequestID string `json:"request_id"`
+	Token     string `json:"token"`
 }
 
 // DenyRequestInput for POST /requests/{id}/deny
@@ -131,16 +646,102 @@ type RevokeRequestInput struct {
 	ActorEmail    string `json:"actor_email"`
 }
 
-// ReportingInput for GET /requests query parameters
+// CommentInput for POST /requests/{id}/comment
+type CommentInput struct {
+	RequestID     string `json:"request_id"`
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	ActorEmail    string `json:"actor_email"`
+	Comment       string `json:"comment"`
+}
+
+// CommentResponse is the response shape for POST /requests/{id}/comment.
+type CommentResponse struct {
+	RequestID  string `json:"request_id"`
+	ActorEmail string `json:"actor_email"`
+	Comment    string `json:"comment"`
+}
+
+// MaxCommentLength is the longest comment, in characters, accepted on a request.
+const MaxCommentLength = 2000
+
+// ReportingInput for GET /requests query parameters. Status may list several
+// statuses, comma-separated.
 type ReportingInput struct {
 	ChannelID      string `json:"channel_id"`
 	AccountID      string `json:"account_id"`
 	RequesterEmail string `json:"requester_email"`
+	Jira           string `json:"jira"`
 	Status         string `json:"status"`
 	StartDate      string `json:"start_date"`
 	EndDate        string `json:"end_date"`
 	NextToken      string `json:"next_token"`
 	Limit          int    `json:"limit"`
+
+	// Fields, when set, is a comma-separated list of request fields to
+	// return for each item (see ParseRequestFields).
+	Fields string `json:"fields,omitempty"`
+}
+
+// StatusList returns the statuses in the Status filter. It does not validate
+// them; see ParseStatuses.
+func (in ReportingInput) StatusList() []string {
+	var out []string
+	for _, part := range strings.Split(in.Status, ",") {
+		if status := strings.TrimSpace(part); status != "" {
+			out = append(out, status)
+		}
+	}
+	return out
+}
+
+// Page size bounds shared by every paginated listing.
+const (
+	MinQueryLimit     = 1
+	DefaultQueryLimit = 50
+	MaxQueryLimit     = 200
+)
+
+// NormalizeLimit maps a requested page size onto [MinQueryLimit, MaxQueryLimit].
+// Zero or negative values mean "not set" and yield DefaultQueryLimit; values
+// above MaxQueryLimit are capped.
+func NormalizeLimit(requested int) int {
+	if requested < MinQueryLimit {
+		return DefaultQueryLimit
+	}
+	if requested > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return requested
+}
+
+// ErrInvalidNextToken marks a pagination token that could not be decoded,
+// which is the caller's mistake rather than a storage failure.
+var ErrInvalidNextToken = errors.New("invalid next_token")
+
+// MaxIDLength is the longest Mattermost channel or user ID accepted. Real IDs
+// are 26 characters; the bound keeps stray input out of DynamoDB keys.
+const MaxIDLength = 64
+
+// ValidateID checks a Mattermost channel or user ID before it is stored or
+// used as a key: it must be non-empty valid UTF-8 of at most MaxIDLength
+// bytes, with no whitespace or control characters. field names the ID in the
+// returned error.
+func ValidateID(field, id string) error {
+	if id == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if len(id) > MaxIDLength {
+		return fmt.Errorf("%s must be at most %d characters", field, MaxIDLength)
+	}
+	if !utf8.ValidString(id) {
+		return fmt.Errorf("%s must be valid UTF-8", field)
+	}
+	for _, r := range id {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%s must not contain whitespace or control characters", field)
+		}
+	}
+	return nil
 }
 
 // StepFunctionInput is the input to the Step Functions state machine
@@ -151,16 +752,315 @@ type StepFunctionInput struct {
 	IdentityStoreUserID string `json:"identity_store_user_id"`
 	DurationMinutes     int    `json:"duration_minutes"`
 	RequesterEmail      string `json:"requester_email"`
+	// WaitSeconds, when positive, replaces DurationMinutes*60 as the wait
+	// before revoking.
+	WaitSeconds int `json:"wait_seconds,omitempty"`
+	// ActionSignatures maps each workflow action to its signature when
+	// action signing is enabled.
+	ActionSignatures map[string]string `json:"action_signatures,omitempty"`
+}
+
+// ExecutionEvent is one simplified step of a request's Step Functions execution.
+type ExecutionEvent struct {
+	Step      string `json:"step"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExecutionTimeline is the response shape for GET /requests/{id}/execution
+type ExecutionTimeline struct {
+	RequestID string           `json:"request_id"`
+	Events    []ExecutionEvent `json:"events"`
+}
+
+// RequestApproversResponse is the response shape for GET /requests/{id}/approvers.
+// ApproverMMUserIDs are the users who may approve the request, which excludes
+// the requester unless self-approval is allowed.
+type RequestApproversResponse struct {
+	RequestID           string   `json:"request_id"`
+	ApproverMMUserIDs   []string `json:"approver_mm_user_ids"`
+	SelfApprovalAllowed bool     `json:"self_approval_allowed"`
 }
 
 // BindAccountInput for POST /config/bind
 type BindAccountInput struct {
-	ChannelID string `json:"channel_id"`
-	AccountID string `json:"account_id"`
+	ChannelID                string   `json:"channel_id"`
+	AccountID                string   `json:"account_id"`
+	AllowedPermissionSetARNs []string `json:"allowed_permission_set_arns,omitempty"`
+	ActorMMUserID            string   `json:"actor_mm_user_id,omitempty"`
+	ActorEmail               string   `json:"actor_email,omitempty"`
+}
+
+// UpdateConfigInput for PATCH /config/bind. Nil fields are left unchanged.
+type UpdateConfigInput struct {
+	ChannelID              string  `json:"channel_id"`
+	AccountID              string  `json:"account_id"`
+	MaxRequestHours        *int    `json:"max_request_hours,omitempty"`
+	MinRequestMinutes      *int    `json:"min_request_minutes,omitempty"`
+	AllowSelfApproval      *bool   `json:"allow_self_approval,omitempty"`
+	AllowSelfDeny          *bool   `json:"allow_self_deny,omitempty"`
+	AllowSelfRevoke        *bool   `json:"allow_self_revoke,omitempty"`
+	RequireStrongAuth      *bool   `json:"require_strong_auth,omitempty"`
+	SessionDurationMinutes *int    `json:"session_duration_minutes,omitempty"`
+	MaxConcurrentGrants    *int    `json:"max_concurrent_grants,omitempty"`
+	AutoApprove            *bool   `json:"auto_approve,omitempty"`
+	AutoApproveMaxMinutes  *int    `json:"auto_approve_max_minutes,omitempty"`
+	WebhookURL             *string `json:"webhook_url,omitempty"`
+	WebhookKeyID           *string `json:"webhook_key_id,omitempty"`
+	ActorMMUserID          string  `json:"actor_mm_user_id,omitempty"`
+	ActorEmail             string  `json:"actor_email,omitempty"`
+}
+
+// ConfigAuditKey returns the synthetic audit request_id under which changes
+// to a binding's configuration (bind, settings, approvers) are recorded.
+func ConfigAuditKey(channelID, accountID string) string {
+	return "config#" + channelID + "#" + accountID
+}
+
+// GrantCounterKey returns the synthetic request_id of the requests-table item
+// that counts a binding's active grants. It has none of the GSI key attributes,
+// so it never appears in request queries.
+func GrantCounterKey(channelID, accountID string) string {
+	return "grants#" + channelID + "#" + accountID
+}
+
+// ScanCursorKey returns the synthetic request_id of the requests-table item
+// that records where the named periodic table scan stopped. Like the grant
+// counters, it has none of the GSI key attributes.
+func ScanCursorKey(name string) string {
+	return "cursor#" + name
+}
+
+// MyRequestsInput for GET /requests/mine query parameters
+type MyRequestsInput struct {
+	RequesterEmail string `json:"requester_email"`
+	NextToken      string `json:"next_token"`
+	Limit          int    `json:"limit"`
+}
+
+// ConfigReportInput for GET /admin/config-report query parameters
+type ConfigReportInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	NextToken     string `json:"next_token"`
+	Limit         int    `json:"limit"`
+}
+
+// ActiveGrantsInput for GET /admin/active-grants query parameters
+type ActiveGrantsInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	NextToken     string `json:"next_token"`
+	Limit         int    `json:"limit"`
+}
+
+// ActiveGrant is one currently GRANTED request in the active-grants view.
+type ActiveGrant struct {
+	RequestID         string `json:"request_id"`
+	AccountID         string `json:"account_id"`
+	ChannelID         string `json:"channel_id"`
+	RequesterMMUserID string `json:"requester_mm_user_id,omitempty"`
+	RequesterEmail    string `json:"requester_email"`
+	ApproverEmail     string `json:"approver_email,omitempty"`
+	GrantTime         string `json:"grant_time,omitempty"`
+	EndTime           string `json:"end_time"`
+}
+
+// ActiveGrantsResponse is the response shape for GET /admin/active-grants.
+// Items are ordered by end_time, soonest first.
+type ActiveGrantsResponse struct {
+	Items     []ActiveGrant `json:"items"`
+	NextToken string        `json:"next_token,omitempty"`
+	HasMore   bool          `json:"has_more"`
+	Count     int           `json:"count"`
+}
+
+// RecountGrantsInput for POST /admin/active-grants/recount
+type RecountGrantsInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	ActorEmail    string `json:"actor_email"`
+	ChannelID     string `json:"channel_id"`
+	AccountID     string `json:"account_id"`
+}
+
+// RecountGrantsResponse is the response shape for
+// POST /admin/active-grants/recount: the binding's active-grant counter as
+// rebuilt from its GRANTED requests.
+type RecountGrantsResponse struct {
+	ChannelID    string `json:"channel_id"`
+	AccountID    string `json:"account_id"`
+	ActiveGrants int    `json:"active_grants"`
+}
+
+// AccountBindingsInput for GET /admin/account/{id}/bindings
+type AccountBindingsInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	AccountID     string `json:"account_id"`
+}
+
+// AccountBindingsResponse lists every channel an account is bound to.
+// Duplicate is set when there is more than one.
+type AccountBindingsResponse struct {
+	AccountID string      `json:"account_id"`
+	Bindings  []JitConfig `json:"bindings"`
+	Count     int         `json:"count"`
+	Duplicate bool        `json:"duplicate"`
+}
+
+// Config report issue flags
+const (
+	ConfigIssueNoApprovers     = "no_approvers"
+	ConfigIssueNoMaxRequestHrs = "missing_max_request_hours"
+)
+
+// ConfigReportEntry describes one binding in the config report.
+type ConfigReportEntry struct {
+	ChannelID       string   `json:"channel_id"`
+	AccountID       string   `json:"account_id"`
+	ApproverCount   int      `json:"approver_count"`
+	MaxRequestHours int      `json:"max_request_hours"`
+	Issues          []string `json:"issues,omitempty"`
+}
+
+// ConfigReportResponse is the response shape for GET /admin/config-report
+type ConfigReportResponse struct {
+	Items     []ConfigReportEntry `json:"items"`
+	NextToken string              `json:"next_token,omitempty"`
+	HasMore   bool                `json:"has_more"`
+	Count     int                 `json:"count"`
 }
 
 // SetApproversInput for POST /config/approvers
 type SetApproversInput struct {
-	ChannelID   string   `json:"channel_id"`
-	ApproverIDs []string `json:"approver_ids"`
+	ChannelID     string   `json:"channel_id"`
+	ApproverIDs   []string `json:"approver_ids"`
+	ActorMMUserID string   `json:"actor_mm_user_id,omitempty"`
+	ActorEmail    string   `json:"actor_email,omitempty"`
+}
+
+// PauseChannelInput for POST /config/pause and POST /config/resume. Reason is
+// only used when pausing.
+type PauseChannelInput struct {
+	ChannelID     string `json:"channel_id"`
+	Reason        string `json:"reason,omitempty"`
+	ActorMMUserID string `json:"actor_mm_user_id,omitempty"`
+	ActorEmail    string `json:"actor_email,omitempty"`
+}
+
+// SetAccountApproversInput for POST /config/account/{id}/approvers
+type SetAccountApproversInput struct {
+	ChannelID     string   `json:"channel_id"`
+	AccountID     string   `json:"account_id"`
+	ApproverIDs   []string `json:"approver_ids"`
+	ActorMMUserID string   `json:"actor_mm_user_id,omitempty"`
+	ActorEmail    string   `json:"actor_email,omitempty"`
+}
+
+// MaxImportBatch is the largest number of grants accepted by one POST /admin/import.
+const MaxImportBatch = 100
+
+// ImportedGrant is one pre-existing grant from an external approval system.
+// ChannelID may be omitted, in which case the account's binding is looked up.
+type ImportedGrant struct {
+	RequestID         string `json:"request_id"`
+	ChannelID         string `json:"channel_id,omitempty"`
+	AccountID         string `json:"account_id"`
+	RequesterEmail    string `json:"requester_email"`
+	ApproverEmail     string `json:"approver_email"`
+	GrantTime         string `json:"grant_time"`
+	EndTime           string `json:"end_time"`
+	Status            string `json:"status"`
+	Reason            string `json:"reason,omitempty"`
+	RequesterMMUserID string `json:"requester_mm_user_id,omitempty"`
+}
+
+// ImportGrantsInput for POST /admin/import
+type ImportGrantsInput struct {
+	ActorMMUserID string          `json:"actor_mm_user_id"`
+	ActorEmail    string          `json:"actor_email,omitempty"`
+	Grants        []ImportedGrant `json:"grants"`
+}
+
+// ImportGrantsResponse is the response shape for POST /admin/import.
+// Skipped lists request IDs that were already imported by an earlier call.
+type ImportGrantsResponse struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+// WebhookFailuresInput for GET /admin/webhook-failures query parameters
+type WebhookFailuresInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	NextToken     string `json:"next_token"`
+	Limit         int    `json:"limit"`
+}
+
+// WebhookFailuresResponse is the response shape for GET /admin/webhook-failures.
+type WebhookFailuresResponse struct {
+	Items     []WebhookFailure `json:"items"`
+	NextToken string           `json:"next_token,omitempty"`
+	HasMore   bool             `json:"has_more"`
+	Count     int              `json:"count"`
+}
+
+// AuditExportInput for GET /admin/audit/export query parameters.
+type AuditExportInput struct {
+	ActorMMUserID string `json:"actor_mm_user_id"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	NextToken     string `json:"next_token"`
+	Limit         int    `json:"limit"`
+}
+
+// AuditExportResponse is the response shape for GET /admin/audit/export.
+type AuditExportResponse struct {
+	Items     []AuditEvent `json:"items"`
+	NextToken string       `json:"next_token,omitempty"`
+	HasMore   bool         `json:"has_more"`
+	Count     int          `json:"count"`
+}
+
+// MaxAuditExportDays is the longest date range one GET /admin/audit/export
+// may cover.
+const MaxAuditExportDays = 366
+
+// MaxRedriveBatch is the largest number of failures re-sent by one
+// POST /admin/webhook-failures/redrive.
+const MaxRedriveBatch = 25
+
+// RedriveWebhooksInput for POST /admin/webhook-failures/redrive
+type RedriveWebhooksInput struct {
+	ActorMMUserID string   `json:"actor_mm_user_id"`
+	ActorEmail    string   `json:"actor_email"`
+	FailureIDs    []string `json:"failure_ids"`
+}
+
+// RedriveFailure reports a failure that could not be re-sent.
+type RedriveFailure struct {
+	FailureID string `json:"failure_id"`
+	Error     string `json:"error"`
+}
+
+// RedriveWebhooksResponse is the response shape for
+// POST /admin/webhook-failures/redrive. Redelivered failures are removed
+// from the log; the rest stay with their attempt count bumped.
+type RedriveWebhooksResponse struct {
+	Redelivered []string         `json:"redelivered"`
+	Failed      []RedriveFailure `json:"failed"`
+}
+
+// RequestExportBundle is the signed content of a compliance export: the
+// request as stored plus its full audit chain in event order.
+type RequestExportBundle struct {
+	ExportedAt  string       `json:"exported_at"`
+	ExportedBy  string       `json:"exported_by"`
+	Request     JitRequest   `json:"request"`
+	AuditEvents []AuditEvent `json:"audit_events"`
+}
+
+// RequestExportResponse is the response shape for GET /requests/{id}/export.
+// Signature is an HMAC over the exact bytes of Bundle, which are the
+// canonical (compact JSON) encoding of a RequestExportBundle.
+type RequestExportResponse struct {
+	Bundle    json.RawMessage `json:"bundle"`
+	Signature string          `json:"signature"`
 }
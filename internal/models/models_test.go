@@ -0,0 +1,159 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeLimit(t *testing.T) {
+	cases := []struct {
+		requested, want int
+	}{
+		{0, DefaultQueryLimit},
+		{-5, DefaultQueryLimit},
+		{1, 1},
+		{75, 75},
+		{MaxQueryLimit, MaxQueryLimit},
+		{MaxQueryLimit + 1, MaxQueryLimit},
+		{10000, MaxQueryLimit},
+	}
+	for _, tc := range cases {
+		if got := NormalizeLimit(tc.requested); got != tc.want {
+			t.Errorf("NormalizeLimit(%d) = %d, want %d", tc.requested, got, tc.want)
+		}
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	cases := []struct {
+		name, id, want string
+	}{
+		{"valid", "abc123def456ghi789jkl012mn", ""},
+		{"empty", "", "is required"},
+		{"leading space", " abc", "whitespace or control"},
+		{"trailing newline", "abc\n", "whitespace or control"},
+		{"embedded tab", "ab\tc", "whitespace or control"},
+		{"non-breaking space", "ab\u00a0c", "whitespace or control"},
+		{"control character", "ab\x7fc", "whitespace or control"},
+		{"invalid utf-8", "ab\xffc", "valid UTF-8"},
+		{"max length", strings.Repeat("a", MaxIDLength), ""},
+		{"oversized", strings.Repeat("a", MaxIDLength+1), "at most"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateID("channel_id", tc.id)
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("expected %q to be valid, got: %v", tc.id, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.HasPrefix(err.Error(), "channel_id ") {
+				t.Errorf("expected channel_id error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	statuses := []string{
+		StatusPending, StatusApproved, StatusDenied, StatusGranted,
+		StatusRevoked, StatusExpired, StatusError,
+	}
+	allowed := map[[2]string]bool{
+		{StatusPending, StatusApproved}: true,
+		{StatusPending, StatusDenied}:   true,
+		{StatusApproved, StatusGranted}: true,
+		{StatusApproved, StatusError}:   true,
+		{StatusGranted, StatusRevoked}:  true,
+		{StatusGranted, StatusExpired}:  true,
+		{StatusGranted, StatusError}:    true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	if CanTransition(StatusPending, "") || CanTransition("", StatusApproved) {
+		t.Error("expected empty statuses to be rejected")
+	}
+	if CanTransition(StatusGranted, StatusExpiringSoon) {
+		t.Error("expected webhook-only status to be rejected")
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	got, err := ParseStatuses(" PENDING,APPROVED ,,PENDING,GRANTED")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "PENDING,APPROVED,GRANTED" {
+		t.Errorf("expected trimmed, de-duplicated statuses in order, got %v", got)
+	}
+
+	for _, s := range []string{"pending", "PENDING,EXPIRING_SOON", "ACTIVE"} {
+		if _, err := ParseStatuses(s); err == nil || !strings.Contains(err.Error(), "invalid status") {
+			t.Errorf("%q: expected invalid status error, got %v", s, err)
+		}
+	}
+}
+
+func TestParseRequestFields(t *testing.T) {
+	got, err := ParseRequestFields(" status,end_time ,,status,request_id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "request_id,status,end_time" {
+		t.Errorf("expected request_id first, then trimmed, de-duplicated fields, got %v", got)
+	}
+
+	for _, s := range []string{"Status", "status,error_info", "identity_store_user_id"} {
+		if _, err := ParseRequestFields(s); err == nil || !strings.Contains(err.Error(), "invalid field") {
+			t.Errorf("%q: expected invalid field error, got %v", s, err)
+		}
+	}
+}
+
+func TestJitRequest_PauseAndResumeClock(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2025, 6, 1, hour, min, 0, 0, time.UTC) }
+	req := &JitRequest{RequestID: "req-1", EndTime: "2025-06-01T10:00:00Z"}
+	if req.ExpiresAt() != req.EndTime || req.ClockPaused() {
+		t.Fatalf("expected a never-paused grant to expire at end_time, got %q", req.ExpiresAt())
+	}
+	if _, err := req.ResumeClockUpdates(at(9, 0)); err == nil {
+		t.Error("expected an error resuming a clock that is not paused")
+	}
+
+	// Two pauses, of 15 and 30 minutes, add up.
+	for _, p := range []struct{ from, to time.Time }{{at(8, 0), at(8, 15)}, {at(9, 0), at(9, 30)}} {
+		updates, err := req.PauseClockUpdates(p.from)
+		if err != nil {
+			t.Fatalf("pause: %v", err)
+		}
+		req.PausedAt = updates["paused_at"].(string)
+		if _, err := req.PauseClockUpdates(p.from); err == nil {
+			t.Error("expected an error pausing a paused clock")
+		}
+
+		updates, err = req.ResumeClockUpdates(p.to)
+		if err != nil {
+			t.Fatalf("resume: %v", err)
+		}
+		if updates["paused_at"] != "" {
+			t.Errorf("expected paused_at cleared, got %v", updates["paused_at"])
+		}
+		req.PausedAt = ""
+		req.PausedSeconds = updates["paused_seconds"].(int)
+		req.EffectiveEndTime = updates["effective_end_time"].(string)
+	}
+
+	if req.PausedSeconds != 45*60 || req.ExpiresAt() != "2025-06-01T10:45:00Z" || req.EndTime != "2025-06-01T10:00:00Z" {
+		t.Errorf("expected 45 minutes paused and expiry at 10:45 with end_time kept, got %+v", req)
+	}
+}
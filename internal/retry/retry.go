@@ -0,0 +1,110 @@
+// Package retry runs a call again after a schedule of backoffs while it fails
+// with errors that a later attempt could clear.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Policy describes how Do retries a call.
+type Policy struct {
+	// Backoffs are the waits before each retry, so the call is attempted at
+	// most len(Backoffs)+1 times.
+	Backoffs []time.Duration
+
+	// Jitter, when positive, spreads each wait uniformly over that fraction
+	// either side of the backoff, so callers recovering from the same
+	// failure do not retry in lockstep.
+	Jitter float64
+
+	// Rand returns a value in [0, 1) for jitter. Defaults to math/rand.
+	Rand func() float64
+
+	// Retryable reports whether a failed attempt may succeed if repeated.
+	// Nil retries every error.
+	Retryable func(error) bool
+
+	// Delay, when set, adjusts the (jittered) wait before a retry given the
+	// error that caused it, e.g. to honour a server's Retry-After.
+	Delay func(wait time.Duration, err error) time.Duration
+
+	// OnError, when set, is called after each failed attempt, numbered from
+	// zero, with whether another attempt will follow.
+	OnError func(attempt int, err error, retrying bool)
+
+	// OnRetry, when set, is called before waiting for each retry, numbered
+	// from one, with the error that caused it and the wait.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// ExhaustedError is returned by Do when the last permitted attempt failed
+// with a retryable error. It reads and unwraps as that error.
+type ExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *ExhaustedError) Error() string { return e.Err.Error() }
+func (e *ExhaustedError) Unwrap() error { return e.Err }
+
+// Do calls fn until it succeeds, fails with an error the policy does not
+// retry, or the backoffs run out. It returns nil, the non-retryable error
+// as-is, an *ExhaustedError wrapping the last error, or ctx.Err() if ctx is
+// done while waiting to retry.
+func Do(ctx context.Context, p Policy, fn func() error) error {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			wait := p.wait(p.Backoffs[attempt-1], lastErr)
+			if p.OnRetry != nil {
+				p.OnRetry(attempt, lastErr, wait)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		retryable := p.Retryable == nil || p.Retryable(err)
+		retrying := retryable && attempt < len(p.Backoffs)
+		if p.OnError != nil {
+			p.OnError(attempt, err, retrying)
+		}
+		if !retryable {
+			return err
+		}
+		if !retrying {
+			return &ExhaustedError{Attempts: attempt + 1, Err: err}
+		}
+		lastErr = err
+	}
+}
+
+// wait returns how long to wait after backoff, before the retry caused by err.
+func (p Policy) wait(backoff time.Duration, err error) time.Duration {
+	wait := backoff
+	if p.Jitter > 0 {
+		rnd := p.Rand
+		if rnd == nil {
+			rnd = rand.Float64
+		}
+		wait = Jitter(backoff, p.Jitter, rnd())
+	}
+	if p.Delay != nil {
+		wait = p.Delay(wait, err)
+	}
+	return wait
+}
+
+// Jitter spreads d uniformly over [d*(1-fraction), d*(1+fraction)] as r
+// ranges over [0, 1).
+func Jitter(d time.Duration, fraction, r float64) time.Duration {
+	return d + time.Duration(float64(d)*fraction*(2*r-1))
+}
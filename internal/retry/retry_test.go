@@ -0,0 +1,165 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failing returns a call that fails its first n invocations with err and
+// counts every invocation in calls.
+func failing(n int, err error, calls *int) func() error {
+	return func() error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func shortBackoffs(n int) []time.Duration {
+	backoffs := make([]time.Duration, n)
+	for i := range backoffs {
+		backoffs[i] = time.Millisecond
+	}
+	return backoffs
+}
+
+func TestDo_SucceedsAfterRetries(t *testing.T) {
+	var calls int
+	var failed, retried []int
+	err := Do(context.Background(), Policy{
+		Backoffs: shortBackoffs(3),
+		OnError:  func(attempt int, _ error, _ bool) { failed = append(failed, attempt) },
+		OnRetry:  func(attempt int, _ error, _ time.Duration) { retried = append(retried, attempt) },
+	}, failing(2, errors.New("boom"), &calls))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if len(failed) != 2 || failed[0] != 0 || failed[1] != 1 {
+		t.Errorf("expected failed attempts 0 and 1, got %v", failed)
+	}
+	if len(retried) != 2 || retried[0] != 1 || retried[1] != 2 {
+		t.Errorf("expected retries 1 and 2, got %v", retried)
+	}
+}
+
+func TestDo_Exhausted(t *testing.T) {
+	cause := errors.New("boom")
+	var calls int
+	lastRetrying := true
+	err := Do(context.Background(), Policy{
+		Backoffs: shortBackoffs(2),
+		OnError:  func(_ int, _ error, retrying bool) { lastRetrying = retrying },
+	}, failing(100, cause, &calls))
+
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("expected *ExhaustedError, got: %v", err)
+	}
+	if exhausted.Attempts != 3 || calls != 3 {
+		t.Errorf("expected 3 attempts, got %d (%d calls)", exhausted.Attempts, calls)
+	}
+	if !errors.Is(err, cause) || err.Error() != "boom" {
+		t.Errorf("expected the last error wrapped unchanged, got %q", err)
+	}
+	if lastRetrying {
+		t.Error("expected the final failure reported as not retrying")
+	}
+}
+
+func TestDo_NoBackoffsIsSingleAttempt(t *testing.T) {
+	var calls int
+	err := Do(context.Background(), Policy{}, failing(100, errors.New("boom"), &calls))
+
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) || calls != 1 {
+		t.Errorf("expected one attempt then *ExhaustedError, got %d calls and %v", calls, err)
+	}
+}
+
+func TestDo_NonRetryableReturnedAsIs(t *testing.T) {
+	permanent := errors.New("bad request")
+	var calls int
+	err := Do(context.Background(), Policy{
+		Backoffs:  shortBackoffs(3),
+		Retryable: func(err error) bool { return !errors.Is(err, permanent) },
+	}, failing(100, permanent, &calls))
+
+	if err != permanent {
+		t.Errorf("expected the non-retryable error unwrapped, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry, got %d attempts", calls)
+	}
+}
+
+func TestDo_ContextCancelledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	start := time.Now()
+	err := Do(ctx, Policy{
+		Backoffs: []time.Duration{time.Minute},
+		OnError:  func(int, error, bool) { cancel() },
+	}, failing(100, errors.New("boom"), &calls))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no attempt after cancellation, got %d", calls)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected cancellation to cut the wait short")
+	}
+}
+
+func TestDo_DelayAndJitter(t *testing.T) {
+	var waits []time.Duration
+	var calls int
+	err := Do(context.Background(), Policy{
+		Backoffs: []time.Duration{4 * time.Millisecond, 8 * time.Millisecond},
+		Jitter:   0.25,
+		Rand:     func() float64 { return 0 },
+		Delay: func(wait time.Duration, _ error) time.Duration {
+			if calls == 2 {
+				return time.Millisecond
+			}
+			return wait
+		},
+		OnRetry: func(_ int, _ error, wait time.Duration) { waits = append(waits, wait) },
+	}, failing(2, errors.New("boom"), &calls))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(waits) != 2 || waits[0] != 3*time.Millisecond || waits[1] != time.Millisecond {
+		t.Errorf("expected a jittered first wait and an overridden second, got %v", waits)
+	}
+}
+
+func TestJitter_Bounds(t *testing.T) {
+	base := 4 * time.Second
+	lower := time.Duration(float64(base) * 0.75)
+	upper := time.Duration(float64(base) * 1.25)
+
+	if got := Jitter(base, 0.25, 0); got != lower {
+		t.Errorf("expected lower bound %s, got %s", lower, got)
+	}
+	if got := Jitter(base, 0.25, 0.5); got != base {
+		t.Errorf("expected unchanged backoff at midpoint, got %s", got)
+	}
+
+	p := Policy{Jitter: 0.25}
+	for i := 0; i < 1000; i++ {
+		if got := p.wait(base, nil); got < lower || got > upper {
+			t.Fatalf("jittered backoff %s outside [%s, %s]", got, lower, upper)
+		}
+	}
+}
@@ -4,94 +4,556 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/dgwhited/jit-aws-controller/internal/auth"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
+	"github.com/dgwhited/jit-aws-controller/internal/retry"
 )
 
 // Client sends signed webhook notifications to the plugin.
 type Client struct {
-	webhookURL string
-	keyID      string
-	secret     string
-	httpClient *http.Client
+	webhookURL   string
+	fallbackURLs []string
+	keyID        string
+	secret       string
+	signingPath  string
+	batchPath    string
+	successCodes map[int]bool
+	failures     FailureRecorder
+	httpClient   *http.Client
+	disabled     bool
+
+	// Per-binding destinations. clients caches a Client per URL and key ID.
+	destinations DestinationLookup
+	keys         map[string]string
+	mu           sync.Mutex
+	clients      map[string]*Client
+}
+
+// DestinationLookup finds the binding a notification belongs to, whose
+// WebhookURL and WebhookKeyID override the client's own endpoint and key.
+type DestinationLookup interface {
+	GetConfig(ctx context.Context, channelID, accountID string) (*models.JitConfig, error)
+}
+
+// FailureRecorder persists notifications that could not be delivered so they
+// can be re-sent later.
+type FailureRecorder interface {
+	PutWebhookFailure(ctx context.Context, failure *models.WebhookFailure) error
+}
+
+// DefaultSigningPath is the path covered by the HMAC signature when none is
+// configured. It must match where the plugin receives webhooks.
+const DefaultSigningPath = "/jit/webhook"
+
+// DefaultBatchPath is appended to the webhook URL and signing path to reach
+// the plugin's batch endpoint when none is configured.
+const DefaultBatchPath = "/batch"
+
+// maxBatchSize is the most payloads sent in a single batch request.
+const maxBatchSize = 25
+
+// Option configures optional Client behavior.
+type Option func(*Client)
+
+// WithFallbackURLs sets an ordered list of endpoints to try when the primary
+// webhook URL cannot be reached.
+func WithFallbackURLs(urls ...string) Option {
+	return func(c *Client) {
+		c.fallbackURLs = urls
+	}
+}
+
+// WithSigningPath sets the request path included in the HMAC signing message.
+// An empty path keeps DefaultSigningPath.
+func WithSigningPath(path string) Option {
+	return func(c *Client) {
+		if path != "" {
+			c.signingPath = path
+		}
+	}
+}
+
+// WithBatchPath sets the path appended to each webhook URL and to the signing
+// path for NotifyBatch requests. An empty path keeps DefaultBatchPath.
+func WithBatchPath(path string) Option {
+	return func(c *Client) {
+		if path != "" {
+			c.batchPath = path
+		}
+	}
+}
+
+// WithTerminalSuccessCodes treats the given non-2xx status codes as delivered,
+// for plugins that answer duplicates with e.g. 409 Conflict.
+func WithTerminalSuccessCodes(codes ...int) Option {
+	return func(c *Client) {
+		c.successCodes = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			c.successCodes[code] = true
+		}
+	}
+}
+
+// FailureRetention is how long an undelivered notification is kept for
+// re-sending before its record expires.
+const FailureRetention = 14 * 24 * time.Hour
+
+// WithFailureRecorder records every notification that is still undelivered
+// after retries, so it can be listed and re-sent from the admin API.
+func WithFailureRecorder(r FailureRecorder) Option {
+	return func(c *Client) {
+		c.failures = r
+	}
+}
+
+// WithChannelDestinations sends each notification to its binding's
+// WebhookURL, when set, instead of the client's URL and fallbacks. The
+// binding's WebhookKeyID selects the signing secret from keys; bindings
+// without one are signed with the client's own key.
+func WithChannelDestinations(lookup DestinationLookup, keys map[string]string) Option {
+	return func(c *Client) {
+		c.destinations = lookup
+		c.keys = keys
+	}
+}
+
+// ErrDisabled is returned by Deliver on a client created WithDisabled.
+var ErrDisabled = errors.New("webhook notifications are disabled")
+
+// WithDisabled turns the client into a no-op for environments with no plugin
+// to notify. Notify and NotifyBatch send nothing, log the suppressed
+// notification at debug level, and report success. Deliver, which re-sends
+// recorded failures, returns ErrDisabled so those records are kept.
+func WithDisabled() Option {
+	return func(c *Client) {
+		c.disabled = true
+	}
 }
 
 // NewClient creates a new webhook client.
-func NewClient(webhookURL, keyID, secret string) *Client {
-	return &Client{
-		webhookURL: webhookURL,
-		keyID:      keyID,
-		secret:     secret,
+func NewClient(webhookURL, keyID, secret string, opts ...Option) *Client {
+	c := &Client{
+		webhookURL:  webhookURL,
+		keyID:       keyID,
+		secret:      secret,
+		signingPath: DefaultSigningPath,
+		batchPath:   DefaultBatchPath,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// connectionError marks a send failure where no HTTP response was received,
+// which is the only kind of failure that triggers failover.
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string { return e.err.Error() }
+func (e *connectionError) Unwrap() error { return e.err }
+
+// statusError is an HTTP error response from the plugin.
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("webhook returned status %d", e.code) }
+
+// retryable reports whether a later attempt could succeed. Client errors other
+// than 429 will fail the same way every time.
+func (e *statusError) retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
 }
 
-// retryBackoffs for webhook delivery attempts.
+// retryBackoffs for webhook delivery attempts, before jitter.
 var retryBackoffs = []time.Duration{
 	1 * time.Second,
 	2 * time.Second,
 	4 * time.Second,
 }
 
+// jitterFraction is the largest share of a backoff added or removed at random,
+// so that callers recovering from the same outage do not retry in lockstep.
+const jitterFraction = 0.25
+
+// jitterSource returns a value in [0, 1). Tests replace it for determinism.
+var jitterSource = rand.Float64
+
+// maxRetryAfter caps how long a 429 Retry-After header can delay a retry.
+var maxRetryAfter = 30 * time.Second
+
 // Notify sends a webhook payload to the plugin with HMAC signing and retry.
+// A payload that is still undelivered after retries is recorded with the
+// client's FailureRecorder, if any.
 func (c *Client) Notify(ctx context.Context, payload models.WebhookPayload) error {
+	if c.disabled {
+		c.logSuppressed(ctx, payload)
+		return nil
+	}
+	err := c.Deliver(ctx, payload)
+	if err != nil {
+		c.recordFailure(ctx, payload, err)
+	}
+	return err
+}
+
+// Deliver sends a webhook payload like Notify but never records a failure.
+// It is used to re-send payloads that are already recorded.
+func (c *Client) Deliver(ctx context.Context, payload models.WebhookPayload) error {
+	if c.disabled {
+		return ErrDisabled
+	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("webhook marshal: %w", err)
 	}
 
-	var lastErr error
-	for attempt := 0; attempt <= len(retryBackoffs); attempt++ {
-		if attempt > 0 {
+	dest, err := c.destination(ctx, payload.ChannelID, payload.AccountID)
+	if err != nil {
+		return fmt.Errorf("webhook notify: %w", err)
+	}
+	if _, err := dest.deliver(ctx, body, "", payload.RequestID); err != nil {
+		return fmt.Errorf("webhook notify: %w", err)
+	}
+	slog.Info("webhook notification sent",
+		"request_id", payload.RequestID,
+		"status", payload.Status,
+	)
+	return nil
+}
+
+// logSuppressed records a notification dropped by a disabled client.
+func (c *Client) logSuppressed(ctx context.Context, payload models.WebhookPayload) {
+	slog.DebugContext(ctx, "webhook notification suppressed, notifications are disabled",
+		"request_id", payload.RequestID,
+		"status", payload.Status,
+	)
+}
+
+// destination returns the client that delivers notifications for a binding:
+// a client for the binding's own webhook, or c itself when it has none.
+func (c *Client) destination(ctx context.Context, channelID, accountID string) (*Client, error) {
+	if c.destinations == nil || channelID == "" || accountID == "" {
+		return c, nil
+	}
+	cfg, err := c.destinations.GetConfig(ctx, channelID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("lookup webhook destination: %w", err)
+	}
+	if cfg == nil || cfg.WebhookURL == "" {
+		return c, nil
+	}
+
+	keyID, secret := c.keyID, c.secret
+	if cfg.WebhookKeyID != "" {
+		var ok bool
+		keyID = cfg.WebhookKeyID
+		if secret, ok = c.keys[keyID]; !ok {
+			return nil, fmt.Errorf("webhook key %s for channel %s is not in the callback signing secret", keyID, channelID)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cacheKey := cfg.WebhookURL + "|" + keyID
+	if dest, ok := c.clients[cacheKey]; ok {
+		return dest, nil
+	}
+	dest := &Client{
+		webhookURL:   cfg.WebhookURL,
+		keyID:        keyID,
+		secret:       secret,
+		signingPath:  c.signingPath,
+		batchPath:    c.batchPath,
+		successCodes: c.successCodes,
+		failures:     c.failures,
+		httpClient:   c.httpClient,
+	}
+	if c.clients == nil {
+		c.clients = make(map[string]*Client)
+	}
+	c.clients[cacheKey] = dest
+	return dest, nil
+}
+
+// recordFailure persists an undelivered payload. Recording runs even if ctx
+// was cancelled, since a cancelled delivery is exactly what should be kept.
+func (c *Client) recordFailure(ctx context.Context, payload models.WebhookPayload, cause error) {
+	if c.failures == nil {
+		return
+	}
+	now := time.Now().UTC()
+	failure := &models.WebhookFailure{
+		FailureID: uuid.New().String(),
+		RequestID: payload.RequestID,
+		Payload:   payload,
+		LastError: cause.Error(),
+		FailedAt:  now.Format(time.RFC3339),
+		Attempts:  1,
+		ExpiresAt: now.Add(FailureRetention).Unix(),
+	}
+	if err := c.failures.PutWebhookFailure(context.WithoutCancel(ctx), failure); err != nil {
+		slog.Error("failed to record undelivered webhook",
+			"request_id", payload.RequestID,
+			"status", payload.Status,
+			"error", err,
+		)
+	}
+}
+
+// BatchError reports the payloads of a NotifyBatch call that were not
+// delivered. Everything else in the batch was accepted.
+type BatchError struct {
+	RequestIDs []string
+	Total      int
+	// Cause is the last transport error, if a whole request failed.
+	Cause error
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("webhook batch: %d of %d notifications failed", len(e.RequestIDs), e.Total)
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *BatchError) Unwrap() error { return e.Cause }
+
+// batchResponse is the body the plugin's batch endpoint returns. Request IDs
+// listed in Failed were rejected; the rest were accepted.
+type batchResponse struct {
+	Failed []string `json:"failed"`
+}
+
+// NotifyBatch posts payloads to the plugin's batch endpoint as JSON arrays of
+// at most maxBatchSize, each signed and retried like Notify. Payloads for
+// bindings with their own webhook are batched separately to that webhook.
+// It returns a *BatchError naming every payload that was not delivered.
+func (c *Client) NotifyBatch(ctx context.Context, payloads []models.WebhookPayload) error {
+	if c.disabled {
+		for _, p := range payloads {
+			c.logSuppressed(ctx, p)
+		}
+		return nil
+	}
+	if c.destinations == nil {
+		return c.notifyBatch(ctx, payloads)
+	}
+
+	type group struct {
+		dest     *Client
+		payloads []models.WebhookPayload
+	}
+	var groups []*group
+	byDest := map[*Client]*group{}
+	byBinding := map[string]*Client{}
+	var failed []string
+	var cause error
+	for _, p := range payloads {
+		binding := p.ChannelID + "|" + p.AccountID
+		dest, ok := byBinding[binding]
+		if !ok {
+			var err error
+			if dest, err = c.destination(ctx, p.ChannelID, p.AccountID); err != nil {
+				failed = append(failed, p.RequestID)
+				cause = err
+				c.recordFailure(ctx, p, err)
+				continue
+			}
+			byBinding[binding] = dest
+		}
+		g, ok := byDest[dest]
+		if !ok {
+			g = &group{dest: dest}
+			byDest[dest] = g
+			groups = append(groups, g)
+		}
+		g.payloads = append(g.payloads, p)
+	}
+
+	for _, g := range groups {
+		err := g.dest.notifyBatch(ctx, g.payloads)
+		var batchErr *BatchError
+		switch {
+		case err == nil:
+		case errors.As(err, &batchErr):
+			failed = append(failed, batchErr.RequestIDs...)
+			if batchErr.Cause != nil {
+				cause = batchErr.Cause
+			}
+		default:
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return &BatchError{RequestIDs: failed, Total: len(payloads), Cause: cause}
+	}
+	return nil
+}
+
+// notifyBatch sends payloads in chunks to c's own batch endpoint.
+func (c *Client) notifyBatch(ctx context.Context, payloads []models.WebhookPayload) error {
+	var failed []string
+	var cause error
+	for start := 0; start < len(payloads); start += maxBatchSize {
+		chunk := payloads[start:min(start+maxBatchSize, len(payloads))]
+		ids := make([]string, len(chunk))
+		for i, p := range chunk {
+			ids[i] = p.RequestID
+		}
+
+		body, err := json.Marshal(chunk)
+		if err != nil {
+			return fmt.Errorf("webhook batch marshal: %w", err)
+		}
+		respBody, err := c.deliver(ctx, body, c.batchPath, fmt.Sprintf("batch of %d", len(chunk)))
+		if err != nil {
+			failed = append(failed, ids...)
+			cause = err
+			for _, p := range chunk {
+				c.recordFailure(ctx, p, err)
+			}
+			continue
+		}
+
+		var resp batchResponse
+		if len(bytes.TrimSpace(respBody)) > 0 {
+			if err := json.Unmarshal(respBody, &resp); err != nil {
+				slog.Warn("unreadable webhook batch response, assuming all delivered", "error", err)
+			}
+		}
+		failed = append(failed, resp.Failed...)
+		if len(resp.Failed) > 0 {
+			rejected := make(map[string]bool, len(resp.Failed))
+			for _, id := range resp.Failed {
+				rejected[id] = true
+			}
+			for _, p := range chunk {
+				if rejected[p.RequestID] {
+					c.recordFailure(ctx, p, errors.New("rejected by plugin batch endpoint"))
+				}
+			}
+		}
+		slog.Info("webhook batch sent",
+			"count", len(chunk),
+			"failed", len(resp.Failed),
+		)
+	}
+
+	if len(failed) > 0 {
+		return &BatchError{RequestIDs: failed, Total: len(payloads), Cause: cause}
+	}
+	return nil
+}
+
+// deliver sends body with retries, appending suffix to the endpoint URL and
+// signing path. It returns the successful response body. label identifies the
+// delivery in logs.
+func (c *Client) deliver(ctx context.Context, body []byte, suffix, label string) ([]byte, error) {
+	var respBody []byte
+	err := retry.Do(ctx, retry.Policy{
+		Backoffs:  retryBackoffs,
+		Jitter:    jitterFraction,
+		Rand:      jitterSource,
+		Retryable: retryable,
+		Delay: func(wait time.Duration, err error) time.Duration {
+			var statusErr *statusError
+			if errors.As(err, &statusErr) && statusErr.retryAfter > 0 {
+				return statusErr.retryAfter
+			}
+			return wait
+		},
+		OnError: func(attempt int, err error, _ bool) {
+			slog.Error("webhook send failed",
+				"attempt", attempt,
+				"error", err,
+			)
+		},
+		OnRetry: func(attempt int, _ error, wait time.Duration) {
 			slog.Warn("retrying webhook notification",
 				"attempt", attempt,
-				"request_id", payload.RequestID,
+				"request_id", label,
+				"delay", wait,
 			)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(retryBackoffs[attempt-1]):
-			}
-		}
+		},
+	}, func() error {
+		var err error
+		respBody, err = c.sendWithFailover(ctx, body, suffix, label)
+		return err
+	})
+	var exhausted *retry.ExhaustedError
+	if errors.As(err, &exhausted) {
+		return nil, fmt.Errorf("failed after retries: %w", exhausted.Err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return respBody, nil
+}
 
-		err := c.send(ctx, body)
-		if err == nil {
-			slog.Info("webhook notification sent",
-				"request_id", payload.RequestID,
-				"status", payload.Status,
+// retryable reports whether a failed send could succeed on a later attempt.
+// Everything but a non-retryable HTTP error response is retried.
+func retryable(err error) bool {
+	var statusErr *statusError
+	return !errors.As(err, &statusErr) || statusErr.retryable()
+}
+
+// sendWithFailover delivers to the primary URL, advancing through the fallback
+// URLs only on connection-level failures. An HTTP error response ends the
+// attempt, since the endpoint is up and the retry loop should handle it.
+func (c *Client) sendWithFailover(ctx context.Context, body []byte, suffix, label string) ([]byte, error) {
+	urls := append([]string{c.webhookURL}, c.fallbackURLs...)
+
+	var respBody []byte
+	var err error
+	for i, url := range urls {
+		if i > 0 {
+			slog.Warn("failing over webhook endpoint",
+				"request_id", label,
+				"endpoint_index", i,
 			)
-			return nil
 		}
-		lastErr = err
-		slog.Error("webhook send failed",
-			"attempt", attempt,
-			"error", err,
-		)
+		respBody, err = c.send(ctx, url+suffix, c.signingPath+suffix, body)
+		var connErr *connectionError
+		if err == nil || !errors.As(err, &connErr) || ctx.Err() != nil {
+			return respBody, err
+		}
 	}
-	return fmt.Errorf("webhook notify failed after retries: %w", lastErr)
+	return nil, err
 }
 
-func (c *Client) send(ctx context.Context, body []byte) error {
+func (c *Client) send(ctx context.Context, url, path string, body []byte) ([]byte, error) {
 	method := "POST"
-	path := "/jit/webhook"
 
 	// Sign the payload.
 	hmacHeaders, err := auth.SignPayload(c.keyID, c.secret, method, path, body)
 	if err != nil {
-		return fmt.Errorf("sign webhook payload: %w", err)
+		return nil, fmt.Errorf("sign webhook payload: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.webhookURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("create webhook request: %w", err)
+		return nil, fmt.Errorf("create webhook request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	for k, v := range hmacHeaders {
@@ -100,15 +562,41 @@ func (c *Client) send(ctx context.Context, body []byte) error {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("webhook HTTP error: %w", err)
+		return nil, &connectionError{err: fmt.Errorf("webhook HTTP error: %w", err)}
 	}
 	defer resp.Body.Close()
 
-	// Read and discard body to allow connection reuse.
-	_, _ = io.ReadAll(resp.Body)
+	// Always drain the body to allow connection reuse.
+	respBody, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		if c.successCodes[resp.StatusCode] {
+			return nil, nil
+		}
+		statusErr := &statusError{code: resp.StatusCode}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			statusErr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, statusErr
 	}
-	return nil
+	return respBody, nil
+}
+
+// parseRetryAfter reads a Retry-After value in either delay-seconds or
+// HTTP-date form, capped at maxRetryAfter. It returns zero if the header is
+// absent or malformed.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	return min(d, maxRetryAfter)
 }
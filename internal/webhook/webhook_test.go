@@ -3,13 +3,17 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dgwhited/jit-aws-controller/internal/auth"
 	"github.com/dgwhited/jit-aws-controller/internal/models"
 )
 
@@ -114,6 +118,100 @@ func TestNotify_AllRetriesFail(t *testing.T) {
 	}
 }
 
+// memFailures is an in-memory FailureRecorder.
+type memFailures struct {
+	recorded []models.WebhookFailure
+}
+
+func (m *memFailures) PutWebhookFailure(_ context.Context, failure *models.WebhookFailure) error {
+	m.recorded = append(m.recorded, *failure)
+	return nil
+}
+
+func TestNotify_RecordsFailureAfterRetries(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond, 1 * time.Millisecond, 1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	failures := &memFailures{}
+	client := NewClient(server.URL, "test-key", "test-secret", WithFailureRecorder(failures))
+	payload := models.WebhookPayload{RequestID: "req-1", Status: "GRANTED", AccountID: "acct1", ChannelID: "ch1"}
+	if err := client.Notify(context.Background(), payload); err == nil {
+		t.Fatal("expected error when all retries fail")
+	}
+
+	if attempts.Load() != 4 {
+		t.Errorf("expected failure recorded only after 4 attempts, got %d", attempts.Load())
+	}
+	if len(failures.recorded) != 1 {
+		t.Fatalf("expected 1 recorded failure, got %d", len(failures.recorded))
+	}
+	got := failures.recorded[0]
+	if got.FailureID == "" || got.RequestID != "req-1" || got.Payload.Status != "GRANTED" || got.Payload.ChannelID != "ch1" {
+		t.Errorf("expected the payload recorded under a failure ID, got %+v", got)
+	}
+	if got.Attempts != 1 || got.FailedAt == "" || !strings.Contains(got.LastError, "status 500") {
+		t.Errorf("expected attempts, failed_at and last error recorded, got %+v", got)
+	}
+	if want := time.Now().Add(FailureRetention).Unix(); got.ExpiresAt < want-60 || got.ExpiresAt > want {
+		t.Errorf("expected expiry about %s from now, got %d", FailureRetention, got.ExpiresAt)
+	}
+}
+
+func TestDeliver_DoesNotRecordFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	failures := &memFailures{}
+	client := NewClient(server.URL, "test-key", "test-secret", WithFailureRecorder(failures))
+	if err := client.Deliver(context.Background(), models.WebhookPayload{RequestID: "req-1"}); err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if len(failures.recorded) != 0 {
+		t.Errorf("expected Deliver not to record failures, got %d", len(failures.recorded))
+	}
+}
+
+func TestDisabled_MakesNoHTTPCalls(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	failures := &memFailures{}
+	client := NewClient(server.URL, "test-key", "test-secret", WithDisabled(), WithFailureRecorder(failures))
+	ctx := context.Background()
+	payload := models.WebhookPayload{RequestID: "req-1", Status: "GRANTED", AccountID: "acct1", ChannelID: "ch1"}
+
+	if err := client.Notify(ctx, payload); err != nil {
+		t.Errorf("expected Notify to succeed while disabled, got %v", err)
+	}
+	if err := client.NotifyBatch(ctx, []models.WebhookPayload{payload, payload}); err != nil {
+		t.Errorf("expected NotifyBatch to succeed while disabled, got %v", err)
+	}
+	if err := client.Deliver(ctx, payload); !errors.Is(err, ErrDisabled) {
+		t.Errorf("expected Deliver to report ErrDisabled, got %v", err)
+	}
+
+	if requests.Load() != 0 {
+		t.Errorf("expected no HTTP requests while disabled, got %d", requests.Load())
+	}
+	if len(failures.recorded) != 0 {
+		t.Errorf("expected suppressed notifications not recorded as failures, got %d", len(failures.recorded))
+	}
+}
+
 func TestNotify_ContextCancelled(t *testing.T) {
 	origBackoffs := retryBackoffs
 	retryBackoffs = []time.Duration{1 * time.Second}
@@ -150,3 +248,487 @@ func TestNewClient(t *testing.T) {
 		t.Error("expected non-nil HTTP client")
 	}
 }
+
+func TestNotify_FailsOverOnConnectionError(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	// Reserve an address and close it so connections are refused.
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := dead.URL
+	dead.Close()
+
+	var received atomic.Int32
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		if r.Header.Get("X-JIT-KeyID") != "test-key" || r.Header.Get("X-JIT-Signature") == "" {
+			t.Error("expected signed request on the fallback endpoint")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondary.Close()
+
+	client := NewClient(deadURL, "test-key", "test-secret", WithFallbackURLs(secondary.URL))
+	err := client.Notify(context.Background(), models.WebhookPayload{
+		RequestID: "req-1",
+		Status:    "GRANTED",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Load() != 1 {
+		t.Errorf("expected 1 request to the secondary, got %d", received.Load())
+	}
+}
+
+func TestNotify_NoFailoverOnHTTPError(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	var secondaryHits atomic.Int32
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondary.Close()
+
+	client := NewClient(primary.URL, "test-key", "test-secret", WithFallbackURLs(secondary.URL))
+	err := client.Notify(context.Background(), models.WebhookPayload{
+		RequestID: "req-1",
+		Status:    "GRANTED",
+	})
+	if err == nil {
+		t.Fatal("expected error when the primary returns 5xx")
+	}
+	if secondaryHits.Load() != 0 {
+		t.Errorf("expected no failover on HTTP errors, got %d secondary requests", secondaryHits.Load())
+	}
+}
+
+type memNonces map[string]bool
+
+func (m memNonces) StoreNonce(_ context.Context, keyID, nonce string, _ int64) error {
+	m[keyID+"/"+nonce] = true
+	return nil
+}
+
+func (m memNonces) CheckNonce(_ context.Context, keyID, nonce string) (bool, error) {
+	return m[keyID+"/"+nonce], nil
+}
+
+func TestNotify_SignsConfiguredPath(t *testing.T) {
+	validator := auth.NewHMACValidator(map[string]string{"test-key": "test-secret"}, memNonces{})
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		headers := map[string]string{}
+		for k := range r.Header {
+			headers[k] = r.Header.Get(k)
+		}
+		verifyErr = validator.ValidateRequest(r.Context(), r.Method, "/plugins/jit/callback", headers, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret", WithSigningPath("/plugins/jit/callback"))
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", Status: "GRANTED"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("expected signature over the configured path, got: %v", verifyErr)
+	}
+}
+
+func TestNewClient_DefaultSigningPath(t *testing.T) {
+	if got := NewClient("http://example.com", "k", "s").signingPath; got != DefaultSigningPath {
+		t.Errorf("expected default signing path %s, got %s", DefaultSigningPath, got)
+	}
+	if got := NewClient("http://example.com", "k", "s", WithSigningPath("")).signingPath; got != DefaultSigningPath {
+		t.Errorf("expected empty path to keep the default, got %s", got)
+	}
+}
+
+func TestNotify_ClientErrorNotRetried(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond, 1 * time.Millisecond, 1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret")
+	err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", Status: "GRANTED"})
+	if err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected a single attempt for 400, got %d", attempts.Load())
+	}
+}
+
+func TestNotify_TooManyRequestsHonorsRetryAfter(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond, 1 * time.Millisecond, 1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	var attempts atomic.Int32
+	var first, second time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		second = time.Now()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret")
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", Status: "GRANTED"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts.Load() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts.Load())
+	}
+	if gap := second.Sub(first); gap < time.Second {
+		t.Errorf("expected retry to wait for Retry-After, waited %s", gap)
+	}
+}
+
+func TestNotify_ServiceUnavailableRetried(t *testing.T) {
+	origBackoffs := retryBackoffs
+	retryBackoffs = []time.Duration{1 * time.Millisecond, 1 * time.Millisecond, 1 * time.Millisecond}
+	defer func() { retryBackoffs = origBackoffs }()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret")
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", Status: "GRANTED"}); err == nil {
+		t.Fatal("expected error when every attempt returns 503")
+	}
+	if attempts.Load() != 4 {
+		t.Errorf("expected 4 attempts for 503, got %d", attempts.Load())
+	}
+}
+
+func TestNotify_TerminalSuccessCode(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret", WithTerminalSuccessCodes(http.StatusConflict))
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", Status: "GRANTED"}); err != nil {
+		t.Fatalf("expected 409 to count as delivered, got: %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"3600", maxRetryAfter},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func batchPayloads(n int) []models.WebhookPayload {
+	payloads := make([]models.WebhookPayload, n)
+	for i := range payloads {
+		payloads[i] = models.WebhookPayload{RequestID: fmt.Sprintf("req-%d", i+1), Status: models.StatusExpired}
+	}
+	return payloads
+}
+
+func TestNotifyBatch_Success(t *testing.T) {
+	validator := auth.NewHMACValidator(map[string]string{"test-key": "test-secret"}, memNonces{})
+	var paths []string
+	var sizes []int
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		headers := map[string]string{}
+		for k := range r.Header {
+			headers[k] = r.Header.Get(k)
+		}
+		if err := validator.ValidateRequest(r.Context(), r.Method, "/jit/webhook/batch", headers, body); err != nil {
+			verifyErr = err
+		}
+		var batch []models.WebhookPayload
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Errorf("expected a JSON array body: %v", err)
+		}
+		sizes = append(sizes, len(batch))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/hook", "test-key", "test-secret")
+	if err := client.NotifyBatch(context.Background(), batchPayloads(maxBatchSize+5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("expected batch signed over the batch path, got: %v", verifyErr)
+	}
+	if len(sizes) != 2 || sizes[0] != maxBatchSize || sizes[1] != 5 {
+		t.Errorf("expected batches of %d and 5, got %v", maxBatchSize, sizes)
+	}
+	for _, p := range paths {
+		if p != "/hook/batch" {
+			t.Errorf("expected requests to /hook/batch, got %s", p)
+		}
+	}
+}
+
+func TestNotifyBatch_PartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"failed":["req-2"]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret", WithBatchPath("/bulk"))
+	err := client.NotifyBatch(context.Background(), batchPayloads(3))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *BatchError, got: %v", err)
+	}
+	if len(batchErr.RequestIDs) != 1 || batchErr.RequestIDs[0] != "req-2" || batchErr.Total != 3 {
+		t.Errorf("expected req-2 of 3 reported failed, got %+v", batchErr)
+	}
+	if batchErr.Cause != nil {
+		t.Errorf("expected no transport cause for a rejected item, got %v", batchErr.Cause)
+	}
+}
+
+func TestNotifyBatch_RequestFailureReportsWholeChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "test-secret")
+	err := client.NotifyBatch(context.Background(), batchPayloads(2))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *BatchError, got: %v", err)
+	}
+	if len(batchErr.RequestIDs) != 2 || batchErr.Cause == nil {
+		t.Errorf("expected both payloads failed with a cause, got %+v", batchErr)
+	}
+}
+
+func TestNotifyBatch_RecordsUndeliveredPayloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"failed":["req-2"]}`))
+	}))
+	defer server.Close()
+
+	failures := &memFailures{}
+	client := NewClient(server.URL, "test-key", "test-secret", WithFailureRecorder(failures))
+	if err := client.NotifyBatch(context.Background(), batchPayloads(3)); err == nil {
+		t.Fatal("expected *BatchError")
+	}
+	if len(failures.recorded) != 1 || failures.recorded[0].Payload.RequestID != "req-2" {
+		t.Errorf("expected only the rejected payload recorded, got %+v", failures.recorded)
+	}
+}
+
+// memBindings is a DestinationLookup over configs keyed by "channel|account".
+type memBindings struct {
+	configs map[string]*models.JitConfig
+	err     error
+	lookups int
+}
+
+func (m *memBindings) GetConfig(_ context.Context, channelID, accountID string) (*models.JitConfig, error) {
+	m.lookups++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.configs[channelID+"|"+accountID], nil
+}
+
+// keyRecorder is a plugin endpoint that records the key ID of each request.
+func keyRecorder(keys *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*keys = append(*keys, r.Header.Get("X-JIT-KeyID"))
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestNotify_ChannelWebhookOverride(t *testing.T) {
+	var globalKeys, channelKeys []string
+	global := keyRecorder(&globalKeys)
+	defer global.Close()
+
+	validator := auth.NewHMACValidator(map[string]string{"east": "east-secret"}, memNonces{})
+	var verifyErr error
+	channel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		channelKeys = append(channelKeys, r.Header.Get("X-JIT-KeyID"))
+		body, _ := io.ReadAll(r.Body)
+		headers := map[string]string{}
+		for k := range r.Header {
+			headers[k] = r.Header.Get(k)
+		}
+		if err := validator.ValidateRequest(r.Context(), r.Method, DefaultSigningPath, headers, body); err != nil {
+			verifyErr = err
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer channel.Close()
+
+	bindings := &memBindings{configs: map[string]*models.JitConfig{
+		"ch-east|acct1": {ChannelID: "ch-east", AccountID: "acct1", WebhookURL: channel.URL, WebhookKeyID: "east"},
+		"ch-main|acct1": {ChannelID: "ch-main", AccountID: "acct1"},
+	}}
+	keys := map[string]string{"default": "default-secret", "east": "east-secret"}
+	client := NewClient(global.URL, "default", "default-secret", WithChannelDestinations(bindings, keys))
+
+	for _, ch := range []string{"ch-east", "ch-main", "ch-east"} {
+		if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", AccountID: "acct1", ChannelID: ch}); err != nil {
+			t.Fatalf("Notify(%s): unexpected error: %v", ch, err)
+		}
+	}
+
+	if len(channelKeys) != 2 || channelKeys[0] != "east" {
+		t.Errorf("expected the overridden channel's notifications at its own URL signed with its key, got %v", channelKeys)
+	}
+	if verifyErr != nil {
+		t.Errorf("expected the channel's secret to sign its notifications, got: %v", verifyErr)
+	}
+	if len(globalKeys) != 1 || globalKeys[0] != "default" {
+		t.Errorf("expected the other channel to fall back to the global webhook, got %v", globalKeys)
+	}
+	if len(client.clients) != 1 {
+		t.Errorf("expected one cached destination client, got %d", len(client.clients))
+	}
+}
+
+func TestNotify_ChannelWebhookUnknownKey(t *testing.T) {
+	var received []string
+	server := keyRecorder(&received)
+	defer server.Close()
+
+	bindings := &memBindings{configs: map[string]*models.JitConfig{
+		"ch1|acct1": {ChannelID: "ch1", AccountID: "acct1", WebhookURL: server.URL, WebhookKeyID: "missing"},
+	}}
+	failures := &memFailures{}
+	client := NewClient(server.URL, "default", "default-secret",
+		WithChannelDestinations(bindings, map[string]string{"default": "default-secret"}),
+		WithFailureRecorder(failures))
+
+	err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", AccountID: "acct1", ChannelID: "ch1"})
+	if err == nil || !strings.Contains(err.Error(), "webhook key missing") {
+		t.Fatalf("expected an unknown key error, got: %v", err)
+	}
+	if len(received) != 0 {
+		t.Errorf("expected nothing sent without the channel's key, got %d requests", len(received))
+	}
+	if len(failures.recorded) != 1 {
+		t.Errorf("expected the undelivered notification recorded, got %d", len(failures.recorded))
+	}
+}
+
+func TestNotify_ChannelWebhookLookupFails(t *testing.T) {
+	var received []string
+	server := keyRecorder(&received)
+	defer server.Close()
+
+	bindings := &memBindings{err: errors.New("table unavailable")}
+	client := NewClient(server.URL, "default", "default-secret", WithChannelDestinations(bindings, nil))
+
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-1", AccountID: "acct1", ChannelID: "ch1"}); err == nil {
+		t.Fatal("expected the lookup failure to fail delivery")
+	}
+	if len(received) != 0 {
+		t.Errorf("expected nothing sent to the global webhook, got %d requests", len(received))
+	}
+	if err := client.Notify(context.Background(), models.WebhookPayload{RequestID: "req-2"}); err != nil {
+		t.Errorf("expected a payload without a binding to use the global webhook, got: %v", err)
+	}
+}
+
+func TestNotifyBatch_GroupsByChannelWebhook(t *testing.T) {
+	var globalSizes, channelSizes []int
+	sizeRecorder := func(sizes *[]int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var batch []models.WebhookPayload
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+			*sizes = append(*sizes, len(batch))
+			if strings.HasSuffix(r.URL.Path, "/batch") {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	}
+	global := sizeRecorder(&globalSizes)
+	defer global.Close()
+	channel := sizeRecorder(&channelSizes)
+	defer channel.Close()
+
+	bindings := &memBindings{configs: map[string]*models.JitConfig{
+		"ch-east|acct1": {ChannelID: "ch-east", AccountID: "acct1", WebhookURL: channel.URL},
+	}}
+	client := NewClient(global.URL, "default", "default-secret", WithChannelDestinations(bindings, nil))
+
+	payloads := batchPayloads(5)
+	for i := range payloads {
+		payloads[i].AccountID = "acct1"
+		payloads[i].ChannelID = "ch-main"
+		if i%2 == 0 {
+			payloads[i].ChannelID = "ch-east"
+		}
+	}
+	if err := client.NotifyBatch(context.Background(), payloads); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(channelSizes) != 1 || channelSizes[0] != 3 {
+		t.Errorf("expected one batch of 3 to the channel webhook, got %v", channelSizes)
+	}
+	if len(globalSizes) != 1 || globalSizes[0] != 2 {
+		t.Errorf("expected one batch of 2 to the global webhook, got %v", globalSizes)
+	}
+	if bindings.lookups != 2 {
+		t.Errorf("expected one lookup per binding, got %d", bindings.lookups)
+	}
+}
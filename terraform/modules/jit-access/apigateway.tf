@@ -31,12 +31,36 @@ resource "aws_apigatewayv2_route" "post_requests" {
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "post_validate_request" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /requests/validate"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "post_approve" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "POST /requests/{id}/approve"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "post_approve_batch" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /requests/approve-batch"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_approval_token" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /requests/{id}/approval-token"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_approve_with_token" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /requests/{id}/approve-with-token"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "post_deny" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "POST /requests/{id}/deny"
@@ -49,36 +73,138 @@ resource "aws_apigatewayv2_route" "post_revoke" {
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "post_comment" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /requests/{id}/comment"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_export" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /requests/{id}/export"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "get_requests" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "GET /requests"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "get_my_requests" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /requests/mine"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "get_request_by_id" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "GET /requests/{id}"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "get_request_execution" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /requests/{id}/execution"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_request_approvers" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /requests/{id}/approvers"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "post_config_bind" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "POST /config/bind"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "patch_config_bind" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "PATCH /config/bind"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "post_config_approvers" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "POST /config/approvers"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "post_config_pause" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /config/pause"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_config_resume" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /config/resume"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_config_account_approvers" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /config/account/{id}/approvers"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 resource "aws_apigatewayv2_route" "get_config_accounts" {
   api_id    = aws_apigatewayv2_api.jit.id
   route_key = "GET /config/accounts"
   target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
 }
 
+resource "aws_apigatewayv2_route" "get_admin_config_report" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /admin/config-report"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_admin_active_grants" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /admin/active-grants"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_admin_active_grants_recount" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /admin/active-grants/recount"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_admin_account_bindings" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /admin/account/{id}/bindings"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_admin_import" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /admin/import"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_admin_webhook_failures" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /admin/webhook-failures"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "post_admin_webhook_failures_redrive" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "POST /admin/webhook-failures/redrive"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
+resource "aws_apigatewayv2_route" "get_admin_audit_export" {
+  api_id    = aws_apigatewayv2_api.jit.id
+  route_key = "GET /admin/audit/export"
+  target    = "integrations/${aws_apigatewayv2_integration.api_lambda.id}"
+}
+
 ########################################
 # Default stage with auto-deploy
 ########################################
@@ -48,6 +48,33 @@ resource "aws_cloudwatch_log_metric_filter" "reconciler_errors" {
   }
 }
 
+# DynamoDB calls still throttled after the SDK's retries, from either Lambda
+resource "aws_cloudwatch_log_metric_filter" "dynamodb_throttles_api" {
+  name           = "${var.environment}-jit-dynamodb-throttles-api"
+  log_group_name = aws_cloudwatch_log_group.api_lambda.name
+  pattern        = "{ $.msg = \"dynamodb throttled\" }"
+
+  metric_transformation {
+    name          = "DynamoDBThrottles"
+    namespace     = "${var.environment}/JITAccess"
+    value         = "1"
+    default_value = "0"
+  }
+}
+
+resource "aws_cloudwatch_log_metric_filter" "dynamodb_throttles_reconciler" {
+  name           = "${var.environment}-jit-dynamodb-throttles-reconciler"
+  log_group_name = aws_cloudwatch_log_group.reconciler_lambda.name
+  pattern        = "{ $.msg = \"dynamodb throttled\" }"
+
+  metric_transformation {
+    name          = "DynamoDBThrottles"
+    namespace     = "${var.environment}/JITAccess"
+    value         = "1"
+    default_value = "0"
+  }
+}
+
 ########################################
 # Alarms – Failed grant/revoke operations
 ########################################
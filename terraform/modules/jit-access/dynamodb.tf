@@ -75,6 +75,11 @@ resource "aws_dynamodb_table" "jit_requests" {
     type = "S"
   }
 
+  attribute {
+    name = "jira"
+    type = "S"
+  }
+
   global_secondary_index {
     name            = "gsi_channel_created"
     hash_key        = "channel_id"
@@ -96,6 +101,13 @@ resource "aws_dynamodb_table" "jit_requests" {
     projection_type = "ALL"
   }
 
+  global_secondary_index {
+    name            = "gsi_jira_created"
+    hash_key        = "jira"
+    range_key       = "created_at"
+    projection_type = "ALL"
+  }
+
   global_secondary_index {
     name            = "gsi_status_endtime"
     hash_key        = "status"
@@ -141,6 +153,11 @@ resource "aws_dynamodb_table" "jit_audit" {
     type = "S"
   }
 
+  attribute {
+    name = "event_date"
+    type = "S"
+  }
+
   global_secondary_index {
     name            = "gsi_account_event"
     hash_key        = "account_id"
@@ -155,6 +172,13 @@ resource "aws_dynamodb_table" "jit_audit" {
     projection_type = "ALL"
   }
 
+  global_secondary_index {
+    name            = "gsi_date_event"
+    hash_key        = "event_date"
+    range_key       = "event_time_event_id"
+    projection_type = "ALL"
+  }
+
   point_in_time_recovery {
     enabled = true
   }
@@ -196,3 +220,30 @@ resource "aws_dynamodb_table" "jit_nonces" {
     Name = "${var.environment}-jit-nonces"
   })
 }
+
+########################################
+# jit_webhook_failures table
+########################################
+resource "aws_dynamodb_table" "jit_webhook_failures" {
+  name         = "${var.environment}-jit-webhook-failures"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "failure_id"
+
+  attribute {
+    name = "failure_id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  tags = merge(var.tags, {
+    Name = "${var.environment}-jit-webhook-failures"
+  })
+}
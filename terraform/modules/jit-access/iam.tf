@@ -12,6 +12,7 @@ locals {
     aws_dynamodb_table.jit_requests.arn,
     aws_dynamodb_table.jit_audit.arn,
     aws_dynamodb_table.jit_nonces.arn,
+    aws_dynamodb_table.jit_webhook_failures.arn,
   ]
 
   dynamodb_index_arns = [
@@ -19,6 +20,7 @@ locals {
     "${aws_dynamodb_table.jit_requests.arn}/index/*",
     "${aws_dynamodb_table.jit_audit.arn}/index/*",
     "${aws_dynamodb_table.jit_nonces.arn}/index/*",
+    "${aws_dynamodb_table.jit_webhook_failures.arn}/index/*",
   ]
 }
 
@@ -66,7 +68,7 @@ data "aws_iam_policy_document" "api_lambda" {
     ]
   }
 
-  # DynamoDB — Config table: read, write, query
+  # DynamoDB — Config table: read, write, query, and scan (admin config report)
   statement {
     sid    = "DynamoDBConfig"
     effect = "Allow"
@@ -75,6 +77,7 @@ data "aws_iam_policy_document" "api_lambda" {
       "dynamodb:PutItem",
       "dynamodb:UpdateItem",
       "dynamodb:Query",
+      "dynamodb:Scan",
     ]
     resources = [
       aws_dynamodb_table.jit_config.arn,
@@ -109,6 +112,21 @@ data "aws_iam_policy_document" "api_lambda" {
     ]
   }
 
+  # DynamoDB — Webhook failures table: record, list and redrive undelivered notifications
+  statement {
+    sid    = "DynamoDBWebhookFailures"
+    effect = "Allow"
+    actions = [
+      "dynamodb:GetItem",
+      "dynamodb:PutItem",
+      "dynamodb:Scan",
+      "dynamodb:DeleteItem",
+    ]
+    resources = [
+      aws_dynamodb_table.jit_webhook_failures.arn,
+    ]
+  }
+
   # SSO account assignment management
   statement {
     sid    = "SSOAdmin"
@@ -122,13 +140,14 @@ data "aws_iam_policy_document" "api_lambda" {
     resources = ["*"]
   }
 
-  # Identity Store user lookups
+  # Identity Store user and group lookups
   statement {
     sid    = "IdentityStore"
     effect = "Allow"
     actions = [
       "identitystore:ListUsers",
       "identitystore:GetUserId",
+      "identitystore:ListGroups",
     ]
     resources = ["*"]
   }
@@ -143,6 +162,8 @@ data "aws_iam_policy_document" "api_lambda" {
     resources = [
       aws_secretsmanager_secret.signing_key.arn,
       aws_secretsmanager_secret.callback_signing_key.arn,
+      aws_secretsmanager_secret.action_signing_key.arn,
+      aws_secretsmanager_secret.export_signing_key.arn,
     ]
   }
 
@@ -185,6 +206,45 @@ data "aws_iam_policy_document" "api_lambda" {
       "arn:aws:states:${local.region}:${local.account_id}:stateMachine:${var.environment}-jit-grant-revoke",
     ]
   }
+
+  # Step Functions — read a request's execution history for GET /requests/{id}/execution.
+  statement {
+    sid    = "StepFunctionsHistory"
+    effect = "Allow"
+    actions = [
+      "states:GetExecutionHistory",
+    ]
+    resources = [
+      "arn:aws:states:${local.region}:${local.account_id}:execution:${var.environment}-jit-grant-revoke:*",
+    ]
+  }
+
+  # KMS — data keys for request field encryption (only when enabled)
+  dynamic "statement" {
+    for_each = var.field_encryption_enabled ? [var.kms_key_arn] : []
+    content {
+      sid    = "FieldEncryption"
+      effect = "Allow"
+      actions = [
+        "kms:GenerateDataKey",
+        "kms:Decrypt",
+      ]
+      resources = [statement.value]
+    }
+  }
+
+  # EventBridge — publish request lifecycle events (only when configured)
+  dynamic "statement" {
+    for_each = var.event_bus_arn != "" ? [var.event_bus_arn] : []
+    content {
+      sid    = "LifecycleEvents"
+      effect = "Allow"
+      actions = [
+        "events:PutEvents",
+      ]
+      resources = [statement.value]
+    }
+  }
 }
 
 resource "aws_iam_role_policy" "api_lambda" {
@@ -213,12 +273,14 @@ resource "aws_iam_role" "reconciler_lambda" {
 }
 
 data "aws_iam_policy_document" "reconciler_lambda" {
-  # DynamoDB — Requests table: query (GSI) + conditional update only
+  # DynamoDB — Requests table: query (GSI), scan for grants missing end_time,
+  # conditional update
   statement {
     sid    = "DynamoDBRequests"
     effect = "Allow"
     actions = [
       "dynamodb:Query",
+      "dynamodb:Scan",
       "dynamodb:UpdateItem",
     ]
     resources = [
@@ -227,6 +289,18 @@ data "aws_iam_policy_document" "reconciler_lambda" {
     ]
   }
 
+  # DynamoDB — Config table: read bindings' webhook destinations
+  statement {
+    sid    = "DynamoDBConfig"
+    effect = "Allow"
+    actions = [
+      "dynamodb:GetItem",
+    ]
+    resources = [
+      aws_dynamodb_table.jit_config.arn,
+    ]
+  }
+
   # DynamoDB — Audit table: write only
   statement {
     sid    = "DynamoDBAudit"
@@ -239,6 +313,34 @@ data "aws_iam_policy_document" "reconciler_lambda" {
     ]
   }
 
+  # DynamoDB — Nonces table: TTL self-check and fallback sweep
+  statement {
+    sid    = "DynamoDBNonces"
+    effect = "Allow"
+    actions = [
+      "dynamodb:DescribeTimeToLive",
+      "dynamodb:GetItem",
+      "dynamodb:PutItem",
+      "dynamodb:Scan",
+      "dynamodb:DeleteItem",
+    ]
+    resources = [
+      aws_dynamodb_table.jit_nonces.arn,
+    ]
+  }
+
+  # DynamoDB — Webhook failures table: record undelivered notifications
+  statement {
+    sid    = "DynamoDBWebhookFailures"
+    effect = "Allow"
+    actions = [
+      "dynamodb:PutItem",
+    ]
+    resources = [
+      aws_dynamodb_table.jit_webhook_failures.arn,
+    ]
+  }
+
   # SSO account assignment management
   statement {
     sid    = "SSOAdmin"
@@ -252,13 +354,14 @@ data "aws_iam_policy_document" "reconciler_lambda" {
     resources = ["*"]
   }
 
-  # Identity Store user lookups
+  # Identity Store user and group lookups
   statement {
     sid    = "IdentityStore"
     effect = "Allow"
     actions = [
       "identitystore:ListUsers",
       "identitystore:GetUserId",
+      "identitystore:ListGroups",
     ]
     resources = ["*"]
   }
@@ -301,6 +404,19 @@ data "aws_iam_policy_document" "reconciler_lambda" {
       "arn:aws:logs:${local.region}:${local.account_id}:log-group:/aws/lambda/${var.environment}-jit-reconciler:*",
     ]
   }
+
+  # EventBridge — publish request lifecycle events (only when configured)
+  dynamic "statement" {
+    for_each = var.event_bus_arn != "" ? [var.event_bus_arn] : []
+    content {
+      sid    = "LifecycleEvents"
+      effect = "Allow"
+      actions = [
+        "events:PutEvents",
+      ]
+      resources = [statement.value]
+    }
+  }
 }
 
 resource "aws_iam_role_policy" "reconciler_lambda" {
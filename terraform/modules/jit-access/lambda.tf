@@ -53,17 +53,38 @@ resource "aws_lambda_function" "jit_api" {
 
   environment {
     variables = {
-      TABLE_CONFIG                = aws_dynamodb_table.jit_config.name
-      TABLE_REQUESTS              = aws_dynamodb_table.jit_requests.name
-      TABLE_AUDIT                 = aws_dynamodb_table.jit_audit.name
-      TABLE_NONCES                = aws_dynamodb_table.jit_nonces.name
-      SSO_INSTANCE_ARN            = var.sso_instance_arn
-      IDENTITY_STORE_ID           = var.identity_store_id
-      PERMISSION_SET_ARN          = local.permission_set_arn
-      SIGNING_SECRET_ARN          = aws_secretsmanager_secret.signing_key.arn
-      PLUGIN_WEBHOOK_URL          = var.plugin_webhook_url
-      CALLBACK_SIGNING_SECRET_ARN = aws_secretsmanager_secret.callback_signing_key.arn
-      STEP_FUNCTION_ARN           = "arn:aws:states:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:stateMachine:${var.environment}-jit-grant-revoke"
+      TABLE_CONFIG                 = aws_dynamodb_table.jit_config.name
+      TABLE_REQUESTS               = aws_dynamodb_table.jit_requests.name
+      TABLE_AUDIT                  = aws_dynamodb_table.jit_audit.name
+      TABLE_NONCES                 = aws_dynamodb_table.jit_nonces.name
+      TABLE_WEBHOOK_FAILURES       = aws_dynamodb_table.jit_webhook_failures.name
+      SSO_INSTANCE_ARN             = var.sso_instance_arn
+      IDENTITY_STORE_ID            = var.identity_store_id
+      IDENTITY_CENTER_REGION       = var.identity_center_region
+      IDENTITY_PRINCIPAL_TYPE      = var.identity_principal_type
+      PERMISSION_SET_ARN           = local.permission_set_arn
+      SIGNING_SECRET_ARN           = aws_secretsmanager_secret.signing_key.arn
+      PLUGIN_WEBHOOK_URL           = var.plugin_webhook_url
+      PLUGIN_WEBHOOK_FALLBACK_URLS = join(",", var.plugin_webhook_fallback_urls)
+      PLUGIN_WEBHOOK_PATH          = var.plugin_webhook_path
+      WEBHOOK_ENABLED              = tostring(var.webhook_enabled)
+      CALLBACK_SIGNING_SECRET_ARN  = aws_secretsmanager_secret.callback_signing_key.arn
+      STEP_FUNCTION_ARN            = "arn:aws:states:${data.aws_region.current.name}:${data.aws_caller_identity.current.account_id}:stateMachine:${var.environment}-jit-grant-revoke"
+      ADMIN_MM_USER_IDS            = join(",", var.admin_mm_user_ids)
+      NO_SELF_APPROVAL_CHANNELS    = join(",", var.no_self_approval_channels)
+      MAX_APPROVERS                = tostring(var.max_approvers)
+      REVOKE_MODE                  = var.revoke_mode
+      FIELD_ENCRYPTION_ENABLED     = tostring(var.field_encryption_enabled)
+      KMS_KEY_ARN                  = var.kms_key_arn
+      ACTION_SIGNING_ENABLED       = tostring(var.action_signing_enabled)
+      ACTION_SIGNING_SECRET_ARN    = aws_secretsmanager_secret.action_signing_key.arn
+      EXPORT_SIGNING_SECRET_ARN    = aws_secretsmanager_secret.export_signing_key.arn
+      NOTIFY_ON_APPROVE            = tostring(var.notify_on_approve)
+      NOTIFY_REQUESTER_ON_DENY     = tostring(var.notify_requester_on_deny)
+      PRE_GRANT_JITTER             = var.pre_grant_jitter
+      MAX_WAIT_SECONDS             = tostring(var.max_wait_seconds)
+      MAX_BATCH_GRANT_STARTS       = tostring(var.max_batch_grant_starts)
+      EVENT_BUS_ARN                = var.event_bus_arn
     }
   }
 
@@ -106,16 +127,29 @@ resource "aws_lambda_function" "jit_reconciler" {
 
   environment {
     variables = {
-      TABLE_CONFIG                = aws_dynamodb_table.jit_config.name
-      TABLE_REQUESTS              = aws_dynamodb_table.jit_requests.name
-      TABLE_AUDIT                 = aws_dynamodb_table.jit_audit.name
-      TABLE_NONCES                = aws_dynamodb_table.jit_nonces.name
-      SSO_INSTANCE_ARN            = var.sso_instance_arn
-      IDENTITY_STORE_ID           = var.identity_store_id
-      PERMISSION_SET_ARN          = local.permission_set_arn
-      SIGNING_SECRET_ARN          = aws_secretsmanager_secret.signing_key.arn
-      PLUGIN_WEBHOOK_URL          = var.plugin_webhook_url
-      CALLBACK_SIGNING_SECRET_ARN = aws_secretsmanager_secret.callback_signing_key.arn
+      TABLE_CONFIG                 = aws_dynamodb_table.jit_config.name
+      TABLE_REQUESTS               = aws_dynamodb_table.jit_requests.name
+      TABLE_AUDIT                  = aws_dynamodb_table.jit_audit.name
+      TABLE_NONCES                 = aws_dynamodb_table.jit_nonces.name
+      TABLE_WEBHOOK_FAILURES       = aws_dynamodb_table.jit_webhook_failures.name
+      SSO_INSTANCE_ARN             = var.sso_instance_arn
+      IDENTITY_STORE_ID            = var.identity_store_id
+      IDENTITY_CENTER_REGION       = var.identity_center_region
+      IDENTITY_PRINCIPAL_TYPE      = var.identity_principal_type
+      PERMISSION_SET_ARN           = local.permission_set_arn
+      SIGNING_SECRET_ARN           = aws_secretsmanager_secret.signing_key.arn
+      PLUGIN_WEBHOOK_URL           = var.plugin_webhook_url
+      PLUGIN_WEBHOOK_FALLBACK_URLS = join(",", var.plugin_webhook_fallback_urls)
+      PLUGIN_WEBHOOK_PATH          = var.plugin_webhook_path
+      WEBHOOK_ENABLED              = tostring(var.webhook_enabled)
+      PLUGIN_WEBHOOK_BATCH_PATH    = var.plugin_webhook_batch_path
+      CALLBACK_SIGNING_SECRET_ARN  = aws_secretsmanager_secret.callback_signing_key.arn
+      EXPIRY_WARNING_WINDOW        = var.expiry_warning_window
+      APPROVER_REMINDER_INTERVAL   = var.approver_reminder_interval
+      APPROVER_REMINDER_COOLDOWN   = var.approver_reminder_cooldown
+      APPROVAL_GRACE_PERIOD        = var.approval_grace_period != "" ? var.approval_grace_period : (var.revoke_mode == "reconciler" ? "30m" : "")
+      REVOKE_MAX_ATTEMPTS          = tostring(var.revoke_max_attempts)
+      EVENT_BUS_ARN                = var.event_bus_arn
     }
   }
 
@@ -21,10 +21,11 @@ output "step_function_arn" {
 output "dynamodb_table_arns" {
   description = "Map of DynamoDB table names to their ARNs."
   value = {
-    config   = aws_dynamodb_table.jit_config.arn
-    requests = aws_dynamodb_table.jit_requests.arn
-    audit    = aws_dynamodb_table.jit_audit.arn
-    nonces   = aws_dynamodb_table.jit_nonces.arn
+    config           = aws_dynamodb_table.jit_config.arn
+    requests         = aws_dynamodb_table.jit_requests.arn
+    audit            = aws_dynamodb_table.jit_audit.arn
+    nonces           = aws_dynamodb_table.jit_nonces.arn
+    webhook_failures = aws_dynamodb_table.jit_webhook_failures.arn
   }
 }
 
@@ -54,3 +54,59 @@ resource "random_password" "callback_signing_key" {
   length  = 64
   special = false
 }
+
+########################################
+# Action signing secret – HMAC signing of Step Functions action payloads
+########################################
+resource "aws_secretsmanager_secret" "action_signing_key" {
+  name                    = "${var.environment}/jit-access/action-signing-key"
+  description             = "HMAC signing key used to sign and verify Step Functions action payloads when action signing is enabled."
+  recovery_window_in_days = 30
+
+  tags = merge(var.tags, {
+    Name = "${var.environment}-jit-action-signing-key"
+  })
+}
+
+resource "aws_secretsmanager_secret_version" "action_signing_key" {
+  secret_id = aws_secretsmanager_secret.action_signing_key.id
+
+  secret_string = random_password.action_signing_key.result
+
+  lifecycle {
+    ignore_changes = [secret_string]
+  }
+}
+
+resource "random_password" "action_signing_key" {
+  length  = 64
+  special = false
+}
+
+########################################
+# Export signing secret – HMAC signing of request compliance exports
+########################################
+resource "aws_secretsmanager_secret" "export_signing_key" {
+  name                    = "${var.environment}/jit-access/export-signing-key"
+  description             = "HMAC signing key used to sign request compliance exports. Auditors verifying an export need this key."
+  recovery_window_in_days = 30
+
+  tags = merge(var.tags, {
+    Name = "${var.environment}-jit-export-signing-key"
+  })
+}
+
+resource "aws_secretsmanager_secret_version" "export_signing_key" {
+  secret_id = aws_secretsmanager_secret.export_signing_key.id
+
+  secret_string = random_password.export_signing_key.result
+
+  lifecycle {
+    ignore_changes = [secret_string]
+  }
+}
+
+resource "random_password" "export_signing_key" {
+  length  = 64
+  special = false
+}
@@ -1,6 +1,15 @@
 ########################################
 # Step Functions state machine – Grant → Wait → Revoke
 ########################################
+locals {
+  # With action signing enabled, each task passes on its action's signature,
+  # which the API Lambda added to the execution input.
+  action_signature = {
+    for action in ["validate", "grant", "notify_granted", "revoke", "notify_revoked", "handle_grant_error", "handle_revoke_error"] :
+    action => var.action_signing_enabled ? { "signature.$" = "$.action_signatures.${action}" } : {}
+  }
+}
+
 resource "aws_sfn_state_machine" "jit_grant_revoke" {
   name     = "${var.environment}-jit-grant-revoke"
   role_arn = aws_iam_role.sfn.arn
@@ -15,7 +24,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "validate"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
@@ -23,12 +32,19 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
             "duration_seconds.$"       = "$.duration_seconds"
-          }
+          }, local.action_signature["validate"])
         }
         ResultPath = "$.validation"
         ResultSelector = {
           "payload.$" = "$.Payload"
         }
+        Catch = [
+          {
+            ErrorEquals = ["States.ALL"]
+            ResultPath  = "$.error"
+            Next        = "HandleGrantError"
+          }
+        ]
         Next = "GrantAccess"
       }
 
@@ -37,7 +53,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "grant"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
@@ -45,7 +61,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
             "duration_seconds.$"       = "$.duration_seconds"
-          }
+          }, local.action_signature["grant"])
         }
         ResultPath = "$.grant_result"
         ResultSelector = {
@@ -66,7 +82,27 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             Next        = "HandleGrantError"
           }
         ]
-        Next = "NotifyGranted"
+        Next = "CheckGranted"
+      }
+
+      # The grant step refuses windows that have already ended; it has
+      # recorded the request as ERROR, so there is nothing to wait for.
+      CheckGranted = {
+        Type = "Choice"
+        Choices = [
+          {
+            Variable     = "$.grant_result.payload.status"
+            StringEquals = "not_granted"
+            Next         = "GrantWindowElapsed"
+          }
+        ]
+        Default = "NotifyGranted"
+      }
+
+      GrantWindowElapsed = {
+        Type  = "Fail"
+        Error = "GrantWindowElapsed"
+        Cause = "end_time had passed before access was granted"
       }
 
       NotifyGranted = {
@@ -74,7 +110,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "notify_granted"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
@@ -82,7 +118,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
             "duration_seconds.$"       = "$.duration_seconds"
-          }
+          }, local.action_signature["notify_granted"])
         }
         ResultPath = "$.notify_grant_result"
         ResultSelector = {
@@ -110,14 +146,14 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "revoke"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
             "channel_id.$"             = "$.channel_id"
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
-          }
+          }, local.action_signature["revoke"])
         }
         ResultPath = "$.revoke_result"
         ResultSelector = {
@@ -146,14 +182,14 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "notify_revoked"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
             "channel_id.$"             = "$.channel_id"
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
-          }
+          }, local.action_signature["notify_revoked"])
         }
         ResultPath = "$.notify_revoke_result"
         ResultSelector = {
@@ -180,7 +216,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "handle_grant_error"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
@@ -188,7 +224,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
             "error.$"                  = "$.error"
-          }
+          }, local.action_signature["handle_grant_error"])
         }
         ResultPath = "$.error_handler_result"
         ResultSelector = {
@@ -202,7 +238,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
         Resource = "arn:aws:states:::lambda:invoke"
         Parameters = {
           FunctionName = aws_lambda_function.jit_api.arn
-          Payload = {
+          Payload = merge({
             "action"                   = "handle_revoke_error"
             "request_id.$"             = "$.request_id"
             "account_id.$"             = "$.account_id"
@@ -210,7 +246,7 @@ resource "aws_sfn_state_machine" "jit_grant_revoke" {
             "identity_store_user_id.$" = "$.identity_store_user_id"
             "requester_email.$"        = "$.requester_email"
             "error.$"                  = "$.error"
-          }
+          }, local.action_signature["handle_revoke_error"])
         }
         ResultPath = "$.error_handler_result"
         ResultSelector = {
@@ -38,6 +38,30 @@ variable "plugin_webhook_url" {
   type        = string
 }
 
+variable "webhook_enabled" {
+  description = "Whether to send status notifications to the plugin. Set to false in environments with no plugin; notifications are then dropped instead of failing after retries."
+  type        = bool
+  default     = true
+}
+
+variable "plugin_webhook_fallback_urls" {
+  description = "Ordered fallback plugin callback endpoints, tried when the primary cannot be reached."
+  type        = list(string)
+  default     = []
+}
+
+variable "plugin_webhook_path" {
+  description = "Request path covered by callback signatures. Must match the path the plugin receives webhooks on."
+  type        = string
+  default     = "/jit/webhook"
+}
+
+variable "plugin_webhook_batch_path" {
+  description = "Suffix appended to the plugin webhook URL and signing path for the reconciler's batched callbacks."
+  type        = string
+  default     = "/batch"
+}
+
 variable "sso_instance_arn" {
   description = "ARN of the AWS SSO (IAM Identity Center) instance."
   type        = string
@@ -48,6 +72,18 @@ variable "identity_store_id" {
   type        = string
 }
 
+variable "identity_center_region" {
+  description = "IAM Identity Center home region. SSO Admin and Identity Store calls must target this region; leave empty only when it matches the deployment region."
+  type        = string
+  default     = ""
+}
+
+variable "identity_principal_type" {
+  description = "Principal type of account assignments: \"USER\" assigns the requester; \"GROUP\" assigns the Identity Store group whose display name is the requester's email."
+  type        = string
+  default     = "USER"
+}
+
 variable "lambda_artifact_bucket" {
   description = "Name of the S3 bucket containing Lambda deployment packages."
   type        = string
@@ -78,6 +114,114 @@ variable "lambda_vpc_config" {
   default = null
 }
 
+variable "admin_mm_user_ids" {
+  description = "Mattermost user IDs allowed to call admin endpoints (e.g. GET /admin/config-report)."
+  type        = list(string)
+  default     = []
+}
+
+variable "no_self_approval_channels" {
+  description = "Mattermost channel IDs where self-approval is always refused, overriding each binding's allow_self_approval."
+  type        = list(string)
+  default     = []
+}
+
+variable "max_approvers" {
+  description = "Largest approver list accepted by POST /config/approvers and POST /config/account/{id}/approvers, after removing duplicates."
+  type        = number
+  default     = 50
+}
+
+variable "revoke_mode" {
+  description = "How approved requests are granted and expired: \"stepfn\" runs the Step Functions workflow; \"reconciler\" grants at approval and leaves expiry to the scheduled reconciler."
+  type        = string
+  default     = "stepfn"
+}
+
+variable "expiry_warning_window" {
+  description = "How long before a grant ends the reconciler warns the requester (Go duration, e.g. \"10m\"). Leave empty to disable warnings."
+  type        = string
+  default     = ""
+}
+
+variable "pre_grant_jitter" {
+  description = "Maximum random delay before each Step Functions grant touches SSO Admin (Go duration, e.g. \"5s\"). Spreads out bursts of approvals. Leave empty for no delay."
+  type        = string
+  default     = ""
+}
+
+variable "max_wait_seconds" {
+  description = "Cap on how long the Step Functions workflow waits before revoking, in seconds. Longer grants end early, with end_time moved to match. 0 means no cap."
+  type        = number
+  default     = 0
+}
+
+variable "max_batch_grant_starts" {
+  description = "Most grants one batch approval starts. Requests beyond it stay PENDING and are reported as deferred. 0 means 10, or 5 when revoke_mode is \"reconciler\"."
+  type        = number
+  default     = 0
+}
+
+variable "approver_reminder_interval" {
+  description = "How long a request must be pending before the reconciler reminds approvers (Go duration, e.g. \"30m\"). Leave empty to disable reminders."
+  type        = string
+  default     = ""
+}
+
+variable "approver_reminder_cooldown" {
+  description = "Minimum time between approver reminders for one request (Go duration). Defaults to approver_reminder_interval when empty."
+  type        = string
+  default     = ""
+}
+
+variable "approval_grace_period" {
+  description = "How long a request may stay approved without being granted before the reconciler marks it ERROR (Go duration, e.g. \"30m\"). Leave empty to disable the check, or, with revoke_mode = \"reconciler\", to use 30m."
+  type        = string
+  default     = ""
+}
+
+variable "revoke_max_attempts" {
+  description = "How many reconciler runs may fail to revoke an expired grant before it is marked ERROR and an alert webhook is sent."
+  type        = number
+  default     = 3
+}
+
+variable "field_encryption_enabled" {
+  description = "Whether to envelope-encrypt request reason and Jira fields with KMS before they are stored."
+  type        = bool
+  default     = false
+}
+
+variable "kms_key_arn" {
+  description = "ARN of the KMS key used for field encryption. Required when field_encryption_enabled is true."
+  type        = string
+  default     = ""
+}
+
+variable "event_bus_arn" {
+  description = "ARN of an EventBridge bus to publish request lifecycle events (detail-type jit.request.<event>) to. Empty disables publishing."
+  type        = string
+  default     = ""
+}
+
+variable "action_signing_enabled" {
+  description = "Whether Step Functions action payloads carry an HMAC signature that the API Lambda verifies, rejecting unsigned actions."
+  type        = bool
+  default     = false
+}
+
+variable "notify_on_approve" {
+  description = "Whether to send the plugin an APPROVED webhook as soon as a request is approved, before access is granted."
+  type        = bool
+  default     = false
+}
+
+variable "notify_requester_on_deny" {
+  description = "Whether to send the plugin a DENIED webhook so it can notify the requester directly when their request is denied."
+  type        = bool
+  default     = false
+}
+
 variable "tags" {
   description = "Tags to apply to all resources."
   type        = map(string)